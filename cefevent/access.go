@@ -0,0 +1,164 @@
+package cefevent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+)
+
+// SourceIdentityKey is the extension network sources with access control
+// tag every accepted event with, it holds the verified identity of the
+// sender: the common name of its TLS client certificate or its IP address.
+// A value sent by the device itself is overwritten, so it can not be spoofed.
+const SourceIdentityKey = "cefSourceIdentity"
+
+// handshakeTimeout limits the TLS handshake of connections without an IdleTimeout.
+const handshakeTimeout = 10 * time.Second
+
+// addrPort returns the IP address and port of a TCP or UDP address.
+func addrPort(addr net.Addr) (netip.AddrPort, bool) {
+
+	a, ok := addr.(interface{ AddrPort() netip.AddrPort })
+	if !ok {
+		return netip.AddrPort{}, false
+	}
+
+	ap := a.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), true
+}
+
+// accessControlled reports whether the options restrict the senders, in
+// which case the events are tagged with the SourceIdentityKey.
+func (o ListenOptions) accessControlled() bool {
+	return len(o.Allow) > 0 || o.TLS != nil
+}
+
+// allowed reports whether the remote address is within the Allow list,
+// every address is allowed when the list is empty.
+func (o ListenOptions) allowed(addr net.Addr) bool {
+
+	if len(o.Allow) == 0 {
+		return true
+	}
+
+	ap, ok := addrPort(addr)
+	if !ok {
+		return false
+	}
+
+	for _, prefix := range o.Allow {
+		if prefix.Contains(ap.Addr()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// identify completes the TLS handshake of the connection and returns the
+// identity of the sender, the common name, or else the first DNS name, of
+// its verified client certificate, or else its IP address.
+func (o ListenOptions) identify(conn net.Conn) (string, error) {
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		timeout := o.IdleTimeout
+		if timeout <= 0 {
+			timeout = handshakeTimeout
+		}
+		tlsConn.SetDeadline(time.Now().Add(timeout))
+		err := tlsConn.Handshake()
+		tlsConn.SetDeadline(time.Time{})
+		if err != nil {
+			return "", err
+		}
+
+		if chains := tlsConn.ConnectionState().VerifiedChains; len(chains) > 0 {
+			cert := chains[0][0]
+			if cert.Subject.CommonName != "" {
+				return cert.Subject.CommonName, nil
+			}
+			if len(cert.DNSNames) > 0 {
+				return cert.DNSNames[0], nil
+			}
+		}
+	}
+
+	ap, ok := addrPort(conn.RemoteAddr())
+	if !ok {
+		return conn.RemoteAddr().String(), nil
+	}
+
+	return ap.Addr().String(), nil
+}
+
+// tagIdentity sets the SourceIdentityKey of a successfully parsed event.
+func tagIdentity(env *Envelope, identity string) {
+
+	if env.Err != nil {
+		return
+	}
+
+	if env.Event.Extensions == nil {
+		env.Event.Extensions = make(map[string]string)
+	}
+	env.Event.Extensions[SourceIdentityKey] = identity
+}
+
+// parseAllowList parses IP addresses and CIDR prefixes, e.g. "10.0.0.0/8"
+// or "2001:db8::1", into the prefixes of ListenOptions.Allow.
+func parseAllowList(entries []string) ([]netip.Prefix, error) {
+
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("allow entry %q is no IP address or CIDR prefix", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// serverTLSConfig returns the TLS configuration of a source from PEM
+// files, clients must present a certificate signed by the client CA when
+// one is given.
+func serverTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+
+	if certFile == "" && keyFile == "" && clientCAFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls_cert and tls_key must be given together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no PEM certificate", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
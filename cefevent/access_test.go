@@ -0,0 +1,199 @@
+package cefevent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAllowList(t *testing.T) {
+
+	got, err := parseAllowList([]string{"10.1.2.3/8", "192.0.2.7", "2001:db8::/32", "::ffff:198.51.100.1"})
+	if err != nil {
+		t.Fatalf("parseAllowList() = %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.7/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("198.51.100.1/32"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAllowList() = %v, want %v", got, want)
+	}
+
+	for _, entry := range []string{"", "localhost", "10.0.0.0/33"} {
+		if _, err := parseAllowList([]string{entry}); err == nil {
+			t.Errorf("parseAllowList(%q) = nil, want an error", entry)
+		}
+	}
+}
+
+func TestTCPSourceAllow(t *testing.T) {
+
+	denied, err := ListenTCP("127.0.0.1:0", ListenOptions{Allow: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+	defer denied.Close()
+
+	conn, err := net.Dial("tcp", denied.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(eventLine + "\n"))
+	if env, err := receiveWithin(denied, 200*time.Millisecond); err == nil {
+		t.Errorf("Receive() = %+v, want the connection of a sender outside the allow list dropped", env)
+	}
+
+	allowed, err := ListenTCP("127.0.0.1:0", ListenOptions{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+	defer allowed.Close()
+
+	conn, err = net.Dial("tcp", allowed.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// the identity sent by the device is overwritten.
+	conn.Write([]byte(eventLine + " " + SourceIdentityKey + "=spoofed\n"))
+	env, err := receiveWithin(allowed, time.Second)
+	if err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+	if got := env.Event.Extensions[SourceIdentityKey]; got != "127.0.0.1" {
+		t.Errorf("Receive() %s = %q, want %q", SourceIdentityKey, got, "127.0.0.1")
+	}
+}
+
+func TestUDPSourceAllow(t *testing.T) {
+
+	source, err := ListenUDP("127.0.0.1:0", ListenOptions{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.2/32")}})
+	if err != nil {
+		t.Fatalf("ListenUDP() = %v", err)
+	}
+	defer source.Close()
+
+	for _, local := range []string{"127.0.0.1", "127.0.0.2"} {
+		conn, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP(local)}, source.Addr().(*net.UDPAddr))
+		if err != nil {
+			t.Skipf("DialUDP() from %s = %v", local, err)
+		}
+		defer conn.Close()
+		conn.Write([]byte(eventLine + " msg=" + local))
+	}
+
+	env, err := receiveWithin(source, time.Second)
+	if err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+	if got := env.Event.Extensions["msg"]; got != "127.0.0.2" {
+		t.Errorf("Receive() msg = %q, want the datagram of the allowed sender", got)
+	}
+	if got := env.Event.Extensions[SourceIdentityKey]; got != "127.0.0.2" {
+		t.Errorf("Receive() %s = %q, want %q", SourceIdentityKey, got, "127.0.0.2")
+	}
+}
+
+// testCertificate issues a certificate for the common name, signed by the
+// parent or self-signed when parent is nil.
+func testCertificate(t *testing.T, cn string, parent *tls.Certificate) tls.Certificate {
+
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestTCPSourceMutualTLS(t *testing.T) {
+
+	ca := testCertificate(t, "Test CA", nil)
+	server := testCertificate(t, "collector", &ca)
+	client := testCertificate(t, "fw01.example.com", &ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	source, err := ListenTCP("127.0.0.1:0", ListenOptions{
+		IdleTimeout: time.Second,
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{server},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+	})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+	defer source.Close()
+
+	conn, err := tls.Dial("tcp", source.Addr().String(), &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{client}})
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(eventLine + "\n"))
+
+	env, err := receiveWithin(source, time.Second)
+	if err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+	if got := env.Event.Extensions[SourceIdentityKey]; got != "fw01.example.com" {
+		t.Errorf("Receive() %s = %q, want the common name of the client certificate", SourceIdentityKey, got)
+	}
+
+	anonymous, err := tls.Dial("tcp", source.Addr().String(), &tls.Config{RootCAs: pool})
+	if err == nil {
+		defer anonymous.Close()
+		anonymous.Write([]byte(eventLine + "\n"))
+	}
+	plain, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	plain.Write([]byte(eventLine + "\n"))
+	// a pending Receive would take the next event, so this is checked last.
+	if env, err := receiveWithin(source, 200*time.Millisecond); err == nil {
+		t.Errorf("Receive() = %+v, want unauthenticated clients dropped", env)
+	}
+}
@@ -0,0 +1,597 @@
+package cefevent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// The archive container stores events in a compact, checksummed form which
+// can be searched by time without reading the complete file:
+//
+//	header  "CEFA" version(1)
+//	record  'R' length(uint32) crc(uint32) time(int64) payload
+//	index   'I' chunk(int64) min(int64) max(int64) count(uint32) previous(int64) crc(uint32)
+//	footer  'F' last index(int64) "CEFZ"
+//
+// Records hold the MarshalBinary serialization of an event and the time it
+// is filed under in Unix nanoseconds, the CRC-32C covers the time and the
+// payload. Every IndexInterval records an index block summarizes the
+// preceding chunk of records, the index blocks are chained backwards and the
+// footer points to the last one. All integers are little endian.
+const (
+	archiveVersion     = 1
+	archiveRecordType  = 'R'
+	archiveIndexType   = 'I'
+	archiveFooterType  = 'F'
+	archiveRecordHead  = 1 + 4 + 4 + 8
+	archiveIndexSize   = 1 + 8 + 8 + 8 + 4 + 8 + 4
+	archiveFooterSize  = 1 + 8 + 4
+	archiveMaxRecord   = 64 * 1024 * 1024
+	archiveNoIndex     = -1
+	defaultArchiveSpan = 1000
+)
+
+var (
+	archiveMagic       = []byte("CEFA")
+	archiveFooterMagic = []byte("CEFZ")
+	archiveCRCTable    = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// ErrArchiveChecksum is returned by ArchiveReader.Next for a record whose
+// checksum does not match, the reader can continue with the next record.
+var ErrArchiveChecksum = errors.New("archive record checksum mismatch")
+
+// ArchiveOptions configures an ArchiveWriter.
+type ArchiveOptions struct {
+	IndexInterval int // IndexInterval is the number of records per index block, defaults to 1000.
+}
+
+// archiveChunk is the summary of a run of records stored in an index block.
+type archiveChunk struct {
+	offset int64
+	min    int64
+	max    int64
+	count  uint32
+}
+
+// ArchiveWriter writes events into the archive container.
+type ArchiveWriter struct {
+	w         *bufio.Writer
+	closer    io.Closer
+	opts      ArchiveOptions
+	offset    int64
+	lastIndex int64
+	chunk     archiveChunk
+	closed    bool
+}
+
+// NewArchiveWriter writes the archive header to w and returns a writer
+// appending records to it.
+//
+// Parameters:
+// - w: The destination of the archive, if it is an io.Closer it is closed by Close.
+// - opts: The index options, zero values select the defaults.
+//
+// Returns:
+// - A pointer to an ArchiveWriter, which must be closed to write the final index and footer.
+// - An error if the header could not be written.
+func NewArchiveWriter(w io.Writer, opts ArchiveOptions) (*ArchiveWriter, error) {
+
+	if opts.IndexInterval <= 0 {
+		opts.IndexInterval = defaultArchiveSpan
+	}
+
+	aw := &ArchiveWriter{w: bufio.NewWriter(w), opts: opts, lastIndex: archiveNoIndex}
+	if closer, ok := w.(io.Closer); ok {
+		aw.closer = closer
+	}
+
+	if err := aw.write(append(append([]byte(nil), archiveMagic...), archiveVersion)); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// write writes buf and advances the offset.
+func (aw *ArchiveWriter) write(buf []byte) error {
+
+	n, err := aw.w.Write(buf)
+	aw.offset += int64(n)
+
+	return err
+}
+
+// Write appends the event as a record filed under the time t, usually the
+// time the event occurred at.
+//
+// Returns:
+// - An error if the event is not valid or could not be written.
+func (aw *ArchiveWriter) Write(t time.Time, event CefEvent) error {
+
+	if aw.closed {
+		return errors.New("archive writer is closed")
+	}
+
+	payload, err := event.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > archiveMaxRecord {
+		return fmt.Errorf("archive record of %d bytes exceeds %d bytes", len(payload), archiveMaxRecord)
+	}
+
+	nanos := t.UnixNano()
+
+	record := make([]byte, archiveRecordHead, archiveRecordHead+len(payload))
+	record[0] = archiveRecordType
+	binary.LittleEndian.PutUint32(record[1:], uint32(len(payload)))
+	binary.LittleEndian.PutUint64(record[9:], uint64(nanos))
+	record = append(record, payload...)
+	binary.LittleEndian.PutUint32(record[5:], crc32.Checksum(record[9:], archiveCRCTable))
+
+	if aw.chunk.count == 0 {
+		aw.chunk = archiveChunk{offset: aw.offset, min: nanos, max: nanos}
+	}
+
+	if err := aw.write(record); err != nil {
+		return err
+	}
+
+	aw.chunk.count++
+	aw.chunk.min = min(aw.chunk.min, nanos)
+	aw.chunk.max = max(aw.chunk.max, nanos)
+
+	if int(aw.chunk.count) >= aw.opts.IndexInterval {
+		return aw.writeIndex()
+	}
+
+	return nil
+}
+
+// writeIndex writes the index block of the current chunk.
+func (aw *ArchiveWriter) writeIndex() error {
+
+	block := make([]byte, archiveIndexSize)
+	block[0] = archiveIndexType
+	binary.LittleEndian.PutUint64(block[1:], uint64(aw.chunk.offset))
+	binary.LittleEndian.PutUint64(block[9:], uint64(aw.chunk.min))
+	binary.LittleEndian.PutUint64(block[17:], uint64(aw.chunk.max))
+	binary.LittleEndian.PutUint32(block[25:], aw.chunk.count)
+	binary.LittleEndian.PutUint64(block[29:], uint64(aw.lastIndex))
+	binary.LittleEndian.PutUint32(block[37:], crc32.Checksum(block[1:37], archiveCRCTable))
+
+	offset := aw.offset
+	if err := aw.write(block); err != nil {
+		return err
+	}
+
+	aw.lastIndex = offset
+	aw.chunk = archiveChunk{}
+
+	return nil
+}
+
+// Close writes the index of the last chunk and the footer, flushes the
+// archive and closes the underlying writer if it implements io.Closer.
+func (aw *ArchiveWriter) Close() error {
+
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+
+	var errs []error
+	if aw.chunk.count > 0 {
+		errs = append(errs, aw.writeIndex())
+	}
+
+	footer := make([]byte, 0, archiveFooterSize)
+	footer = append(footer, archiveFooterType)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(aw.lastIndex))
+	footer = append(footer, archiveFooterMagic...)
+
+	errs = append(errs, aw.write(footer), aw.w.Flush())
+
+	if aw.closer != nil {
+		errs = append(errs, aw.closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// ArchiveRecord is a single event read from an archive.
+type ArchiveRecord struct {
+	Time   time.Time // Time is the time the event was filed under.
+	Event  CefEvent  // Event is the archived event.
+	Offset int64     // Offset is the position of the record in the archive.
+}
+
+// ArchiveReader reads the records of an archive and seeks to records by time.
+type ArchiveReader struct {
+	r       io.ReadSeeker
+	br      *bufio.Reader
+	offset  int64
+	chunks  []archiveChunk
+	onIndex func(chunk archiveChunk)
+}
+
+// OpenArchive reads the header and index of an archive.
+//
+// The index is located through the footer. Archives which were not closed
+// properly lack the footer, their index is rebuilt by scanning all records
+// once, which is slow but recovers every complete record.
+//
+// Returns:
+// - A pointer to an ArchiveReader positioned at the first record.
+// - An error if the input is not an archive or could not be read.
+func OpenArchive(r io.ReadSeeker) (*ArchiveReader, error) {
+
+	ar := &ArchiveReader{r: r}
+
+	header := make([]byte, len(archiveMagic)+1)
+	if _, err := ar.seek(0); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(ar.br, header); err != nil || !bytes.Equal(header[:len(archiveMagic)], archiveMagic) {
+		return nil, errors.New("not a CEF archive")
+	}
+	if header[len(archiveMagic)] != archiveVersion {
+		return nil, fmt.Errorf("unsupported CEF archive version %d", header[len(archiveMagic)])
+	}
+
+	if err := ar.loadIndex(); err != nil {
+		if err := ar.scanIndex(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := ar.seek(int64(len(header))); err != nil {
+		return nil, err
+	}
+
+	return ar, nil
+}
+
+// seek positions the reader at the absolute offset.
+func (ar *ArchiveReader) seek(offset int64) (int64, error) {
+
+	offset, err := ar.r.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	ar.offset = offset
+	if ar.br == nil {
+		ar.br = bufio.NewReader(ar.r)
+	} else {
+		ar.br.Reset(ar.r)
+	}
+
+	return offset, nil
+}
+
+// read fills buf and advances the offset.
+func (ar *ArchiveReader) read(buf []byte) error {
+
+	n, err := io.ReadFull(ar.br, buf)
+	ar.offset += int64(n)
+
+	return err
+}
+
+// loadIndex reads the chain of index blocks starting at the footer, every
+// block must point to one before it, so a corrupt chain can not loop.
+func (ar *ArchiveReader) loadIndex() error {
+
+	end, err := ar.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	footer := make([]byte, archiveFooterSize)
+	if _, err := ar.seek(end - archiveFooterSize); err != nil {
+		return err
+	}
+	if err := ar.read(footer); err != nil {
+		return err
+	}
+	if footer[0] != archiveFooterType || !bytes.Equal(footer[9:], archiveFooterMagic) {
+		return errors.New("archive has no footer")
+	}
+
+	var chunks []archiveChunk
+	block := make([]byte, archiveIndexSize)
+
+	for next := int64(binary.LittleEndian.Uint64(footer[1:])); next != archiveNoIndex; {
+		if next < 0 || next >= end {
+			return errors.New("archive index is corrupt")
+		}
+		if _, err := ar.seek(next); err != nil {
+			return err
+		}
+		if err := ar.read(block); err != nil {
+			return err
+		}
+
+		chunk, previous, err := parseArchiveIndex(block)
+		if err != nil {
+			return err
+		}
+		if previous != archiveNoIndex && previous >= next {
+			return errors.New("archive index is corrupt")
+		}
+		chunks = append(chunks, chunk)
+		next = previous
+	}
+
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].offset < chunks[j].offset })
+	ar.chunks = chunks
+
+	return nil
+}
+
+// parseArchiveIndex decodes and verifies an index block.
+func parseArchiveIndex(block []byte) (archiveChunk, int64, error) {
+
+	if block[0] != archiveIndexType || crc32.Checksum(block[1:37], archiveCRCTable) != binary.LittleEndian.Uint32(block[37:]) {
+		return archiveChunk{}, 0, errors.New("archive index is corrupt")
+	}
+
+	chunk := archiveChunk{
+		offset: int64(binary.LittleEndian.Uint64(block[1:])),
+		min:    int64(binary.LittleEndian.Uint64(block[9:])),
+		max:    int64(binary.LittleEndian.Uint64(block[17:])),
+		count:  binary.LittleEndian.Uint32(block[25:]),
+	}
+
+	return chunk, int64(binary.LittleEndian.Uint64(block[29:])), nil
+}
+
+// scanIndex rebuilds the index by reading every record of the archive,
+// the index blocks which were written are used as they are found and the
+// records following the last one are summarized in chunks of their own of
+// as many records as the first index block covers, the IndexInterval of
+// the archive.
+func (ar *ArchiveReader) scanIndex() error {
+
+	if _, err := ar.seek(int64(len(archiveMagic) + 1)); err != nil {
+		return err
+	}
+
+	ar.chunks = nil
+	chunk := archiveChunk{min: math.MaxInt64, max: math.MinInt64}
+	span, indexed := defaultArchiveSpan, 0
+
+	// an index block replaces the chunks summarized since the previous one.
+	ar.onIndex = func(block archiveChunk) {
+		if indexed == 0 && block.count > 0 {
+			span = int(block.count)
+		}
+		ar.chunks = append(ar.chunks[:indexed], block)
+		indexed = len(ar.chunks)
+		chunk = archiveChunk{min: math.MaxInt64, max: math.MinInt64}
+	}
+	defer func() { ar.onIndex = nil }()
+
+	for {
+		record, err := ar.Next()
+		if errors.Is(err, ErrArchiveChecksum) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		if chunk.count == 0 {
+			chunk.offset = record.Offset
+		}
+		chunk.count++
+		chunk.min = min(chunk.min, record.Time.UnixNano())
+		chunk.max = max(chunk.max, record.Time.UnixNano())
+
+		if int(chunk.count) >= span {
+			ar.chunks = append(ar.chunks, chunk)
+			chunk = archiveChunk{min: math.MaxInt64, max: math.MinInt64}
+		}
+	}
+
+	if chunk.count > 0 {
+		ar.chunks = append(ar.chunks, chunk)
+	}
+
+	return nil
+}
+
+// errRecordSkipped is returned by next for records which were not wanted.
+var errRecordSkipped = errors.New("archive record skipped")
+
+// Next returns the next record of the archive.
+//
+// Returns:
+// - The next ArchiveRecord.
+// - io.EOF at the end of the archive, io.ErrUnexpectedEOF for a truncated record or ErrArchiveChecksum for a corrupt record, which is skipped.
+func (ar *ArchiveReader) Next() (ArchiveRecord, error) {
+	return ar.next(nil)
+}
+
+// next reads the next record, records whose time is not wanted are not
+// decoded and reported with errRecordSkipped. A nil want decodes all records.
+func (ar *ArchiveReader) next(want func(nanos int64) bool) (ArchiveRecord, error) {
+
+	head := make([]byte, archiveRecordHead)
+
+	for {
+		offset := ar.offset
+
+		kind, err := ar.br.ReadByte()
+		if err != nil {
+			return ArchiveRecord{}, io.EOF
+		}
+		ar.offset++
+
+		switch kind {
+		case archiveIndexType:
+			block := make([]byte, archiveIndexSize)
+			block[0] = kind
+			if err := ar.read(block[1:]); err != nil {
+				return ArchiveRecord{}, io.ErrUnexpectedEOF
+			}
+			if ar.onIndex != nil {
+				if chunk, _, err := parseArchiveIndex(block); err == nil {
+					ar.onIndex(chunk)
+				}
+			}
+			continue
+		case archiveFooterType:
+			return ArchiveRecord{}, io.EOF
+		case archiveRecordType:
+		default:
+			return ArchiveRecord{}, fmt.Errorf("archive is corrupt at offset %d", offset)
+		}
+
+		head[0] = kind
+		if err := ar.read(head[1:]); err != nil {
+			return ArchiveRecord{}, io.ErrUnexpectedEOF
+		}
+
+		length := binary.LittleEndian.Uint32(head[1:])
+		if length > archiveMaxRecord {
+			return ArchiveRecord{}, fmt.Errorf("archive is corrupt at offset %d", offset)
+		}
+
+		payload := make([]byte, length)
+		if err := ar.read(payload); err != nil {
+			return ArchiveRecord{}, io.ErrUnexpectedEOF
+		}
+
+		nanos := int64(binary.LittleEndian.Uint64(head[9:]))
+		if want != nil && !want(nanos) {
+			return ArchiveRecord{Time: time.Unix(0, nanos), Offset: offset}, errRecordSkipped
+		}
+
+		crc := crc32.Update(crc32.Checksum(head[9:], archiveCRCTable), archiveCRCTable, payload)
+		if crc != binary.LittleEndian.Uint32(head[5:]) {
+			return ArchiveRecord{Offset: offset}, fmt.Errorf("%w at offset %d", ErrArchiveChecksum, offset)
+		}
+
+		var event CefEvent
+		if err := event.UnmarshalBinary(payload); err != nil {
+			return ArchiveRecord{Offset: offset}, err
+		}
+
+		return ArchiveRecord{
+			Time:   time.Unix(0, nanos),
+			Event:  event,
+			Offset: offset,
+		}, nil
+	}
+}
+
+// Seek positions the reader at the first chunk of records which may
+// contain records filed at or after t, skipping all chunks which end
+// before it. Records are not required to be in time order, the records
+// returned by Next afterwards may therefore still be older than t.
+//
+// Returns:
+// - An error if the archive could not be repositioned.
+func (ar *ArchiveReader) Seek(t time.Time) error {
+
+	nanos := t.UnixNano()
+	for _, chunk := range ar.chunks {
+		if chunk.max >= nanos {
+			_, err := ar.seek(chunk.offset)
+			return err
+		}
+	}
+
+	// no chunk contains newer records, position at the end.
+	_, err := ar.r.Seek(0, io.SeekEnd)
+	ar.br.Reset(eofReader{})
+
+	return err
+}
+
+// Extract calls fn for every record of the archive filed within the time
+// range. Only the chunks of records whose index overlaps the range are read
+// and only the records within the range are decoded, corrupt records are
+// skipped.
+//
+// Returns:
+// - An error if the archive could not be read or fn returned one.
+func (ar *ArchiveReader) Extract(tr TimeRange, fn func(record ArchiveRecord) error) error {
+
+	want := func(nanos int64) bool {
+		return tr.Contains(time.Unix(0, nanos))
+	}
+
+	for _, chunk := range ar.chunks {
+		if !tr.overlaps(chunk.min, chunk.max) {
+			continue
+		}
+
+		if _, err := ar.seek(chunk.offset); err != nil {
+			return err
+		}
+
+		for i := uint32(0); i < chunk.count; i++ {
+			record, err := ar.next(want)
+			if errors.Is(err, errRecordSkipped) || errors.Is(err, ErrArchiveChecksum) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Range returns the time range of all records of the archive according to its index.
+//
+// Returns:
+// - The earliest and latest time of the archived records, zero times for an empty archive.
+func (ar *ArchiveReader) Range() (time.Time, time.Time) {
+
+	if len(ar.chunks) == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	first, last := ar.chunks[0].min, ar.chunks[0].max
+	for _, chunk := range ar.chunks[1:] {
+		first, last = min(first, chunk.min), max(last, chunk.max)
+	}
+
+	return time.Unix(0, first), time.Unix(0, last)
+}
+
+// Len returns the number of records in the archive according to its index.
+func (ar *ArchiveReader) Len() int {
+
+	n := 0
+	for _, chunk := range ar.chunks {
+		n += int(chunk.count)
+	}
+
+	return n
+}
+
+// eofReader is an io.Reader which is always exhausted.
+type eofReader struct{}
+
+func (eofReader) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
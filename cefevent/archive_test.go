@@ -0,0 +1,173 @@
+package cefevent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeTestArchive archives n events filed one minute apart.
+func writeTestArchive(t *testing.T, n int, close bool) (*bytes.Buffer, time.Time) {
+
+	var buf bytes.Buffer
+	aw, err := NewArchiveWriter(&buf, ArchiveOptions{IndexInterval: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		archived := event
+		archived.Extensions = map[string]string{"cnt": strconv.Itoa(i)}
+		if err := aw.Write(start.Add(time.Duration(i)*time.Minute), archived); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+
+	if close {
+		aw.Close()
+	} else {
+		aw.w.Flush()
+	}
+
+	return &buf, start
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+
+	buf, start := writeTestArchive(t, 25, true)
+
+	ar, err := OpenArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("OpenArchive() = %v", err)
+	}
+
+	if ar.Len() != 25 {
+		t.Errorf("Len() = %d, want 25", ar.Len())
+	}
+
+	if first, last := ar.Range(); !first.Equal(start) || !last.Equal(start.Add(24*time.Minute)) {
+		t.Errorf("Range() = %v, %v", first, last)
+	}
+
+	for i := 0; i < 25; i++ {
+		record, err := ar.Next()
+		if err != nil {
+			t.Fatalf("Next() = %v", err)
+		}
+		if record.Event.Extensions["cnt"] != strconv.Itoa(i) || !record.Time.Equal(start.Add(time.Duration(i)*time.Minute)) {
+			t.Errorf("Next() = %v at %v, want record %d", record.Event.Extensions, record.Time, i)
+		}
+	}
+
+	if _, err := ar.Next(); err != io.EOF {
+		t.Errorf("Next() = %v at the end, want io.EOF", err)
+	}
+}
+
+func TestArchiveSeek(t *testing.T) {
+
+	for _, closed := range []bool{true, false} {
+
+		buf, start := writeTestArchive(t, 25, closed)
+
+		ar, err := OpenArchive(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("OpenArchive() = %v", err)
+		}
+
+		if err := ar.Seek(start.Add(22 * time.Minute)); err != nil {
+			t.Fatalf("Seek() = %v", err)
+		}
+
+		// the seek lands at the start of the chunk containing the 23rd record.
+		record, err := ar.Next()
+		if err != nil || record.Event.Extensions["cnt"] != "20" {
+			t.Errorf("Next() after Seek() = %v, %v, want record 20 (closed: %v)", record.Event.Extensions, err, closed)
+		}
+
+		ar.Seek(start.Add(time.Hour))
+		if _, err := ar.Next(); err != io.EOF {
+			t.Errorf("Next() after seeking past the end = %v, want io.EOF", err)
+		}
+	}
+}
+
+func TestArchiveChecksum(t *testing.T) {
+
+	buf, _ := writeTestArchive(t, 3, true)
+	data := buf.Bytes()
+
+	// corrupt the payload of the first record.
+	data[len(archiveMagic)+1+archiveRecordHead+10] ^= 0xff
+
+	ar, err := OpenArchive(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("OpenArchive() = %v", err)
+	}
+
+	if _, err := ar.Next(); !errors.Is(err, ErrArchiveChecksum) {
+		t.Errorf("Next() = %v, want ErrArchiveChecksum", err)
+	}
+
+	if record, err := ar.Next(); err != nil || record.Event.Extensions["cnt"] != "1" {
+		t.Errorf("Next() after a corrupt record = %v, %v, want record 1", record.Event.Extensions, err)
+	}
+
+	if _, err := OpenArchive(bytes.NewReader([]byte("CEF:0|"))); err == nil {
+		t.Errorf("OpenArchive() should fail for a plain CEF file")
+	}
+}
+
+func TestArchiveCorruptIndex(t *testing.T) {
+
+	buf, _ := writeTestArchive(t, 25, true)
+	data := buf.Bytes()
+
+	// point the last index block at itself, with a valid checksum.
+	last := int64(binary.LittleEndian.Uint64(data[len(data)-archiveFooterSize+1:]))
+	block := data[last : last+archiveIndexSize]
+	binary.LittleEndian.PutUint64(block[29:], uint64(last))
+	binary.LittleEndian.PutUint32(block[37:], crc32.Checksum(block[1:37], archiveCRCTable))
+
+	ar := &ArchiveReader{r: bytes.NewReader(data)}
+	if err := ar.loadIndex(); err == nil {
+		t.Errorf("loadIndex() = nil, want an error for a looping index")
+	}
+
+	// the index is rebuilt from the records instead.
+	ar, err := OpenArchive(bytes.NewReader(data))
+	if err != nil || ar.Len() != 25 {
+		t.Fatalf("OpenArchive() = %v, want the 25 records", err)
+	}
+}
+
+func TestArchiveScanIndexInterval(t *testing.T) {
+
+	var buf bytes.Buffer
+	aw, err := NewArchiveWriter(&buf, ArchiveOptions{IndexInterval: 1500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2900; i++ {
+		if err := aw.Write(start.Add(time.Duration(i)*time.Second), event); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+	}
+	aw.w.Flush()
+
+	// without footer, the records after the index block form one chunk of the interval.
+	ar, err := OpenArchive(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("OpenArchive() = %v", err)
+	}
+	if ar.Len() != 2900 || len(ar.chunks) != 2 || ar.chunks[0].count != 1500 || ar.chunks[1].count != 1400 {
+		t.Errorf("OpenArchive() indexed %d records in %+v, want chunks of 1500 and 1400 records", ar.Len(), ar.chunks)
+	}
+}
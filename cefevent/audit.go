@@ -0,0 +1,39 @@
+package cefevent
+
+import (
+	"strconv"
+	"time"
+)
+
+// The extensions stamped on events which stand in for events dropped by
+// sampling or duplicate suppression, so SIEM analytics can account for the
+// missing data. They are only added when auditing is enabled, see
+// SamplerOptions.Audit and Suppressor.SetAudit.
+const (
+	AuditPolicyKey  = "auditPolicy"     // AuditPolicyKey names the policy which dropped events, AuditPolicySampling or AuditPolicySuppression.
+	AuditRateKey    = "auditSampleRate" // AuditRateKey is the sample rate N, one in N events of the kind was kept.
+	AuditDroppedKey = "auditDropped"    // AuditDroppedKey is the number of events of the kind dropped since the previous one was passed on.
+	AuditWindowKey  = "auditWindow"     // AuditWindowKey is the window of the policy in milliseconds.
+)
+
+// The values of the AuditPolicyKey extension.
+const (
+	AuditPolicySampling    = "adaptive-sampling"
+	AuditPolicySuppression = "duplicate-suppression"
+)
+
+// stampAudit returns a copy of the event carrying the audit extensions, a
+// rate of zero is left out.
+func stampAudit(event CefEvent, policy string, rate uint64, dropped uint64, window time.Duration) CefEvent {
+
+	extensions := cloneExtensions(event.Extensions)
+	extensions[AuditPolicyKey] = policy
+	if rate > 0 {
+		extensions[AuditRateKey] = strconv.FormatUint(rate, 10)
+	}
+	extensions[AuditDroppedKey] = strconv.FormatUint(dropped, 10)
+	extensions[AuditWindowKey] = strconv.FormatInt(window.Milliseconds(), 10)
+	event.Extensions = extensions
+
+	return event
+}
@@ -0,0 +1,224 @@
+package cefevent
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// AvroSchema is the Avro schema of the events written by ToAvro, e.g. to
+// register it for a Kafka topic in a schema registry. The header fields are
+// named like the fields of CefEvent and the extensions are a map of strings.
+const AvroSchema = `{
+  "type": "record",
+  "name": "CefEvent",
+  "namespace": "com.github.pcktdmp.cef",
+  "fields": [
+    {"name": "Version", "type": "int"},
+    {"name": "DeviceVendor", "type": "string"},
+    {"name": "DeviceProduct", "type": "string"},
+    {"name": "DeviceVersion", "type": "string"},
+    {"name": "DeviceEventClassId", "type": "string"},
+    {"name": "Name", "type": "string"},
+    {"name": "Severity", "type": "string"},
+    {"name": "Extensions", "type": {"type": "map", "values": "string"}, "default": {}}
+  ]
+}`
+
+// errAvroTruncated is remembered by an avroReader for data ending within a value.
+var errAvroTruncated = errors.New("Avro encoding is truncated")
+
+// avroWireMagic starts every message of the schema registry wire format,
+// followed by the schema ID as 4 byte big-endian integer.
+const avroWireMagic = 0
+
+// ToAvro converts the CefEvent instance to the Avro binary encoding of
+// AvroSchema. The extensions are written as a single map block sorted by
+// key, so equal events encode to equal bytes.
+//
+// Returns:
+// - The Avro encoded event, without schema or container file header.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToAvro() ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	return event.appendAvro(nil), nil
+}
+
+// appendAvro appends the Avro binary encoding of the event to buf.
+func (event *CefEvent) appendAvro(buf []byte) []byte {
+
+	buf = binary.AppendVarint(buf, int64(event.Version))
+	for _, name := range headerFieldNames[1:] {
+		buf = appendAvroString(buf, *documentHeader(event, name))
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) > 0 {
+		buf = binary.AppendVarint(buf, int64(len(keys)))
+		for _, k := range keys {
+			buf = appendAvroString(buf, k)
+			buf = appendAvroString(buf, event.Extensions[k])
+		}
+	}
+
+	return binary.AppendVarint(buf, 0)
+}
+
+// ToAvroMessage converts the CefEvent instance to a message of the schema
+// registry wire format: a zero byte, the schema ID as 4 byte big-endian
+// integer and the Avro encoding of ToAvro. Kafka consumers resolving the
+// schema by its ID read the events without further configuration.
+//
+// Parameters:
+// - schemaID: The ID the schema registry assigned to AvroSchema.
+//
+// Returns:
+// - The message.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToAvroMessage(schemaID uint32) ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := binary.BigEndian.AppendUint32([]byte{avroWireMagic}, schemaID)
+
+	return event.appendAvro(buf), nil
+}
+
+// FromAvro is the inverse of ToAvro, it reads an event from its Avro binary
+// encoding and validates it like FromJSON. Maps split into several blocks,
+// as other Avro encoders may write them, are read as well.
+//
+// Parameters:
+// - data: The Avro encoded event.
+//
+// Returns:
+// - The CefEvent.
+// - A *DocumentError with Format "Avro" if the data is truncated, followed
+// by further data or violates the schema, it wraps ErrMalformedDocument,
+// ErrMissingField, ErrInvalidVersion or ErrMalformedExtension.
+func FromAvro(data []byte) (CefEvent, error) {
+
+	d := newDocumentDecoder("Avro")
+	r := avroReader{data: data}
+
+	if err := d.header(headerFieldNames[0], strconv.FormatInt(r.long(), 10)); err != nil {
+		return CefEvent{}, err
+	}
+	for _, name := range headerFieldNames[1:] {
+		if err := d.header(name, r.string()); err != nil {
+			return CefEvent{}, err
+		}
+	}
+
+	for r.err == nil {
+		count := r.long()
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// a negative count is followed by the size of the block in bytes.
+			count = -count
+			r.long()
+		}
+		for i := int64(0); i < count && r.err == nil; i++ {
+			k := r.string()
+			v := r.string()
+			if r.err == nil {
+				if err := d.extension(k, v); err != nil {
+					return CefEvent{}, err
+				}
+			}
+		}
+	}
+
+	if r.err != nil {
+		return CefEvent{}, d.errorf("", ErrMalformedDocument, "event is truncated")
+	}
+	if len(r.data) > 0 {
+		return CefEvent{}, d.errorf("", ErrMalformedDocument, "unexpected data after the event")
+	}
+
+	return d.finish()
+}
+
+// FromAvroMessage is the inverse of ToAvroMessage, it reads an event from a
+// message of the schema registry wire format.
+//
+// Parameters:
+// - data: The message.
+//
+// Returns:
+// - The CefEvent.
+// - The schema ID of the message, so consumers can reject unknown schemas.
+// - A *DocumentError like FromAvro, also if the message does not start with the wire format header.
+func FromAvroMessage(data []byte) (CefEvent, uint32, error) {
+
+	if len(data) < 5 || data[0] != avroWireMagic {
+		return CefEvent{}, 0, &DocumentError{Format: "Avro", Reason: "missing schema registry header", Err: ErrMalformedDocument}
+	}
+
+	schemaID := binary.BigEndian.Uint32(data[1:5])
+	event, err := FromAvro(data[5:])
+
+	return event, schemaID, err
+}
+
+// appendAvroString appends the length prefixed string to buf, Avro encodes
+// the length as zig-zag varint.
+func appendAvroString(buf []byte, s string) []byte {
+	buf = binary.AppendVarint(buf, int64(len(s)))
+	return append(buf, s...)
+}
+
+// avroReader decodes the values of an Avro encoding, the first error is
+// remembered and makes all further reads return zero values.
+type avroReader struct {
+	data []byte
+	err  error
+}
+
+func (r *avroReader) long() int64 {
+
+	if r.err != nil {
+		return 0
+	}
+
+	value, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errAvroTruncated
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return value
+}
+
+func (r *avroReader) string() string {
+
+	length := r.long()
+	if r.err != nil {
+		return ""
+	}
+
+	if length < 0 || length > int64(len(r.data)) {
+		r.err = errAvroTruncated
+		return ""
+	}
+
+	s := string(r.data[:length])
+	r.data = r.data[length:]
+
+	return s
+}
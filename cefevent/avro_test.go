@@ -0,0 +1,82 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCefEventAvro(t *testing.T) {
+
+	tricky := event
+	tricky.Name = "ünïcode | pipe"
+	tricky.Extensions = map[string]string{"src": "127.0.0.1", "msg": "line\nbreak", "empty": ""}
+
+	for _, e := range []CefEvent{event, tricky, {Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "1", Name: "N", Severity: "5"}} {
+		data, err := e.ToAvro()
+		if err != nil {
+			t.Fatalf("ToAvro() = %v", err)
+		}
+		got, err := FromAvro(data)
+		if err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("FromAvro(%x) = %#v, %v, want %#v", data, got, err, e)
+		}
+	}
+
+	// the encoding of Avro: zig-zag varints, length prefixed strings and map blocks ending with a zero count.
+	small := CefEvent{Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "2", Name: "N", Severity: "5", Extensions: map[string]string{"a": "b"}}
+	header := []byte{0x02, 0x02, 'V', 0x02, 'P', 0x02, '1', 0x02, '2', 0x02, 'N', 0x02, '5'}
+	want := append(append([]byte(nil), header...), 0x02, 0x02, 'a', 0x02, 'b', 0x00)
+	if got, _ := small.ToAvro(); !bytes.Equal(got, want) {
+		t.Errorf("ToAvro() = %x, want %x", got, want)
+	}
+
+	// a block with a negative count carries its size in bytes.
+	sized := append(append([]byte(nil), header...), 0x01, 0x08, 0x02, 'a', 0x02, 'b', 0x00)
+	if got, err := FromAvro(sized); err != nil || !reflect.DeepEqual(got, small) {
+		t.Errorf("FromAvro(%x) = %#v, %v, want %#v", sized, got, err, small)
+	}
+
+	message, err := small.ToAvroMessage(42)
+	if err != nil || !bytes.Equal(message[:5], []byte{0, 0, 0, 0, 42}) || !bytes.Equal(message[5:], want) {
+		t.Errorf("ToAvroMessage(42) = %x, %v", message, err)
+	}
+	if got, id, err := FromAvroMessage(message); err != nil || id != 42 || !reflect.DeepEqual(got, small) {
+		t.Errorf("FromAvroMessage() = %#v, %d, %v, want %#v, 42", got, id, err, small)
+	}
+
+	invalid := small
+	invalid.Name = ""
+	if _, err := invalid.ToAvro(); err == nil {
+		t.Error("ToAvro() of an invalid event = nil, want an error")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrMalformedDocument},
+		{"truncated", want[:len(want)-3], ErrMalformedDocument},
+		{"trailing data", append(append([]byte(nil), want...), 0x00), ErrMalformedDocument},
+		{"negative length", append(append([]byte(nil), header[:2]...), 0x03), ErrMalformedDocument},
+		{"invalid version", append([]byte{0x04}, want[1:]...), ErrInvalidVersion},
+		{"missing field", append(append([]byte(nil), header[:len(header)-2]...), 0x00, 0x00), ErrMissingField},
+		{"duplicate extension", append(append([]byte(nil), header...), 0x04, 0x02, 'a', 0x02, 'b', 0x02, 'a', 0x02, 'c', 0x00), ErrMalformedExtension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromAvro(tt.data)
+			var docErr *DocumentError
+			if !errors.Is(err, tt.want) || !errors.As(err, &docErr) || docErr.Format != "Avro" {
+				t.Errorf("FromAvro() = %v, want a DocumentError wrapping %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := FromAvroMessage(want); !errors.Is(err, ErrMalformedDocument) {
+		t.Errorf("FromAvroMessage() without header = %v, want %v", err, ErrMalformedDocument)
+	}
+}
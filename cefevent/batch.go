@@ -0,0 +1,55 @@
+package cefevent
+
+import (
+	"runtime"
+	"sync"
+)
+
+// batchChunkSize is the number of lines a worker of ParseBatch parses at
+// once, it keeps the coordination overhead low for short lines.
+const batchChunkSize = 256
+
+// ParseBatch parses the lines concurrently with a pool of workers, e.g. to
+// re-ingest archived CEF files on multicore machines. The results keep the
+// order of the input, events[i] and errs[i] belong to lines[i].
+//
+// Parameters:
+// - lines: The CEF messages.
+// - workers: The number of goroutines, runtime.GOMAXPROCS(0) if zero or less.
+//
+// Returns:
+// - The parsed events, the zero CefEvent for lines which could not be parsed.
+// - The parse errors, nil for lines which were parsed successfully.
+func ParseBatch(lines []string, workers int) ([]CefEvent, []error) {
+
+	events := make([]CefEvent, len(lines))
+	errs := make([]error, len(lines))
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+batchChunkSize, len(lines))
+				for i := start; i < end; i++ {
+					events[i], errs[i] = Parse(lines[i])
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(lines); start += batchChunkSize {
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+
+	return events, errs
+}
@@ -0,0 +1,41 @@
+package cefevent
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = eventLine + " cnt=" + strconv.Itoa(i)
+	}
+	lines[500] = "not a CEF line"
+
+	for _, workers := range []int{0, 1, 7} {
+
+		events, errs := ParseBatch(lines, workers)
+
+		if len(events) != len(lines) || len(errs) != len(lines) {
+			t.Fatalf("ParseBatch() returned %d events and %d errors, want %d", len(events), len(errs), len(lines))
+		}
+
+		for i := range lines {
+			if i == 500 {
+				if errs[i] == nil || !reflect.DeepEqual(events[i], CefEvent{}) {
+					t.Errorf("ParseBatch() line %d = %+v, %v, want an error", i, events[i], errs[i])
+				}
+				continue
+			}
+			if errs[i] != nil || events[i].Extensions["cnt"] != strconv.Itoa(i) {
+				t.Fatalf("ParseBatch() line %d = %+v, %v, want the input order", i, events[i], errs[i])
+			}
+		}
+	}
+
+	if events, errs := ParseBatch(nil, 4); len(events) != 0 || len(errs) != 0 {
+		t.Errorf("ParseBatch(nil) should return no results")
+	}
+}
@@ -0,0 +1,176 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FailureBundle holds everything needed to reproduce the failed delivery of
+// a single event offline: the raw input line, the parsed event, the error
+// and the configuration of the pipeline it happened in.
+type FailureBundle struct {
+	Time   time.Time       `json:"time"`             // Time is when the failure happened.
+	Error  string          `json:"error"`            // Error is the message of the sink or middleware error.
+	Raw    string          `json:"raw,omitempty"`    // Raw is the input line the event was parsed from, if known.
+	Event  *CefEvent       `json:"event,omitempty"`  // Event is the parsed event as it entered the Emitter.
+	Config json.RawMessage `json:"config,omitempty"` // Config is the JSON snapshot of the configuration.
+}
+
+// LoadFailureBundle reads a bundle written by a FailureCapture.
+//
+// Returns:
+// - The FailureBundle.
+// - An error if the file could not be read or is not a bundle.
+func LoadFailureBundle(path string) (FailureBundle, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FailureBundle{}, err
+	}
+
+	var bundle FailureBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return FailureBundle{}, fmt.Errorf("%s is not a failure bundle: %w", path, err)
+	}
+
+	return bundle, nil
+}
+
+// Replay emits the event of the bundle again, e.g. on an Emitter with the
+// sinks and middlewares of the failed pipeline to reproduce the failure.
+// The raw line is parsed again when the bundle holds no parsed event.
+//
+// Returns:
+// - The error of the Emitter, or the parse error of the raw line.
+func (b FailureBundle) Replay(emitter *Emitter) error {
+
+	if b.Event != nil {
+		return emitter.Emit(*b.Event)
+	}
+
+	event, err := Parse(b.Raw)
+	if err != nil {
+		return err
+	}
+
+	return emitter.Emit(event)
+}
+
+// FailureCapture writes a FailureBundle file for every event a sink or
+// middleware failed on into a directory, so the failures can be reproduced
+// offline and attached to issue reports. The number of bundles is capped,
+// further failures are only counted. A FailureCapture is safe for
+// concurrent use.
+type FailureCapture struct {
+	dir        string
+	config     json.RawMessage
+	maxBundles int
+	opts       FileOptions
+
+	mu      sync.Mutex
+	bundles int
+	dropped uint64
+	seq     uint64
+}
+
+// NewFailureCapture creates the directory when needed and returns a
+// FailureCapture writing bundles into it.
+//
+// Parameters:
+// - dir: The directory the bundles are written to.
+// - config: The configuration included in every bundle, e.g. a PipelineConfig, nil for none.
+// - maxBundles: The maximum number of bundles written, zero or less means unlimited.
+//
+// Returns:
+// - A pointer to a FailureCapture.
+// - An error if the directory could not be created or the configuration could not be marshaled.
+func NewFailureCapture(dir string, config interface{}, maxBundles int) (*FailureCapture, error) {
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	c := &FailureCapture{dir: dir, maxBundles: maxBundles}
+
+	if config != nil {
+		snapshot, err := json.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("unable to snapshot configuration: %w", err)
+		}
+		c.config = snapshot
+	}
+
+	return c, nil
+}
+
+// SetFileOptions sets the permission and ownership of the bundles written
+// from now on, bundles are private to the running user by default. The
+// Atomic option is implied, every bundle is written atomically.
+func (c *FailureCapture) SetFileOptions(opts FileOptions) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts = opts
+}
+
+// Capture writes a bundle for the failed event. Its signature matches the
+// OnFailure callback of Pipeline, so it can be registered directly:
+//
+//	pipeline.OnFailure(capture.Capture)
+//
+// Bundles exceeding the cap, and bundles which could not be written, are
+// dropped and counted.
+func (c *FailureCapture) Capture(raw string, event CefEvent, reason error) {
+
+	bundle := FailureBundle{Time: time.Now().UTC(), Raw: raw, Event: &event, Config: c.config}
+	if reason != nil {
+		bundle.Error = reason.Error()
+	}
+
+	c.mu.Lock()
+	if c.maxBundles > 0 && c.bundles >= c.maxBundles {
+		c.dropped++
+		c.mu.Unlock()
+		return
+	}
+	c.bundles++
+	c.seq++
+	name := fmt.Sprintf("failure-%s-%06d.json", bundle.Time.Format("20060102T150405"), c.seq)
+	opts := c.opts
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(c.dir, name), data, opts, 0o600)
+	}
+
+	if err != nil {
+		c.mu.Lock()
+		c.bundles--
+		c.dropped++
+		c.mu.Unlock()
+	}
+}
+
+// Bundles returns the number of bundles written.
+func (c *FailureCapture) Bundles() int {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bundles
+}
+
+// Dropped returns the number of failures no bundle was written for.
+func (c *FailureCapture) Dropped() uint64 {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.dropped
+}
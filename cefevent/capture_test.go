@@ -0,0 +1,90 @@
+package cefevent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPipelineFailureCapture(t *testing.T) {
+
+	RegisterSink("failing", func(c SinkConfig, format Format) (Sink, error) {
+		return &fakeEndpoint{down: true}, nil
+	})
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.log")
+	os.WriteFile(input, []byte(eventLine+"\n"+eventLine+"\n"), 0o644)
+
+	config := PipelineConfig{
+		Sources: []SourceConfig{{Type: "file", Path: input}},
+		Sinks:   []SinkConfig{{Type: "failing"}},
+		Capture: &CaptureConfig{Dir: filepath.Join(dir, "failures"), MaxBundles: 1},
+	}
+
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	pipeline.Close()
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "failures", "failure-*.json"))
+	if len(paths) != 1 {
+		t.Fatalf("capture wrote %d bundles, want 1", len(paths))
+	}
+
+	bundle, err := LoadFailureBundle(paths[0])
+	if err != nil {
+		t.Fatalf("LoadFailureBundle() = %v", err)
+	}
+
+	if bundle.Raw != eventLine || bundle.Error != "endpoint is down" || !reflect.DeepEqual(*bundle.Event, event) {
+		t.Errorf("LoadFailureBundle() = %+v", bundle)
+	}
+
+	var snapshot PipelineConfig
+	if err := json.Unmarshal(bundle.Config, &snapshot); err != nil || snapshot.Sinks[0].Type != "failing" {
+		t.Errorf("bundle config = %s, %v, want the pipeline configuration", bundle.Config, err)
+	}
+
+	endpoint := &fakeEndpoint{}
+	if err := bundle.Replay(NewSinkEmitter(endpoint)); err != nil || len(endpoint.messages) != 1 {
+		t.Errorf("Replay() = %v, delivered %v", err, endpoint.messages)
+	}
+}
+
+func TestFailureCaptureLimit(t *testing.T) {
+
+	capture, err := NewFailureCapture(t.TempDir(), nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		capture.Capture(eventLine, event, os.ErrDeadlineExceeded)
+	}
+
+	if capture.Bundles() != 2 || capture.Dropped() != 1 {
+		t.Errorf("Bundles(), Dropped() = %d, %d, want 2, 1", capture.Bundles(), capture.Dropped())
+	}
+}
+
+func TestFailureBundleReplayRaw(t *testing.T) {
+
+	endpoint := &fakeEndpoint{}
+	bundle := FailureBundle{Raw: eventLine}
+
+	if err := bundle.Replay(NewSinkEmitter(endpoint)); err != nil || len(endpoint.messages) != 1 || endpoint.messages[0] != eventLine {
+		t.Errorf("Replay() = %v, delivered %v", err, endpoint.messages)
+	}
+
+	if err := (FailureBundle{Raw: "garbage"}).Replay(NewSinkEmitter(endpoint)); err == nil {
+		t.Errorf("Replay() should fail for a raw line which is not CEF")
+	}
+}
@@ -0,0 +1,355 @@
+package cefevent
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// The major types of CBOR (RFC 8949) used by events.
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborText     = 3
+	cborMap      = 5
+)
+
+// cborNull and cborBreak are the simple values null and the end of an
+// indefinite-length map.
+const (
+	cborNull  = 0xf6
+	cborBreak = 0xff
+)
+
+// errCborTruncated is remembered by a cborReader for data ending within a value.
+var errCborTruncated = errors.New("CBOR encoding is truncated")
+
+// ToCBOR converts the CefEvent instance to CBOR (RFC 8949), the compact
+// encoding constrained devices such as embedded sensors use between agent
+// and collector. The event is a map holding the header fields by their JSON
+// names, Version as integer, and the Extensions map sorted by key, all
+// lengths are encoded in the shortest form.
+//
+// Returns:
+// - The CBOR encoded event.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToCBOR() ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := appendCborHead(nil, cborMap, uint64(len(headerFieldNames)+1))
+	buf = appendCborText(buf, headerFieldNames[0])
+	buf = appendCborInt(buf, int64(event.Version))
+	for _, name := range headerFieldNames[1:] {
+		buf = appendCborText(buf, name)
+		buf = appendCborText(buf, *documentHeader(event, name))
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf = appendCborText(buf, extensionsTable)
+	buf = appendCborHead(buf, cborMap, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendCborText(buf, k)
+		buf = appendCborText(buf, event.Extensions[k])
+	}
+
+	return buf, nil
+}
+
+// FromCBOR is the inverse of ToCBOR, it reads an event from a CBOR map and
+// validates it like FromJSON. The fields may come in any order, maps may
+// have an indefinite length as written by streaming encoders, Extensions
+// may be null and Version any integer, other types, tags and unknown fields
+// are rejected.
+//
+// Parameters:
+// - data: The CBOR encoded event.
+//
+// Returns:
+// - The CefEvent.
+// - A *DocumentError with Format "CBOR" if the data is malformed, followed
+// by further data or violates the schema, it wraps ErrMalformedDocument,
+// ErrMissingField, ErrInvalidVersion or ErrMalformedExtension.
+func FromCBOR(data []byte) (CefEvent, error) {
+
+	d := newDocumentDecoder("CBOR")
+	r := cborReader{data: data}
+
+	fields, indefinite, ok := r.mapHeader()
+	if !ok {
+		return CefEvent{}, r.failure(d, "", ErrMalformedDocument, "event is not a map")
+	}
+
+	for i := 0; indefinite || i < fields; i++ {
+		if indefinite && r.end() {
+			break
+		}
+
+		name, ok := r.text()
+		if !ok {
+			return CefEvent{}, r.failure(d, "", ErrMalformedDocument, "field name is not a text string")
+		}
+
+		var err error
+		switch name {
+		case headerFieldNames[0]:
+			version, ok := r.int()
+			if !ok {
+				return CefEvent{}, r.failure(d, name, ErrInvalidVersion, "version must be an integer")
+			}
+			err = d.header(name, strconv.FormatInt(version, 10))
+		case extensionsTable:
+			err = d.cborExtensions(&r)
+		default:
+			if documentHeader(&d.event, name) == nil {
+				return CefEvent{}, d.errorf(name, ErrMalformedDocument, "unknown field")
+			}
+			value, ok := r.text()
+			if !ok {
+				return CefEvent{}, r.failure(d, name, ErrMalformedDocument, "value is not a text string")
+			}
+			err = d.header(name, value)
+		}
+		if err != nil {
+			return CefEvent{}, err
+		}
+	}
+
+	if len(r.data) > 0 {
+		return CefEvent{}, d.errorf("", ErrMalformedDocument, "unexpected data after the event")
+	}
+
+	return d.finish()
+}
+
+// cborExtensions reads the Extensions map, or null, of the event.
+func (d *documentDecoder) cborExtensions(r *cborReader) error {
+
+	if d.seen[extensionsTable] {
+		return d.errorf(extensionsTable, ErrMalformedDocument, "duplicate field")
+	}
+	d.seen[extensionsTable] = true
+
+	if r.null() {
+		return nil
+	}
+	n, indefinite, ok := r.mapHeader()
+	if !ok {
+		return r.failure(d, extensionsTable, ErrMalformedDocument, "extensions must be a map")
+	}
+
+	for i := 0; indefinite || i < n; i++ {
+		if indefinite && r.end() {
+			break
+		}
+
+		key, ok := r.text()
+		if !ok {
+			return r.failure(d, extensionsTable, ErrMalformedExtension, "key is not a text string")
+		}
+		value, ok := r.text()
+		if !ok {
+			return r.failure(d, key, ErrMalformedExtension, "value is not a text string")
+		}
+		if err := d.extension(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendCborHead appends the initial byte of a value of the major type
+// and its argument n in the shortest form to buf.
+func appendCborHead(buf []byte, major byte, n uint64) []byte {
+
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+}
+
+// appendCborText appends s as text string to buf.
+func appendCborText(buf []byte, s string) []byte {
+
+	return append(appendCborHead(buf, cborText, uint64(len(s))), s...)
+}
+
+// appendCborInt appends v as unsigned or negative integer to buf.
+func appendCborInt(buf []byte, v int64) []byte {
+
+	if v < 0 {
+		return appendCborHead(buf, cborNegative, uint64(-1-v))
+	}
+
+	return appendCborHead(buf, cborUnsigned, uint64(v))
+}
+
+// cborReader decodes the values of a CBOR encoding, err is set when a read
+// goes beyond the data.
+type cborReader struct {
+	data []byte
+	err  error
+}
+
+// failure returns a DocumentError, reporting truncated data as such.
+func (r *cborReader) failure(d *documentDecoder, field string, err error, reason string) error {
+
+	if r.err != nil {
+		return d.errorf(field, ErrMalformedDocument, "event is truncated")
+	}
+
+	return d.errorf(field, err, "%s", reason)
+}
+
+// take consumes the first n bytes of the data.
+//
+// Returns:
+// - The bytes.
+// - Whether the data holds n bytes.
+func (r *cborReader) take(n uint64) ([]byte, bool) {
+
+	if n > uint64(len(r.data)) {
+		r.err = errCborTruncated
+		return nil, false
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b, true
+}
+
+// head consumes the initial byte and the argument of a value of the major
+// type, the data is left as it is if the next value has another type.
+//
+// Returns:
+// - The argument, e.g. the length of a string.
+// - Whether the length is indefinite.
+// - Whether a value of the major type was read.
+func (r *cborReader) head(major byte) (uint64, bool, bool) {
+
+	if len(r.data) == 0 {
+		r.err = errCborTruncated
+		return 0, false, false
+	}
+	if r.data[0]>>5 != major {
+		return 0, false, false
+	}
+
+	info := r.data[0] & 0x1f
+	switch {
+	case info < 24:
+		r.data = r.data[1:]
+		return uint64(info), false, true
+	case info == 31:
+		r.data = r.data[1:]
+		return 0, true, true
+	case info > 27:
+		return 0, false, false
+	}
+
+	b, ok := r.take(1 + 1<<(info-24))
+	if !ok {
+		return 0, false, false
+	}
+
+	switch info {
+	case 24:
+		return uint64(b[1]), false, true
+	case 25:
+		return uint64(binary.BigEndian.Uint16(b[1:])), false, true
+	case 26:
+		return uint64(binary.BigEndian.Uint32(b[1:])), false, true
+	}
+
+	return binary.BigEndian.Uint64(b[1:]), false, true
+}
+
+// null consumes a null value.
+func (r *cborReader) null() bool {
+
+	if len(r.data) == 0 || r.data[0] != cborNull {
+		return false
+	}
+	r.data = r.data[1:]
+
+	return true
+}
+
+// end consumes the break code ending an indefinite-length map.
+func (r *cborReader) end() bool {
+
+	if len(r.data) == 0 || r.data[0] != cborBreak {
+		return false
+	}
+	r.data = r.data[1:]
+
+	return true
+}
+
+// mapHeader consumes the head of a map.
+//
+// Returns:
+// - The number of entries of a map of definite length.
+// - Whether the map has an indefinite length and ends with a break code.
+// - Whether a map was read.
+func (r *cborReader) mapHeader() (int, bool, bool) {
+
+	n, indefinite, ok := r.head(cborMap)
+	if !ok || indefinite {
+		return 0, indefinite, ok
+	}
+
+	// every entry takes at least two bytes, larger counts are truncated.
+	if n > uint64(len(r.data))/2 {
+		r.err = errCborTruncated
+		return 0, false, false
+	}
+
+	return int(n), false, true
+}
+
+// text consumes a text string of definite length.
+func (r *cborReader) text() (string, bool) {
+
+	n, indefinite, ok := r.head(cborText)
+	if !ok || indefinite {
+		return "", false
+	}
+
+	b, ok := r.take(n)
+
+	return string(b), ok
+}
+
+// int consumes an unsigned or negative integer which fits into an int64.
+func (r *cborReader) int() (int64, bool) {
+
+	if len(r.data) > 0 && r.data[0]>>5 == cborNegative {
+		n, indefinite, ok := r.head(cborNegative)
+		return -1 - int64(n), ok && !indefinite && n <= math.MaxInt64
+	}
+
+	n, indefinite, ok := r.head(cborUnsigned)
+
+	return int64(n), ok && !indefinite && n <= math.MaxInt64
+}
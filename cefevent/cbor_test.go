@@ -0,0 +1,93 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCefEventCBOR(t *testing.T) {
+
+	tricky := event
+	tricky.Name = "ünïcode | " + strings.Repeat("long ", 100)
+	tricky.Extensions = map[string]string{"src": "127.0.0.1", "msg": strings.Repeat("x", 70000), "empty": ""}
+	for i := 0; i < 30; i++ {
+		tricky.Extensions["custom"+strconv.Itoa(i)] = "value"
+	}
+
+	for _, e := range []CefEvent{event, tricky} {
+		data, err := e.ToCBOR()
+		if err != nil {
+			t.Fatalf("ToCBOR() = %v", err)
+		}
+		got, err := FromCBOR(data)
+		if err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("FromCBOR() = %#v, %v, want %#v", got, err, e)
+		}
+	}
+
+	text := func(s string) []byte { return appendCborText(nil, s) }
+	field := func(name, value string) []byte { return append(text(name), text(value)...) }
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	header := join(field("DeviceVendor", "V"), field("DeviceProduct", "P"), field("DeviceVersion", "1"), field("DeviceEventClassId", "2"), field("Name", "N"), field("Severity", "5"))
+	small := CefEvent{Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "2", Name: "N", Severity: "5"}
+
+	want := join([]byte{0xa8}, text("Version"), []byte{0x01}, header, text("Extensions"), []byte{0xa0})
+	if got, _ := small.ToCBOR(); !bytes.Equal(got, want) {
+		t.Errorf("ToCBOR() = %x, want %x", got, want)
+	}
+
+	// streaming encoders may order the fields differently, use indefinite
+	// lengths, wider integers and null.
+	foreign := join([]byte{0xbf}, text("Extensions"), []byte{0xf6}, header, text("Version"), []byte{0x1a, 0, 0, 0, 1}, []byte{0xff})
+	if got, err := FromCBOR(foreign); err != nil || !reflect.DeepEqual(got, small) {
+		t.Errorf("FromCBOR(%x) = %#v, %v, want %#v", foreign, got, err, small)
+	}
+	small.Extensions = map[string]string{"src": "10.0.0.1"}
+	foreign = join([]byte{0xa8}, text("Version"), []byte{0x01}, header, text("Extensions"), []byte{0xbf}, field("src", "10.0.0.1"), []byte{0xff})
+	if got, err := FromCBOR(foreign); err != nil || !reflect.DeepEqual(got, small) {
+		t.Errorf("FromCBOR(%x) = %#v, %v, want %#v", foreign, got, err, small)
+	}
+
+	for _, v := range []int64{0, 23, 24, -1, -24, -25, 1 << 40, math.MinInt64, math.MaxInt64} {
+		r := cborReader{data: appendCborInt(nil, v)}
+		if got, ok := r.int(); !ok || got != v || len(r.data) > 0 {
+			t.Errorf("int() of %d = %d, %v", v, got, ok)
+		}
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrMalformedDocument},
+		{"no map", text("event"), ErrMalformedDocument},
+		{"truncated", want[:len(want)-4], ErrMalformedDocument},
+		{"unterminated", want[:len(want)-1], ErrMalformedDocument},
+		{"missing break", join([]byte{0xbf}, text("Version"), []byte{0x01}, header), ErrMalformedDocument},
+		{"trailing data", append(append([]byte(nil), want...), 0xf6), ErrMalformedDocument},
+		{"unknown field", join([]byte{0xa8}, field("Vendor", "V")), ErrMalformedDocument},
+		{"indefinite text", join([]byte{0xa8}, text("Name"), []byte{0x7f, 0x61, 'N', 0xff}), ErrMalformedDocument},
+		{"string version", join([]byte{0xa7}, field("Version", "0"), header), ErrInvalidVersion},
+		{"invalid version", join([]byte{0xa7}, text("Version"), []byte{0x05}, header), ErrInvalidVersion},
+		{"missing field", join([]byte{0xa7}, text("Version"), []byte{0x00}, header[:len(header)-len(field("Severity", "5"))], text("Extensions"), []byte{0xa0}), ErrMissingField},
+		{"integer value", join([]byte{0xa8}, text("Version"), []byte{0x00}, header, text("Extensions"), []byte{0xa1}, text("cnt"), []byte{0x05}), ErrMalformedExtension},
+		{"malformed key", join([]byte{0xa8}, text("Version"), []byte{0x00}, header, text("Extensions"), []byte{0xa1}, field("a b", "c")), ErrMalformedExtension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromCBOR(tt.data)
+			var docErr *DocumentError
+			if !errors.Is(err, tt.want) || !errors.As(err, &docErr) || docErr.Format != "CBOR" {
+				t.Errorf("FromCBOR() = %v, want a DocumentError wrapping %v", err, tt.want)
+			}
+		})
+	}
+}
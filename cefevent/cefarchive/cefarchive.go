@@ -0,0 +1,460 @@
+// Package cefarchive writes CEF events to Apache Parquet files for the
+// long-term storage in data lakes, e.g. S3 queried by Athena, where the
+// columnar layout keeps storage cheap and queries fast:
+//
+//	w, err := cefarchive.NewWriter(file, cefarchive.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	if err := w.Write(events...); err != nil {
+//		return err
+//	}
+//	return w.Close()
+//
+// Every header field is a column of its own, named like the fields of
+// CefEvent, and the extensions are a MAP<STRING, STRING> column called
+// Extensions, i.e. the Athena table of the files is
+//
+//	CREATE EXTERNAL TABLE cef (
+//	  Version int, DeviceVendor string, DeviceProduct string, DeviceVersion string,
+//	  DeviceEventClassId string, Name string, Severity string,
+//	  Extensions map<string,string>
+//	) STORED AS PARQUET LOCATION 's3://bucket/cef/'
+//
+// The package has no dependencies, it writes PLAIN encoded data pages,
+// compressed with gzip unless configured otherwise.
+package cefarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// defaultRowGroupSize is the default number of events per row group.
+const defaultRowGroupSize = 10000
+
+// parquetMagic starts and ends every Parquet file.
+var parquetMagic = []byte("PAR1")
+
+// The values of the enums of the Parquet format used by the Writer.
+const (
+	typeInt32     = 1
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionRepeated = 2
+
+	convertedUTF8 = 0
+	convertedMap  = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	codecGzip         = 2
+
+	pageData = 0
+)
+
+// headerColumns are the string header fields, the columns following Version.
+var headerColumns = []string{"DeviceVendor", "DeviceProduct", "DeviceVersion", "DeviceEventClassId", "Name", "Severity"}
+
+// extensionsColumn is the map column holding the extensions.
+const extensionsColumn = "Extensions"
+
+// Compression selects the codec of the data pages.
+type Compression int
+
+const (
+	CompressionGzip Compression = iota // CompressionGzip compresses the pages with gzip, it is the default.
+	CompressionNone                    // CompressionNone writes the pages uncompressed.
+)
+
+// Options configures a Writer.
+type Options struct {
+	RowGroupSize int         // RowGroupSize is the number of events per row group, defaults to 10000.
+	Compression  Compression // Compression selects the codec of the pages, gzip by default.
+}
+
+// columnChunk is the metadata of a written column of a row group.
+type columnChunk struct {
+	path         []string
+	physical     int32
+	values       int64
+	offset       int64
+	uncompressed int64
+	compressed   int64
+}
+
+// rowGroup is the metadata of a written row group.
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+}
+
+// Writer writes events to a Parquet file. Events are buffered until a row
+// group is complete, Close writes the remaining events and the footer
+// without which the file can not be read.
+type Writer struct {
+	w       *bufio.Writer
+	closer  io.Closer
+	opts    Options
+	offset  int64
+	pending []cefevent.CefEvent
+	groups  []rowGroup
+	closed  bool
+}
+
+// NewWriter writes the Parquet magic to w and returns a Writer appending
+// row groups to it.
+//
+// Parameters:
+// - w: The destination of the file, if it is an io.Closer it is closed by Close.
+// - opts: The row group size and compression, zero values select the defaults.
+//
+// Returns:
+// - A pointer to a Writer, which must be closed to write the footer.
+// - An error if the magic could not be written.
+func NewWriter(w io.Writer, opts Options) (*Writer, error) {
+
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = defaultRowGroupSize
+	}
+
+	pw := &Writer{w: bufio.NewWriter(w), opts: opts}
+	if closer, ok := w.(io.Closer); ok {
+		pw.closer = closer
+	}
+
+	if err := pw.write(parquetMagic); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+// write writes buf and advances the offset.
+func (pw *Writer) write(buf []byte) error {
+
+	n, err := pw.w.Write(buf)
+	pw.offset += int64(n)
+
+	return err
+}
+
+// Write adds the events to the file, every complete row group is written.
+//
+// Returns:
+// - An error if an event is not valid, no event of the batch is added then, or a row group could not be written.
+func (pw *Writer) Write(events ...cefevent.CefEvent) error {
+
+	if pw.closed {
+		return errors.New("parquet writer is closed")
+	}
+
+	for i := range events {
+		if err := events[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range events {
+		pw.pending = append(pw.pending, event)
+		if len(pw.pending) >= pw.opts.RowGroupSize {
+			if err := pw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Flush writes the buffered events as row group, e.g. to bound the number
+// of events lost when the process ends before Close.
+func (pw *Writer) Flush() error {
+
+	if len(pw.pending) == 0 {
+		return nil
+	}
+
+	group := rowGroup{rows: int64(len(pw.pending))}
+
+	// the header fields, required columns without levels.
+	versions := make([]byte, 0, 4*len(pw.pending))
+	for _, event := range pw.pending {
+		versions = binary.LittleEndian.AppendUint32(versions, uint32(int32(event.Version)))
+	}
+	chunk, err := pw.writeColumn([]string{"Version"}, typeInt32, len(pw.pending), versions)
+	if err != nil {
+		return err
+	}
+	group.columns = append(group.columns, chunk)
+
+	for i, name := range headerColumns {
+		var values []byte
+		for j := range pw.pending {
+			values = appendByteArray(values, headerValue(&pw.pending[j], i))
+		}
+		chunk, err := pw.writeColumn([]string{name}, typeByteArray, len(pw.pending), values)
+		if err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+	}
+
+	// the extensions, the repetition level tells whether an entry continues
+	// the map of the previous one and the definition level whether the map
+	// has entries at all.
+	var repetition, definition []byte
+	var keys, values []byte
+	for _, event := range pw.pending {
+		if len(event.Extensions) == 0 {
+			repetition = append(repetition, 0)
+			definition = append(definition, 0)
+			continue
+		}
+		for i, key := range sortedKeys(event.Extensions) {
+			repetition = append(repetition, min(byte(i), 1))
+			definition = append(definition, 1)
+			keys = appendByteArray(keys, key)
+			values = appendByteArray(values, event.Extensions[key])
+		}
+	}
+	levels := appendLevels(appendLevels(nil, repetition), definition)
+
+	for _, column := range []struct {
+		name   string
+		values []byte
+	}{{"key", keys}, {"value", values}} {
+		page := append(append([]byte(nil), levels...), column.values...)
+		chunk, err := pw.writeColumn([]string{extensionsColumn, "key_value", column.name}, typeByteArray, len(repetition), page)
+		if err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.pending = pw.pending[:0]
+
+	return pw.w.Flush()
+}
+
+// writeColumn writes the column of a row group as a single data page.
+//
+// Parameters:
+// - path: The path of the column in the schema.
+// - physical: The physical type of the values.
+// - count: The number of values, including empty maps.
+// - page: The levels, if any, and the PLAIN encoded values.
+func (pw *Writer) writeColumn(path []string, physical int32, count int, page []byte) (columnChunk, error) {
+
+	body := page
+	if pw.opts.Compression == CompressionGzip {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		zw.Write(page)
+		if err := zw.Close(); err != nil {
+			return columnChunk{}, err
+		}
+		body = b.Bytes()
+	}
+
+	t := newThriftWriter()
+	t.i32(1, pageData)
+	t.i32(2, int32(len(page)))
+	t.i32(3, int32(len(body)))
+	t.structField(5)
+	t.i32(1, int32(count))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.end()
+	t.end()
+
+	chunk := columnChunk{
+		path:         path,
+		physical:     physical,
+		values:       int64(count),
+		offset:       pw.offset,
+		uncompressed: int64(len(t.buf) + len(page)),
+		compressed:   int64(len(t.buf) + len(body)),
+	}
+
+	if err := pw.write(t.buf); err != nil {
+		return columnChunk{}, err
+	}
+	if err := pw.write(body); err != nil {
+		return columnChunk{}, err
+	}
+
+	return chunk, nil
+}
+
+// Close writes the buffered events and the footer holding the schema and
+// the metadata of the row groups, flushes the file and closes the
+// underlying writer if it implements io.Closer.
+func (pw *Writer) Close() error {
+
+	if pw.closed {
+		return nil
+	}
+
+	var errs []error
+	errs = append(errs, pw.Flush())
+	pw.closed = true
+
+	footer := pw.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, parquetMagic...)
+
+	errs = append(errs, pw.write(footer), pw.w.Flush())
+	if pw.closer != nil {
+		errs = append(errs, pw.closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// footer encodes the FileMetaData of the file.
+func (pw *Writer) footer() []byte {
+
+	codec := int32(codecGzip)
+	if pw.opts.Compression == CompressionNone {
+		codec = codecUncompressed
+	}
+
+	var rows int64
+	for _, group := range pw.groups {
+		rows += group.rows
+	}
+
+	t := newThriftWriter()
+	t.i32(1, 1)
+
+	// the root, Version, the string header fields and the four elements of the map.
+	t.list(2, thriftStruct, 1+1+len(headerColumns)+4)
+	schemaElement(t, "schema", -1, -1, -1, int32(1+len(headerColumns)+1))
+	schemaElement(t, "Version", typeInt32, repetitionRequired, -1, 0)
+	for _, name := range headerColumns {
+		schemaElement(t, name, typeByteArray, repetitionRequired, convertedUTF8, 0)
+	}
+	schemaElement(t, extensionsColumn, -1, repetitionRequired, convertedMap, 1)
+	schemaElement(t, "key_value", -1, repetitionRepeated, -1, 2)
+	schemaElement(t, "key", typeByteArray, repetitionRequired, convertedUTF8, 0)
+	schemaElement(t, "value", typeByteArray, repetitionRequired, convertedUTF8, 0)
+
+	t.i64(3, rows)
+
+	t.list(4, thriftStruct, len(pw.groups))
+	for _, group := range pw.groups {
+		var size int64
+		t.begin()
+		t.list(1, thriftStruct, len(group.columns))
+		for _, chunk := range group.columns {
+			size += chunk.uncompressed
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, chunk.physical)
+			t.i32List(2, encodingPlain, encodingRLE)
+			t.stringList(3, chunk.path...)
+			t.i32(4, codec)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.uncompressed)
+			t.i64(7, chunk.compressed)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+
+	t.string(6, "github.com/pcktdmp/cef/cefevent/cefarchive")
+	t.end()
+
+	return t.buf
+}
+
+// schemaElement writes a SchemaElement as element of the schema list,
+// negative values leave the optional fields unset.
+func schemaElement(t *thriftWriter, name string, physical, repetition, converted int32, children int32) {
+
+	t.begin()
+	if physical >= 0 {
+		t.i32(1, physical)
+	}
+	if repetition >= 0 {
+		t.i32(3, repetition)
+	}
+	t.string(4, name)
+	if children > 0 {
+		t.i32(5, children)
+	}
+	if converted >= 0 {
+		t.i32(6, converted)
+	}
+	t.end()
+}
+
+// headerValue returns the value of the i-th of the headerColumns.
+func headerValue(event *cefevent.CefEvent, i int) string {
+
+	return [...]string{
+		event.DeviceVendor,
+		event.DeviceProduct,
+		event.DeviceVersion,
+		event.DeviceEventClassId,
+		event.Name,
+		event.Severity,
+	}[i]
+}
+
+// sortedKeys returns the keys of the extensions in order.
+func sortedKeys(extensions map[string]string) []string {
+
+	keys := make([]string, 0, len(extensions))
+	for k := range extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// appendByteArray appends s PLAIN encoded, prefixed with its length, to buf.
+func appendByteArray(buf []byte, s string) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s)))
+	return append(buf, s...)
+}
+
+// appendLevels appends the levels of a column of the map to buf, in the
+// RLE/bit-packing hybrid encoding with a bit width of 1 as runs of equal
+// levels, prefixed with the length of the encoding.
+func appendLevels(buf []byte, levels []byte) []byte {
+
+	var runs []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		runs = binary.AppendUvarint(runs, uint64(j-i)<<1)
+		runs = append(runs, levels[i])
+		i = j
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(runs)))
+
+	return append(buf, runs...)
+}
@@ -0,0 +1,264 @@
+package cefarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// thriftReader decodes Thrift compact structs into maps of their field IDs.
+type thriftReader struct {
+	data []byte
+	err  error
+}
+
+func (r *thriftReader) byte() byte {
+
+	if len(r.data) == 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+
+	return b
+}
+
+func (r *thriftReader) varint() int64 {
+
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *thriftReader) value(kind byte) interface{} {
+
+	switch kind {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n, size := binary.Uvarint(r.data)
+		if size <= 0 || uint64(len(r.data)-size) < n {
+			r.err = io.ErrUnexpectedEOF
+			return ""
+		}
+		s := string(r.data[size : size+int(n)])
+		r.data = r.data[size+int(n):]
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int64(header >> 4)
+		if n == 15 {
+			v, size := binary.Uvarint(r.data)
+			r.data = r.data[size:]
+			n = int64(v)
+		}
+		var list []interface{}
+		for i := int64(0); i < n && r.err == nil; i++ {
+			list = append(list, r.value(header&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+
+	r.err = errors.New("unsupported thrift type")
+	return nil
+}
+
+func (r *thriftReader) structure() map[int64]interface{} {
+
+	fields := map[int64]interface{}{}
+	var last int64
+	for r.err == nil {
+		header := r.byte()
+		if header == 0 {
+			break
+		}
+		id := last + int64(header>>4)
+		if header>>4 == 0 {
+			id = r.varint()
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+
+	return fields
+}
+
+// readLevels decodes levels written as RLE runs.
+func readLevels(data []byte) ([]byte, []byte) {
+
+	n := binary.LittleEndian.Uint32(data)
+	runs, rest := data[4:4+n], data[4+n:]
+
+	var levels []byte
+	for len(runs) > 0 {
+		header, size := binary.Uvarint(runs)
+		levels = append(levels, bytes.Repeat([]byte{runs[size]}, int(header>>1))...)
+		runs = runs[size+1:]
+	}
+
+	return levels, rest
+}
+
+// readByteArrays decodes PLAIN encoded strings.
+func readByteArrays(data []byte) []string {
+
+	var values []string
+	for len(data) > 0 {
+		n := binary.LittleEndian.Uint32(data)
+		values = append(values, string(data[4:4+n]))
+		data = data[4+n:]
+	}
+
+	return values
+}
+
+// readParquet reads the events of a file written by the Writer.
+func readParquet(t *testing.T, file []byte) []cefevent.CefEvent {
+
+	t.Helper()
+
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatalf("file lacks the magic")
+	}
+	size := binary.LittleEndian.Uint32(file[len(file)-8:])
+	r := thriftReader{data: file[len(file)-8-int(size) : len(file)-8]}
+	meta := r.structure()
+	if r.err != nil || len(r.data) != 0 {
+		t.Fatalf("footer is malformed: %v", r.err)
+	}
+
+	var names []string
+	for _, element := range meta[2].([]interface{}) {
+		names = append(names, element.(map[int64]interface{})[4].(string))
+	}
+	if want := []string{"schema", "Version", "DeviceVendor", "DeviceProduct", "DeviceVersion", "DeviceEventClassId", "Name", "Severity", "Extensions", "key_value", "key", "value"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("schema = %v, want %v", names, want)
+	}
+
+	var events []cefevent.CefEvent
+	for _, g := range meta[4].([]interface{}) {
+		group := g.(map[int64]interface{})
+		rows := int(group[3].(int64))
+		columns := map[string][]byte{}
+		var values int64
+
+		for _, c := range group[1].([]interface{}) {
+			column := c.(map[int64]interface{})[3].(map[int64]interface{})
+			offset := column[9].(int64)
+			page := thriftReader{data: file[offset:]}
+			header := page.structure()
+			body := page.data[:header[3].(int64)]
+			if column[4].(int64) == codecGzip {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ = io.ReadAll(zr)
+			}
+			if int64(len(body)) != header[2].(int64) {
+				t.Errorf("page of %d bytes, want %d", len(body), header[2])
+			}
+			path := column[3].([]interface{})
+			columns[path[len(path)-1].(string)] = body
+			values = column[5].(int64)
+		}
+
+		batch := make([]cefevent.CefEvent, rows)
+		for i := range batch {
+			batch[i].Version = int(int32(binary.LittleEndian.Uint32(columns["Version"][4*i:])))
+		}
+		for i, name := range headerColumns {
+			for j, value := range readByteArrays(columns[name]) {
+				*[]*string{&batch[j].DeviceVendor, &batch[j].DeviceProduct, &batch[j].DeviceVersion, &batch[j].DeviceEventClassId, &batch[j].Name, &batch[j].Severity}[i] = value
+			}
+		}
+
+		repetition, rest := readLevels(columns["key"])
+		definition, rest := readLevels(rest)
+		keys := readByteArrays(rest)
+		_, rest = readLevels(columns["value"])
+		_, rest = readLevels(rest)
+		vals := readByteArrays(rest)
+		if int64(len(repetition)) != values {
+			t.Errorf("%d levels, want %d values", len(repetition), values)
+		}
+
+		row := -1
+		for i := range repetition {
+			if repetition[i] == 0 {
+				row++
+			}
+			if definition[i] == 1 {
+				if batch[row].Extensions == nil {
+					batch[row].Extensions = map[string]string{}
+				}
+				batch[row].Extensions[keys[0]] = vals[0]
+				keys, vals = keys[1:], vals[1:]
+			}
+		}
+
+		events = append(events, batch...)
+	}
+
+	if int64(len(events)) != meta[3].(int64) {
+		t.Errorf("file has %d events, footer says %d", len(events), meta[3])
+	}
+
+	return events
+}
+
+func TestWriter(t *testing.T) {
+
+	events := []cefevent.CefEvent{
+		{Version: 0, DeviceVendor: "Vendor", DeviceProduct: "Product", DeviceVersion: "1.0", DeviceEventClassId: "100", Name: "name", Severity: "5",
+			Extensions: map[string]string{"src": "10.0.0.1", "suser": "alice", "msg": "ünïcode"}},
+		{Version: 1, DeviceVendor: "Vendor", DeviceProduct: "Product", DeviceVersion: "1.0", DeviceEventClassId: "200", Name: "no extensions", Severity: "Low"},
+		{Version: 0, DeviceVendor: "Other", DeviceProduct: "Product", DeviceVersion: "2.0", DeviceEventClassId: "300", Name: "name", Severity: "10",
+			Extensions: map[string]string{"dst": "10.0.0.2"}},
+	}
+
+	for _, opts := range []Options{{}, {Compression: CompressionNone}, {RowGroupSize: 2}} {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, opts)
+		if err != nil {
+			t.Fatalf("NewWriter() = %v", err)
+		}
+		if err := w.Write(events...); err != nil {
+			t.Fatalf("Write() = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() = %v", err)
+		}
+
+		if got := readParquet(t, b.Bytes()); !reflect.DeepEqual(got, events) {
+			t.Errorf("file of %+v holds %+v, want %+v", opts, got, events)
+		}
+	}
+
+	var b bytes.Buffer
+	w, _ := NewWriter(&b, Options{})
+	if err := w.Write(events[0], cefevent.CefEvent{}); err == nil {
+		t.Error("Write() of an invalid event = nil, want an error")
+	}
+	w.Close()
+	if got := readParquet(t, b.Bytes()); len(got) != 0 {
+		t.Errorf("file holds %d events, want none of the invalid batch", len(got))
+	}
+	if err := w.Write(events...); err == nil {
+		t.Error("Write() after Close() = nil, want an error")
+	}
+}
@@ -0,0 +1,106 @@
+package cefarchive
+
+import "encoding/binary"
+
+// The types of the Thrift compact protocol the Parquet metadata is encoded with.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol. Field IDs
+// are written as delta to the previous field of the same struct, last
+// holds the previous field ID of every open struct.
+type thriftWriter struct {
+	buf  []byte
+	last []int16
+}
+
+// newThriftWriter returns a thriftWriter with the top-level struct opened.
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+// field writes the header of a field.
+func (t *thriftWriter) field(id int16, kind byte) {
+
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|kind)
+	} else {
+		t.buf = append(t.buf, kind)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.appendString(s)
+}
+
+func (t *thriftWriter) appendString(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// list writes the header of a list field with n elements of the kind, the
+// elements are appended by the caller.
+func (t *thriftWriter) list(id int16, kind byte, n int) {
+
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|kind)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|kind)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+// i32List writes a list field of integers.
+func (t *thriftWriter) i32List(id int16, values ...int32) {
+
+	t.list(id, thriftI32, len(values))
+	for _, v := range values {
+		t.buf = binary.AppendVarint(t.buf, int64(v))
+	}
+}
+
+// stringList writes a list field of strings.
+func (t *thriftWriter) stringList(id int16, values ...string) {
+
+	t.list(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.appendString(v)
+	}
+}
+
+// structField opens a struct field, its fields follow until end.
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// begin opens a struct, e.g. an element of a list of structs.
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost open struct.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
@@ -1,15 +1,28 @@
 package cefevent
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stdoutLogger and stderrLogger are dedicated loggers used by Log so that
+// emitting events never touches the global logger output, which would
+// race when events are logged from multiple goroutines.
+var (
+	stdoutLogger = log.New(os.Stdout, "", log.LstdFlags)
+	stderrLogger = log.New(os.Stderr, "", log.LstdFlags)
 )
 
 // CefEventer defines the interface for handling Common Event Format (CEF) events.
@@ -36,15 +49,20 @@ type CefEvent struct {
 	Name               string            `json:"Name" yaml:"Name" toml:"Name" xml:"Name" header:"Name" comment:"The name of the event."`
 	Severity           string            `json:"Severity" yaml:"Severity" toml:"Severity" xml:"Severity" header:"Severity" comment:"The severity of the event."`
 	Extensions         map[string]string `json:"Extensions,omitempty" yaml:"Extensions" toml:"Extensions" xml:"Extensions" header:"Extensions" comment:"Additional extensions to the CEF message."`
+	// Raw is the untouched message the event was parsed from when
+	// ParseOptions.KeepRaw or Decoder.SetKeepRaw is set, e.g. to forward the
+	// original record for chain of custody. It is neither rendered nor serialized.
+	Raw string `json:"-" yaml:"-" toml:"-" xml:"-"`
 }
 
 // cefEscapeField escapes special characters in a given string that are used in CEF (Common Event Format) fields.
-// It replaces backslashes, pipes, and newlines with their escaped counterparts.
+// It replaces backslashes, pipes, and line breaks with their escaped counterparts.
 //
 // The following replacements are performed:
 // - "\" becomes "\\"
 // - "|" becomes "\\|"
 // - "\n" becomes "\\n"
+// - "\r" becomes "\\r"
 //
 // Parameters:
 // - field: A string that needs to be escaped.
@@ -57,17 +75,19 @@ func cefEscapeField(field string) string {
 		"\\", "\\\\",
 		"|", "\\|",
 		"\n", "\\n",
+		"\r", "\\r",
 	)
 
 	return replacer.Replace(field)
 }
 
 // cefEscapeExtension escapes special characters in a given string that are used in CEF (Common Event Format) extensions.
-// It replaces backslashes, newlines, and equals signs with their escaped counterparts.
+// It replaces backslashes, line breaks, and equals signs with their escaped counterparts.
 //
 // The following replacements are performed:
 // - "\" becomes "\\"
 // - "\n" becomes "\\n"
+// - "\r" becomes "\\r"
 // - "=" becomes "\\="
 //
 // Parameters:
@@ -79,12 +99,131 @@ func cefEscapeExtension(field string) string {
 
 	replacer := strings.NewReplacer(
 		"\\", "\\\\", "\n",
-		"\\n", "=", "\\=",
+		"\\n", "\r", "\\r", "=", "\\=",
 	)
 
 	return replacer.Replace(field)
 }
 
+// cefUnescapeExtension reverses cefEscapeExtension, it replaces the escape sequences of
+// CEF extensions with the characters they stand for.
+//
+// The following replacements are performed:
+// - "\\" becomes "\"
+// - "\=" becomes "="
+// - "\n" becomes a newline
+// - "\r" becomes a carriage return
+//
+// Backslashes which do not start one of these sequences are kept as they are.
+//
+// Parameters:
+// - field: A string taken from the extensions of a CEF message.
+//
+// Returns:
+// - The string holding the literal characters.
+func cefUnescapeExtension(field string) string {
+
+	if !strings.Contains(field, "\\") {
+		return field
+	}
+
+	var unescaped strings.Builder
+	unescaped.Grow(len(field))
+
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c == '\\' && i+1 < len(field) {
+			switch field[i+1] {
+			case '\\', '=':
+				c = field[i+1]
+				i++
+			case 'n':
+				c = '\n'
+				i++
+			case 'r':
+				c = '\r'
+				i++
+			}
+		}
+		unescaped.WriteByte(c)
+	}
+
+	return unescaped.String()
+}
+
+// stripControl removes the control characters other than tab, newline and
+// carriage return from a string, strings without them are returned as they are.
+func stripControl(field string) string {
+
+	keep := func(r rune) bool {
+		return r == '\t' || r == '\n' || r == '\r' || !unicode.IsControl(r)
+	}
+
+	for _, r := range field {
+		if !keep(r) {
+			return strings.Map(func(r rune) rune {
+				if !keep(r) {
+					return -1
+				}
+				return r
+			}, field)
+		}
+	}
+
+	return field
+}
+
+// StripControlCharacters removes the control characters other than tab,
+// newline and carriage return from the header fields and extensions of the
+// event. Line breaks are escaped when the event is rendered, other control
+// characters, e.g. NUL, escape sequences of terminals or the C1 controls of
+// misdecoded Windows logs, are passed through otherwise.
+//
+// Returns:
+// - Whether a character was removed.
+func (event *CefEvent) StripControlCharacters() bool {
+
+	changed := false
+	for _, field := range []*string{
+		&event.DeviceVendor, &event.DeviceProduct, &event.DeviceVersion,
+		&event.DeviceEventClassId, &event.Name, &event.Severity,
+	} {
+		if stripped := stripControl(*field); stripped != *field {
+			*field = stripped
+			changed = true
+		}
+	}
+
+	for k, v := range event.Extensions {
+		key, value := stripControl(k), stripControl(v)
+		if key == k && value == v {
+			continue
+		}
+		delete(event.Extensions, k)
+		event.Extensions[key] = value
+		changed = true
+	}
+
+	return changed
+}
+
+// StripControlCharacters returns a Middleware removing the control
+// characters other than tab, newline and carriage return from all events,
+// see CefEvent.StripControlCharacters.
+//
+// Returns:
+// - A Middleware stripping the events passing through it.
+func StripControlCharacters() Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			event.Extensions = cloneExtensions(event.Extensions)
+			event.StripControlCharacters()
+			return next(event)
+		}
+	}
+}
+
 // escapeEventData processes and escapes all necessary fields within the CefEvent struct according
 // to the Common Event Format (CEF) specifications. It ensures that fields such as DeviceVendor,
 // DeviceProduct, DeviceVersion, DeviceEventClassId, Name, Severity, and Extensions have their
@@ -169,6 +308,10 @@ func (event *CefEvent) Validate() error {
 // of the event is successful, it logs the message to stdout, otherwise,
 // it logs an error message to stderr.
 //
+// Log does not modify the event nor the global logger and is therefore safe
+// to call from multiple goroutines, for concurrent emission to an arbitrary
+// writer see Emitter.
+//
 // Returns:
 // - An error indicating whether the logging operation succeeded (nil) or failed (err).
 func (event *CefEvent) Log() error {
@@ -176,18 +319,17 @@ func (event *CefEvent) Log() error {
 	logMessage, err := event.String()
 
 	if err != nil {
-		log.SetOutput(os.Stderr)
 		errMsg := "unable to create and thereby log the CEF message"
-		log.Println(errMsg)
+		stderrLogger.Println(errMsg)
 		return errors.New(errMsg)
 	}
 
-	log.SetOutput(os.Stdout)
-	log.Println(logMessage)
+	stdoutLogger.Println(logMessage)
 	return nil
 }
 
 // Build constructs and returns a CEF (Common Event Format) message just as String() but then as CefEvent type.
+// The escaping is applied to a copy, the receiver itself is left untouched.
 //
 // Returns:
 // - A CefEvent type representing the CEF message.
@@ -198,11 +340,12 @@ func (event *CefEvent) Build() (CefEvent, error) {
 		return CefEvent{}, errors.New("not all mandatory CEF fields are set")
 	}
 
-	if event.escapeEventData() != nil {
+	escaped := *event
+	if escaped.escapeEventData() != nil {
 		return CefEvent{}, errors.New("unable to escape CEF event data")
 	}
 
-	return *event, nil
+	return escaped, nil
 }
 
 // String constructs and returns a CEF (Common Event Format) message string if all the mandatory
@@ -212,6 +355,8 @@ func (event *CefEvent) Build() (CefEvent, error) {
 // CEF:Version|Device Vendor|Device Product|Device Version|Device Event Class ID|Name|Severity|Extensions
 //
 // Each field is escaped to ensure that special characters do not interfere with the CEF format.
+// The escaping is applied to a copy of the event, so calling String() repeatedly or from
+// multiple goroutines on the same event is safe and always yields the same result.
 //
 // Returns:
 // - A string representing the CEF message.
@@ -222,14 +367,15 @@ func (event *CefEvent) String() (string, error) {
 		return "", errors.New("not all mandatory CEF fields are set")
 	}
 
-	if event.escapeEventData() != nil {
+	escaped := *event
+	if escaped.escapeEventData() != nil {
 		return "", errors.New("unable to escape CEF event data")
 	}
 
 	var p strings.Builder
 
 	var sortedExtensions []string
-	for k := range event.Extensions {
+	for k := range escaped.Extensions {
 		sortedExtensions = append(sortedExtensions, k)
 	}
 	sort.Strings(sortedExtensions)
@@ -239,84 +385,535 @@ func (event *CefEvent) String() (string, error) {
 		p.WriteString(fmt.Sprintf(
 			"%s=%s ",
 			k,
-			event.Extensions[k]),
+			escaped.Extensions[k]),
 		)
 	}
 
 	// make sure there is not a trailing space for the extension
-	// fields according to the CEF standard.
-	extensionString := strings.TrimSpace(p.String())
+	// fields according to the CEF standard, whitespace of the keys and
+	// values themselves is kept.
+	extensionString := strings.TrimSuffix(p.String(), " ")
 
 	eventCef := fmt.Sprintf(
 		"CEF:%v|%v|%v|%v|%v|%v|%v|%v",
-		event.Version, event.DeviceVendor,
-		event.DeviceProduct, event.DeviceVersion,
-		event.DeviceEventClassId, event.Name,
-		event.Severity, extensionString,
+		escaped.Version, escaped.DeviceVendor,
+		escaped.DeviceProduct, escaped.DeviceVersion,
+		escaped.DeviceEventClassId, escaped.Name,
+		escaped.Severity, extensionString,
 	)
 
 	return eventCef, nil
 }
 
+// The CEF versions defined by the specification, the version of a message
+// is the number following the "CEF:" prefix.
+const (
+	Version0 = 0 // Version0 is CEF version 0, it covers the CEF 0.x specifications.
+	Version1 = 1 // Version1 is CEF version 1, it covers the CEF 1.x specifications.
+)
+
+// cefHeaderFields is the number of pipe separated fields preceding the
+// extensions, the version included.
+const cefHeaderFields = 7
+
+// splitHeader splits the CEF message, without the "CEF:" prefix, into its
+// header fields and the remaining extension string.
+//
+// Only pipes which are not escaped by a backslash separate header fields,
+// the escape sequences "\|", "\\", "\n" and "\r" inside header fields are replaced
+// by the characters they stand for. The extensions start after the seventh
+// separating pipe and are returned as they are, since pipes do not have to
+// be escaped in extensions. The message is scanned once and fields without
+// escape sequences are sub-strings of it, so only escaped fields allocate.
+//
+// Parameters:
+// - message: The CEF message without the "CEF:" prefix.
+// - header: The array receiving the header fields.
+//
+// Returns:
+// - The number of header fields found, an incomplete last field included.
+// - The extension string.
+// - Whether all header fields were found.
+func splitHeader(message string, header *[cefHeaderFields]string) (int, string, bool) {
+
+	n := 0
+	start := 0
+	escaped := false
+
+	for i := 0; i < len(message); i++ {
+		switch message[i] {
+		case '\\':
+			if i+1 < len(message) {
+				switch message[i+1] {
+				case '|', '\\', 'n', 'r':
+					escaped = true
+					i++
+				}
+			}
+		case '|':
+			header[n] = headerField(message[start:i], escaped)
+			n++
+			start = i + 1
+			escaped = false
+			if n == cefHeaderFields {
+				return n, message[start:], true
+			}
+		}
+	}
+
+	header[n] = headerField(message[start:], escaped)
+	return n + 1, "", false
+}
+
+// headerField replaces the escape sequences of a header field split off by
+// splitHeader, fields without escape sequences are returned as they are.
+func headerField(field string, escaped bool) string {
+
+	if !escaped {
+		return field
+	}
+
+	var unescaped strings.Builder
+	unescaped.Grow(len(field))
+
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c == '\\' && i+1 < len(field) {
+			switch field[i+1] {
+			case '|', '\\':
+				c = field[i+1]
+				i++
+			case 'n':
+				c = '\n'
+				i++
+			case 'r':
+				c = '\r'
+				i++
+			}
+		}
+		unescaped.WriteByte(c)
+	}
+
+	return unescaped.String()
+}
+
+// nextExtensionStart returns the index of the space in front of the next
+// "key=" pair of the extension string, or its length when there is none.
+// Spaces which are not followed by a key belong to the current value, so
+// values such as "msg=User logged in" are kept together.
+func nextExtensionStart[T string | []byte](extensions T) int {
+
+	for i := 0; i < len(extensions); i++ {
+		if extensions[i] != ' ' {
+			continue
+		}
+
+		if eq := keyEnd(extensions[i+1:]); eq > 0 {
+			return i
+		}
+	}
+
+	return len(extensions)
+}
+
+// keyEnd returns the index of the "=" ending the key at the start of the
+// extension string, -1 if a space comes first. An "=" preceded by an odd
+// number of backslashes is escaped and part of the key.
+func keyEnd[T string | []byte](extension T) int {
+
+	for j := 0; j < len(extension); j++ {
+		switch extension[j] {
+		case ' ':
+			return -1
+		case '=':
+			n := 0
+			for n < j && extension[j-1-n] == '\\' {
+				n++
+			}
+			if n%2 == 0 {
+				return j
+			}
+		}
+	}
+
+	return -1
+}
+
+// prefixEnd returns the index following the "CEF:" prefix of the message,
+// -1 if it has none. Unless strict, the prefix may be in any case and
+// preceded by a byte order mark, spaces and tabs, as several forwarders
+// lowercase the prefix or indent their messages.
+func prefixEnd[T string | []byte](line T, strict bool) int {
+
+	i := 0
+	if !strict {
+		if len(line) >= 3 && line[0] == 0xEF && line[1] == 0xBB && line[2] == 0xBF {
+			i = 3
+		}
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+	}
+
+	const prefix = "CEF:"
+	if len(line)-i < len(prefix) {
+		return -1
+	}
+	for j := 0; j < len(prefix); j++ {
+		c := line[i+j]
+		if !strict && 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c != prefix[j] {
+			return -1
+		}
+	}
+
+	return i + len(prefix)
+}
+
+// ParseOptions controls how strictly ParseWithOptions treats messages which
+// do not conform to the CEF specification. The zero value is the lenient
+// best-effort behaviour of Parse.
+type ParseOptions struct {
+	// Strict rejects messages with malformed extensions, i.e. tokens that are
+	// no key=value pair, invalid or duplicated keys, instead of skipping the
+	// offending parts. It also
+	// requires the message to start with "CEF:" exactly, otherwise the
+	// prefix may be in any case and preceded by a byte order mark, spaces
+	// and tabs.
+	Strict bool
+	// MaxExtensions rejects messages with more than the given number of
+	// extensions with a ParseError wrapping ErrLimitExceeded, zero means
+	// unlimited.
+	MaxExtensions int
+	// MaxLineBytes rejects messages longer than the given number of bytes
+	// before anything is allocated for them, zero means unlimited.
+	MaxLineBytes int
+	// MaxValueBytes rejects messages with an extension value longer than
+	// the given number of bytes, as written in the message or decompressed,
+	// zero means unlimited.
+	MaxValueBytes int
+	// AllowMissingFields accepts messages with fewer than seven header fields
+	// or empty mandatory fields, the missing fields are left empty.
+	AllowMissingFields bool
+	// Location is the time zone assumed for dates of time-typed extensions
+	// such as rt, start and end which lack a zone. When it is set the dates
+	// are normalized to UTC, see ParseTimestampIn, which prevents offsets of
+	// hours when events of several regions are combined.
+	Location *time.Location
+	// AllowUnknownVersion accepts versions other than Version0 and Version1
+	// for forward compatibility with future revisions of the specification.
+	AllowUnknownVersion bool
+	// AllowTruncated returns the parsed portion of messages which were cut
+	// off, e.g. at the MTU of a UDP datagram, together with a *TruncatedError
+	// instead of discarding them. A message counts as truncated when it ends
+	// within the header, within an escape sequence or within the key of its
+	// only extension, a key cut off after other extensions can not be told
+	// apart from a value containing spaces. The incomplete header fields are
+	// left empty and the incomplete extension is dropped.
+	AllowTruncated bool
+	// UTF8 selects how invalid UTF-8 in the message is handled, by default
+	// it is passed through, which can make JSON renderings of the event invalid.
+	UTF8 UTF8Mode
+	// KeepRaw keeps the untouched message in the Raw field of the event,
+	// before invalid UTF-8 is replaced or timestamps are normalized.
+	KeepRaw bool
+	// Quirks correct known deviations of devices from the specification,
+	// e.g. QuirkPipesInName, in the message before it is parsed. They are
+	// applied in order, offsets of ParseErrors refer to the corrected message.
+	Quirks []Quirk
+}
+
+// UTF8Mode selects how ReadWithOptions handles messages which are not valid UTF-8.
+type UTF8Mode int
+
+const (
+	UTF8Keep    UTF8Mode = iota // UTF8Keep passes invalid byte sequences through unchanged.
+	UTF8Reject                  // UTF8Reject rejects the message with a ParseError wrapping ErrInvalidUTF8.
+	UTF8Replace                 // UTF8Replace replaces every run of invalid bytes with U+FFFD.
+)
+
+// checkUTF8 applies the mode to s.
+//
+// Returns:
+// - s, with invalid byte sequences replaced for UTF8Replace.
+// - The offset of the first invalid byte for UTF8Reject, -1 if s is valid or may be used.
+func (m UTF8Mode) checkUTF8(s string) (string, int) {
+
+	if m == UTF8Keep || utf8.ValidString(s) {
+		return s, -1
+	}
+
+	if m == UTF8Replace {
+		return strings.ToValidUTF8(s, string(utf8.RuneError)), -1
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return s, i
+		}
+		i += size
+	}
+
+	return s, -1
+}
+
+// validExtensionKey reports whether key consists of letters, digits, "_"
+// and "." only, as the keys defined by the specification and vendors do.
+func validExtensionKey(key string) bool {
+
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Read parses a CEF (Common Event Format) message string and populates the CefEvent struct
 // with the extracted data.
 //
-// The method checks if the provided string starts with the "CEF:" prefix and then splits
-// the string into its constituent fields. It also extracts any key-value pairs present in the
-// Extensions part of the CEF message.
+// The method checks if the provided string starts with the "CEF:" prefix, in any case and
+// possibly preceded by a byte order mark, spaces and tabs, and then splits
+// the string into its constituent fields. Pipes escaped as "\|" inside header fields do not
+// separate fields, so every message produced by String() can be parsed back. It also extracts
+// any key-value pairs present in the Extensions part of the CEF message.
+//
+// Escape sequences are replaced by the characters they stand for, the struct holds the raw
+// values and parsing the output of String() yields the original event again.
 //
 // The format of a CEF message is:
 // CEF:Version|Device Vendor|Device Product|Device Version|Device Event Class ID|Name|Severity|Extensions
 //
 // The method ensures that if any mandatory field is missing or improperly formatted, it returns an error.
+// Malformed extensions are skipped, use ReadWithOptions to reject them instead.
 //
 // Returns:
 // - A CefEvent struct populated with the parsed CEF message data.
-// - An error if the CEF message is improperly formatted or if any mandatory field is missing.
+// - A *ParseError if the CEF message is improperly formatted or if any mandatory field is missing.
+//
+// Deprecated: Read overwrites the receiver and returns a copy of it, use Parse instead.
 func (event *CefEvent) Read(eventLine string) (CefEvent, error) {
-	if strings.HasPrefix(eventLine, "CEF:") {
-		eventSlashed := strings.Split(strings.TrimPrefix(eventLine, "CEF:"), "|")
-
-		// convert CEF version to int
-		cefVersion, err := strconv.Atoi(eventSlashed[0])
-		if err != nil {
-			return CefEvent{}, err
-		}
-
-		event.Version = cefVersion
-		parsedExtensions := make(map[string]string)
-
-		// each extension k,v is separated by a " ".
-		// in the substring, "=" separator defines the kv pair of the extension
-		if len(eventSlashed) >= 7 {
-			extensions := strings.Split(eventSlashed[7], " ")
-			for _, ext := range extensions {
-				kv := strings.SplitN(ext, "=", 2)
-				if len(kv) == 2 {
-					parsedExtensions[kv[0]] = kv[1]
-				}
+	return event.ReadWithOptions(eventLine, ParseOptions{})
+}
+
+// Parse parses a CEF message into a new CefEvent, see Read for the details
+// of the format. Unlike Read it has no receiver which is overwritten.
+//
+// Parameters:
+// - line: The CEF message.
+//
+// Returns:
+// - The parsed CefEvent.
+// - A *ParseError if the CEF message is improperly formatted or if any mandatory field is missing.
+func Parse(line string) (CefEvent, error) {
+	return new(CefEvent).ReadWithOptions(line, ParseOptions{})
+}
+
+// ParseWithOptions parses a CEF message into a new CefEvent like Parse, the
+// options choose between rejecting non-conformant messages and best-effort
+// extraction, see ParseOptions.
+//
+// Parameters:
+// - line: The CEF message.
+// - opts: The ParseOptions, the zero value behaves like Parse.
+//
+// Returns:
+// - The parsed CefEvent.
+// - A *ParseError if the CEF message violates the options or is improperly formatted,
+// a *TruncatedError along with the parsed portion if AllowTruncated is set and the message was cut off.
+func ParseWithOptions(line string, opts ParseOptions) (CefEvent, error) {
+	return new(CefEvent).ReadWithOptions(line, opts)
+}
+
+// ReadWithOptions parses a CEF message like Read, the options choose between
+// rejecting non-conformant messages and best-effort extraction of whatever
+// the message contains.
+//
+// Parameters:
+// - eventLine: The CEF message.
+// - opts: The ParseOptions, the zero value behaves like Read.
+//
+// Returns:
+// - A CefEvent struct populated with the parsed CEF message data.
+// - A *ParseError if the CEF message violates the options or is improperly formatted,
+// a *TruncatedError along with the parsed portion if AllowTruncated is set and the message was cut off.
+//
+// Deprecated: ReadWithOptions overwrites the receiver and returns a copy of it, use ParseWithOptions instead.
+func (event *CefEvent) ReadWithOptions(eventLine string, opts ParseOptions) (CefEvent, error) {
+
+	if opts.MaxLineBytes > 0 && len(eventLine) > opts.MaxLineBytes {
+		return CefEvent{}, newParseError(opts.MaxLineBytes, "", ErrLimitExceeded, fmt.Sprintf("message of %d bytes exceeds %d bytes", len(eventLine), opts.MaxLineBytes))
+	}
+
+	raw := eventLine
+	// the line terminator of messages read with it, e.g. the CRLF of
+	// Windows-originated logs, is not part of the last value.
+	eventLine = strings.TrimRight(eventLine, "\r\n")
+	for _, quirk := range opts.Quirks {
+		eventLine = quirk(eventLine)
+	}
+	eventLine, invalid := opts.UTF8.checkUTF8(eventLine)
+	if invalid >= 0 {
+		return CefEvent{}, newParseError(invalid, "", ErrInvalidUTF8, "")
+	}
+
+	start := prefixEnd(eventLine, opts.Strict)
+	if start < 0 {
+		return CefEvent{}, newParseError(0, "", ErrMissingPrefix, "")
+	}
+
+	message := eventLine[start:]
+	var header [cefHeaderFields]string
+	n, extensionString, ok := splitHeader(message, &header)
+	var truncated *TruncatedError
+	if !ok {
+		if opts.AllowTruncated && n > 1 {
+			// the last field was cut off, only the complete fields are kept.
+			truncated = &TruncatedError{Offset: len(eventLine), Field: headerFieldNames[n-1], Err: ErrIncompleteHeader}
+			header[n-1] = ""
+		} else if !opts.AllowMissingFields {
+			return CefEvent{}, incompleteHeaderError(len(eventLine), n-1)
+		}
+		// the extensions can not be told apart from the last header field
+		// when the header is incomplete, the fields present are kept and
+		// the missing ones are left empty.
+	}
+
+	// convert CEF version to int
+	cefVersion, err := strconv.Atoi(header[0])
+	if err != nil {
+		return CefEvent{}, &ParseError{Offset: start, Field: headerFieldNames[0], Reason: fmt.Sprintf("version %q is not a number", header[0]), Err: ErrInvalidVersion}
+	}
+	if cefVersion != Version0 && cefVersion != Version1 && !opts.AllowUnknownVersion {
+		return CefEvent{}, &ParseError{Offset: start, Field: headerFieldNames[0], Reason: fmt.Sprintf("version %d is not 0 or 1", cefVersion), Err: ErrInvalidVersion}
+	}
+
+	if !opts.AllowMissingFields && truncated == nil {
+		for i := 1; i < cefHeaderFields; i++ {
+			if header[i] == "" {
+				return CefEvent{}, newParseError(start+headerFieldOffset(message, i), headerFieldNames[i], ErrMissingField, "")
 			}
 		}
+	}
+
+	event.Version = cefVersion
+	parsedExtensions := make(map[string]string)
+
+	// extensions are separated by a " " followed by the next key, values
+	// may contain spaces. In each pair the first "=" separates key and value.
+	offset := len(eventLine) - len(extensionString)
+	rest := strings.TrimRight(extensionString, " ")
+	for len(rest) > 0 {
+		if rest[0] == ' ' {
+			rest = rest[1:]
+			offset++
+			continue
+		}
 
-		event.DeviceVendor = eventSlashed[1]
-		event.DeviceProduct = eventSlashed[2]
-		event.DeviceVersion = eventSlashed[3]
-		event.DeviceEventClassId = eventSlashed[4]
-		event.Name = eventSlashed[5]
-		event.Severity = eventSlashed[6]
-		event.Extensions = parsedExtensions
+		end := nextExtensionStart(rest)
+		ext := rest[:end]
+		start := offset
+		rest = rest[end:]
+		offset += end
 
-		if event.escapeEventData() != nil {
-			return CefEvent{}, errors.New("could not escape CEF event data")
+		eq := keyEnd(ext)
+		if eq >= 0 && rest == "" && opts.AllowTruncated && danglingEscape(ext[eq+1:]) {
+			truncated = &TruncatedError{Offset: len(eventLine), Field: cefUnescapeExtension(ext[:eq]), Err: ErrMalformedExtension}
+			continue
+		}
+		if eq <= 0 {
+			if eq < 0 && rest == "" && opts.AllowTruncated {
+				truncated = &TruncatedError{Offset: len(eventLine), Field: cefUnescapeExtension(ext), Err: ErrMalformedExtension}
+				continue
+			}
+			if opts.Strict {
+				return CefEvent{}, newParseError(start, "", ErrMalformedExtension, fmt.Sprintf("%q is not a key=value pair", ext))
+			}
+			continue
 		}
 
-		if CefEventer.Validate(event) != nil {
-			return CefEvent{}, errors.New("not all mandatory CEF fields are set")
+		key := cefUnescapeExtension(ext[:eq])
+		if opts.Strict {
+			if !validExtensionKey(key) {
+				return CefEvent{}, newParseError(start, key, ErrMalformedExtension, "invalid extension key")
+			}
+			if _, ok := parsedExtensions[key]; ok {
+				return CefEvent{}, newParseError(start, key, ErrMalformedExtension, "duplicated extension key")
+			}
 		}
 
-		return *event, nil
+		if _, ok := parsedExtensions[key]; !ok && opts.MaxExtensions > 0 && len(parsedExtensions) >= opts.MaxExtensions {
+			return CefEvent{}, newParseError(start, key, ErrLimitExceeded, fmt.Sprintf("more than %d extensions", opts.MaxExtensions))
+		}
+
+		if value := ext[eq+1:]; opts.MaxValueBytes > 0 && len(value) > opts.MaxValueBytes {
+			return CefEvent{}, newParseError(start+eq+1, key, ErrLimitExceeded, fmt.Sprintf("value of %d bytes exceeds %d bytes", len(value), opts.MaxValueBytes))
+		}
+
+		parsedExtensions[key] = cefUnescapeExtension(ext[eq+1:])
+	}
+
+	event.DeviceVendor = header[1]
+	event.DeviceProduct = header[2]
+	event.DeviceVersion = header[3]
+	event.DeviceEventClassId = header[4]
+	event.Name = header[5]
+	event.Severity = header[6]
+	event.Extensions = parsedExtensions
+	event.Raw = ""
+	if opts.KeepRaw {
+		event.Raw = raw
+	}
+
+	compressed := event.compressedKeys()
+	if key, err := event.decompressExtensions(opts.MaxValueBytes); err != nil {
+		return CefEvent{}, newParseError(len(eventLine)-len(extensionString), key, err, "")
 	}
-	return CefEvent{}, errors.New("not a valid CEF message")
+
+	// decompressed values did not take part in the check of the message.
+	for _, k := range compressed {
+		value, invalid := opts.UTF8.checkUTF8(event.Extensions[k])
+		if invalid >= 0 {
+			return CefEvent{}, newParseError(len(eventLine)-len(extensionString), k, ErrInvalidUTF8, "")
+		}
+		if opts.MaxValueBytes > 0 && len(value) > opts.MaxValueBytes {
+			return CefEvent{}, newParseError(len(eventLine)-len(extensionString), k, ErrLimitExceeded, fmt.Sprintf("decompressed value of %d bytes exceeds %d bytes", len(value), opts.MaxValueBytes))
+		}
+		if _, ok := event.Extensions[k]; ok {
+			event.Extensions[k] = value
+		}
+	}
+
+	if opts.Location != nil {
+		event.normalizeTimestamps(opts.Location)
+	}
+
+	if truncated != nil {
+		return *event, truncated
+	}
+
+	return *event, nil
+}
+
+// danglingEscape reports whether the value ends with an incomplete escape
+// sequence, i.e. an odd number of backslashes.
+func danglingEscape(value string) bool {
+
+	n := 0
+	for n < len(value) && value[len(value)-1-n] == '\\' {
+		n++
+	}
+
+	return n%2 == 1
 }
 
 // ToJSON converts the CefEvent instance to a JSON string.
@@ -341,3 +938,96 @@ func (event *CefEvent) ToJSON() (string, error) {
 
 	return string(jsonData), nil
 }
+
+// FromJSON is the inverse of ToJSON, it decodes a JSON object of the
+// fields of an event and validates it like the parser validates a CEF
+// message: the mandatory header fields must be set, the version must be 0
+// or 1 and the extension keys must be renderable. Fields which are not
+// fields of CefEvent are rejected, documents written by MarshalStored are
+// read with UnmarshalStored.
+//
+// Parameters:
+// - data: The JSON document, e.g. written by ToJSON.
+//
+// Returns:
+// - The CefEvent.
+// - A *JSONError if the document is malformed or violates the schema, it
+// wraps ErrMissingField, ErrInvalidVersion, ErrMalformedExtension or the
+// error of encoding/json.
+func FromJSON(data []byte) (CefEvent, error) {
+
+	var event CefEvent
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode((*cefEventFields)(&event)); err != nil {
+		return CefEvent{}, jsonDecodeError(err, decoder.InputOffset())
+	}
+	if decoder.More() {
+		return CefEvent{}, &JSONError{Offset: decoder.InputOffset(), Reason: "data after the event", Err: errors.New("trailing data")}
+	}
+
+	if field, reason, err := checkDocument(&event); err != nil {
+		return CefEvent{}, &JSONError{Offset: -1, Field: field, Reason: reason, Err: err}
+	}
+
+	return event, nil
+}
+
+// checkDocument validates an event decoded from a document, see FromJSON.
+//
+// Returns:
+// - The header field name or extension key violating the schema.
+// - The reason of the violation.
+// - ErrInvalidVersion, ErrMissingField or ErrMalformedExtension, nil if the event is valid.
+func checkDocument(event *CefEvent) (string, string, error) {
+
+	if event.Version != Version0 && event.Version != Version1 {
+		return headerFieldNames[0], fmt.Sprintf("version %d is not 0 or 1", event.Version), ErrInvalidVersion
+	}
+
+	header := [cefHeaderFields]string{"", event.DeviceVendor, event.DeviceProduct, event.DeviceVersion, event.DeviceEventClassId, event.Name, event.Severity}
+	for i := 1; i < cefHeaderFields; i++ {
+		if header[i] == "" {
+			return headerFieldNames[i], ErrMissingField.Error(), ErrMissingField
+		}
+	}
+
+	for key := range event.Extensions {
+		if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+			return key, fmt.Sprintf("extension key %q can not be rendered", key), ErrMalformedExtension
+		}
+	}
+
+	return "", "", nil
+}
+
+// jsonDecodeError converts an error of encoding/json into a JSONError.
+func jsonDecodeError(err error, offset int64) *JSONError {
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &JSONError{Offset: syntaxErr.Offset, Reason: syntaxErr.Error(), Err: err}
+	case errors.As(err, &typeErr):
+		jsonErr := &JSONError{Offset: typeErr.Offset, Field: typeErr.Field, Reason: fmt.Sprintf("%s value is not a %s", typeErr.Value, typeErr.Type), Err: err}
+		if typeErr.Field == headerFieldNames[0] {
+			jsonErr.Err = ErrInvalidVersion
+		} else if strings.HasPrefix(typeErr.Field, "Extensions") {
+			jsonErr.Err = ErrMalformedExtension
+		}
+		return jsonErr
+	case errors.Is(err, io.EOF):
+		return &JSONError{Offset: 0, Reason: "empty document", Err: err}
+	}
+
+	// unknown fields are reported by encoding/json without a type.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field, _ = strconv.Unquote(field)
+		return &JSONError{Offset: offset, Field: field, Reason: "unknown field", Err: err}
+	}
+
+	return &JSONError{Offset: offset, Reason: err.Error(), Err: err}
+}
@@ -1,7 +1,10 @@
 package cefevent
 
 import (
+	"errors"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +45,55 @@ func TestCefEventParsed(t *testing.T) {
 	}
 }
 
+func TestParse(t *testing.T) {
+
+	got, err := Parse(eventLine)
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("Parse() = %v, %v, want %v", got, err, event)
+	}
+
+	got, err = ParseWithOptions(eventLine+" src=10.0.0.1", ParseOptions{Strict: true})
+	if err == nil {
+		t.Errorf("ParseWithOptions() = %v, want an error in strict mode", got)
+	}
+}
+
+func TestParseLenientPrefix(t *testing.T) {
+
+	var tests = []struct {
+		line       string
+		wantStrict bool
+	}{
+		{eventLine, true},
+		{"cef:" + eventLine[4:], false},
+		{"Cef:" + eventLine[4:], false},
+		{"  \t" + eventLine, false},
+		{"\uFEFF" + eventLine, false},
+		{"\uFEFF cef:" + eventLine[4:], false},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.line)
+		if err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("Parse(%q) = %v, %v, want %v", tt.line, got, err, event)
+		}
+		if _, err := ParseWithOptions(tt.line, ParseOptions{Strict: true}); (err == nil) != tt.wantStrict {
+			t.Errorf("ParseWithOptions(%q, strict) = %v, want an error: %v", tt.line, err, !tt.wantStrict)
+		}
+	}
+
+	for _, line := range []string{"CE:0|a|b|c|d|e|f|", "x CEF:0|a|b|c|d|e|f|", "\n" + eventLine} {
+		if _, err := Parse(line); !errors.Is(err, ErrMissingPrefix) {
+			t.Errorf("Parse(%q) = %v, want ErrMissingPrefix", line, err)
+		}
+	}
+
+	var parseErr *ParseError
+	if _, err := Parse("  cef:0||b|c|d|e|f|"); !errors.As(err, &parseErr) || parseErr.Offset != 8 {
+		t.Errorf("Parse() = %v, want the offset of the empty vendor", err)
+	}
+}
+
 func TestCefEventParsedAndGenerated(t *testing.T) {
 
 	newEvent := CefEvent{}
@@ -83,6 +135,77 @@ func TestCefEventEscape(t *testing.T) {
 
 }
 
+func TestCefEventEscapeCarriageReturn(t *testing.T) {
+
+	crlfEvent := event
+	crlfEvent.Name = "line one\r\nline two"
+	crlfEvent.Extensions = map[string]string{"msg": "a\r\nb\r"}
+
+	line, _ := crlfEvent.String()
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|line one\\r\\nline two|Unknown|msg=a\\r\\nb\\r"
+	if line != want {
+		t.Errorf("event.String() = %q, want %q", line, want)
+	}
+
+	got, err := Parse(line)
+	if err != nil || !reflect.DeepEqual(got, crlfEvent) {
+		t.Errorf("Parse(%q) = %+v, %v, want %+v", line, got, err, crlfEvent)
+	}
+}
+
+func TestParseCRLF(t *testing.T) {
+
+	// Windows-originated logs end their lines with CRLF.
+	for _, line := range []string{eventLine + "\r\n", eventLine + "\n", eventLine + " \r\n"} {
+		got, err := Parse(line)
+		if err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", line, got, err, event)
+		}
+	}
+
+	got, err := Parse("CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|\r\n")
+	if err != nil || len(got.Extensions) != 0 || got.Severity != "Unknown" {
+		t.Errorf("Parse() = %+v, %v, want no extensions", got, err)
+	}
+
+	dec := NewDecoder(strings.NewReader(eventLine + "\r\n" + eventLine + "\r\n"))
+	for i := 0; i < 2; i++ {
+		if got, err := dec.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("Decode() = %+v, %v, want %+v", got, err, event)
+		}
+	}
+}
+
+func TestStripControlCharacters(t *testing.T) {
+
+	dirty := event
+	dirty.Name = "Some\x00thing\x1b[31m cool\u0085 happened."
+	dirty.Extensions = map[string]string{"src": "127.0.0.1", "msg\x07": "tab\tline\r\nend\x7f"}
+
+	var got CefEvent
+	handler := StripControlCharacters()(func(event CefEvent) error {
+		got = event
+		return nil
+	})
+	handler(dirty)
+
+	if got.Name != "Something[31m cool happened." {
+		t.Errorf("StripControlCharacters() Name = %q", got.Name)
+	}
+	want := map[string]string{"src": "127.0.0.1", "msg": "tab\tline\r\nend"}
+	if !reflect.DeepEqual(got.Extensions, want) {
+		t.Errorf("StripControlCharacters() = %q, want %q", got.Extensions, want)
+	}
+	if _, ok := dirty.Extensions["msg\x07"]; !ok {
+		t.Error("StripControlCharacters() modified the extensions of the original event")
+	}
+
+	clean := event
+	if clean.StripControlCharacters() {
+		t.Error("StripControlCharacters() = true for an event without control characters")
+	}
+}
+
 func TestCefEventMandatoryVersionField(t *testing.T) {
 
 	brokenEvent := event
@@ -243,3 +366,312 @@ func TestCefEvent_ToJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestFromJSON(t *testing.T) {
+
+	data, _ := event.ToJSON()
+	got, err := FromJSON([]byte(data))
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("FromJSON(%s) = %v, %v, want %v", data, got, err, event)
+	}
+
+	header := `"Version":0,"DeviceVendor":"V","DeviceProduct":"P","DeviceVersion":"1","DeviceEventClassId":"1","Name":"N","Severity":"5"`
+
+	tests := []struct {
+		data  string
+		field string
+		want  error
+	}{
+		{`{"Version":0,"DeviceVendor":"V"`, "", nil},
+		{``, "", io.EOF},
+		{`{` + header + `}{}`, "", nil},
+		{`{` + header + `,"Source":"x"}`, "Source", nil},
+		{`{` + strings.Replace(header, `"Version":0`, `"Version":"0"`, 1) + `}`, "Version", ErrInvalidVersion},
+		{`{` + strings.Replace(header, `"Version":0`, `"Version":2`, 1) + `}`, "Version", ErrInvalidVersion},
+		{`{` + strings.Replace(header, `"Name":"N",`, ``, 1) + `}`, "Name", ErrMissingField},
+		{`{` + strings.Replace(header, `"Severity":"5"`, `"Severity":""`, 1) + `}`, "Severity", ErrMissingField},
+		{`{` + header + `,"Extensions":{"spt":514}}`, "Extensions.spt", ErrMalformedExtension},
+		{`{` + header + `,"Extensions":{"a b":"c"}}`, "a b", ErrMalformedExtension},
+	}
+
+	for _, tt := range tests {
+		_, err := FromJSON([]byte(tt.data))
+		var jsonErr *JSONError
+		if !errors.As(err, &jsonErr) {
+			t.Errorf("FromJSON(%s) = %v, want a *JSONError", tt.data, err)
+			continue
+		}
+		if jsonErr.Field != tt.field {
+			t.Errorf("FromJSON(%s).Field = %q, want %q", tt.data, jsonErr.Field, tt.field)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("FromJSON(%s) = %v, want %v", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestCefEventParsedEscapedPipes(t *testing.T) {
+
+	pipedEvent := event
+	pipedEvent.Name = "pipe|in|name"
+	pipedEvent.DeviceProduct = "back\\slash"
+
+	line, _ := pipedEvent.String()
+	got, err := new(CefEvent).Read(line)
+	if err != nil {
+		t.Fatalf("Read(%q) = %v", line, err)
+	}
+
+	if got.Severity != "Unknown" || got.DeviceEventClassId != "COOL_THING" || got.Extensions["src"] != "127.0.0.1" {
+		t.Errorf("Read(%q) = %+v, the escaped pipes were taken as separators", line, got)
+	}
+}
+
+func TestSplitHeader(t *testing.T) {
+
+	var tests = []struct {
+		message    string
+		header     []string
+		extensions string
+		ok         bool
+	}{
+		{`0|a|b|c|d|e|f|k=v`, []string{"0", "a", "b", "c", "d", "e", "f"}, "k=v", true},
+		{`0|a\|b|c|d|e|f|g|k=v|w`, []string{"0", "a|b", "c", "d", "e", "f", "g"}, "k=v|w", true},
+		{`0|a\\|b|c|d|e|f|`, []string{"0", `a\`, "b", "c", "d", "e", "f"}, "", true},
+		{`0|a|b|c`, []string{"0", "a", "b", "c"}, "", false},
+		{`0|a\nb|c\d|\|`, []string{"0", "a\nb", `c\d`, "|"}, "", false},
+		{`0|a\r\nb|c`, []string{"0", "a\r\nb", "c"}, "", false},
+	}
+
+	for _, tt := range tests {
+		var fields [cefHeaderFields]string
+		n, extensions, ok := splitHeader(tt.message, &fields)
+		header := fields[:n]
+		if !reflect.DeepEqual(header, tt.header) || extensions != tt.extensions || ok != tt.ok {
+			t.Errorf("splitHeader(%q) = %q, %q, %v, want %q, %q, %v", tt.message, header, extensions, ok, tt.header, tt.extensions, tt.ok)
+		}
+	}
+
+	if _, err := new(CefEvent).Read("CEF:0|a|b|c|d|e|f"); err == nil {
+		t.Errorf("Read() should fail for an incomplete header")
+	}
+}
+
+func TestCefEventRoundTripUnescaped(t *testing.T) {
+
+	special := event
+	special.Name = "back\\slash|pipe\nnewline"
+	special.Extensions = map[string]string{"msg": "a=b\\c\nd", "request": "https://example.com/?q=1"}
+
+	line, err := special.String()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// repeated parse and serialize cycles must not change the event.
+	for i := 0; i < 3; i++ {
+		got, err := new(CefEvent).Read(line)
+		if err != nil || !reflect.DeepEqual(got, special) {
+			t.Fatalf("Read(%q) = %+v, %v, want %+v", line, got, err, special)
+		}
+		if again, _ := got.String(); again != line {
+			t.Fatalf("String() = %q, want %q", again, line)
+		}
+	}
+}
+
+func TestCefUnescapeExtension(t *testing.T) {
+
+	var tests = []struct {
+		field string
+		want  string
+	}{
+		{`plain`, "plain"},
+		{`a\=b`, "a=b"},
+		{`c:\\temp\\x`, `c:\temp\x`},
+		{`line\nbreak\r`, "line\nbreak\r"},
+		{`unknown\t`, `unknown\t`},
+		{`trailing\`, `trailing\`},
+	}
+
+	for _, tt := range tests {
+		if got := cefUnescapeExtension(tt.field); got != tt.want {
+			t.Errorf("cefUnescapeExtension(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestReadWithOptions(t *testing.T) {
+
+	var tests = []struct {
+		line string
+		opts ParseOptions
+		want map[string]string
+		ok   bool
+	}{
+		{strings.Replace(eventLine, "|src=", "|garbage src=", 1), ParseOptions{}, map[string]string{"src": "127.0.0.1"}, true},
+		{strings.Replace(eventLine, "|src=", "|garbage src=", 1), ParseOptions{Strict: true}, nil, false},
+		{eventLine + " bad-key=1", ParseOptions{Strict: true}, nil, false},
+		{eventLine + " src=10.0.0.1", ParseOptions{Strict: true}, nil, false},
+		{eventLine + " dst=10.0.0.1 spt=80", ParseOptions{MaxExtensions: 2}, nil, false},
+		{eventLine + " dst=10.0.0.1 src=10.0.0.2", ParseOptions{MaxExtensions: 2}, map[string]string{"src": "10.0.0.2", "dst": "10.0.0.1"}, true},
+		{eventLine + " dst=10.0.0.1 spt=80", ParseOptions{MaxExtensions: 2, Strict: true}, nil, false},
+		{eventLine + " dst=10.0.0.1 spt=80", ParseOptions{MaxExtensions: 3, Strict: true}, map[string]string{"src": "127.0.0.1", "dst": "10.0.0.1", "spt": "80"}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := new(CefEvent).ReadWithOptions(tt.line, tt.opts)
+		if (err == nil) != tt.ok || tt.ok && !reflect.DeepEqual(got.Extensions, tt.want) {
+			t.Errorf("ReadWithOptions(%q, %+v) = %v, %v, want %v", tt.line, tt.opts, got.Extensions, err, tt.want)
+		}
+	}
+}
+
+func TestParseKeepRaw(t *testing.T) {
+
+	line := eventLine + " msg=caf\xe9"
+
+	got, err := ParseWithOptions(line, ParseOptions{KeepRaw: true, UTF8: UTF8Replace})
+	if err != nil || got.Raw != line || got.Extensions["msg"] != "caf\uFFFD" {
+		t.Errorf("ParseWithOptions() = %+v, %v, want the untouched line in Raw", got, err)
+	}
+
+	if data, err := got.ToJSON(); err != nil || strings.Contains(data, "Raw") {
+		t.Errorf("ToJSON() = %s, %v, want Raw omitted", data, err)
+	}
+
+	reused := got
+	if got, err := reused.ReadWithOptions(eventLine, ParseOptions{}); err != nil || got.Raw != "" {
+		t.Errorf("ReadWithOptions() Raw = %q, %v, want it empty without KeepRaw", got.Raw, err)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+
+	compressed := event
+	compressed.Extensions = map[string]string{"rawEvent": strings.Repeat("payload ", 20)}
+	compressed.CompressExtension("rawEvent")
+	compressedLine, _ := compressed.String()
+
+	var tests = []struct {
+		line    string
+		opts    ParseOptions
+		field   string
+		wantErr bool
+	}{
+		{eventLine, ParseOptions{MaxLineBytes: len(eventLine)}, "", false},
+		{eventLine, ParseOptions{MaxLineBytes: len(eventLine) - 1}, "", true},
+		{eventLine + " msg=" + strings.Repeat("x", 64), ParseOptions{MaxValueBytes: 64}, "", false},
+		{eventLine + " msg=" + strings.Repeat("x", 65), ParseOptions{MaxValueBytes: 64}, "msg", true},
+		{compressedLine, ParseOptions{MaxValueBytes: 64}, "rawEvent", true},
+		{compressedLine, ParseOptions{MaxValueBytes: 160}, "", false},
+		{eventLine + " dst=10.0.0.1", ParseOptions{MaxExtensions: 1, Strict: true}, "dst", true},
+		{eventLine + " dst=10.0.0.1", ParseOptions{MaxExtensions: 1}, "dst", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseWithOptions(tt.line, tt.opts)
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("ParseWithOptions(%q, %+v) = %v, want no error", tt.line, tt.opts, err)
+			}
+			continue
+		}
+		var parseErr *ParseError
+		if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &parseErr) || parseErr.Field != tt.field {
+			t.Errorf("ParseWithOptions(%q, %+v) = %v, want ErrLimitExceeded in %q", tt.line, tt.opts, err, tt.field)
+		}
+	}
+}
+
+func TestReadWithOptionsMissingFields(t *testing.T) {
+
+	line := "CEF:0|Cool Vendor|Cool Product||COOL_THING"
+
+	if _, err := new(CefEvent).Read(line); err == nil {
+		t.Errorf("Read(%q) should fail", line)
+	}
+
+	got, err := new(CefEvent).ReadWithOptions(line, ParseOptions{AllowMissingFields: true})
+	if err != nil || got.DeviceEventClassId != "COOL_THING" || got.Name != "" || got.Severity != "" {
+		t.Errorf("ReadWithOptions(%q) = %+v, %v, want the fields present", line, got, err)
+	}
+}
+
+func TestReadWithOptionsUTF8(t *testing.T) {
+
+	line := "CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|suser=j\xfcrgen msg=\xe2\x9c"
+
+	got, err := new(CefEvent).Read(line)
+	if err != nil || got.Extensions["suser"] != "j\xfcrgen" {
+		t.Errorf("Read(%q) = %v, %v, want the bytes kept", line, got.Extensions, err)
+	}
+
+	got, err = new(CefEvent).ReadWithOptions(line, ParseOptions{UTF8: UTF8Replace})
+	if err != nil || got.Extensions["suser"] != "j\uFFFDrgen" || got.Extensions["msg"] != "\uFFFD" {
+		t.Errorf("ReadWithOptions(UTF8Replace) = %v, %v, want U+FFFD replacements", got.Extensions, err)
+	}
+
+	_, err = new(CefEvent).ReadWithOptions(line, ParseOptions{UTF8: UTF8Reject})
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrInvalidUTF8) || parseErr.Offset != strings.Index(line, "\xfc") {
+		t.Errorf("ReadWithOptions(UTF8Reject) = %v, want ErrInvalidUTF8 at offset %d", err, strings.Index(line, "\xfc"))
+	}
+
+	if _, err := new(CefEvent).ReadWithOptions(eventLine+" msg=Überwachung", ParseOptions{UTF8: UTF8Reject}); err != nil {
+		t.Errorf("ReadWithOptions(UTF8Reject) = %v for valid UTF-8", err)
+	}
+
+	compressed := event
+	compressed.Extensions = map[string]string{"rawEvent": strings.Repeat("\xff", 100)}
+	compressed.CompressExtension("rawEvent")
+	compressedLine, _ := compressed.String()
+
+	if _, err := new(CefEvent).ReadWithOptions(compressedLine, ParseOptions{UTF8: UTF8Reject}); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("ReadWithOptions(UTF8Reject) = %v for invalid compressed values, want ErrInvalidUTF8", err)
+	}
+	if got, err := new(CefEvent).ReadWithOptions(compressedLine, ParseOptions{UTF8: UTF8Replace}); err != nil || got.Extensions["rawEvent"] != "\uFFFD" {
+		t.Errorf("ReadWithOptions(UTF8Replace) = %q, %v for invalid compressed values", got.Extensions["rawEvent"], err)
+	}
+}
+
+func TestCefEventParsedSpacedValues(t *testing.T) {
+
+	var tests = []struct {
+		extensions string
+		want       map[string]string
+	}{
+		{"src=10.0.0.1 msg=User logged in from 10.0.0.2", map[string]string{"src": "10.0.0.1", "msg": "User logged in from 10.0.0.2"}},
+		{"msg=a = b  c dst=10.0.0.2 ", map[string]string{"msg": "a = b  c", "dst": "10.0.0.2"}},
+		{`msg=x\= y z cs1Label=Some label`, map[string]string{"msg": "x= y z", "cs1Label": "Some label"}},
+		{"rt=Jan 02 2024 15:04:05 suser=alice", map[string]string{"rt": "Jan 02 2024 15:04:05", "suser": "alice"}},
+	}
+
+	for _, tt := range tests {
+		line := "CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|" + tt.extensions
+		got, err := new(CefEvent).Read(line)
+		if err != nil || !reflect.DeepEqual(got.Extensions, tt.want) {
+			t.Errorf("Read(%q) = %v, %v, want %v", line, got.Extensions, err, tt.want)
+		}
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+
+	for _, version := range []string{"0", "1"} {
+		line := "CEF:" + version + eventLine[len("CEF:0"):]
+		if _, err := new(CefEvent).Read(line); err != nil {
+			t.Errorf("Read(%q) = %v", line, err)
+		}
+	}
+
+	line := "CEF:42" + eventLine[len("CEF:0"):]
+	if _, err := new(CefEvent).Read(line); err == nil {
+		t.Errorf("Read(%q) should fail for an unknown version", line)
+	}
+
+	got, err := new(CefEvent).ReadWithOptions(line, ParseOptions{AllowUnknownVersion: true})
+	if err != nil || got.Version != 42 {
+		t.Errorf("ReadWithOptions(%q) = %+v, %v, want version 42", line, got, err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package cefimport converts the output of other security tools into CEF
+// events, so it can be forwarded to SIEMs which only accept CEF. Every
+// importer maps the fields of the tool onto the header fields and the
+// standard extension keys, e.g. the alerts of Suricata:
+//
+//	d := cefimport.NewSuricataDecoder(eveFile, cefimport.SuricataOptions{})
+//	for {
+//		event, err := d.Decode()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			log.Print(err)
+//			continue
+//		}
+//		line, _ := event.String()
+//		fmt.Println(line)
+//	}
+package cefimport
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// ErrNotAlert is returned by FromSuricataEVE for EVE records of other event
+// types than "alert", e.g. flow, dns or stats records.
+var ErrNotAlert = errors.New("EVE record is not an alert")
+
+// suricataTimestamp is the layout of the timestamps of EVE records.
+const suricataTimestamp = "2006-01-02T15:04:05.999999-0700"
+
+// maxEVELineSize is the maximum size of a single EVE record the SuricataDecoder accepts.
+const maxEVELineSize = 1024 * 1024
+
+// SuricataOptions configure the conversion of Suricata alerts.
+type SuricataOptions struct {
+	// DeviceVersion is the version of Suricata, which EVE records do not
+	// contain, "Unknown" if empty.
+	DeviceVersion string
+}
+
+// eveRecord holds the fields of an EVE record which are mapped onto the event.
+type eveRecord struct {
+	Timestamp string      `json:"timestamp"`
+	FlowID    json.Number `json:"flow_id"`
+	InIface   string      `json:"in_iface"`
+	EventType string      `json:"event_type"`
+	SrcIP     string      `json:"src_ip"`
+	SrcPort   int         `json:"src_port"`
+	DestIP    string      `json:"dest_ip"`
+	DestPort  int         `json:"dest_port"`
+	Proto     string      `json:"proto"`
+	AppProto  string      `json:"app_proto"`
+	Host      string      `json:"host"`
+	Alert     *struct {
+		Action      string `json:"action"`
+		GID         int64  `json:"gid"`
+		SignatureID int64  `json:"signature_id"`
+		Rev         int64  `json:"rev"`
+		Signature   string `json:"signature"`
+		Category    string `json:"category"`
+		Severity    int    `json:"severity"`
+	} `json:"alert"`
+	HTTP *struct {
+		Hostname  string `json:"hostname"`
+		URL       string `json:"url"`
+		UserAgent string `json:"http_user_agent"`
+		Method    string `json:"http_method"`
+	} `json:"http"`
+}
+
+// FromSuricataEVE converts an alert record of the Suricata EVE JSON output
+// to a CEF event of the device OISF Suricata:
+//
+//   - DeviceEventClassId is "gid:signature_id:rev", Name the signature.
+//   - The Suricata severities 1 (high) to 3 (low) become the CEF severities 8, 5 and 3, others 1.
+//   - src_ip and dest_ip become src and dst, or c6a2 and c6a3 if they are IPv6 addresses.
+//   - src_port, dest_port, proto, app_proto, in_iface and host become spt, dpt, proto, app,
+//     deviceInboundInterface and dvchost, the timestamp rt in milliseconds.
+//   - The action and category of the alert become act and cat, the flow ID cs1 labeled "flowId".
+//   - The hostname, url, user agent and method of HTTP alerts become dhost, request,
+//     requestClientApplication and requestMethod.
+//
+// Parameters:
+// - data: The EVE record, a JSON object.
+// - opts: The options of the conversion.
+//
+// Returns:
+// - The CefEvent.
+// - ErrNotAlert if the record is not an alert, or an error if it is not valid JSON or misses the signature.
+func FromSuricataEVE(data []byte, opts SuricataOptions) (cefevent.CefEvent, error) {
+
+	var record eveRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return cefevent.CefEvent{}, fmt.Errorf("malformed EVE record: %w", err)
+	}
+	if record.EventType != "alert" || record.Alert == nil {
+		return cefevent.CefEvent{}, ErrNotAlert
+	}
+
+	version := opts.DeviceVersion
+	if version == "" {
+		version = "Unknown"
+	}
+
+	alert := record.Alert
+	event := cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "OISF",
+		DeviceProduct:      "Suricata",
+		DeviceVersion:      version,
+		DeviceEventClassId: fmt.Sprintf("%d:%d:%d", alert.GID, alert.SignatureID, alert.Rev),
+		Name:               alert.Signature,
+		Severity:           suricataSeverity(alert.Severity),
+		Extensions:         map[string]string{},
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			event.Extensions[key] = value
+		}
+	}
+
+	setAddress(event.Extensions, record.SrcIP, "src", "c6a2", "Source IPv6 Address")
+	setAddress(event.Extensions, record.DestIP, "dst", "c6a3", "Destination IPv6 Address")
+	if record.SrcPort > 0 {
+		set("spt", strconv.Itoa(record.SrcPort))
+	}
+	if record.DestPort > 0 {
+		set("dpt", strconv.Itoa(record.DestPort))
+	}
+	set("proto", record.Proto)
+	set("app", record.AppProto)
+	set("deviceInboundInterface", record.InIface)
+	set("dvchost", record.Host)
+	set("act", alert.Action)
+	set("cat", alert.Category)
+
+	if record.FlowID != "" {
+		set("cs1", record.FlowID.String())
+		set("cs1Label", "flowId")
+	}
+
+	if t, err := time.Parse(suricataTimestamp, record.Timestamp); err == nil {
+		set("rt", strconv.FormatInt(t.UnixMilli(), 10))
+	}
+
+	if record.HTTP != nil {
+		set("dhost", record.HTTP.Hostname)
+		set("request", record.HTTP.URL)
+		set("requestClientApplication", record.HTTP.UserAgent)
+		set("requestMethod", record.HTTP.Method)
+	}
+
+	if err := event.Validate(); err != nil {
+		return cefevent.CefEvent{}, fmt.Errorf("EVE alert can not be converted: %w", err)
+	}
+
+	return event, nil
+}
+
+// suricataSeverity maps the severity of a Suricata alert onto the CEF severity.
+func suricataSeverity(severity int) string {
+
+	switch severity {
+	case 1:
+		return "8"
+	case 2:
+		return "5"
+	case 3:
+		return "3"
+	}
+
+	return "1"
+}
+
+// setAddress sets an IPv4 address as key and an IPv6 address as the
+// custom IPv6 extension with its label.
+func setAddress(extensions map[string]string, address string, key string, ipv6Key string, label string) {
+
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+	case ip.To4() != nil:
+		extensions[key] = address
+	default:
+		extensions[ipv6Key] = address
+		extensions[ipv6Key+"Label"] = label
+	}
+}
+
+// SuricataDecoder reads the alerts of an EVE JSON file, one record per
+// line, and converts them with FromSuricataEVE. Records of other event
+// types are skipped.
+type SuricataDecoder struct {
+	scanner *bufio.Scanner
+	opts    SuricataOptions
+}
+
+// NewSuricataDecoder returns a SuricataDecoder reading EVE records from r.
+//
+// Parameters:
+// - r: The io.Reader providing the EVE JSON output, e.g. eve.json.
+// - opts: The options of the conversion.
+//
+// Returns:
+// - A pointer to a SuricataDecoder.
+func NewSuricataDecoder(r io.Reader, opts SuricataOptions) *SuricataDecoder {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEVELineSize)
+
+	return &SuricataDecoder{scanner: scanner, opts: opts}
+}
+
+// Decode converts the next alert of the input. A malformed record is
+// returned as error, the next call to Decode continues with the following
+// record.
+//
+// Returns:
+// - The CefEvent of the alert.
+// - io.EOF when the input is exhausted, the conversion error of the record or the read error of the underlying reader.
+func (d *SuricataDecoder) Decode() (cefevent.CefEvent, error) {
+
+	for d.scanner.Scan() {
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		event, err := FromSuricataEVE([]byte(line), d.opts)
+		if errors.Is(err, ErrNotAlert) {
+			continue
+		}
+
+		return event, err
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return cefevent.CefEvent{}, err
+	}
+
+	return cefevent.CefEvent{}, io.EOF
+}
@@ -0,0 +1,104 @@
+package cefimport
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+const eveAlert = `{"timestamp":"2024-01-02T10:00:00.123456+0000","flow_id":1234567890123456,"in_iface":"eth0","event_type":"alert",` +
+	`"src_ip":"10.0.0.1","src_port":51234,"dest_ip":"192.168.1.10","dest_port":80,"proto":"TCP","app_proto":"http","host":"sensor1",` +
+	`"alert":{"action":"allowed","gid":1,"signature_id":2100498,"rev":7,"signature":"GPL ATTACK_RESPONSE id check returned root","category":"Potentially Bad Traffic","severity":2},` +
+	`"http":{"hostname":"example.com","url":"/index.html","http_user_agent":"curl/8.0","http_method":"GET"}}`
+
+func TestFromSuricataEVE(t *testing.T) {
+
+	got, err := FromSuricataEVE([]byte(eveAlert), SuricataOptions{DeviceVersion: "7.0.2"})
+	if err != nil {
+		t.Fatalf("FromSuricataEVE() = %v", err)
+	}
+	want := cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "OISF",
+		DeviceProduct:      "Suricata",
+		DeviceVersion:      "7.0.2",
+		DeviceEventClassId: "1:2100498:7",
+		Name:               "GPL ATTACK_RESPONSE id check returned root",
+		Severity:           "5",
+		Extensions: map[string]string{
+			"src": "10.0.0.1", "spt": "51234", "dst": "192.168.1.10", "dpt": "80", "proto": "TCP", "app": "http",
+			"deviceInboundInterface": "eth0", "dvchost": "sensor1", "act": "allowed", "cat": "Potentially Bad Traffic",
+			"cs1": "1234567890123456", "cs1Label": "flowId", "rt": "1704189600123",
+			"dhost": "example.com", "request": "/index.html", "requestClientApplication": "curl/8.0", "requestMethod": "GET",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromSuricataEVE() = %#v, want %#v", got, want)
+	}
+
+	ipv6 := `{"event_type":"alert","src_ip":"2001:db8::1","dest_ip":"2001:db8::2","alert":{"signature":"ICMPv6 probe","severity":1}}`
+	got, err = FromSuricataEVE([]byte(ipv6), SuricataOptions{})
+	if err != nil {
+		t.Fatalf("FromSuricataEVE() = %v", err)
+	}
+	wantExtensions := map[string]string{"c6a2": "2001:db8::1", "c6a2Label": "Source IPv6 Address", "c6a3": "2001:db8::2", "c6a3Label": "Destination IPv6 Address"}
+	if got.Severity != "8" || got.DeviceVersion != "Unknown" || !reflect.DeepEqual(got.Extensions, wantExtensions) {
+		t.Errorf("FromSuricataEVE() = %#v, want severity 8 and IPv6 extensions %v", got, wantExtensions)
+	}
+
+	tests := []struct {
+		name   string
+		record string
+		want   error
+	}{
+		{"flow", `{"event_type":"flow","src_ip":"10.0.0.1"}`, ErrNotAlert},
+		{"malformed", `{"event_type":`, nil},
+		{"no signature", `{"event_type":"alert","alert":{"severity":1}}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromSuricataEVE([]byte(tt.record), SuricataOptions{})
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("FromSuricataEVE() = %v, want an error", err)
+			}
+		})
+	}
+}
+
+func TestSuricataDecoder(t *testing.T) {
+
+	input := strings.Join([]string{
+		`{"event_type":"stats","stats":{}}`,
+		eveAlert,
+		"",
+		`{"event_type":`,
+		`{"event_type":"dns"}`,
+		eveAlert,
+	}, "\n")
+
+	d := NewSuricataDecoder(strings.NewReader(input), SuricataOptions{})
+	var events, failures int
+	for {
+		event, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			failures++
+			continue
+		}
+		if event.DeviceEventClassId != "1:2100498:7" {
+			t.Errorf("Decode() = %#v, want the alert", event)
+		}
+		events++
+	}
+
+	if events != 2 || failures != 1 {
+		t.Errorf("Decode() returned %d events and %d errors, want 2 and 1", events, failures)
+	}
+}
@@ -0,0 +1,350 @@
+package cefimport
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// WindowsOptions configure the conversion of Windows events.
+type WindowsOptions struct {
+	// DeviceVersion is the version of Windows, which events do not
+	// contain, "Unknown" if empty.
+	DeviceVersion string
+}
+
+// windowsEvent holds the fields of a Windows event common to its XML and JSON renderings.
+type windowsEvent struct {
+	provider string
+	eventID  string
+	level    string
+	created  time.Time
+	recordID string
+	channel  string
+	computer string
+	pid      string
+	message  string
+	data     map[string]string
+}
+
+// windowsEventNames name the events of the Security log which are rendered
+// without message.
+var windowsEventNames = map[string]string{
+	"1102": "The audit log was cleared",
+	"4624": "An account was successfully logged on",
+	"4625": "An account failed to log on",
+	"4634": "An account was logged off",
+	"4648": "A logon was attempted using explicit credentials",
+	"4672": "Special privileges assigned to new logon",
+	"4688": "A new process has been created",
+	"4720": "A user account was created",
+	"4726": "A user account was deleted",
+	"4732": "A member was added to a security-enabled local group",
+	"4740": "A user account was locked out",
+}
+
+// windowsLevels name the numeric levels of the XML rendering.
+var windowsLevels = map[string]string{
+	"0": "information",
+	"1": "critical",
+	"2": "error",
+	"3": "warning",
+	"4": "information",
+	"5": "verbose",
+}
+
+// windowsSeverities map the levels onto the CEF severity.
+var windowsSeverities = map[string]string{
+	"critical":    "10",
+	"error":       "7",
+	"warning":     "5",
+	"information": "3",
+	"verbose":     "1",
+}
+
+// windowsDataKeys map the EventData fields onto the standard extension keys.
+var windowsDataKeys = map[string]string{
+	"SubjectUserName":   "suser",
+	"SubjectDomainName": "sntdom",
+	"SubjectUserSid":    "suid",
+	"TargetUserName":    "duser",
+	"TargetDomainName":  "dntdom",
+	"TargetUserSid":     "duid",
+	"WorkstationName":   "shost",
+	"IpAddress":         "src",
+	"IpPort":            "spt",
+	"ProcessName":       "sproc",
+	"ProcessId":         "spid",
+	"NewProcessName":    "dproc",
+	"NewProcessId":      "dpid",
+	"FailureReason":     "reason",
+}
+
+// eventXML is the XML rendering of a Windows event, e.g. as exported by
+// wevtutil or forwarded by Windows Event Forwarding.
+type eventXML struct {
+	XMLName xml.Name `xml:"Event"`
+	System  struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     string `xml:"EventID"`
+		Level       string `xml:"Level"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID string `xml:"EventRecordID"`
+		Execution     struct {
+			ProcessID string `xml:"ProcessID,attr"`
+		} `xml:"Execution"`
+		Channel  string `xml:"Channel"`
+		Computer string `xml:"Computer"`
+	} `xml:"System"`
+	EventData []struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"EventData>Data"`
+	UserData struct {
+		Inner struct {
+			Fields []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:",any"`
+	} `xml:"UserData"`
+	RenderingInfo struct {
+		Message string `xml:"Message"`
+	} `xml:"RenderingInfo"`
+}
+
+// eventJSON is the JSON rendering of a Windows event by Winlogbeat.
+type eventJSON struct {
+	Timestamp string `json:"@timestamp"`
+	Message   string `json:"message"`
+	Log       struct {
+		Level string `json:"level"`
+	} `json:"log"`
+	Winlog struct {
+		EventID      json.RawMessage `json:"event_id"`
+		ProviderName string          `json:"provider_name"`
+		Channel      string          `json:"channel"`
+		ComputerName string          `json:"computer_name"`
+		RecordID     json.RawMessage `json:"record_id"`
+		Process      struct {
+			PID json.RawMessage `json:"pid"`
+		} `json:"process"`
+		EventData map[string]json.RawMessage `json:"event_data"`
+		UserData  map[string]json.RawMessage `json:"user_data"`
+	} `json:"winlog"`
+}
+
+// FromWindowsEventXML converts a Windows event in its XML rendering, as
+// exported by wevtutil, Get-WinEvent or Windows Event Forwarding, to a CEF
+// event of the device Microsoft Windows, see FromWindowsEventJSON for the
+// mapping of the fields.
+//
+// Parameters:
+// - data: The Event element.
+// - opts: The options of the conversion.
+//
+// Returns:
+// - The CefEvent.
+// - An error if the data is not a Windows event or misses the EventID.
+func FromWindowsEventXML(data []byte, opts WindowsOptions) (cefevent.CefEvent, error) {
+
+	var doc eventXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return cefevent.CefEvent{}, fmt.Errorf("malformed Windows event XML: %w", err)
+	}
+
+	e := windowsEvent{
+		provider: doc.System.Provider.Name,
+		eventID:  strings.TrimSpace(doc.System.EventID),
+		level:    windowsLevels[strings.TrimSpace(doc.System.Level)],
+		recordID: strings.TrimSpace(doc.System.EventRecordID),
+		channel:  strings.TrimSpace(doc.System.Channel),
+		computer: strings.TrimSpace(doc.System.Computer),
+		pid:      doc.System.Execution.ProcessID,
+		message:  strings.TrimSpace(doc.RenderingInfo.Message),
+		data:     map[string]string{},
+	}
+	if t, err := time.Parse(time.RFC3339Nano, doc.System.TimeCreated.SystemTime); err == nil {
+		e.created = t
+	}
+	for _, d := range doc.EventData {
+		if d.Name != "" {
+			e.data[d.Name] = strings.TrimSpace(d.Value)
+		}
+	}
+	for _, f := range doc.UserData.Inner.Fields {
+		e.data[f.XMLName.Local] = strings.TrimSpace(f.Value)
+	}
+
+	return e.toCEF(opts)
+}
+
+// FromWindowsEventJSON converts a Windows event rendered as JSON by
+// Winlogbeat, i.e. with the winlog object holding event_id, provider_name
+// and event_data, to a CEF event of the device Microsoft Windows:
+//
+//   - DeviceEventClassId is the EventID, Name the first line of the rendered message,
+//     the name of well-known Security events or the provider and EventID.
+//   - The levels critical, error, warning, information and verbose become the CEF severities 10, 7, 5, 3 and 1.
+//   - The time the event was created becomes rt in milliseconds, the computer dvchost,
+//     the record ID externalId, the channel deviceFacility, the process ID dvcpid and the message msg.
+//   - The subject and target users, domains and SIDs of EventData become suser, sntdom,
+//     suid, duser, dntdom and duid, IpAddress, IpPort and WorkstationName src, spt and shost.
+//   - ProcessName, ProcessId, NewProcessName and NewProcessId become sproc, spid, dproc
+//     and dpid, FailureReason reason and LogonType cn1 labeled "logonType".
+//
+// Placeholders such as "-" and addresses, ports and IDs which are not valid are left out.
+//
+// Parameters:
+// - data: The JSON object.
+// - opts: The options of the conversion.
+//
+// Returns:
+// - The CefEvent.
+// - An error if the data is not a Windows event or misses the EventID.
+func FromWindowsEventJSON(data []byte, opts WindowsOptions) (cefevent.CefEvent, error) {
+
+	var doc eventJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return cefevent.CefEvent{}, fmt.Errorf("malformed Windows event JSON: %w", err)
+	}
+
+	e := windowsEvent{
+		provider: doc.Winlog.ProviderName,
+		eventID:  jsonScalar(doc.Winlog.EventID),
+		level:    strings.ToLower(doc.Log.Level),
+		recordID: jsonScalar(doc.Winlog.RecordID),
+		channel:  doc.Winlog.Channel,
+		computer: doc.Winlog.ComputerName,
+		pid:      jsonScalar(doc.Winlog.Process.PID),
+		message:  strings.TrimSpace(doc.Message),
+		data:     map[string]string{},
+	}
+	if t, err := time.Parse(time.RFC3339Nano, doc.Timestamp); err == nil {
+		e.created = t
+	}
+	for _, fields := range []map[string]json.RawMessage{doc.Winlog.EventData, doc.Winlog.UserData} {
+		for name, value := range fields {
+			e.data[name] = jsonScalar(value)
+		}
+	}
+
+	return e.toCEF(opts)
+}
+
+// jsonScalar returns a JSON string or number as string, other values as "".
+func jsonScalar(raw json.RawMessage) string {
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+
+	return ""
+}
+
+// toCEF maps the event onto a CefEvent.
+func (e windowsEvent) toCEF(opts WindowsOptions) (cefevent.CefEvent, error) {
+
+	if e.eventID == "" {
+		return cefevent.CefEvent{}, errors.New("Windows event has no EventID")
+	}
+
+	version := opts.DeviceVersion
+	if version == "" {
+		version = "Unknown"
+	}
+
+	name, _, _ := strings.Cut(e.message, "\n")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = windowsEventNames[e.eventID]
+	}
+	if name == "" {
+		name = strings.TrimSpace(e.provider + " " + e.eventID)
+	}
+
+	severity, ok := windowsSeverities[e.level]
+	if !ok {
+		severity = "Unknown"
+	}
+
+	event := cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "Microsoft",
+		DeviceProduct:      "Microsoft Windows",
+		DeviceVersion:      version,
+		DeviceEventClassId: e.eventID,
+		Name:               name,
+		Severity:           severity,
+		Extensions:         map[string]string{},
+	}
+
+	set := func(key, value string) {
+		if value != "" && value != "-" {
+			event.Extensions[key] = value
+		}
+	}
+
+	if !e.created.IsZero() {
+		set("rt", strconv.FormatInt(e.created.UnixMilli(), 10))
+	}
+	set("dvchost", e.computer)
+	set("externalId", e.recordID)
+	set("deviceFacility", e.channel)
+	set("dvcpid", windowsNumber(e.pid))
+	set("msg", e.message)
+
+	for field, value := range e.data {
+		key, ok := windowsDataKeys[field]
+		if !ok {
+			continue
+		}
+		switch key {
+		case "src":
+			if net.ParseIP(value) == nil {
+				continue
+			}
+		case "spt", "spid", "dpid":
+			if value = windowsNumber(value); value == "0" {
+				continue
+			}
+		}
+		set(key, value)
+	}
+
+	if logonType := windowsNumber(e.data["LogonType"]); logonType != "" {
+		set("cn1", logonType)
+		set("cn1Label", "logonType")
+	}
+
+	return event, nil
+}
+
+// windowsNumber converts a decimal or hexadecimal number such as the
+// process IDs "0x1a4" of EventData to decimal, other values to "".
+func windowsNumber(value string) string {
+
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 0, 64)
+	if err != nil {
+		return ""
+	}
+
+	return strconv.FormatUint(n, 10)
+}
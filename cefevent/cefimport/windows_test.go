@@ -0,0 +1,110 @@
+package cefimport
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+const windowsXML = `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event">
+  <System>
+    <Provider Name="Microsoft-Windows-Security-Auditing" Guid="{54849625-5478-4994-a5ba-3e3b0328c30d}"/>
+    <EventID>4625</EventID>
+    <Version>0</Version>
+    <Level>0</Level>
+    <TimeCreated SystemTime="2024-01-02T10:00:00.1234567Z"/>
+    <EventRecordID>123456</EventRecordID>
+    <Execution ProcessID="636" ThreadID="700"/>
+    <Channel>Security</Channel>
+    <Computer>DC01.corp.local</Computer>
+  </System>
+  <EventData>
+    <Data Name="SubjectUserSid">S-1-0-0</Data>
+    <Data Name="SubjectUserName">-</Data>
+    <Data Name="TargetUserName">alice</Data>
+    <Data Name="TargetDomainName">CORP</Data>
+    <Data Name="LogonType">3</Data>
+    <Data Name="FailureReason">%%2313</Data>
+    <Data Name="WorkstationName">WS01</Data>
+    <Data Name="ProcessId">0x0</Data>
+    <Data Name="IpAddress">10.0.0.5</Data>
+    <Data Name="IpPort">51234</Data>
+  </EventData>
+</Event>`
+
+const windowsJSON = `{"@timestamp":"2024-01-02T10:00:00.123Z","message":"A new process has been created.\n\nCreator Subject: ...",` +
+	`"log":{"level":"information"},"winlog":{"event_id":"4688","provider_name":"Microsoft-Windows-Security-Auditing",` +
+	`"channel":"Security","computer_name":"WS01.corp.local","record_id":98765,"process":{"pid":4},` +
+	`"event_data":{"SubjectUserName":"bob","SubjectDomainName":"CORP","NewProcessId":"0x1a4","NewProcessName":"C:\\Windows\\System32\\cmd.exe","ProcessId":"0x3e8","IpAddress":"-"}}}`
+
+func TestFromWindowsEvent(t *testing.T) {
+
+	got, err := FromWindowsEventXML([]byte(windowsXML), WindowsOptions{DeviceVersion: "10.0"})
+	if err != nil {
+		t.Fatalf("FromWindowsEventXML() = %v", err)
+	}
+	want := cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "Microsoft",
+		DeviceProduct:      "Microsoft Windows",
+		DeviceVersion:      "10.0",
+		DeviceEventClassId: "4625",
+		Name:               "An account failed to log on",
+		Severity:           "3",
+		Extensions: map[string]string{
+			"rt": "1704189600123", "dvchost": "DC01.corp.local", "externalId": "123456", "deviceFacility": "Security", "dvcpid": "636",
+			"suid": "S-1-0-0", "duser": "alice", "dntdom": "CORP", "reason": "%%2313", "shost": "WS01", "src": "10.0.0.5", "spt": "51234",
+			"cn1": "3", "cn1Label": "logonType",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromWindowsEventXML() = %#v, want %#v", got, want)
+	}
+
+	got, err = FromWindowsEventJSON([]byte(windowsJSON), WindowsOptions{})
+	if err != nil {
+		t.Fatalf("FromWindowsEventJSON() = %v", err)
+	}
+	want = cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "Microsoft",
+		DeviceProduct:      "Microsoft Windows",
+		DeviceVersion:      "Unknown",
+		DeviceEventClassId: "4688",
+		Name:               "A new process has been created.",
+		Severity:           "3",
+		Extensions: map[string]string{
+			"rt": "1704189600123", "dvchost": "WS01.corp.local", "externalId": "98765", "deviceFacility": "Security", "dvcpid": "4",
+			"msg": "A new process has been created.\n\nCreator Subject: ...", "suser": "bob", "sntdom": "CORP",
+			"dpid": "420", "dproc": `C:\Windows\System32\cmd.exe`, "spid": "1000",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromWindowsEventJSON() = %#v, want %#v", got, want)
+	}
+
+	cleared := `<Event><System><Provider Name="Microsoft-Windows-Eventlog"/><EventID Qualifiers="0">1102</EventID><Level>4</Level></System>` +
+		`<UserData><LogFileCleared xmlns="http://manifests.microsoft.com/win/2004/08/windows/eventlog"><SubjectUserName>admin</SubjectUserName></LogFileCleared></UserData></Event>`
+	got, err = FromWindowsEventXML([]byte(cleared), WindowsOptions{})
+	if err != nil || got.Name != "The audit log was cleared" || got.Extensions["suser"] != "admin" {
+		t.Errorf("FromWindowsEventXML() = %#v, %v, want the cleared audit log by admin", got, err)
+	}
+
+	custom := `{"log":{"level":"Error"},"winlog":{"event_id":1000,"provider_name":"Application Error"}}`
+	got, err = FromWindowsEventJSON([]byte(custom), WindowsOptions{})
+	if err != nil || got.Name != "Application Error 1000" || got.Severity != "7" {
+		t.Errorf("FromWindowsEventJSON() = %#v, %v, want the provider and EventID as name", got, err)
+	}
+
+	for _, data := range []string{"<Event>", "<Event><System></System></Event>", "<Other/>"} {
+		if _, err := FromWindowsEventXML([]byte(data), WindowsOptions{}); err == nil {
+			t.Errorf("FromWindowsEventXML(%q) = nil, want an error", data)
+		}
+	}
+	for _, data := range []string{"{", `{"winlog":{}}`} {
+		if _, err := FromWindowsEventJSON([]byte(data), WindowsOptions{}); err == nil {
+			t.Errorf("FromWindowsEventJSON(%q) = nil, want an error", data)
+		}
+	}
+}
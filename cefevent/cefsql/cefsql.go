@@ -0,0 +1,203 @@
+// Package cefsql maps CEF events onto a canonical table, so events can be
+// stored in Postgres or MySQL without every application inventing its own
+// mapping. The header fields become columns, the time of the event a
+// nullable timestamp column and the extensions a JSON document:
+//
+//	if _, err := db.Exec(cefsql.Postgres.CreateTable(cefsql.DefaultTable)); err != nil {
+//		return err
+//	}
+//	args, err := cefsql.Args(event)
+//	if err != nil {
+//		return err
+//	}
+//	_, err = db.Exec(cefsql.Postgres.Insert(cefsql.DefaultTable), args...)
+//
+// Drivers supporting named arguments take the row of NamedArgs instead,
+// with statements referencing the columns by their names.
+package cefsql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// DefaultTable is the name of the table created by CreateTable if no
+// other name is chosen.
+const DefaultTable = "cef_events"
+
+// Columns name the columns of a row in the order of Args, the
+// auto-incremented id of the table is not part of rows.
+var Columns = []string{
+	"version",
+	"device_vendor",
+	"device_product",
+	"device_version",
+	"device_event_class_id",
+	"name",
+	"severity",
+	"event_time",
+	"extensions",
+}
+
+// Dialect is the SQL dialect the statements are written in.
+type Dialect int
+
+const (
+	// Postgres stores the extensions as JSONB and the time of the event as
+	// TIMESTAMPTZ.
+	Postgres Dialect = iota
+	// MySQL stores the extensions as JSON and the time of the event as
+	// DATETIME(6) in UTC.
+	MySQL
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+
+	switch d {
+	case Postgres:
+		return "postgres"
+	case MySQL:
+		return "mysql"
+	default:
+		return "Dialect(" + strconv.Itoa(int(d)) + ")"
+	}
+}
+
+// quote quotes an identifier such as the table name.
+func (d Dialect) quote(name string) string {
+
+	if d == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// CreateTable returns the statement creating the canonical table for
+// events if it does not exist yet. Indexes depend on the queries run
+// against the table and are left to the operator, e.g. on event_time.
+//
+// Parameters:
+// - table: The name of the table, e.g. DefaultTable.
+//
+// Returns:
+// - The CREATE TABLE statement.
+func (d Dialect) CreateTable(table string) string {
+
+	types := []string{"INTEGER", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TEXT", "TIMESTAMPTZ", "JSONB"}
+	id := "id BIGSERIAL PRIMARY KEY"
+	if d == MySQL {
+		types = []string{"INT", "VARCHAR(1023)", "VARCHAR(1023)", "VARCHAR(1023)", "VARCHAR(1023)", "TEXT", "VARCHAR(255)", "DATETIME(6)", "JSON"}
+		id = "id BIGINT AUTO_INCREMENT PRIMARY KEY"
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE TABLE IF NOT EXISTS " + d.quote(table) + " (\n\t" + id)
+	for i, column := range Columns {
+		b.WriteString(",\n\t" + column + " " + types[i])
+		if column == "event_time" {
+			b.WriteString(" NULL")
+		} else {
+			b.WriteString(" NOT NULL")
+		}
+	}
+	b.WriteString("\n)")
+
+	return b.String()
+}
+
+// Insert returns the statement inserting a row into the table, with the
+// positional placeholders of the dialect in the order of Columns, i.e. $1
+// to $9 for Postgres and ? for MySQL, see Args.
+//
+// Parameters:
+// - table: The name of the table, e.g. DefaultTable.
+//
+// Returns:
+// - The INSERT statement.
+func (d Dialect) Insert(table string) string {
+
+	placeholders := make([]string, len(Columns))
+	for i := range Columns {
+		if d == MySQL {
+			placeholders[i] = "?"
+		} else {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+
+	return "INSERT INTO " + d.quote(table) + " (" + strings.Join(Columns, ", ") + ") VALUES (" + strings.Join(placeholders, ", ") + ")"
+}
+
+// Args converts the event to the values of a row in the order of Columns,
+// the arguments of the statement returned by Insert. The time of the event,
+// see EventTime of cefevent, is a sql.NullTime in UTC which is not valid if
+// the event has none, the extensions are a JSON object.
+//
+// Parameters:
+// - event: The event to store.
+//
+// Returns:
+// - The values of the row.
+// - An error if the event is not valid.
+func Args(event cefevent.CefEvent) ([]interface{}, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	var eventTime sql.NullTime
+	if t, ok := event.EventTime(); ok {
+		eventTime = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+
+	extensions := event.Extensions
+	if extensions == nil {
+		extensions = map[string]string{}
+	}
+	data, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	return []interface{}{
+		event.Version,
+		event.DeviceVendor,
+		event.DeviceProduct,
+		event.DeviceVersion,
+		event.DeviceEventClassId,
+		event.Name,
+		event.Severity,
+		eventTime,
+		string(data),
+	}, nil
+}
+
+// NamedArgs converts the event to a row of named arguments, named after
+// Columns, for drivers and statements referencing the columns by name,
+// e.g. "INSERT INTO cef_events (name, ...) VALUES (@name, ...)".
+//
+// Parameters:
+// - event: The event to store.
+//
+// Returns:
+// - The named values of the row, in the order of Columns.
+// - An error if the event is not valid.
+func NamedArgs(event cefevent.CefEvent) ([]interface{}, error) {
+
+	args, err := Args(event)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, column := range Columns {
+		args[i] = sql.Named(column, args[i])
+	}
+
+	return args, nil
+}
@@ -0,0 +1,101 @@
+package cefsql
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+func TestDialect(t *testing.T) {
+
+	tests := []struct {
+		dialect Dialect
+		create  []string
+		insert  string
+	}{
+		{
+			dialect: Postgres,
+			create:  []string{`CREATE TABLE IF NOT EXISTS "cef_events" (`, "id BIGSERIAL PRIMARY KEY", "version INTEGER NOT NULL", "event_time TIMESTAMPTZ NULL", "extensions JSONB NOT NULL"},
+			insert:  `INSERT INTO "cef_events" (version, device_vendor, device_product, device_version, device_event_class_id, name, severity, event_time, extensions) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		},
+		{
+			dialect: MySQL,
+			create:  []string{"CREATE TABLE IF NOT EXISTS `cef_events` (", "id BIGINT AUTO_INCREMENT PRIMARY KEY", "name TEXT NOT NULL", "event_time DATETIME(6) NULL", "extensions JSON NOT NULL"},
+			insert:  "INSERT INTO `cef_events` (version, device_vendor, device_product, device_version, device_event_class_id, name, severity, event_time, extensions) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dialect.String(), func(t *testing.T) {
+
+			create := test.dialect.CreateTable(DefaultTable)
+			for _, want := range test.create {
+				if !strings.Contains(create, want) {
+					t.Errorf("CreateTable() = %q, want it to contain %q", create, want)
+				}
+			}
+
+			if got := test.dialect.Insert(DefaultTable); got != test.insert {
+				t.Errorf("Insert() = %q, want %q", got, test.insert)
+			}
+		})
+	}
+
+	if got := Postgres.Insert(`my"table`); !strings.HasPrefix(got, `INSERT INTO "my""table" (`) {
+		t.Errorf("Insert() = %q, want the table name quoted", got)
+	}
+}
+
+func TestArgs(t *testing.T) {
+
+	event := cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "Cool Vendor",
+		DeviceProduct:      "Cool Product",
+		DeviceVersion:      "1.0",
+		DeviceEventClassId: "COOL_THING",
+		Name:               "Something cool happened.",
+		Severity:           "Unknown",
+		Extensions:         map[string]string{"src": "127.0.0.1", "rt": "1700000000000"},
+	}
+
+	args, err := Args(event)
+	if err != nil {
+		t.Fatalf("Args() = %v", err)
+	}
+	want := []interface{}{
+		0, "Cool Vendor", "Cool Product", "1.0", "COOL_THING", "Something cool happened.", "Unknown",
+		sql.NullTime{Time: time.UnixMilli(1700000000000).UTC(), Valid: true},
+		`{"rt":"1700000000000","src":"127.0.0.1"}`,
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Args() = %v, want %v", args, want)
+	}
+
+	event.Extensions = nil
+	args, err = Args(event)
+	if err != nil {
+		t.Fatalf("Args() = %v", err)
+	}
+	if args[7] != (sql.NullTime{}) || args[8] != "{}" {
+		t.Errorf("Args() = %v, want no event time and empty extensions", args)
+	}
+
+	named, err := NamedArgs(event)
+	if err != nil {
+		t.Fatalf("NamedArgs() = %v", err)
+	}
+	for i, column := range Columns {
+		if arg, ok := named[i].(sql.NamedArg); !ok || arg.Name != column || arg.Value != args[i] {
+			t.Errorf("NamedArgs()[%d] = %v, want %s", i, named[i], column)
+		}
+	}
+
+	if _, err := Args(cefevent.CefEvent{}); err == nil {
+		t.Error("Args() of an invalid event = nil, want an error")
+	}
+}
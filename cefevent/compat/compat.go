@@ -0,0 +1,113 @@
+// Package compat keeps the renamed and deprecated APIs of the cefevent
+// package available under their old names, so downstream code bases can
+// upgrade gradually instead of breaking at once. Every function delegates
+// to its replacement:
+//
+//	line, err := compat.Generate(&event) // event.String()
+//	parsed, err := compat.Read(&event, line) // cefevent.Parse(line)
+//
+// Deprecation notices are off by default, OnDeprecated registers a callback
+// which is told about every old name in use, e.g. to log it:
+//
+//	compat.OnDeprecated(func(old, replacement string) {
+//		log.Printf("%s is deprecated, use %s", old, replacement)
+//	})
+package compat
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+var (
+	noticeMu sync.Mutex
+	notice   func(old, replacement string)
+	noticed  = map[string]bool{}
+)
+
+// OnDeprecated registers a callback which is called the first time each
+// deprecated name of this package is used, later uses of the same name are
+// not reported again.
+//
+// Parameters:
+// - fn: The callback receiving the old name and its replacement, nil turns the notices off.
+func OnDeprecated(fn func(old, replacement string)) {
+
+	noticeMu.Lock()
+	defer noticeMu.Unlock()
+
+	notice = fn
+	noticed = map[string]bool{}
+}
+
+// deprecated reports the use of an old name to the registered callback.
+func deprecated(old, replacement string) {
+
+	noticeMu.Lock()
+	fn := notice
+	first := fn != nil && !noticed[old]
+	if first {
+		noticed[old] = true
+	}
+	noticeMu.Unlock()
+
+	// the callback runs unlocked so it may use this package itself.
+	if first {
+		fn(old, replacement)
+	}
+}
+
+// Generate renders the event as CEF message.
+//
+// Deprecated: Generate was renamed to CefEvent.String.
+func Generate(event *cefevent.CefEvent) (string, error) {
+
+	deprecated("Generate", "CefEvent.String")
+	return event.String()
+}
+
+// Read parses a CEF message into the event and returns a copy of it.
+//
+// Deprecated: Read overwrites the event, use cefevent.Parse instead.
+func Read(event *cefevent.CefEvent, line string) (cefevent.CefEvent, error) {
+
+	deprecated("Read", "cefevent.Parse")
+	return readInto(event, cefevent.Parse, line)
+}
+
+// ReadWithOptions parses a CEF message into the event like Read and returns a copy of it.
+//
+// Deprecated: ReadWithOptions overwrites the event, use cefevent.ParseWithOptions instead.
+func ReadWithOptions(event *cefevent.CefEvent, line string, opts cefevent.ParseOptions) (cefevent.CefEvent, error) {
+
+	deprecated("ReadWithOptions", "cefevent.ParseWithOptions")
+	return readInto(event, func(line string) (cefevent.CefEvent, error) {
+		return cefevent.ParseWithOptions(line, opts)
+	}, line)
+}
+
+// ReadBytes parses a CEF message held in a byte slice into the event like
+// Read and returns a copy of it.
+//
+// Deprecated: ReadBytes overwrites the event, use cefevent.ParseBytes instead.
+func ReadBytes(event *cefevent.CefEvent, line []byte) (cefevent.CefEvent, error) {
+
+	deprecated("ReadBytes", "cefevent.ParseBytes")
+	return readInto(event, cefevent.ParseBytes, line)
+}
+
+// readInto parses the line and overwrites the event with the result, as the
+// old methods did, unless parsing failed. The parsed portion of a truncated
+// message is kept like by the old methods.
+func readInto[T string | []byte](event *cefevent.CefEvent, parse func(T) (cefevent.CefEvent, error), line T) (cefevent.CefEvent, error) {
+
+	parsed, err := parse(line)
+	var truncated *cefevent.TruncatedError
+	if err == nil || errors.As(err, &truncated) {
+		*event = parsed
+	}
+
+	return parsed, err
+}
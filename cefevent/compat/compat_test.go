@@ -0,0 +1,62 @@
+package compat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+const line = "CEF:0|Vendor|Product|1.0|100|name|5|src=10.0.0.1"
+
+func TestDelegates(t *testing.T) {
+
+	want, err := cefevent.Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var event cefevent.CefEvent
+	if got, err := Read(&event, line); err != nil || !reflect.DeepEqual(got, want) || !reflect.DeepEqual(event, want) {
+		t.Errorf("Read() = %+v, %v, event %+v, want %+v", got, err, event, want)
+	}
+
+	event = cefevent.CefEvent{}
+	if got, err := ReadBytes(&event, []byte(line)); err != nil || !reflect.DeepEqual(got, want) || !reflect.DeepEqual(event, want) {
+		t.Errorf("ReadBytes() = %+v, %v, event %+v, want %+v", got, err, event, want)
+	}
+
+	event = cefevent.CefEvent{}
+	if got, err := ReadWithOptions(&event, line+" src=10.0.0.2", cefevent.ParseOptions{Strict: true}); err == nil || !reflect.DeepEqual(event, cefevent.CefEvent{}) {
+		t.Errorf("ReadWithOptions() = %+v, %v, event %+v, want an error and the event untouched", got, err, event)
+	}
+
+	if got, err := Generate(&want); err != nil || got != line {
+		t.Errorf("Generate() = %q, %v, want %q", got, err, line)
+	}
+}
+
+func TestOnDeprecated(t *testing.T) {
+
+	var notices []string
+	OnDeprecated(func(old, replacement string) {
+		notices = append(notices, old+" -> "+replacement)
+	})
+	defer OnDeprecated(nil)
+
+	var event cefevent.CefEvent
+	Read(&event, line)
+	Read(&event, line)
+	Generate(&event)
+
+	want := []string{"Read -> cefevent.Parse", "Generate -> CefEvent.String"}
+	if !reflect.DeepEqual(notices, want) {
+		t.Errorf("OnDeprecated() notices = %q, want %q", notices, want)
+	}
+
+	OnDeprecated(nil)
+	Read(&event, line)
+	if len(notices) != len(want) {
+		t.Errorf("OnDeprecated(nil) still reports %q", notices)
+	}
+}
@@ -0,0 +1,196 @@
+package cefevent
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CompressedMarker is the extension listing the comma separated keys of
+// the extensions whose values are compressed.
+const CompressedMarker = "cefCompressed"
+
+// maxDecompressedSize limits the total size of the values decompressed
+// from a single event, so that a malicious event can not exhaust the memory.
+const maxDecompressedSize = 16 * 1024 * 1024
+
+// maxCompressedKeys limits the number of keys listed in the marker extension.
+const maxCompressedKeys = 64
+
+// DefaultCompressedExtensions are the bulky extensions compressed by
+// CompressExtensions when no keys are given.
+var DefaultCompressedExtensions = []string{"rawEvent", "requestContext"}
+
+// compressedKeys returns the keys listed in the marker extension.
+func (event *CefEvent) compressedKeys() []string {
+
+	marker := event.Extensions[CompressedMarker]
+	if marker == "" {
+		return nil
+	}
+
+	return strings.Split(marker, ",")
+}
+
+// CompressExtension replaces the value of the extension key with its
+// DEFLATE compressed and base64 encoded form and records the key in the
+// CompressedMarker extension. The value is left untouched when compressing
+// does not make it smaller.
+//
+// The Extensions map is modified in place, copy it first when it is shared.
+//
+// Returns:
+// - Whether the value was compressed.
+// - An error if the value could not be compressed.
+func (event *CefEvent) CompressExtension(key string) (bool, error) {
+
+	value, ok := event.Extensions[key]
+	if !ok || key == CompressedMarker {
+		return false, nil
+	}
+
+	for _, k := range event.compressedKeys() {
+		if k == key {
+			return false, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.WriteString(w, value); err != nil {
+		return false, err
+	}
+	if err := w.Close(); err != nil {
+		return false, err
+	}
+
+	// the unpadded encoding avoids "=" which would have to be escaped.
+	compressed := base64.RawStdEncoding.EncodeToString(buf.Bytes())
+	if len(compressed) >= len(value) {
+		return false, nil
+	}
+
+	keys := append(event.compressedKeys(), key)
+	sort.Strings(keys)
+
+	event.Extensions[key] = compressed
+	event.Extensions[CompressedMarker] = strings.Join(keys, ",")
+
+	return true, nil
+}
+
+// DecompressExtensions restores the values of all extensions listed in the
+// CompressedMarker extension and removes the marker. Events without the
+// marker are left untouched.
+//
+// Returns:
+// - An error if a value is not a valid compressed value, or an error wrapping
+// ErrLimitExceeded if the marker lists too many keys or the values exceed the size limit.
+func (event *CefEvent) DecompressExtensions() error {
+
+	_, err := event.decompressExtensions(0)
+
+	return err
+}
+
+// decompressExtensions restores the compressed values like
+// DecompressExtensions and stops inflating a value as soon as it exceeds
+// maxValueBytes, unless zero, or all values of the event exceed
+// maxDecompressedSize together. The key of the offending value is returned
+// with the error, CompressedMarker if the marker itself is at fault.
+func (event *CefEvent) decompressExtensions(maxValueBytes int) (string, error) {
+
+	keys := event.compressedKeys()
+	if len(keys) == 0 {
+		return "", nil
+	}
+	if len(keys) > maxCompressedKeys {
+		return CompressedMarker, fmt.Errorf("%s lists more than %d keys: %w", CompressedMarker, maxCompressedKeys, ErrLimitExceeded)
+	}
+
+	budget := maxDecompressedSize
+	decompressed := make(map[string]string, len(keys))
+	for _, k := range keys {
+
+		value, ok := event.Extensions[k]
+		if _, done := decompressed[k]; !ok || done {
+			continue
+		}
+
+		data, err := base64.RawStdEncoding.DecodeString(value)
+		if err != nil {
+			return k, fmt.Errorf("compressed extension %s is not valid base64: %w", k, err)
+		}
+
+		limit := budget
+		if maxValueBytes > 0 && maxValueBytes < limit {
+			limit = maxValueBytes
+		}
+
+		r := flate.NewReader(bytes.NewReader(data))
+		plain, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+		r.Close()
+		if err != nil {
+			return k, fmt.Errorf("compressed extension %s could not be decompressed: %w", k, err)
+		}
+		if len(plain) > limit {
+			return k, fmt.Errorf("compressed extension %s exceeds %d bytes: %w", k, limit, ErrLimitExceeded)
+		}
+
+		budget -= len(plain)
+		decompressed[k] = string(plain)
+	}
+
+	for k, v := range decompressed {
+		event.Extensions[k] = v
+	}
+	delete(event.Extensions, CompressedMarker)
+
+	return "", nil
+}
+
+// CompressExtensions returns a Middleware compressing the values of the
+// given extensions when they are longer than threshold bytes, which keeps
+// events carrying huge payloads below the size limits of collectors.
+// Events are decompressed transparently when they are parsed by Parse.
+//
+// Parameters:
+// - threshold: The value length in bytes from which on a value is compressed.
+// - keys: The extensions to compress, DefaultCompressedExtensions when none are given.
+//
+// Returns:
+// - A Middleware compressing the events passing through it.
+func CompressExtensions(threshold int, keys ...string) Middleware {
+
+	if len(keys) == 0 {
+		keys = DefaultCompressedExtensions
+	}
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+
+			var extensions map[string]string
+			for _, k := range keys {
+				if len(event.Extensions[k]) <= threshold {
+					continue
+				}
+				if extensions == nil {
+					extensions = cloneExtensions(event.Extensions)
+					event.Extensions = extensions
+				}
+				if _, err := event.CompressExtension(k); err != nil {
+					return err
+				}
+			}
+
+			return next(event)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressExtension(t *testing.T) {
+
+	payload := strings.Repeat("GET /index.html HTTP/1.1 ", 100)
+
+	compressed := event
+	compressed.Extensions = map[string]string{"src": "127.0.0.1", "rawEvent": payload, "msg": "short"}
+
+	if ok, err := compressed.CompressExtension("rawEvent"); !ok || err != nil {
+		t.Fatalf("CompressExtension() = %v, %v, want true", ok, err)
+	}
+
+	if ok, _ := compressed.CompressExtension("msg"); ok {
+		t.Errorf("CompressExtension() compressed a value which does not get smaller")
+	}
+
+	if len(compressed.Extensions["rawEvent"]) >= len(payload) || compressed.Extensions[CompressedMarker] != "rawEvent" {
+		t.Errorf("CompressExtension() = %v, want a shorter rawEvent and a marker", compressed.Extensions)
+	}
+
+	line, _ := compressed.String()
+	parsed, err := new(CefEvent).Read(line)
+	if err != nil {
+		t.Fatalf("Read() = %v", err)
+	}
+
+	if parsed.Extensions["rawEvent"] != payload {
+		t.Errorf("Read() did not restore rawEvent, got %d bytes", len(parsed.Extensions["rawEvent"]))
+	}
+
+	if _, ok := parsed.Extensions[CompressedMarker]; ok {
+		t.Errorf("Read() kept the %s extension", CompressedMarker)
+	}
+}
+
+func TestDecompressExtensionsInvalid(t *testing.T) {
+
+	broken := event
+	broken.Extensions = map[string]string{"rawEvent": "not*base64", CompressedMarker: "rawEvent"}
+
+	if err := broken.DecompressExtensions(); err == nil {
+		t.Errorf("DecompressExtensions() should fail for an invalid value")
+	}
+}
+
+func TestDecompressExtensionsLimits(t *testing.T) {
+
+	// compressed, the values are a fraction of the size they inflate to.
+	bomb := func(size int) string {
+		e := event
+		e.Extensions = map[string]string{"rawEvent": strings.Repeat("a", size)}
+		if _, err := e.CompressExtension("rawEvent"); err != nil {
+			t.Fatalf("CompressExtension() = %v", err)
+		}
+		line, _ := e.String()
+		return line
+	}
+
+	small := bomb(64 * 1024)
+	large := bomb(maxDecompressedSize + 1)
+
+	tests := []struct {
+		name  string
+		line  string
+		opts  ParseOptions
+		field string
+	}{
+		{"value exceeding MaxValueBytes", small, ParseOptions{MaxValueBytes: 1024}, "rawEvent"},
+		{"value exceeding the total limit", large, ParseOptions{}, "rawEvent"},
+		{"too many compressed keys", eventLine + " " + CompressedMarker + "=" + strings.Repeat("rawEvent,", maxCompressedKeys+1), ParseOptions{}, CompressedMarker},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.line) > 64*1024 {
+				t.Fatalf("line of %d bytes is not small", len(tt.line))
+			}
+			_, err := ParseWithOptions(tt.line, tt.opts)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, ErrLimitExceeded) || parseErr.Field != tt.field {
+				t.Errorf("ParseWithOptions() = %v, want a ParseError wrapping ErrLimitExceeded in %s", err, tt.field)
+			}
+		})
+	}
+
+	if parsed, err := ParseWithOptions(small, ParseOptions{MaxValueBytes: 64 * 1024}); err != nil || len(parsed.Extensions["rawEvent"]) != 64*1024 {
+		t.Errorf("ParseWithOptions() = %v, want the value within MaxValueBytes", err)
+	}
+}
+
+func TestCompressExtensionsMiddleware(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+	emitter.Use(CompressExtensions(64))
+
+	bulky := event
+	bulky.Extensions = map[string]string{"requestContext": strings.Repeat("a", 1000), "rawEvent": "small"}
+
+	if err := emitter.Emit(bulky); err != nil {
+		t.Fatalf("Emit() = %v", err)
+	}
+
+	if len(bulky.Extensions["requestContext"]) != 1000 {
+		t.Errorf("CompressExtensions() modified the extensions of the caller")
+	}
+
+	if !strings.Contains(buf.String(), CompressedMarker+"=requestContext") || strings.Contains(buf.String(), "aaaa") {
+		t.Errorf("CompressExtensions() wrote %q, want a compressed requestContext", buf.String())
+	}
+}
@@ -0,0 +1,301 @@
+package cefevent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The device event class IDs of the events written by a ConfigAudit.
+const (
+	ConfigLoaded   = "config-loaded"   // ConfigLoaded is a configuration loaded for the first time.
+	ConfigReloaded = "config-reloaded" // ConfigReloaded is a configuration loaded again, possibly changed.
+	ConfigRejected = "config-rejected" // ConfigRejected is a configuration which failed to load or validate.
+)
+
+// modulePath is the path of this module, its version is the device
+// version of the events of a ConfigAudit.
+const modulePath = "github.com/pcktdmp/cef"
+
+// moduleVersion returns the version of this module the binary was built with.
+func moduleVersion() string {
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+
+	return "devel"
+}
+
+// ConfigAudit makes the configuration of a forwarder auditable. Every load,
+// reload and rejection of a pipeline configuration is written as CEF event
+// in its JSON form, one per line, telling who loaded which file when, the
+// SHA-256 hash of its content and the settings a reload changed.
+//
+// The events are from the vendor "pcktdmp" and the product "cef", their
+// class ID is ConfigLoaded, ConfigReloaded or ConfigRejected and they carry
+// the extensions rt, suser, dvchost, filePath, fileHash, act, outcome and
+// msg, the changed settings of a reload in cs1 labelled "changes". A
+// ConfigAudit is safe for concurrent use.
+type ConfigAudit struct {
+	mu      sync.Mutex
+	w       io.Writer
+	user    string
+	host    string
+	version string
+	now     func() time.Time
+	loaded  map[string]map[string]string
+}
+
+// NewConfigAudit opens the file at path for appending, creating it when
+// needed, and returns a ConfigAudit writing to it.
+//
+// Parameters:
+// - path: The path of the audit file.
+// - opts: The FileOptions of the file, its permission defaults to 0600, Atomic is not supported.
+//
+// Returns:
+// - A pointer to a ConfigAudit which closes the file when it is closed.
+// - An error if the file could not be opened or the options could not be applied.
+func NewConfigAudit(path string, opts FileOptions) (*ConfigAudit, error) {
+
+	if opts.Atomic {
+		return nil, errors.New("audit files can not be written atomically")
+	}
+
+	file, err := openAppend(path, opts, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConfigAuditWriter(file), nil
+}
+
+// NewConfigAuditWriter returns a ConfigAudit writing its events to w.
+func NewConfigAuditWriter(w io.Writer) *ConfigAudit {
+
+	host, _ := os.Hostname()
+
+	return &ConfigAudit{
+		w:       w,
+		user:    DetectProcessMetadata().User,
+		host:    host,
+		version: moduleVersion(),
+		now:     time.Now,
+		loaded:  make(map[string]map[string]string),
+	}
+}
+
+// Load reads and validates the pipeline configuration at path, see
+// LoadPipelineConfig, and audits the outcome. Loading a path which was
+// loaded before is audited as reload together with the changed settings.
+//
+// Parameters:
+// - path: The path of the configuration file.
+//
+// Returns:
+// - The PipelineConfig.
+// - An error if the file could not be read or is invalid, joined with the error of writing the event if that failed as well.
+func (a *ConfigAudit) Load(path string) (PipelineConfig, error) {
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PipelineConfig{}, errors.Join(err, a.Reject(path, nil, err))
+	}
+
+	config, err := LoadPipelineConfig(bytes.NewReader(data))
+	if err != nil {
+		return PipelineConfig{}, errors.Join(err, a.Reject(path, data, err))
+	}
+
+	settings, err := flattenConfig(config)
+	if err != nil {
+		return PipelineConfig{}, err
+	}
+
+	a.mu.Lock()
+	previous, reload := a.loaded[path]
+	a.loaded[path] = settings
+	a.mu.Unlock()
+
+	event := a.event(path, data)
+	event.Extensions["outcome"] = "success"
+	if !reload {
+		event.DeviceEventClassId, event.Name, event.Severity = ConfigLoaded, "Pipeline configuration loaded", "3"
+		event.Extensions["act"] = "load"
+		event.Extensions["msg"] = fmt.Sprintf("%d sources, %d sinks", len(config.Sources), len(config.Sinks))
+	} else {
+		changes := diffSettings(previous, settings)
+		event.DeviceEventClassId, event.Name, event.Severity = ConfigReloaded, "Pipeline configuration reloaded", "5"
+		event.Extensions["act"] = "reload"
+		event.Extensions["msg"] = fmt.Sprintf("%d settings changed", len(changes))
+		event.Extensions["cs1Label"] = "changes"
+		event.Extensions["cs1"] = strings.Join(changes, ",")
+	}
+
+	return config, a.write(event)
+}
+
+// Reject audits a configuration which failed to load or validate, e.g.
+// when PipelineConfig.Build failed for a configuration returned by Load.
+//
+// Parameters:
+// - path: The path of the configuration file.
+// - data: The content of the file, nil when it could not be read.
+// - reason: Why the configuration was rejected.
+//
+// Returns:
+// - An error if the event could not be written.
+func (a *ConfigAudit) Reject(path string, data []byte, reason error) error {
+
+	event := a.event(path, data)
+	event.DeviceEventClassId, event.Name, event.Severity = ConfigRejected, "Pipeline configuration rejected", "7"
+	event.Extensions["act"] = "reject"
+	event.Extensions["outcome"] = "failure"
+	event.Extensions["msg"] = reason.Error()
+
+	return a.write(event)
+}
+
+// event returns the fields common to all events about the file at path.
+func (a *ConfigAudit) event(path string, data []byte) CefEvent {
+
+	extensions := map[string]string{
+		"rt":       strconv.FormatInt(a.now().UnixMilli(), 10),
+		"filePath": path,
+	}
+	if a.user != "" {
+		extensions["suser"] = a.user
+	}
+	if a.host != "" {
+		extensions["dvchost"] = a.host
+	}
+	if data != nil {
+		sum := sha256.Sum256(data)
+		extensions["fileHash"] = hex.EncodeToString(sum[:])
+	}
+
+	return CefEvent{
+		Version:       Version0,
+		DeviceVendor:  "pcktdmp",
+		DeviceProduct: "cef",
+		DeviceVersion: a.version,
+		Extensions:    extensions,
+	}
+}
+
+// write appends the event to the audit as JSON line.
+func (a *ConfigAudit) write(event CefEvent) error {
+
+	line, err := event.ToJSON()
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.w == nil {
+		return errors.New("config audit is closed")
+	}
+	_, err = io.WriteString(a.w, line+"\n")
+
+	return err
+}
+
+// Close closes the underlying writer if it implements io.Closer, later
+// events are not written.
+func (a *ConfigAudit) Close() error {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	closer, ok := a.w.(io.Closer)
+	a.w = nil
+	if ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// flattenConfig returns the settings of the configuration by their dotted
+// JSON path, e.g. "sinks.0.address", with their JSON encoded values.
+func flattenConfig(config PipelineConfig) (map[string]string, error) {
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]string)
+	var flatten func(prefix string, node interface{})
+	flatten = func(prefix string, node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for k, v := range node {
+				flatten(strings.TrimPrefix(prefix+"."+k, "."), v)
+			}
+		case []interface{}:
+			for i, v := range node {
+				flatten(strings.TrimPrefix(prefix+"."+strconv.Itoa(i), "."), v)
+			}
+		default:
+			value, _ := json.Marshal(node)
+			settings[prefix] = string(value)
+		}
+	}
+	flatten("", tree)
+
+	return settings, nil
+}
+
+// diffSettings returns the sorted paths of the settings which were added,
+// removed or changed.
+func diffSettings(previous, current map[string]string) []string {
+
+	var changes []string
+	for path, value := range current {
+		if old, ok := previous[path]; !ok || old != value {
+			changes = append(changes, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, path)
+		}
+	}
+	sort.Strings(changes)
+
+	return changes
+}
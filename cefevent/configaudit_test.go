@@ -0,0 +1,82 @@
+package cefevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigAudit(t *testing.T) {
+
+	var buf bytes.Buffer
+	audit := NewConfigAuditWriter(&buf)
+	audit.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	write := func(config string) {
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"sources": [{"type": "stdin"}], "sinks": [{"type": "stdout"}]}`)
+	if _, err := audit.Load(path); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	write(`{"sources": [{"type": "stdin"}], "sinks": [{"type": "udp", "address": "siem:514"}], "dry_run": true}`)
+	if _, err := audit.Load(path); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	write(`{"sources": []}`)
+	if _, err := audit.Load(path); err == nil {
+		t.Error("Load() = nil, want the error of an invalid configuration")
+	}
+
+	var events []CefEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event CefEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("audit line %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Load() wrote %d events, want 3", len(events))
+	}
+
+	tests := []struct {
+		classID string
+		act     string
+		outcome string
+	}{
+		{ConfigLoaded, "load", "success"},
+		{ConfigReloaded, "reload", "success"},
+		{ConfigRejected, "reject", "failure"},
+	}
+
+	for i, tt := range tests {
+		e := events[i]
+		if e.DeviceEventClassId != tt.classID || e.Extensions["act"] != tt.act || e.Extensions["outcome"] != tt.outcome {
+			t.Errorf("event %d = %+v, want %s", i, e, tt.classID)
+		}
+		if e.Extensions["filePath"] != path || e.Extensions["rt"] != "1700000000000" || len(e.Extensions["fileHash"]) != 64 {
+			t.Errorf("event %d = %v, want the file, time and hash", i, e.Extensions)
+		}
+	}
+
+	if got, want := events[1].Extensions["cs1"], "dry_run,sinks.0.address,sinks.0.type"; got != want {
+		t.Errorf("reload changes = %q, want %q", got, want)
+	}
+	if events[0].Extensions["fileHash"] == events[1].Extensions["fileHash"] {
+		t.Error("Load() audited the same hash for different files")
+	}
+
+	audit.Close()
+	if err := audit.Reject(path, nil, os.ErrNotExist); err == nil {
+		t.Error("Reject() = nil after Close, want an error")
+	}
+}
@@ -0,0 +1,208 @@
+// Package conformance provides a curated corpus of tricky real-world CEF
+// messages together with the events they must be parsed into, so parsers
+// and wrappers around the cefevent package can run the same conformance
+// suite:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, func(line string) (cefevent.CefEvent, error) {
+//			return myParser.Parse(line)
+//		})
+//	}
+package conformance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// Case is a single message of the corpus.
+type Case struct {
+	Name   string             // Name describes what the case covers.
+	Line   string             // Line is the message as received.
+	Syslog bool               // Syslog is set for messages wrapped in a syslog header.
+	Want   *cefevent.CefEvent // Want is the parsed event, nil if the message must be rejected.
+}
+
+// ParseFunc parses a single message, it is the parser under test.
+type ParseFunc func(line string) (cefevent.CefEvent, error)
+
+// event returns an event of the corpus vendor with the given extensions.
+func event(name string, extensions map[string]string) *cefevent.CefEvent {
+	return &cefevent.CefEvent{
+		Version:            0,
+		DeviceVendor:       "Security",
+		DeviceProduct:      "threatmanager",
+		DeviceVersion:      "1.0",
+		DeviceEventClassId: "100",
+		Name:               name,
+		Severity:           "10",
+		Extensions:         extensions,
+	}
+}
+
+// Cases returns the corpus, a new slice on every call which the caller may modify.
+func Cases() []Case {
+
+	versioned := event("worm successfully stopped", map[string]string{"src": "10.0.0.1"})
+	versioned.Version = 1
+
+	escapedHeader := event(`detected a | in message`, map[string]string{"src": "10.0.0.1"})
+	escapedHeader.DeviceProduct = `threat\manager`
+
+	unicode := event("Überwachung ausgelöst – 日本語", map[string]string{"suser": "jürgen", "msg": "Zugriff verweigert ✓"})
+
+	wordSeverity := event("worm successfully stopped", map[string]string{"src": "10.0.0.1"})
+	wordSeverity.Severity = "Very-High"
+
+	return []Case{
+		{
+			Name: "plain",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232",
+			Want: event("worm successfully stopped", map[string]string{"src": "10.0.0.1", "dst": "2.1.2.2", "spt": "1232"}),
+		},
+		{
+			Name: "version 1",
+			Line: "CEF:1|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+			Want: versioned,
+		},
+		{
+			Name: "no extensions",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|",
+			Want: event("worm successfully stopped", map[string]string{}),
+		},
+		{
+			Name: "escaped pipe and backslash in header",
+			Line: `CEF:0|Security|threat\\manager|1.0|100|detected a \| in message|10|src=10.0.0.1`,
+			Want: escapedHeader,
+		},
+		{
+			Name: "pipe in extension value",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=a | b src=10.0.0.1",
+			Want: event("worm successfully stopped", map[string]string{"msg": "a | b", "src": "10.0.0.1"}),
+		},
+		{
+			Name: "spaces in values",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 msg=User logged in from 10.0.0.2 suser=alice",
+			Want: event("worm successfully stopped", map[string]string{"src": "10.0.0.1", "msg": "User logged in from 10.0.0.2", "suser": "alice"}),
+		},
+		{
+			Name: "escaped equals and backslash in value",
+			Line: `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=a\=b filePath=C:\\Windows\\cmd.exe`,
+			Want: event("worm successfully stopped", map[string]string{"msg": "a=b", "filePath": `C:\Windows\cmd.exe`}),
+		},
+		{
+			Name: "escaped newlines in value",
+			Line: `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=line one\nline two\r`,
+			Want: event("worm successfully stopped", map[string]string{"msg": "line one\nline two\r"}),
+		},
+		{
+			Name: "unescaped equals in URL value",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|request=https://example.com/?a=1&b=2 src=10.0.0.1",
+			Want: event("worm successfully stopped", map[string]string{"request": "https://example.com/?a=1&b=2", "src": "10.0.0.1"}),
+		},
+		{
+			Name: "timestamp with spaces",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|rt=Jan 12 2024 10:00:00.000 UTC src=10.0.0.1",
+			Want: event("worm successfully stopped", map[string]string{"rt": "Jan 12 2024 10:00:00.000 UTC", "src": "10.0.0.1"}),
+		},
+		{
+			Name: "trailing spaces",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1   ",
+			Want: event("worm successfully stopped", map[string]string{"src": "10.0.0.1"}),
+		},
+		{
+			Name: "unicode",
+			Line: "CEF:0|Security|threatmanager|1.0|100|Überwachung ausgelöst – 日本語|10|suser=jürgen msg=Zugriff verweigert ✓",
+			Want: unicode,
+		},
+		{
+			Name: "severity as word",
+			Line: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|Very-High|src=10.0.0.1",
+			Want: wordSeverity,
+		},
+		{
+			Name:   "RFC 3164 syslog header",
+			Line:   "<134>Jan 12 10:00:00 fw01 CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+			Syslog: true,
+			Want:   event("worm successfully stopped", map[string]string{"src": "10.0.0.1"}),
+		},
+		{
+			Name:   "RFC 3164 syslog header with tag",
+			Line:   "<134>Jan  2 10:00:00 fw01 cefd[4711]: CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+			Syslog: true,
+			Want:   event("worm successfully stopped", map[string]string{"src": "10.0.0.1"}),
+		},
+		{
+			Name:   "RFC 5424 syslog header",
+			Line:   `<134>1 2024-01-12T10:00:00.000Z fw01 cefd 4711 - [origin ip="10.0.0.1"] CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1`,
+			Syslog: true,
+			Want:   event("worm successfully stopped", map[string]string{"src": "10.0.0.1"}),
+		},
+		{
+			Name: "missing prefix",
+			Line: "LEEF:2.0|Security|threatmanager|1.0|100|src=10.0.0.1",
+		},
+		{
+			Name: "incomplete header",
+			Line: "CEF:0|Security|threatmanager|1.0|100",
+		},
+		{
+			Name: "version is no number",
+			Line: "CEF:x|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+		},
+		{
+			Name: "unknown version",
+			Line: "CEF:42|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+		},
+		{
+			Name: "empty mandatory field",
+			Line: "CEF:0|Security||1.0|100|worm successfully stopped|10|src=10.0.0.1",
+		},
+	}
+}
+
+// Run runs every plain CEF case of the corpus as subtest against parse.
+// Messages with a Want event must be parsed into exactly that event, the
+// others must be rejected with an error.
+//
+// Parameters:
+// - t: The test the cases are run in.
+// - parse: The parser under test.
+func Run(t *testing.T, parse ParseFunc) {
+	run(t, parse, false)
+}
+
+// RunSyslog runs every syslog wrapped case of the corpus like Run.
+func RunSyslog(t *testing.T, parse ParseFunc) {
+	run(t, parse, true)
+}
+
+func run(t *testing.T, parse ParseFunc, syslog bool) {
+
+	t.Helper()
+
+	for _, c := range Cases() {
+		if c.Syslog != syslog {
+			continue
+		}
+
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+
+			got, err := parse(c.Line)
+
+			if c.Want == nil {
+				if err == nil {
+					t.Errorf("parse(%q) = %+v, want an error", c.Line, got)
+				}
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, *c.Want) {
+				t.Errorf("parse(%q) = %+v, %v, want %+v", c.Line, got, err, *c.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+func TestParse(t *testing.T) {
+	Run(t, cefevent.Parse)
+}
+
+func TestParseSyslog(t *testing.T) {
+	RunSyslog(t, func(line string) (cefevent.CefEvent, error) {
+		m, err := cefevent.ParseSyslog(line)
+		return m.Event, err
+	})
+}
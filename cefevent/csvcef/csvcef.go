@@ -0,0 +1,147 @@
+// Package csvcef writes CEF events as CSV, so analysts can open samples of
+// events directly in spreadsheets. Every row holds the header fields
+// followed by a column per configured extension, extensions an event lacks
+// are left blank and others are not written:
+//
+//	w := csvcef.NewWriter(os.Stdout, "src", "dst", "suser")
+//	for _, event := range events {
+//		if err := w.Write(event); err != nil {
+//			return err
+//		}
+//	}
+//	w.Flush()
+//	return w.Error()
+//
+// The first row names the columns: Version, DeviceVendor, DeviceProduct,
+// DeviceVersion, DeviceEventClassId, Name, Severity and the extension keys.
+//
+// Values spreadsheets would evaluate as formulas, e.g. "=HYPERLINK(...)"
+// sent by an attacker, are prefixed with "'" unless Writer.RawValues is set.
+package csvcef
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// headerColumns name the columns of the header fields.
+var headerColumns = []string{"Version", "DeviceVendor", "DeviceProduct", "DeviceVersion", "DeviceEventClassId", "Name", "Severity"}
+
+// Writer writes events as rows of a CSV file, see the package documentation.
+type Writer struct {
+	// RawValues writes the values as they are. By default values starting
+	// with "=", "+", "-", "@", a tab or a carriage return are prefixed with
+	// "'", so spreadsheets do not evaluate values such as "=HYPERLINK(...)"
+	// sent by an attacker as formulas. Plain numbers, e.g. negative ones,
+	// are never altered.
+	RawValues bool
+
+	csv     *csv.Writer
+	columns []string
+	header  bool
+}
+
+// NewWriter returns a Writer writing to w.
+//
+// Parameters:
+// - w: The destination of the CSV file.
+// - columns: The keys of the extensions written as columns, in order.
+//
+// Returns:
+// - A pointer to a Writer, its header row is written with the first event.
+func NewWriter(w io.Writer, columns ...string) *Writer {
+
+	return &Writer{
+		csv:     csv.NewWriter(w),
+		columns: append([]string(nil), columns...),
+	}
+}
+
+// Columns returns the names of the columns of the rows.
+func (w *Writer) Columns() []string {
+	return append(append([]string(nil), headerColumns...), w.columns...)
+}
+
+// Write writes the event as a row, preceded by the header row if it is the
+// first one. Rows are buffered, Flush writes them to the destination.
+//
+// Returns:
+// - An error if the event is not valid or the row could not be written.
+func (w *Writer) Write(event cefevent.CefEvent) error {
+
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	if !w.header {
+		if err := w.csv.Write(w.Columns()); err != nil {
+			return err
+		}
+		w.header = true
+	}
+
+	row := []string{
+		strconv.Itoa(event.Version),
+		event.DeviceVendor,
+		event.DeviceProduct,
+		event.DeviceVersion,
+		event.DeviceEventClassId,
+		event.Name,
+		event.Severity,
+	}
+	for _, key := range w.columns {
+		row = append(row, event.Extensions[key])
+	}
+
+	if !w.RawValues {
+		for i, value := range row {
+			row[i] = escapeFormula(value)
+		}
+	}
+
+	return w.csv.Write(row)
+}
+
+// WriteAll writes the events and flushes them.
+//
+// Returns:
+// - The first error writing an event or flushing the rows.
+func (w *Writer) WriteAll(events []cefevent.CefEvent) error {
+
+	for _, event := range events {
+		if err := w.Write(event); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+// Flush writes the buffered rows to the destination, Error reports its failure.
+func (w *Writer) Flush() {
+	w.csv.Flush()
+}
+
+// Error returns the error of a previous Write or Flush.
+func (w *Writer) Error() error {
+	return w.csv.Error()
+}
+
+// escapeFormula prefixes the value with "'" if spreadsheets would evaluate
+// it as formula, numbers such as "-5" or "+1.5" are left as they are.
+func escapeFormula(value string) string {
+
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+
+	return "'" + value
+}
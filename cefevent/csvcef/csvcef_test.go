@@ -0,0 +1,66 @@
+package csvcef
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+func TestWriter(t *testing.T) {
+
+	events := []cefevent.CefEvent{
+		{Version: 0, DeviceVendor: "Vendor", DeviceProduct: "Product", DeviceVersion: "1.0", DeviceEventClassId: "100", Name: "name, quoted \"here\"", Severity: "5",
+			Extensions: map[string]string{"src": "10.0.0.1", "suser": "alice", "msg": "not a column"}},
+		{Version: 1, DeviceVendor: "Vendor", DeviceProduct: "Product", DeviceVersion: "1.0", DeviceEventClassId: "200", Name: "name", Severity: "Low",
+			Extensions: map[string]string{"src": "-12.5", "suser": "=HYPERLINK(\"http://evil\")"}},
+	}
+
+	var b strings.Builder
+	if err := NewWriter(&b, "src", "suser").WriteAll(events); err != nil {
+		t.Fatalf("WriteAll() = %v", err)
+	}
+	want := "Version,DeviceVendor,DeviceProduct,DeviceVersion,DeviceEventClassId,Name,Severity,src,suser\n" +
+		"0,Vendor,Product,1.0,100,\"name, quoted \"\"here\"\"\",5,10.0.0.1,alice\n" +
+		"1,Vendor,Product,1.0,200,name,Low,-12.5,\"'=HYPERLINK(\"\"http://evil\"\")\"\n"
+	if b.String() != want {
+		t.Errorf("WriteAll() wrote %q, want %q", b.String(), want)
+	}
+
+	b.Reset()
+	w := NewWriter(&b, "suser")
+	w.RawValues = true
+	if err := w.WriteAll(events[1:]); err != nil {
+		t.Fatalf("WriteAll() = %v", err)
+	}
+	if !strings.HasSuffix(b.String(), ",\"=HYPERLINK(\"\"http://evil\"\")\"\n") {
+		t.Errorf("WriteAll() wrote %q, want the formula as it is", b.String())
+	}
+
+	if err := NewWriter(&b).Write(cefevent.CefEvent{}); err == nil {
+		t.Error("Write() of an invalid event = nil, want an error")
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+
+	var tests = []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"alice", "alice"},
+		{"-5", "-5"},
+		{"+1.5", "+1.5"},
+		{"-1+2", "'-1+2"},
+		{"=1+2", "'=1+2"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeFormula(tt.value); got != tt.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
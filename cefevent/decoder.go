@@ -0,0 +1,278 @@
+package cefevent
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxDecoderLineSize is the maximum size of a single CEF line the Decoder accepts.
+const maxDecoderLineSize = 1024 * 1024
+
+// Decoder reads CEF events line by line from an io.Reader without
+// loading the complete input into memory.
+type Decoder struct {
+	scanner    *bufio.Scanner
+	input      *detectingReader
+	line       string
+	onError    func(line string, err error)
+	reassemble bool
+	pending    string
+	keepRaw    bool
+	raw        string
+	pendingErr error
+	discarding bool
+	overlong   string
+}
+
+// NewDecoder returns a Decoder reading CEF events from r. The records may
+// be separated by LF or CRLF or be octet-counted as described by ScanCEF.
+// Records longer than 1 MiB are discarded up to the next newline and
+// reported as ParseError wrapping ErrLimitExceeded.
+//
+// Parameters:
+// - r: The io.Reader providing newline separated CEF messages.
+//
+// Returns:
+// - A pointer to a Decoder.
+func NewDecoder(r io.Reader) *Decoder {
+
+	d := &Decoder{input: &detectingReader{r: r}}
+	d.scanner = bufio.NewScanner(d.input)
+	d.scanner.Buffer(make([]byte, 0, 64*1024), maxDecoderLineSize)
+	d.scanner.Split(d.split)
+
+	return d
+}
+
+// detectingReader decompresses its input if compression detection is
+// enabled when it is read for the first time.
+type detectingReader struct {
+	r        io.Reader
+	detect   bool
+	detected bool
+}
+
+func (r *detectingReader) Read(p []byte) (int, error) {
+
+	if !r.detected {
+		r.detected = true
+		if r.detect {
+			decompressed, err := DecompressReader(r.r)
+			if err != nil {
+				return 0, err
+			}
+			r.r = decompressed
+		}
+	}
+
+	return r.r.Read(p)
+}
+
+// split splits the input with ScanCEF. Once the buffer is full without a
+// complete record, the record is discarded up to the next newline and an
+// empty token is returned for it, as the scanner would fail for good with
+// bufio.ErrTooLong otherwise.
+func (d *Decoder) split(data []byte, atEOF bool) (int, []byte, error) {
+
+	if d.discarding {
+		end := bytes.IndexByte(data, '\n')
+		switch {
+		case end >= 0:
+			d.discarding = false
+			return end + 1, []byte{}, nil
+		case atEOF:
+			d.discarding = false
+			return len(data), []byte{}, nil
+		}
+		return len(data), nil, nil
+	}
+
+	advance, token, err := ScanCEF(data, atEOF)
+	if advance == 0 && token == nil && err == nil && len(data) >= maxDecoderLineSize {
+		d.discarding = true
+		d.overlong = string(data)
+		return len(data), nil, nil
+	}
+
+	return advance, token, err
+}
+
+// OnError registers a callback which is called for every line that can not
+// be parsed. Once registered, Decode no longer returns parse errors but
+// reports the line and its error to the callback and continues with the
+// next line, which allows malformed input to be counted, sampled or
+// quarantined without interrupting the decoding.
+//
+// Parameters:
+// - fn: The callback receiving the malformed line and its parse error, nil restores the default behaviour.
+func (d *Decoder) OnError(fn func(line string, err error)) {
+	d.onError = fn
+}
+
+// SetReassembly enables the reassembly of events spread over multiple
+// lines. Some appliances do not escape the newlines within extension
+// values such as msg, which splits their events. With reassembly enabled,
+// every line which does not start with "CEF:" or a syslog header is taken
+// as continuation of the previous line and appended to it as escaped
+// newline, so the value contains the newline once the event is parsed.
+// Reassembled events are limited to the maximum line size of the Decoder.
+//
+// Parameters:
+// - enabled: Whether continuation lines are joined into the previous event.
+func (d *Decoder) SetReassembly(enabled bool) {
+	d.reassemble = enabled
+}
+
+// SetDecompression enables the detection of compressed input, e.g.
+// rotated logs, which is decompressed on the fly, see DecompressReader. It
+// must be called before the first call to Decode.
+//
+// Parameters:
+// - enabled: Whether the input is checked for compression.
+func (d *Decoder) SetDecompression(enabled bool) {
+	d.input.detect = enabled
+}
+
+// SetKeepRaw keeps the untouched record every event was decoded from in
+// its Raw field, reassembled records with their original line breaks.
+//
+// Parameters:
+// - enabled: Whether the Raw field of the decoded events is populated.
+func (d *Decoder) SetKeepRaw(enabled bool) {
+	d.keepRaw = enabled
+}
+
+// Decode reads the next non-empty line and parses it as CEF event.
+//
+// A line which can not be parsed or is too long results in a *ParseError for
+// that line only, the next call to Decode continues with the following line. When a callback
+// has been registered with OnError, malformed lines are passed to it instead
+// and Decode continues until it finds a valid event.
+//
+// Returns:
+// - The parsed CefEvent.
+// - io.EOF when the input is exhausted, the parse error of the line or the read error of the underlying reader.
+func (d *Decoder) Decode() (CefEvent, error) {
+
+	for {
+		line, err := d.next()
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return CefEvent{}, err
+		}
+
+		var parsed CefEvent
+		if err == nil {
+			parsed, err = Parse(line)
+		}
+		if err != nil && d.onError != nil {
+			d.onError(line, err)
+			continue
+		}
+		if err == nil && d.keepRaw {
+			parsed.Raw = d.raw
+		}
+
+		return parsed, err
+	}
+}
+
+// next returns the next non-empty record of the input, joined with its
+// continuation lines when reassembly is enabled, and remembers it as the
+// current line.
+func (d *Decoder) next() (string, error) {
+
+	line, err := d.pending, d.pendingErr
+	d.pending, d.pendingErr = "", nil
+
+	if err == nil && line == "" {
+		line, err = d.scan()
+	}
+	if err != nil {
+		d.line, d.raw = line, line
+		return line, err
+	}
+
+	raw := line
+
+	// the record is complete once the next one starts, read errors are
+	// returned again by the following call, discarded records by it.
+	for d.reassemble {
+		continuation, err := d.scan()
+		if err != nil {
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				d.pending, d.pendingErr = continuation, err
+			}
+			break
+		}
+		if startsRecord(continuation) || len(line)+len(continuation) > maxDecoderLineSize {
+			d.pending = continuation
+			break
+		}
+		line += `\n` + continuation
+		raw += "\n" + continuation
+	}
+
+	d.line = line
+	d.raw = raw
+	return line, nil
+}
+
+// scan returns the next non-empty record of the input.
+//
+// Returns:
+// - The record, the start of a discarded record.
+// - A *ParseError wrapping ErrLimitExceeded for a discarded record, io.EOF or the read error.
+func (d *Decoder) scan() (string, error) {
+
+	for d.scanner.Scan() {
+		if line := d.scanner.Text(); line != "" {
+			return line, nil
+		}
+		if line := d.overlong; line != "" {
+			d.overlong = ""
+			return line, newParseError(maxDecoderLineSize, "", ErrLimitExceeded, fmt.Sprintf("record exceeds %d bytes", maxDecoderLineSize))
+		}
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
+
+// startsRecord reports whether the line starts a new CEF message, i.e.
+// begins with "CEF:" in any case, a syslog priority or an RFC 3164 timestamp.
+func startsRecord(line string) bool {
+
+	if prefixEnd(line, false) >= 0 {
+		return true
+	}
+
+	if strings.HasPrefix(line, "<") {
+		end := strings.IndexByte(line, '>')
+		if end < 2 || end > 4 {
+			return false
+		}
+		for _, c := range line[1:end] {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(line) > len(time.Stamp) {
+		_, err := time.Parse(time.Stamp, line[:len(time.Stamp)])
+		return err == nil
+	}
+
+	return false
+}
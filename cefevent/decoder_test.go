@@ -0,0 +1,195 @@
+package cefevent
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderDecode(t *testing.T) {
+
+	decoder := NewDecoder(strings.NewReader(eventLine + "\r\n\n" + "garbage\n" + eventLine))
+
+	got, err := decoder.Decode()
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+	}
+
+	if _, err := decoder.Decode(); err == nil {
+		t.Errorf("Decode() should fail for a line which is not CEF")
+	}
+
+	if got, err := decoder.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+	}
+
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Errorf("Decode() = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoderOnError(t *testing.T) {
+
+	decoder := NewDecoder(strings.NewReader("garbage\n" + eventLine + "\nCEF:x|broken\n"))
+
+	var lines []string
+	decoder.OnError(func(line string, err error) {
+		if err == nil {
+			t.Errorf("OnError() called without error for %q", line)
+		}
+		lines = append(lines, line)
+	})
+
+	if got, err := decoder.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+	}
+
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Errorf("Decode() = %v, want io.EOF", err)
+	}
+
+	if want := []string{"garbage", "CEF:x|broken"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("OnError() received %v, want %v", lines, want)
+	}
+}
+
+func TestDecoderLineTooLong(t *testing.T) {
+
+	long := "CEF:0|Vendor|Product|1.0|100|Flood|9|msg=" + strings.Repeat("x", maxDecoderLineSize)
+	input := eventLine + "\n" + long + "\n" + eventLine + "\n" + long
+
+	for _, reassemble := range []bool{false, true} {
+		decoder := NewDecoder(strings.NewReader(input))
+		decoder.SetReassembly(reassemble)
+
+		for i, wantErr := range []bool{false, true, false, true} {
+			got, err := decoder.Decode()
+			var parseErr *ParseError
+			if wantErr && (!errors.As(err, &parseErr) || !errors.Is(err, ErrLimitExceeded)) {
+				t.Errorf("Decode() #%d with reassembly %v = %v, want a ParseError wrapping ErrLimitExceeded", i, reassemble, err)
+			}
+			if !wantErr && (err != nil || !reflect.DeepEqual(got, event)) {
+				t.Errorf("Decode() #%d with reassembly %v = %v, %v, want %v", i, reassemble, got, err, event)
+			}
+		}
+
+		if _, err := decoder.Decode(); err != io.EOF {
+			t.Errorf("Decode() with reassembly %v = %v, want io.EOF", reassemble, err)
+		}
+	}
+
+	decoder := NewDecoder(strings.NewReader(input))
+	reported := 0
+	decoder.OnError(func(line string, err error) {
+		if !errors.Is(err, ErrLimitExceeded) || !strings.HasPrefix(long, line) {
+			t.Errorf("OnError() called with %d bytes and %v, want the start of the long line and ErrLimitExceeded", len(line), err)
+		}
+		reported++
+	})
+	for i := 0; i < 2; i++ {
+		if got, err := decoder.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF || reported != 2 {
+		t.Errorf("Decode() = %v after %d reported lines, want io.EOF after 2", err, reported)
+	}
+}
+
+func TestDecoderReassembly(t *testing.T) {
+
+	input := "CEF:0|Vendor|Product|1.0|100|Crash|9|msg=first line\n" +
+		"second line\n" +
+		"\n" +
+		"  third line\n" +
+		"<134>Oct 15 10:00:00 host CEF:0|Vendor|Product|1.0|101|Start|3|msg=single\n" +
+		"CEF:0|Vendor|Product|1.0|102|Report|3|msg=a\n" +
+		"b"
+
+	decoder := NewDecoder(strings.NewReader(input))
+	decoder.SetReassembly(true)
+
+	var lines []string
+	for {
+		line, err := decoder.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next() = %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	want := []string{
+		`CEF:0|Vendor|Product|1.0|100|Crash|9|msg=first line\nsecond line\n  third line`,
+		"<134>Oct 15 10:00:00 host CEF:0|Vendor|Product|1.0|101|Start|3|msg=single",
+		`CEF:0|Vendor|Product|1.0|102|Report|3|msg=a\nb`,
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("next() = %q, want %q", lines, want)
+	}
+
+	decoder = NewDecoder(strings.NewReader(input))
+	decoder.SetReassembly(true)
+
+	got, err := decoder.Decode()
+	if want := "first line\nsecond line\n  third line"; err != nil || got.Extensions["msg"] != want {
+		t.Errorf("Decode() msg = %q, %v, want %q", got.Extensions["msg"], err, want)
+	}
+}
+
+func TestDecoderKeepRaw(t *testing.T) {
+
+	input := "CEF:0|Vendor|Product|1.0|100|Crash|9|msg=first line\r\nsecond line\n" + eventLine + "\n"
+
+	decoder := NewDecoder(strings.NewReader(input))
+	decoder.SetReassembly(true)
+	decoder.SetKeepRaw(true)
+
+	var got []string
+	for {
+		event, err := decoder.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		got = append(got, event.Raw)
+	}
+
+	want := []string{"CEF:0|Vendor|Product|1.0|100|Crash|9|msg=first line\nsecond line", eventLine}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() Raw = %q, want %q", got, want)
+	}
+
+	if event, err := NewDecoder(strings.NewReader(input)).Decode(); err != nil || event.Raw != "" {
+		t.Errorf("Decode() Raw = %q, %v, want it empty by default", event.Raw, err)
+	}
+}
+
+func TestStartsRecord(t *testing.T) {
+
+	var tests = []struct {
+		line string
+		want bool
+	}{
+		{"CEF:0|Vendor|Product", true},
+		{"cef:0|Vendor|Product", true},
+		{"<134>Oct 15 10:00:00 host CEF:0|", true},
+		{"<134>1 2024-01-12T10:00:00Z host app - - - CEF:0|", true},
+		{"Oct  5 10:00:00 host CEF:0|", true},
+		{"continued text", false},
+		{"<html> continued", false},
+		{"Oct 15", false},
+	}
+
+	for _, test := range tests {
+		if got := startsRecord(test.line); got != test.want {
+			t.Errorf("startsRecord(%q) = %v, want %v", test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cefevent
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Decompressor wraps a compressed stream with a reader returning the
+// decompressed data.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// decompressor is a registered Decompressor with the magic bytes its
+// streams start with.
+type decompressor struct {
+	name  string
+	magic []byte
+	fn    Decompressor
+}
+
+var (
+	decompressorMu sync.RWMutex
+	decompressors  = []decompressor{
+		{"gzip", []byte{0x1f, 0x8b}, func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}},
+		// zstd is not part of the standard library, a decompressor e.g. based
+		// on github.com/klauspost/compress/zstd can be registered.
+		{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+			return nil, errors.New("input is zstd compressed, register a zstd decompressor with RegisterDecompressor")
+		}},
+	}
+)
+
+// RegisterDecompressor makes a compression format known to DecompressReader,
+// an existing registration with the same name is replaced. gzip is
+// supported out of the box, zstd is detected but requires a registration:
+//
+//	cefevent.RegisterDecompressor("zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, func(r io.Reader) (io.Reader, error) {
+//		return zstd.NewReader(r)
+//	})
+//
+// Parameters:
+// - name: The name of the compression format.
+// - magic: The bytes every compressed stream of the format starts with.
+// - fn: The Decompressor of the format.
+func RegisterDecompressor(name string, magic []byte, fn Decompressor) {
+
+	decompressorMu.Lock()
+	defer decompressorMu.Unlock()
+
+	for i, d := range decompressors {
+		if d.name == name {
+			decompressors[i] = decompressor{name, magic, fn}
+			return
+		}
+	}
+
+	decompressors = append(decompressors, decompressor{name, magic, fn})
+}
+
+// DecompressReader detects whether r is compressed in one of the registered
+// formats by its first bytes and returns a reader decompressing it, so
+// rotated and compressed CEF logs can be read like plain ones. Uncompressed
+// input is returned as it is.
+//
+// Returns:
+// - A reader providing the decompressed input.
+// - An error if the input is compressed in a format without Decompressor or it is corrupt.
+func DecompressReader(r io.Reader) (io.Reader, error) {
+
+	buffered := bufio.NewReader(r)
+
+	decompressorMu.RLock()
+	defer decompressorMu.RUnlock()
+
+	for _, d := range decompressors {
+		head, _ := buffered.Peek(len(d.magic))
+		if bytes.Equal(head, d.magic) {
+			return d.fn(buffered)
+		}
+	}
+
+	return buffered, nil
+}
@@ -0,0 +1,126 @@
+package cefevent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func gzipLines(t *testing.T, lines ...string) []byte {
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, strings.Join(lines, "\n")+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestDecompressReader(t *testing.T) {
+
+	// rotated logs are often concatenated gzip members.
+	compressed := append(gzipLines(t, eventLine), gzipLines(t, eventLine)...)
+
+	var tests = []struct {
+		input []byte
+		want  string
+	}{
+		{compressed, eventLine + "\n" + eventLine + "\n"},
+		{[]byte(eventLine + "\n"), eventLine + "\n"},
+		{[]byte{0x1f}, "\x1f"},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		r, err := DecompressReader(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("DecompressReader(%q) = %v", tt.input, err)
+			continue
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != tt.want {
+			t.Errorf("DecompressReader(%q) read %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecompressReaderZstd(t *testing.T) {
+
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}
+
+	if _, err := DecompressReader(bytes.NewReader(frame)); err == nil {
+		t.Errorf("DecompressReader() should fail for zstd without a registered decompressor")
+	}
+
+	decompressorMu.RLock()
+	saved := append([]decompressor(nil), decompressors...)
+	decompressorMu.RUnlock()
+	defer func() {
+		decompressorMu.Lock()
+		decompressors = saved
+		decompressorMu.Unlock()
+	}()
+
+	RegisterDecompressor("zstd", frame[:4], func(r io.Reader) (io.Reader, error) {
+		return strings.NewReader(eventLine), nil
+	})
+
+	r, err := DecompressReader(bytes.NewReader(frame))
+	if err != nil {
+		t.Fatalf("DecompressReader() = %v", err)
+	}
+	if got, _ := io.ReadAll(r); string(got) != eventLine {
+		t.Errorf("DecompressReader() read %q, want %q", got, eventLine)
+	}
+}
+
+func TestFileSourceCompressed(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "cef.log.gz")
+	os.WriteFile(path, gzipLines(t, eventLine), 0o644)
+
+	source, err := NewFileSource(path)
+	if err != nil {
+		t.Fatalf("NewFileSource() = %v", err)
+	}
+	defer source.Close()
+
+	env, err := source.Receive()
+	if err != nil || env.Err != nil || env.Raw != eventLine {
+		t.Errorf("Receive() = %+v, %v, want the decompressed event", env, err)
+	}
+}
+
+func TestDecoderDecompression(t *testing.T) {
+
+	decoder := NewDecoder(bytes.NewReader(gzipLines(t, eventLine, eventLine)))
+	decoder.SetDecompression(true)
+
+	for i := 0; i < 2; i++ {
+		if got, err := decoder.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+		}
+	}
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Errorf("Decode() = %v, want io.EOF", err)
+	}
+
+	// plain input is read as it is.
+	decoder = NewDecoder(strings.NewReader(eventLine))
+	decoder.SetDecompression(true)
+	if got, err := decoder.Decode(); err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("Decode() = %v, %v, want %v", got, err, event)
+	}
+
+	// without detection, compressed input is no CEF.
+	decoder = NewDecoder(bytes.NewReader(gzipLines(t, eventLine)))
+	if _, err := decoder.Decode(); err == nil || err == io.EOF {
+		t.Errorf("Decode() = %v, want a parse error", err)
+	}
+}
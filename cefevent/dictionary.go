@@ -0,0 +1,257 @@
+package cefevent
+
+import "strings"
+
+// ExtensionType is the data type the CEF specification defines for an extension.
+type ExtensionType string
+
+const (
+	ExtensionString        ExtensionType = "String"         // ExtensionString is free text, limited to the Length of the extension.
+	ExtensionInteger       ExtensionType = "Integer"        // ExtensionInteger is a 32 bit integer.
+	ExtensionLong          ExtensionType = "Long"           // ExtensionLong is a 64 bit integer.
+	ExtensionFloatingPoint ExtensionType = "Floating Point" // ExtensionFloatingPoint is a 32 bit floating point number.
+	ExtensionDouble        ExtensionType = "Double"         // ExtensionDouble is a 64 bit floating point number.
+	ExtensionIPv4Address   ExtensionType = "IPv4 Address"   // ExtensionIPv4Address is an IPv4 address in dotted notation.
+	ExtensionIPv6Address   ExtensionType = "IPv6 Address"   // ExtensionIPv6Address is an IPv6 address.
+	ExtensionIPAddress     ExtensionType = "IP Address"     // ExtensionIPAddress is an IPv4 or IPv6 address.
+	ExtensionMACAddress    ExtensionType = "MAC Address"    // ExtensionMACAddress is a MAC address in colon separated notation.
+	ExtensionTimestamp     ExtensionType = "TimeStamp"      // ExtensionTimestamp is epoch milliseconds or a date, see ParseTimestamp.
+)
+
+// ExtensionDefinition describes an extension defined by the CEF
+// specification, e.g. for autocompletion and inline documentation in
+// editors and user interfaces.
+type ExtensionDefinition struct {
+	Key         string        `json:"key"`              // Key is the key used in CEF messages, e.g. "src".
+	Name        string        `json:"name"`             // Name is the full name of the extension, e.g. "sourceAddress".
+	Type        ExtensionType `json:"type"`             // Type is the data type of the values.
+	Length      int           `json:"length,omitempty"` // Length is the maximum length of String values, zero for other types.
+	Description string        `json:"description"`      // Description explains the meaning of the extension.
+}
+
+// dictionary holds the extensions defined by the CEF specification, sorted by key.
+var dictionary = []ExtensionDefinition{
+	{"act", "deviceAction", ExtensionString, 63, "Action taken by the device."},
+	{"agentDnsDomain", "agentDnsDomain", ExtensionString, 255, "The DNS domain name of the ArcSight connector that processed the event."},
+	{"agentNtDomain", "agentNtDomain", ExtensionString, 255, "The Windows domain name of the ArcSight connector that processed the event."},
+	{"agentTranslatedAddress", "agentTranslatedAddress", ExtensionIPAddress, 0, "The translated IP address of the ArcSight connector that processed the event."},
+	{"agentTranslatedZoneExternalID", "agentTranslatedZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the translated connector address."},
+	{"agentTranslatedZoneURI", "agentTranslatedZoneURI", ExtensionString, 2048, "The URI of the network zone of the translated connector address."},
+	{"agentZoneExternalID", "agentZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the connector."},
+	{"agentZoneURI", "agentZoneURI", ExtensionString, 2048, "The URI of the network zone of the connector."},
+	{"agt", "agentAddress", ExtensionIPAddress, 0, "The IP address of the ArcSight connector that processed the event."},
+	{"ahost", "agentHostName", ExtensionString, 1023, "The hostname of the ArcSight connector that processed the event."},
+	{"aid", "agentId", ExtensionString, 40, "The agent ID of the ArcSight connector that processed the event."},
+	{"amac", "agentMacAddress", ExtensionMACAddress, 0, "The MAC address of the ArcSight connector that processed the event."},
+	{"app", "applicationProtocol", ExtensionString, 31, "Application level protocol, e.g. HTTP, HTTPS, SSHv2, Telnet, POP or IMAPS."},
+	{"art", "agentReceiptTime", ExtensionTimestamp, 0, "The time at which the event was received by the ArcSight connector."},
+	{"at", "agentType", ExtensionString, 63, "The agent type of the ArcSight connector that processed the event."},
+	{"atz", "agentTimeZone", ExtensionString, 255, "The time zone of the ArcSight connector that processed the event."},
+	{"av", "agentVersion", ExtensionString, 31, "The version of the ArcSight connector that processed the event."},
+	{"c6a1", "deviceCustomIPv6Address1", ExtensionIPv6Address, 0, "One of four IPv6 address fields for values no other extension applies to."},
+	{"c6a1Label", "deviceCustomIPv6Address1Label", ExtensionString, 1023, "The label of c6a1."},
+	{"c6a2", "deviceCustomIPv6Address2", ExtensionIPv6Address, 0, "One of four IPv6 address fields for values no other extension applies to."},
+	{"c6a2Label", "deviceCustomIPv6Address2Label", ExtensionString, 1023, "The label of c6a2."},
+	{"c6a3", "deviceCustomIPv6Address3", ExtensionIPv6Address, 0, "One of four IPv6 address fields for values no other extension applies to."},
+	{"c6a3Label", "deviceCustomIPv6Address3Label", ExtensionString, 1023, "The label of c6a3."},
+	{"c6a4", "deviceCustomIPv6Address4", ExtensionIPv6Address, 0, "One of four IPv6 address fields for values no other extension applies to."},
+	{"c6a4Label", "deviceCustomIPv6Address4Label", ExtensionString, 1023, "The label of c6a4."},
+	{"cat", "deviceEventCategory", ExtensionString, 1023, "The category assigned by the originating device, e.g. /Monitor/Disk/Read."},
+	{"cfp1", "deviceCustomFloatingPoint1", ExtensionFloatingPoint, 0, "One of four floating point fields for values no other extension applies to."},
+	{"cfp1Label", "deviceCustomFloatingPoint1Label", ExtensionString, 1023, "The label of cfp1."},
+	{"cfp2", "deviceCustomFloatingPoint2", ExtensionFloatingPoint, 0, "One of four floating point fields for values no other extension applies to."},
+	{"cfp2Label", "deviceCustomFloatingPoint2Label", ExtensionString, 1023, "The label of cfp2."},
+	{"cfp3", "deviceCustomFloatingPoint3", ExtensionFloatingPoint, 0, "One of four floating point fields for values no other extension applies to."},
+	{"cfp3Label", "deviceCustomFloatingPoint3Label", ExtensionString, 1023, "The label of cfp3."},
+	{"cfp4", "deviceCustomFloatingPoint4", ExtensionFloatingPoint, 0, "One of four floating point fields for values no other extension applies to."},
+	{"cfp4Label", "deviceCustomFloatingPoint4Label", ExtensionString, 1023, "The label of cfp4."},
+	{"cn1", "deviceCustomNumber1", ExtensionLong, 0, "One of three number fields for values no other extension applies to."},
+	{"cn1Label", "deviceCustomNumber1Label", ExtensionString, 1023, "The label of cn1."},
+	{"cn2", "deviceCustomNumber2", ExtensionLong, 0, "One of three number fields for values no other extension applies to."},
+	{"cn2Label", "deviceCustomNumber2Label", ExtensionString, 1023, "The label of cn2."},
+	{"cn3", "deviceCustomNumber3", ExtensionLong, 0, "One of three number fields for values no other extension applies to."},
+	{"cn3Label", "deviceCustomNumber3Label", ExtensionString, 1023, "The label of cn3."},
+	{"cnt", "baseEventCount", ExtensionInteger, 0, "How many times the same event was observed."},
+	{"cs1", "deviceCustomString1", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs1Label", "deviceCustomString1Label", ExtensionString, 1023, "The label of cs1."},
+	{"cs2", "deviceCustomString2", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs2Label", "deviceCustomString2Label", ExtensionString, 1023, "The label of cs2."},
+	{"cs3", "deviceCustomString3", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs3Label", "deviceCustomString3Label", ExtensionString, 1023, "The label of cs3."},
+	{"cs4", "deviceCustomString4", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs4Label", "deviceCustomString4Label", ExtensionString, 1023, "The label of cs4."},
+	{"cs5", "deviceCustomString5", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs5Label", "deviceCustomString5Label", ExtensionString, 1023, "The label of cs5."},
+	{"cs6", "deviceCustomString6", ExtensionString, 4000, "One of six string fields for values no other extension applies to."},
+	{"cs6Label", "deviceCustomString6Label", ExtensionString, 1023, "The label of cs6."},
+	{"customerExternalID", "customerExternalID", ExtensionString, 200, "The external ID of the customer the event belongs to."},
+	{"customerURI", "customerURI", ExtensionString, 2048, "The URI of the customer the event belongs to."},
+	{"destinationDnsDomain", "destinationDnsDomain", ExtensionString, 255, "The DNS domain part of the fully qualified domain name of the destination."},
+	{"destinationServiceName", "destinationServiceName", ExtensionString, 1023, "The service targeted by the event, e.g. sshd."},
+	{"destinationTranslatedAddress", "destinationTranslatedAddress", ExtensionIPv4Address, 0, "The destination address after network address translation."},
+	{"destinationTranslatedPort", "destinationTranslatedPort", ExtensionInteger, 0, "The destination port after network address translation."},
+	{"destinationTranslatedZoneExternalID", "destinationTranslatedZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the translated destination address."},
+	{"destinationTranslatedZoneURI", "destinationTranslatedZoneURI", ExtensionString, 2048, "The URI of the network zone of the translated destination address."},
+	{"destinationZoneExternalID", "destinationZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the destination."},
+	{"destinationZoneURI", "destinationZoneURI", ExtensionString, 2048, "The URI of the network zone of the destination."},
+	{"deviceCustomDate1", "deviceCustomDate1", ExtensionTimestamp, 0, "One of two timestamp fields for values no other extension applies to."},
+	{"deviceCustomDate1Label", "deviceCustomDate1Label", ExtensionString, 1023, "The label of deviceCustomDate1."},
+	{"deviceCustomDate2", "deviceCustomDate2", ExtensionTimestamp, 0, "One of two timestamp fields for values no other extension applies to."},
+	{"deviceCustomDate2Label", "deviceCustomDate2Label", ExtensionString, 1023, "The label of deviceCustomDate2."},
+	{"deviceDirection", "deviceDirection", ExtensionInteger, 0, "The direction of the observed communication, 0 for inbound and 1 for outbound."},
+	{"deviceDnsDomain", "deviceDnsDomain", ExtensionString, 255, "The DNS domain part of the fully qualified domain name of the device."},
+	{"deviceExternalId", "deviceExternalId", ExtensionString, 255, "A name that uniquely identifies the device generating the event."},
+	{"deviceFacility", "deviceFacility", ExtensionString, 1023, "The facility generating the event, e.g. the syslog facility."},
+	{"deviceInboundInterface", "deviceInboundInterface", ExtensionString, 128, "The interface on which the packet or data entered the device."},
+	{"deviceNtDomain", "deviceNtDomain", ExtensionString, 255, "The Windows domain name of the device."},
+	{"deviceOutboundInterface", "deviceOutboundInterface", ExtensionString, 128, "The interface on which the packet or data left the device."},
+	{"devicePayloadId", "devicePayloadId", ExtensionString, 128, "The unique identifier of the payload associated with the event."},
+	{"deviceProcessName", "deviceProcessName", ExtensionString, 1023, "The name of the process on the device generating the event."},
+	{"deviceTranslatedAddress", "deviceTranslatedAddress", ExtensionIPAddress, 0, "The device address after network address translation."},
+	{"deviceTranslatedZoneExternalID", "deviceTranslatedZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the translated device address."},
+	{"deviceTranslatedZoneURI", "deviceTranslatedZoneURI", ExtensionString, 2048, "The URI of the network zone of the translated device address."},
+	{"deviceZoneExternalID", "deviceZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the device."},
+	{"deviceZoneURI", "deviceZoneURI", ExtensionString, 2048, "The URI of the network zone of the device."},
+	{"dhost", "destinationHostName", ExtensionString, 1023, "The destination hostname, preferably fully qualified."},
+	{"dlat", "destinationGeoLatitude", ExtensionDouble, 0, "The latitude of the destination."},
+	{"dlong", "destinationGeoLongitude", ExtensionDouble, 0, "The longitude of the destination."},
+	{"dmac", "destinationMacAddress", ExtensionMACAddress, 0, "The MAC address of the destination."},
+	{"dntdom", "destinationNtDomain", ExtensionString, 255, "The Windows domain name of the destination."},
+	{"dpid", "destinationProcessId", ExtensionInteger, 0, "The ID of the destination process associated with the event."},
+	{"dpriv", "destinationUserPrivileges", ExtensionString, 1023, "The privileges of the destination user, e.g. Administrator, User or Guest."},
+	{"dproc", "destinationProcessName", ExtensionString, 1023, "The name of the destination process associated with the event."},
+	{"dpt", "destinationPort", ExtensionInteger, 0, "The destination port, between 0 and 65535."},
+	{"dst", "destinationAddress", ExtensionIPv4Address, 0, "The IPv4 address of the destination."},
+	{"dtz", "deviceTimeZone", ExtensionString, 255, "The time zone of the device generating the event."},
+	{"duid", "destinationUserId", ExtensionString, 1023, "The ID of the destination user."},
+	{"duser", "destinationUserName", ExtensionString, 1023, "The name of the destination user, e.g. an email address."},
+	{"dvc", "deviceAddress", ExtensionIPv4Address, 0, "The IPv4 address of the device generating the event."},
+	{"dvchost", "deviceHostName", ExtensionString, 100, "The hostname of the device generating the event, preferably fully qualified."},
+	{"dvcmac", "deviceMacAddress", ExtensionMACAddress, 0, "The MAC address of the device generating the event."},
+	{"dvcpid", "deviceProcessId", ExtensionInteger, 0, "The ID of the process on the device generating the event."},
+	{"end", "endTime", ExtensionTimestamp, 0, "The time at which the activity related to the event ended."},
+	{"eventId", "eventId", ExtensionLong, 0, "The unique ID the ArcSight connector assigned to the event."},
+	{"externalId", "externalId", ExtensionString, 40, "The ID the originating device uses for the event."},
+	{"fileCreateTime", "fileCreateTime", ExtensionTimestamp, 0, "The time the file was created."},
+	{"fileHash", "fileHash", ExtensionString, 255, "The hash of the file."},
+	{"fileId", "fileId", ExtensionString, 1023, "An ID associated with the file, e.g. its inode."},
+	{"fileModificationTime", "fileModificationTime", ExtensionTimestamp, 0, "The time the file was last modified."},
+	{"filePath", "filePath", ExtensionString, 1023, "The full path of the file, including the file name."},
+	{"filePermission", "filePermission", ExtensionString, 1023, "The permissions of the file."},
+	{"fileType", "fileType", ExtensionString, 1023, "The type of the file, e.g. pipe, socket or fifo."},
+	{"flexDate1", "flexDate1", ExtensionTimestamp, 0, "A timestamp field for values no other extension applies to, reserved for the operator."},
+	{"flexDate1Label", "flexDate1Label", ExtensionString, 128, "The label of flexDate1."},
+	{"flexNumber1", "flexNumber1", ExtensionLong, 0, "One of two number fields for values no other extension applies to, reserved for the operator."},
+	{"flexNumber1Label", "flexNumber1Label", ExtensionString, 128, "The label of flexNumber1."},
+	{"flexNumber2", "flexNumber2", ExtensionLong, 0, "One of two number fields for values no other extension applies to, reserved for the operator."},
+	{"flexNumber2Label", "flexNumber2Label", ExtensionString, 128, "The label of flexNumber2."},
+	{"flexString1", "flexString1", ExtensionString, 1023, "One of two string fields for values no other extension applies to, reserved for the operator."},
+	{"flexString1Label", "flexString1Label", ExtensionString, 128, "The label of flexString1."},
+	{"flexString2", "flexString2", ExtensionString, 1023, "One of two string fields for values no other extension applies to, reserved for the operator."},
+	{"flexString2Label", "flexString2Label", ExtensionString, 128, "The label of flexString2."},
+	{"fname", "fileName", ExtensionString, 1023, "The name of the file, without its path."},
+	{"fsize", "fileSize", ExtensionInteger, 0, "The size of the file."},
+	{"in", "bytesIn", ExtensionInteger, 0, "The number of bytes transferred inbound, relative to the source."},
+	{"msg", "message", ExtensionString, 1023, "An arbitrary message giving more details about the event."},
+	{"oldFileCreateTime", "oldFileCreateTime", ExtensionTimestamp, 0, "The time the old file was created."},
+	{"oldFileHash", "oldFileHash", ExtensionString, 255, "The hash of the old file."},
+	{"oldFileId", "oldFileId", ExtensionString, 1023, "An ID associated with the old file, e.g. its inode."},
+	{"oldFileModificationTime", "oldFileModificationTime", ExtensionTimestamp, 0, "The time the old file was last modified."},
+	{"oldFileName", "oldFileName", ExtensionString, 1023, "The name of the old file, without its path."},
+	{"oldFilePath", "oldFilePath", ExtensionString, 1023, "The full path of the old file, including the file name."},
+	{"oldFilePermission", "oldFilePermission", ExtensionString, 1023, "The permissions of the old file."},
+	{"oldFileSize", "oldFileSize", ExtensionInteger, 0, "The size of the old file."},
+	{"oldFileType", "oldFileType", ExtensionString, 1023, "The type of the old file, e.g. pipe, socket or fifo."},
+	{"out", "bytesOut", ExtensionInteger, 0, "The number of bytes transferred outbound, relative to the source."},
+	{"outcome", "eventOutcome", ExtensionString, 63, "The outcome of the event, e.g. success or failure."},
+	{"proto", "transportProtocol", ExtensionString, 31, "The layer 4 protocol used, e.g. TCP or UDP."},
+	{"rawEvent", "rawEvent", ExtensionString, 4000, "The original event as received by the ArcSight connector."},
+	{"reason", "reason", ExtensionString, 1023, "The reason an audit event was generated, e.g. bad password."},
+	{"request", "requestUrl", ExtensionString, 1023, "The URL accessed in the request, including the protocol."},
+	{"requestClientApplication", "requestClientApplication", ExtensionString, 1023, "The user agent associated with the request."},
+	{"requestContext", "requestContext", ExtensionString, 2048, "The context the request originated from, e.g. the HTTP referrer."},
+	{"requestCookies", "requestCookies", ExtensionString, 1023, "The cookies associated with the request."},
+	{"requestMethod", "requestMethod", ExtensionString, 1023, "The method used to access the URL, e.g. POST or GET."},
+	{"rt", "deviceReceiptTime", ExtensionTimestamp, 0, "The time at which the event related to the activity was received."},
+	{"shost", "sourceHostName", ExtensionString, 1023, "The source hostname, preferably fully qualified."},
+	{"slat", "sourceGeoLatitude", ExtensionDouble, 0, "The latitude of the source."},
+	{"slong", "sourceGeoLongitude", ExtensionDouble, 0, "The longitude of the source."},
+	{"smac", "sourceMacAddress", ExtensionMACAddress, 0, "The MAC address of the source."},
+	{"sntdom", "sourceNtDomain", ExtensionString, 255, "The Windows domain name of the source."},
+	{"sourceDnsDomain", "sourceDnsDomain", ExtensionString, 255, "The DNS domain part of the fully qualified domain name of the source."},
+	{"sourceServiceName", "sourceServiceName", ExtensionString, 1023, "The service responsible for generating the event."},
+	{"sourceTranslatedAddress", "sourceTranslatedAddress", ExtensionIPv4Address, 0, "The source address after network address translation."},
+	{"sourceTranslatedPort", "sourceTranslatedPort", ExtensionInteger, 0, "The source port after network address translation."},
+	{"sourceTranslatedZoneExternalID", "sourceTranslatedZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the translated source address."},
+	{"sourceTranslatedZoneURI", "sourceTranslatedZoneURI", ExtensionString, 2048, "The URI of the network zone of the translated source address."},
+	{"sourceZoneExternalID", "sourceZoneExternalID", ExtensionString, 200, "The external ID of the network zone of the source."},
+	{"sourceZoneURI", "sourceZoneURI", ExtensionString, 2048, "The URI of the network zone of the source."},
+	{"spid", "sourceProcessId", ExtensionInteger, 0, "The ID of the source process associated with the event."},
+	{"spriv", "sourceUserPrivileges", ExtensionString, 1023, "The privileges of the source user, e.g. Administrator, User or Guest."},
+	{"sproc", "sourceProcessName", ExtensionString, 1023, "The name of the source process associated with the event."},
+	{"spt", "sourcePort", ExtensionInteger, 0, "The source port, between 0 and 65535."},
+	{"src", "sourceAddress", ExtensionIPv4Address, 0, "The IPv4 address of the source."},
+	{"start", "startTime", ExtensionTimestamp, 0, "The time at which the activity related to the event started."},
+	{"suid", "sourceUserId", ExtensionString, 1023, "The ID of the source user."},
+	{"suser", "sourceUserName", ExtensionString, 1023, "The name of the source user, e.g. an email address."},
+	{"type", "type", ExtensionInteger, 0, "The type of the event, 0 for base, 1 for aggregated, 2 for correlation and 3 for action events."},
+}
+
+// dictionaryKeys indexes the dictionary by key.
+var dictionaryKeys = map[string]int{}
+
+func init() {
+
+	for i, definition := range dictionary {
+		dictionaryKeys[definition.Key] = i
+	}
+}
+
+// IsDictionaryKey reports whether key is an extension key defined by the
+// CEF specification, e.g. "src" or "deviceCustomDate1".
+func IsDictionaryKey(key string) bool {
+
+	_, ok := dictionaryKeys[key]
+	return ok
+}
+
+// LookupExtension returns the definition of an extension of the CEF
+// specification by its key, e.g. "src", or its full name, e.g. "sourceAddress".
+//
+// Returns:
+// - The ExtensionDefinition.
+// - Whether the extension is defined by the specification.
+func LookupExtension(name string) (ExtensionDefinition, bool) {
+
+	if i, ok := dictionaryKeys[name]; ok {
+		return dictionary[i], true
+	}
+
+	for _, definition := range dictionary {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+
+	return ExtensionDefinition{}, false
+}
+
+// Dictionary returns the definitions of all extensions of the CEF
+// specification sorted by key, e.g. to export them for autocompletion.
+//
+// Parameters:
+// - prefix: Only definitions whose key or full name starts with the prefix are returned, the empty prefix returns all.
+//
+// Returns:
+// - A copy of the matching definitions.
+func Dictionary(prefix string) []ExtensionDefinition {
+
+	var definitions []ExtensionDefinition
+	for _, definition := range dictionary {
+		if strings.HasPrefix(definition.Key, prefix) || strings.HasPrefix(definition.Name, prefix) {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	return definitions
+}
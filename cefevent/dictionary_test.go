@@ -0,0 +1,66 @@
+package cefevent
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+
+	keys := make([]string, len(dictionary))
+	for i, definition := range dictionary {
+		keys[i] = definition.Key
+		if definition.Name == "" || definition.Type == "" || definition.Description == "" {
+			t.Errorf("dictionary[%q] = %+v, want a name, type and description", definition.Key, definition)
+		}
+		if (definition.Type == ExtensionString) != (definition.Length > 0) {
+			t.Errorf("dictionary[%q] = %+v, want a length for strings only", definition.Key, definition)
+		}
+		if (definition.Type == ExtensionTimestamp) != isTimestampExtension(definition.Key) {
+			t.Errorf("dictionary[%q] = %+v, disagrees with timestampExtensions", definition.Key, definition)
+		}
+	}
+	if !sort.StringsAreSorted(keys) || len(dictionaryKeys) != len(dictionary) {
+		t.Errorf("dictionary keys = %v, want sorted unique keys", keys)
+	}
+
+	if got := Dictionary(""); len(got) != len(dictionary) {
+		t.Errorf("Dictionary() = %d definitions, want %d", len(got), len(dictionary))
+	}
+
+	var got []string
+	for _, definition := range Dictionary("source") {
+		got = append(got, definition.Key)
+	}
+	want := []string{"shost", "slat", "slong", "smac", "sntdom", "sourceDnsDomain", "sourceServiceName",
+		"sourceTranslatedAddress", "sourceTranslatedPort", "sourceTranslatedZoneExternalID",
+		"sourceTranslatedZoneURI", "sourceZoneExternalID", "sourceZoneURI", "spid", "spriv", "sproc",
+		"spt", "src", "suid", "suser"}
+	if len(got) != len(want) {
+		t.Fatalf("Dictionary(source) = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Dictionary(source) = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestLookupExtension(t *testing.T) {
+
+	for _, name := range []string{"src", "sourceAddress"} {
+		got, ok := LookupExtension(name)
+		if !ok || got.Key != "src" || got.Type != ExtensionIPv4Address {
+			t.Errorf("LookupExtension(%q) = %+v, %v, want src", name, got, ok)
+		}
+	}
+
+	if got, ok := LookupExtension("threatScore"); ok {
+		t.Errorf("LookupExtension(threatScore) = %+v, want no definition", got)
+	}
+
+	if !IsDictionaryKey("deviceCustomDate1") || IsDictionaryKey("sourceAddress") {
+		t.Error("IsDictionaryKey() accepts full names or rejects keys")
+	}
+}
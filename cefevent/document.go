@@ -0,0 +1,265 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DocumentError describes why a YAML, TOML, Avro or MessagePack document is
+// not a valid event, see FromYAML, FromTOML, FromAvro and FromMsgpack.
+type DocumentError struct {
+	Format string // Format is "YAML", "TOML", "Avro", "MessagePack" or "CBOR".
+	Line   int    // Line is the line of the document the failure was detected in, 0 if it relates to the whole document.
+	Field  string // Field is the header field name or extension key the failure relates to, empty if none.
+	Reason string // Reason describes the failure.
+	Err    error  // Err is one of ErrMissingField, ErrInvalidVersion and ErrMalformedExtension or ErrMalformedDocument.
+}
+
+// ErrMalformedDocument is wrapped by a DocumentError when the document is
+// not well-formed or uses syntax the event documents do not support.
+var ErrMalformedDocument = errors.New("malformed document")
+
+// Error returns the reason, field and line of the failure.
+func (e *DocumentError) Error() string {
+
+	msg := fmt.Sprintf("not a valid CEF %s document: %s", e.Format, e.Reason)
+	if e.Field != "" {
+		msg += " in " + e.Field
+	}
+	if e.Line > 0 {
+		msg += fmt.Sprintf(" on line %d", e.Line)
+	}
+
+	return msg
+}
+
+// Unwrap returns the kind of the failure.
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// documentHeader returns a pointer to the header field called name, nil
+// for Version and unknown names.
+func documentHeader(event *CefEvent, name string) *string {
+
+	switch name {
+	case "DeviceVendor":
+		return &event.DeviceVendor
+	case "DeviceProduct":
+		return &event.DeviceProduct
+	case "DeviceVersion":
+		return &event.DeviceVersion
+	case "DeviceEventClassId":
+		return &event.DeviceEventClassId
+	case "Name":
+		return &event.Name
+	case "Severity":
+		return &event.Severity
+	}
+
+	return nil
+}
+
+// documentDecoder collects the fields of an event while a YAML or TOML
+// document is read and rejects fields which are set twice.
+type documentDecoder struct {
+	format string
+	event  CefEvent
+	seen   map[string]bool
+	line   int
+}
+
+// newDocumentDecoder returns a documentDecoder for the format.
+func newDocumentDecoder(format string) *documentDecoder {
+	return &documentDecoder{format: format, seen: make(map[string]bool)}
+}
+
+// errorf returns a DocumentError for the current line.
+func (d *documentDecoder) errorf(field string, err error, format string, args ...interface{}) *DocumentError {
+	return &DocumentError{Format: d.format, Line: d.line, Field: field, Reason: fmt.Sprintf(format, args...), Err: err}
+}
+
+// header sets the header field name, an unquoted value of Version must be an integer.
+func (d *documentDecoder) header(name string, value string) error {
+
+	if d.seen[name] {
+		return d.errorf(name, ErrMalformedDocument, "duplicate field")
+	}
+	d.seen[name] = true
+
+	if name == headerFieldNames[0] {
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return d.errorf(name, ErrInvalidVersion, "version %q is not a number", value)
+		}
+		d.event.Version = version
+		return nil
+	}
+
+	field := documentHeader(&d.event, name)
+	if field == nil {
+		return d.errorf(name, ErrMalformedDocument, "unknown field")
+	}
+	*field = value
+
+	return nil
+}
+
+// extension adds an extension.
+func (d *documentDecoder) extension(key string, value string) error {
+
+	if d.event.Extensions == nil {
+		d.event.Extensions = make(map[string]string)
+	}
+	if _, ok := d.event.Extensions[key]; ok {
+		return d.errorf(key, ErrMalformedExtension, "duplicate extension")
+	}
+	d.event.Extensions[key] = value
+
+	return nil
+}
+
+// finish validates the event like FromJSON.
+func (d *documentDecoder) finish() (CefEvent, error) {
+
+	if field, reason, err := checkDocument(&d.event); err != nil {
+		return CefEvent{}, &DocumentError{Format: d.format, Field: field, Reason: reason, Err: err}
+	}
+
+	return d.event, nil
+}
+
+// quoteDocumentString quotes s as double-quoted string valid in YAML and
+// TOML alike, both accept the escapes of JSON. Invalid UTF-8 is replaced.
+func quoteDocumentString(s string) string {
+
+	var b strings.Builder
+	b.WriteByte('"')
+
+	for _, r := range strings.ToValidUTF8(s, string(utf8.RuneError)) {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				b.WriteRune(r)
+			case r > 0xffff:
+				fmt.Fprintf(&b, `\U%08X`, r)
+			default:
+				fmt.Fprintf(&b, `\u%04X`, r)
+			}
+		}
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
+
+// documentEscapes are the single character escapes of double-quoted YAML
+// and basic TOML strings.
+var documentEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+	'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`, 'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// unquoteDocumentString reads the double-quoted string at the start of s.
+//
+// Returns:
+// - The unescaped string.
+// - The remainder of s after the closing quote.
+// - Whether the string is terminated and all escapes are valid.
+func unquoteDocumentString(s string) (string, string, bool) {
+
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			if i+1 >= len(s) {
+				return "", "", false
+			}
+			i++
+			if esc, ok := documentEscapes[s[i]]; ok {
+				b.WriteString(esc)
+				continue
+			}
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+			if digits == 0 || i+digits >= len(s) {
+				return "", "", false
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", "", false
+			}
+			b.WriteRune(rune(r))
+			i += digits
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", "", false
+}
+
+// unquoteLiteralString reads the single-quoted string at the start of s,
+// in YAML a doubled quote stands for a single one.
+//
+// Returns:
+// - The string.
+// - The remainder of s after the closing quote.
+// - Whether the string is terminated.
+func unquoteLiteralString(s string, doubled bool) (string, string, bool) {
+
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if doubled && i+1 < len(s) && s[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+		return b.String(), s[i+1:], true
+	}
+
+	return "", "", false
+}
+
+// plainDocumentKey reports whether key can be written unquoted in YAML and TOML.
+func plainDocumentKey(key string) bool {
+
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
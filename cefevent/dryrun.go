@@ -0,0 +1,136 @@
+package cefevent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultDryRunPreview is the number of messages a DryRunSink of a dry-run
+// pipeline keeps as preview.
+const DefaultDryRunPreview = 5
+
+// DryRunSink is a Sink which discards all messages, it only counts them
+// and keeps the first ones as preview. It takes the place of the real sinks
+// in dry runs, which exercise the whole parse, transform and render path
+// without touching production destinations.
+type DryRunSink struct {
+	name    string
+	format  Format
+	preview int
+
+	mu       sync.Mutex
+	count    int
+	bytes    int
+	messages []string
+	partial  []byte
+}
+
+// NewDryRunSink returns a DryRunSink standing in for a destination.
+//
+// Parameters:
+// - name: The description of the destination used in the report, e.g. "udp 10.0.0.1:514".
+// - format: The Format the destination expects.
+// - preview: The number of messages kept as preview.
+//
+// Returns:
+// - A pointer to a DryRunSink which is safe for concurrent use.
+func NewDryRunSink(name string, format Format, preview int) *DryRunSink {
+	return &DryRunSink{name: name, format: format, preview: preview}
+}
+
+// Format returns the wire format of the destination.
+func (s *DryRunSink) Format() Format {
+	return s.format
+}
+
+// Send counts the message and keeps it if the preview is not full yet.
+func (s *DryRunSink) Send(message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(message)
+
+	return nil
+}
+
+// record counts a message, the lock must be held.
+func (s *DryRunSink) record(message string) {
+
+	s.count++
+	s.bytes += len(message)
+	if len(s.messages) < s.preview {
+		s.messages = append(s.messages, message)
+	}
+}
+
+// Write implements io.Writer, every newline terminated line written is
+// counted as a message. It allows a DryRunSink to replace the output of
+// functions writing to an io.Writer such as RunFilter.
+func (s *DryRunSink) Write(p []byte) (int, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := append(s.partial, p...)
+	for {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		s.record(string(data[:end]))
+		data = data[end+1:]
+	}
+	s.partial = append(s.partial[:0], data...)
+
+	return len(p), nil
+}
+
+// Count returns the number of messages received.
+func (s *DryRunSink) Count() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+// Bytes returns the total size of the messages received.
+func (s *DryRunSink) Bytes() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bytes
+}
+
+// Preview returns the first messages received.
+func (s *DryRunSink) Preview() []string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.messages...)
+}
+
+// WriteReport writes a summary of the messages received and the preview to w.
+//
+// Returns:
+// - An error if writing to w failed.
+func (s *DryRunSink) WriteReport(w io.Writer) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "%s (%s): %d messages, %d bytes\n", s.name, s.format, s.count, s.bytes)
+	for _, message := range s.messages {
+		fmt.Fprintf(&report, "  %s\n", message)
+	}
+
+	_, err := io.WriteString(w, report.String())
+	return err
+}
@@ -0,0 +1,71 @@
+package cefevent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunSink(t *testing.T) {
+
+	sink := NewDryRunSink("udp 10.0.0.1:514", FormatCEF, 2)
+	for _, message := range []string{"one", "two", "three"} {
+		sink.Send(message)
+	}
+	sink.Write([]byte("fo"))
+	sink.Write([]byte("ur\nfive\n"))
+
+	if sink.Count() != 5 || sink.Bytes() != 19 {
+		t.Errorf("Count(), Bytes() = %d, %d, want 5, 19", sink.Count(), sink.Bytes())
+	}
+
+	var report strings.Builder
+	sink.WriteReport(&report)
+
+	want := "udp 10.0.0.1:514 (cef): 5 messages, 19 bytes\n  one\n  two\n"
+	if report.String() != want {
+		t.Errorf("WriteReport() = %q, want %q", report.String(), want)
+	}
+}
+
+func TestPipelineDryRun(t *testing.T) {
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.log")
+	output := filepath.Join(dir, "out.log")
+	os.WriteFile(input, []byte(eventLine+"\n"+eventLine+"\n"), 0o644)
+
+	config := PipelineConfig{
+		Sources:    []SourceConfig{{Type: "file", Path: input}},
+		Sinks:      []SinkConfig{{Type: "file", Path: output, Format: "leef"}, {Type: "udp", Address: "192.0.2.1:514"}},
+		Quarantine: &QuarantineConfig{Path: filepath.Join(dir, "quarantine.log")},
+		DryRun:     true,
+	}
+
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	pipeline.Close()
+
+	sinks := pipeline.DryRunSinks()
+	if len(sinks) != 2 || sinks[0].Count() != 2 || sinks[1].Count() != 2 {
+		t.Fatalf("DryRunSinks() = %v, want two sinks with two messages each", sinks)
+	}
+
+	leef, _ := event.ToLEEF()
+	if preview := sinks[0].Preview(); preview[0] != leef {
+		t.Errorf("Preview() = %q, want %q", preview[0], leef)
+	}
+
+	for _, path := range []string{output, config.Quarantine.Path} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("dry run created %s", path)
+		}
+	}
+}
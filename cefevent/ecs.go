@@ -0,0 +1,147 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ecsExtensionFields maps the CEF extension keys of the specification onto
+// their Elastic Common Schema field names, following the CEF module of Filebeat.
+var ecsExtensionFields = map[string]string{
+	"src":                          "source.ip",
+	"spt":                          "source.port",
+	"smac":                         "source.mac",
+	"shost":                        "source.domain",
+	"suser":                        "source.user.name",
+	"suid":                         "source.user.id",
+	"in":                           "source.bytes",
+	"sourceTranslatedAddress":      "source.nat.ip",
+	"sourceTranslatedPort":         "source.nat.port",
+	"dst":                          "destination.ip",
+	"dpt":                          "destination.port",
+	"dmac":                         "destination.mac",
+	"dhost":                        "destination.domain",
+	"duser":                        "destination.user.name",
+	"duid":                         "destination.user.id",
+	"out":                          "destination.bytes",
+	"destinationTranslatedAddress": "destination.nat.ip",
+	"destinationTranslatedPort":    "destination.nat.port",
+	"dvc":                          "observer.ip",
+	"dvchost":                      "observer.hostname",
+	"dvcmac":                       "observer.mac",
+	"proto":                        "network.transport",
+	"app":                          "network.protocol",
+	"act":                          "event.action",
+	"outcome":                      "event.outcome",
+	"reason":                       "event.reason",
+	"rt":                           "@timestamp",
+	"start":                        "event.start",
+	"end":                          "event.end",
+	"request":                      "url.original",
+	"requestMethod":                "http.request.method",
+	"requestClientApplication":     "user_agent.original",
+	"fname":                        "file.name",
+	"filePath":                     "file.path",
+	"fsize":                        "file.size",
+}
+
+// ecsNumericFields lists the ECS fields that hold numbers instead of strings.
+var ecsNumericFields = map[string]bool{
+	"source.port":          true,
+	"source.bytes":         true,
+	"source.nat.port":      true,
+	"destination.port":     true,
+	"destination.bytes":    true,
+	"destination.nat.port": true,
+	"file.size":            true,
+}
+
+// ecsDateFields lists the ECS fields that hold dates, their values are
+// rendered in RFC 3339 as Elasticsearch expects them.
+var ecsDateFields = map[string]bool{
+	"@timestamp":  true,
+	"event.start": true,
+	"event.end":   true,
+}
+
+// ToECS maps the CefEvent onto Elastic Common Schema (ECS) fields.
+//
+// The CEF header fields are mapped onto the observer.* and event.* fields, the
+// extensions of the specification onto their ECS counterparts, e.g. src onto
+// source.ip and dpt onto destination.port, and all remaining extensions are kept
+// below cef.extensions.* so that no data is lost. Ports, byte counts and sizes
+// become numbers and the timestamps rt, start and end RFC 3339 dates in UTC,
+// values which can not be converted are kept as they are.
+//
+// Returns:
+// - A map with dotted ECS field names as keys.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToECS() (map[string]interface{}, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{
+		"cef.version":      event.Version,
+		"observer.vendor":  event.DeviceVendor,
+		"observer.product": event.DeviceProduct,
+		"observer.version": event.DeviceVersion,
+		"event.code":       event.DeviceEventClassId,
+		"message":          event.Name,
+		"event.severity":   event.Severity,
+	}
+
+	if severity, err := strconv.Atoi(event.Severity); err == nil {
+		doc["event.severity"] = severity
+	}
+
+	for k, v := range event.Extensions {
+
+		field, ok := ecsExtensionFields[k]
+		if !ok {
+			doc["cef.extensions."+k] = v
+			continue
+		}
+
+		if ecsNumericFields[field] {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				doc[field] = n
+				continue
+			}
+		}
+
+		if ecsDateFields[field] {
+			if t, err := ParseTimestamp(v); err == nil {
+				doc[field] = t.UTC().Format(time.RFC3339Nano)
+				continue
+			}
+		}
+
+		doc[field] = v
+	}
+
+	return doc, nil
+}
+
+// ToECSJSON converts the CefEvent into an Elastic Common Schema JSON document
+// using the mapping of ToECS.
+//
+// Returns:
+// - A JSON string with the ECS representation of the CefEvent.
+// - An error if the CefEvent is not valid or could not be marshaled.
+func (event *CefEvent) ToECSJSON() (string, error) {
+
+	doc, err := event.ToECS()
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
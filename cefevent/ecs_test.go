@@ -0,0 +1,60 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToECS(t *testing.T) {
+
+	e := event
+	e.Severity = "7"
+	e.Extensions = map[string]string{
+		"src":   "10.0.0.1",
+		"spt":   "51234",
+		"dst":   "10.0.0.2",
+		"dpt":   "https",
+		"suser": "alice",
+		"in":    "1024",
+		"proto": "TCP",
+		"act":   "blocked",
+		"rt":    "1700000000000",
+		"end":   "Nov 14 2023 21:13:20.500 UTC",
+		"start": "yesterday",
+		"cs1":   "custom",
+	}
+
+	got, err := e.ToECS()
+	if err != nil {
+		t.Fatalf("ToECS() = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"cef.version":        0,
+		"observer.vendor":    "Cool Vendor",
+		"observer.product":   "Cool Product",
+		"observer.version":   "1.0",
+		"event.code":         "COOL_THING",
+		"message":            "Something cool happened.",
+		"event.severity":     7,
+		"source.ip":          "10.0.0.1",
+		"source.port":        int64(51234),
+		"destination.ip":     "10.0.0.2",
+		"destination.port":   "https",
+		"source.user.name":   "alice",
+		"source.bytes":       int64(1024),
+		"network.transport":  "TCP",
+		"event.action":       "blocked",
+		"@timestamp":         "2023-11-14T22:13:20Z",
+		"event.end":          "2023-11-14T21:13:20.5Z",
+		"event.start":        "yesterday",
+		"cef.extensions.cs1": "custom",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToECS() = %v, want %v", got, want)
+	}
+
+	if _, err := (&CefEvent{}).ToECS(); err == nil {
+		t.Error("ToECS() = nil, want an error for an invalid event")
+	}
+}
@@ -0,0 +1,232 @@
+package cefevent
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Emitter delivers CEF events to one or more sinks.
+//
+// An Emitter is safe for concurrent use by multiple goroutines: every call to
+// Emit renders the event without touching shared state and the delivery to
+// the sinks is serialized, so messages are never interleaved on a destination.
+//
+// Each event is rendered once per distinct Format requested by the sinks, so
+// fanning out to multiple destinations with different wire formats does not
+// require any conversion code in the caller.
+//
+// Cross-cutting concerns such as enrichment, redaction or sampling can be
+// added to an Emitter as Middleware through Use, the lifecycle of the events
+// can be observed through Hooks.
+type Emitter struct {
+	mu          sync.Mutex
+	sinks       []Sink
+	middlewares []Middleware
+	handler     Handler
+	hooks       Hooks
+	// reached counts the events which left the middleware chain, Emit
+	// compares it before and after the chain to report drops to OnDropped.
+	reached atomic.Uint64
+}
+
+// NewEmitter returns an Emitter which writes all emitted events as plain CEF messages to w.
+//
+// Parameters:
+// - w: The io.Writer the CEF messages are written to, e.g. os.Stdout or a network connection.
+//
+// Returns:
+// - A pointer to an Emitter ready for concurrent use.
+func NewEmitter(w io.Writer) *Emitter {
+	return NewSinkEmitter(NewWriterSink(w, FormatCEF))
+}
+
+// NewSinkEmitter returns an Emitter which delivers all emitted events to the given sinks,
+// each rendered in the Format the sink declares.
+//
+// Parameters:
+// - sinks: The destinations the events are delivered to.
+//
+// Returns:
+// - A pointer to an Emitter ready for concurrent use.
+func NewSinkEmitter(sinks ...Sink) *Emitter {
+	return &Emitter{sinks: sinks}
+}
+
+// AddSink adds another destination to the Emitter, it may be called while
+// other goroutines are emitting events.
+func (e *Emitter) AddSink(sink Sink) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sinks = append(e.sinks, sink)
+}
+
+// Use appends middlewares to the chain every emitted event passes through
+// before it is delivered to the sinks. Middlewares run in the order they
+// were added, the first one sees the event first.
+//
+// Parameters:
+// - middlewares: The Middleware functions wrapping the delivery of events.
+func (e *Emitter) Use(middlewares ...Middleware) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.middlewares = append(e.middlewares, middlewares...)
+	e.rebuild()
+}
+
+// rebuild builds the middleware chain, whose innermost handler counts the
+// events leaving it in reached. It must be called with mu held.
+func (e *Emitter) rebuild() {
+
+	e.handler = chain(func(event CefEvent) error {
+		e.reached.Add(1)
+		return e.deliver(event)
+	}, e.middlewares)
+}
+
+// Emit passes the event through the middleware chain, renders it in the
+// Format of every sink and delivers it.
+//
+// The event is passed by value and is never modified, callers may therefore
+// keep using and emitting the same event from different goroutines.
+// A failing sink does not prevent delivery to the remaining sinks.
+//
+// Returns:
+// - An error if the event could not be rendered or delivered to one or more sinks; otherwise, returns nil.
+func (e *Emitter) Emit(event CefEvent) error {
+
+	e.mu.Lock()
+	handler := e.handler
+	onDropped := e.hooks.OnDropped
+	e.mu.Unlock()
+
+	if handler == nil {
+		return e.deliver(event)
+	}
+
+	before := e.reached.Load()
+	err := handler(event)
+	if onDropped != nil && e.reached.Load() == before {
+		onDropped(event, ErrDropped)
+	}
+
+	return err
+}
+
+// deliver is the innermost Handler of the Emitter, it renders the
+// event and hands it to all sinks.
+func (e *Emitter) deliver(event CefEvent) error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	hooks := e.hooks
+
+	if err := event.Validate(); err != nil {
+		if hooks.OnDropped != nil {
+			hooks.OnDropped(event, err)
+		}
+		return err
+	}
+
+	if len(e.sinks) == 0 {
+		return errors.New("emitter has no sinks")
+	}
+
+	if hooks.OnBuilt != nil {
+		hooks.OnBuilt(event)
+	}
+
+	// events are rendered once per combination of wire and timestamp format.
+	type rendering struct {
+		format Format
+		tf     TimestampFormat
+	}
+	rendered := make(map[rendering]string)
+	var errs []error
+	delivered := false
+
+	for _, sink := range e.sinks {
+
+		format := sink.Format()
+		formatted := event
+		key := rendering{format: format}
+		if timed, ok := sink.(TimestampSink); ok && timed.TimestampFormat() != TimestampKeep {
+			key.tf = timed.TimestampFormat()
+			formatted.Extensions = cloneExtensions(event.Extensions)
+			formatted.FormatTimestamps(key.tf)
+		}
+
+		message, ok := rendered[key]
+		if !ok {
+			var err error
+			message, err = formatted.Render(format)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			rendered[key] = message
+			if hooks.OnRendered != nil {
+				hooks.OnRendered(formatted, format, message)
+			}
+		}
+
+		if limited, ok := sink.(SizeLimitedSink); ok {
+			if limit := limited.MaxMessageSize(); limit > 0 && len(message) > limit {
+				trimmed, fits, err := formatted.TrimToSize(format, limit)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if !fits {
+					trimmed = truncateMessage(trimmed, limit)
+				}
+				message = trimmed
+			}
+		}
+
+		start := time.Now()
+		err := sink.Send(message)
+		if hooks.OnSent != nil {
+			hooks.OnSent(event, sink, time.Since(start), err)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+	}
+
+	if !delivered && hooks.OnDropped != nil {
+		hooks.OnDropped(event, errors.Join(errs...))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks of the Emitter which implement io.Closer.
+//
+// Returns:
+// - The errors of the sinks which failed to close, nil if all succeeded.
+func (e *Emitter) Close() error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []error
+	for _, sink := range e.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package cefevent
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEmitterEmit(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	if err := emitter.Emit(event); err != nil {
+		t.Fatalf("Emit() = %v, want nil", err)
+	}
+
+	want := eventLine + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Emit() wrote %q, want %q", got, want)
+	}
+}
+
+func TestEmitterEmitInvalid(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	brokenEvent := event
+	brokenEvent.Name = ""
+
+	if err := emitter.Emit(brokenEvent); err == nil {
+		t.Errorf("Emit() should fail for an event without a Name")
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Emit() wrote %q for an invalid event", buf.String())
+	}
+}
+
+func TestEmitterConcurrentEmit(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	escapingEvent := event
+	escapingEvent.Name = "pipe|in|name"
+	want, _ := escapingEvent.String()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := emitter.Emit(escapingEvent); err != nil {
+					t.Errorf("Emit() = %v, want nil", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("Emit() wrote %d lines, want 1000", len(lines))
+	}
+
+	for _, line := range lines {
+		if line != want {
+			t.Fatalf("Emit() wrote %q, want %q", line, want)
+		}
+	}
+}
+
+func TestCefEventStringIsIdempotent(t *testing.T) {
+
+	escapingEvent := event
+	escapingEvent.DeviceVendor = "\\Cool|Vendor"
+
+	first, _ := escapingEvent.String()
+	second, _ := escapingEvent.String()
+
+	if first != second {
+		t.Errorf("event.String() = %q on second call, want %q", second, first)
+	}
+
+	if escapingEvent.DeviceVendor != "\\Cool|Vendor" {
+		t.Errorf("event.String() modified DeviceVendor to %q", escapingEvent.DeviceVendor)
+	}
+}
+
+func TestEmitterFormatNegotiation(t *testing.T) {
+
+	var cefBuf, leefBuf, ecsBuf bytes.Buffer
+	emitter := NewSinkEmitter(
+		NewWriterSink(&cefBuf, FormatCEF),
+		NewWriterSink(&leefBuf, FormatLEEF),
+	)
+	emitter.AddSink(NewWriterSink(&ecsBuf, FormatECS))
+
+	if err := emitter.Emit(event); err != nil {
+		t.Fatalf("Emit() = %v, want nil", err)
+	}
+
+	if got, want := cefBuf.String(), eventLine+"\n"; got != want {
+		t.Errorf("CEF sink got %q, want %q", got, want)
+	}
+
+	wantLEEF, _ := event.ToLEEF()
+	if got := leefBuf.String(); got != wantLEEF+"\n" {
+		t.Errorf("LEEF sink got %q, want %q", got, wantLEEF+"\n")
+	}
+
+	wantECS, _ := event.ToECSJSON()
+	if got := ecsBuf.String(); got != wantECS+"\n" {
+		t.Errorf("ECS sink got %q, want %q", got, wantECS+"\n")
+	}
+}
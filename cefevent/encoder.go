@@ -0,0 +1,81 @@
+package cefevent
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// encoderBufferSize is the size of the buffer of an Encoder.
+const encoderBufferSize = 64 * 1024
+
+// Encoder writes CEF events as newline-delimited JSON (NDJSON) to an
+// io.Writer, the counterpart of the Decoder for log shippers which expect
+// NDJSON input. Every event is a line holding the JSON object of ToJSON.
+//
+// The lines are buffered, Flush writes them to the underlying writer and
+// must be called once the last event was encoded.
+type Encoder struct {
+	w          *bufio.Writer
+	json       *json.Encoder
+	flushEvery int
+	pending    int
+}
+
+// NewEncoder returns an Encoder writing NDJSON to w.
+//
+// Parameters:
+// - w: The io.Writer receiving the lines.
+//
+// Returns:
+// - A pointer to an Encoder.
+func NewEncoder(w io.Writer) *Encoder {
+
+	buffered := bufio.NewWriterSize(w, encoderBufferSize)
+
+	return &Encoder{w: buffered, json: json.NewEncoder(buffered)}
+}
+
+// SetFlushEvery flushes the lines after every n events, e.g. 1 for a
+// shipper tailing the output which should see every event right away. By
+// default the lines are only written when the buffer is full or on Flush.
+//
+// Parameters:
+// - n: The number of events after which the lines are flushed, 0 turns it off.
+func (e *Encoder) SetFlushEvery(n int) {
+	e.flushEvery = n
+}
+
+// Encode writes the event as a line of JSON.
+//
+// Returns:
+// - An error if the event is not valid or writing to the underlying writer failed.
+func (e *Encoder) Encode(event CefEvent) error {
+
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	// json.Encoder terminates every value with a newline.
+	if err := e.json.Encode(&event); err != nil {
+		return err
+	}
+
+	e.pending++
+	if e.flushEvery > 0 && e.pending >= e.flushEvery {
+		return e.Flush()
+	}
+
+	return nil
+}
+
+// Flush writes the buffered lines to the underlying writer.
+//
+// Returns:
+// - The error of the underlying writer.
+func (e *Encoder) Flush() error {
+
+	e.pending = 0
+
+	return e.w.Flush()
+}
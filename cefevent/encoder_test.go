@@ -0,0 +1,57 @@
+package cefevent
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncoderEncode(t *testing.T) {
+
+	var b bytes.Buffer
+	encoder := NewEncoder(&b)
+
+	for i := 0; i < 2; i++ {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Encode() = %v", err)
+		}
+	}
+	if err := encoder.Encode(CefEvent{}); err == nil {
+		t.Error("Encode() of an invalid event = nil, want an error")
+	}
+	if b.Len() != 0 {
+		t.Errorf("Encode() wrote %q before Flush()", b.String())
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatalf("Flush() = %v", err)
+	}
+
+	line, _ := event.ToJSON()
+	if want := line + "\n" + line + "\n"; b.String() != want {
+		t.Errorf("Encode() wrote %q, want %q", b.String(), want)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for _, l := range lines {
+		if got, err := FromJSON([]byte(l)); err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("FromJSON(%q) = %v, %v, want %v", l, got, err, event)
+		}
+	}
+}
+
+func TestEncoderFlushEvery(t *testing.T) {
+
+	var b bytes.Buffer
+	encoder := NewEncoder(&b)
+	encoder.SetFlushEvery(2)
+
+	encoder.Encode(event)
+	if b.Len() != 0 {
+		t.Errorf("Encode() flushed after the first event")
+	}
+	encoder.Encode(event)
+	if got := strings.Count(b.String(), "\n"); got != 2 {
+		t.Errorf("Encode() flushed %d lines, want 2", got)
+	}
+}
@@ -0,0 +1,186 @@
+package cefevent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxBinaryExtensionSize is the largest payload AddBinary accepts and
+// GetBinary returns, it keeps binary extensions well below the size limits
+// of collectors and protects readers from oversized values.
+const MaxBinaryExtensionSize = 64 * 1024
+
+// AddBinary stores a binary payload, such as a packet capture or a file
+// header, base64 encoded in the extension key. The unpadded standard
+// encoding is used, so the value never contains characters which would have
+// to be escaped.
+//
+// Parameters:
+// - key: The extension key, e.g. a custom string extension such as "cs1".
+// - data: The payload, at most MaxBinaryExtensionSize bytes.
+//
+// Returns:
+// - An error if the payload exceeds MaxBinaryExtensionSize.
+func (event *CefEvent) AddBinary(key string, data []byte) error {
+
+	if len(data) > MaxBinaryExtensionSize {
+		return fmt.Errorf("binary extension %s of %d bytes exceeds %d bytes", key, len(data), MaxBinaryExtensionSize)
+	}
+
+	event.setExtension(key, base64.RawStdEncoding.EncodeToString(data))
+
+	return nil
+}
+
+// GetBinary decodes the binary payload stored by AddBinary in the extension
+// key. Padded base64 values as produced by other implementations are
+// accepted as well.
+//
+// Returns:
+// - The decoded payload.
+// - An error if the extension is missing, not valid base64 or its payload exceeds MaxBinaryExtensionSize.
+func (event *CefEvent) GetBinary(key string) ([]byte, error) {
+
+	value, ok := event.Extensions[key]
+	if !ok {
+		return nil, fmt.Errorf("extension %s is not set", key)
+	}
+
+	// reject oversized values before decoding them, padding may account for two extra bytes.
+	if base64.RawStdEncoding.DecodedLen(len(value)) > MaxBinaryExtensionSize+2 {
+		return nil, fmt.Errorf("binary extension %s exceeds %d bytes", key, MaxBinaryExtensionSize)
+	}
+
+	encoding := base64.RawStdEncoding
+	if len(value) > 0 && value[len(value)-1] == '=' {
+		encoding = base64.StdEncoding
+	}
+
+	data, err := encoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("extension %s is not valid base64: %w", key, err)
+	}
+
+	if len(data) > MaxBinaryExtensionSize {
+		return nil, fmt.Errorf("binary extension %s exceeds %d bytes", key, MaxBinaryExtensionSize)
+	}
+
+	return data, nil
+}
+
+// setExtension stores value in the extension key, creating the map when needed.
+func (event *CefEvent) setExtension(key string, value string) {
+
+	if event.Extensions == nil {
+		event.Extensions = make(map[string]string)
+	}
+
+	event.Extensions[key] = value
+}
+
+// AddInt stores n as decimal number in the extension key, as the Integer
+// and Long extensions of the specification, e.g. cnt, spt or cn1, expect.
+//
+// Parameters:
+// - key: The extension key.
+// - n: The number.
+func (event *CefEvent) AddInt(key string, n int64) {
+	event.setExtension(key, strconv.FormatInt(n, 10))
+}
+
+// GetInt parses the decimal number stored in the extension key.
+//
+// Returns:
+// - The number.
+// - An error if the extension is missing or not a decimal number.
+func (event *CefEvent) GetInt(key string) (int64, error) {
+
+	value, ok := event.Extensions[key]
+	if !ok {
+		return 0, fmt.Errorf("extension %s is not set", key)
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("extension %s is not a number: %w", key, err)
+	}
+
+	return n, nil
+}
+
+// AddByteCount stores a size or transferred amount in bytes in the
+// extension key, such as in, out, fsize or oldFileSize.
+//
+// Parameters:
+// - key: The extension key.
+// - n: The number of bytes.
+//
+// Returns:
+// - An error if n is negative.
+func (event *CefEvent) AddByteCount(key string, n int64) error {
+
+	if n < 0 {
+		return fmt.Errorf("byte count %s must not be negative, got %d", key, n)
+	}
+
+	event.AddInt(key, n)
+
+	return nil
+}
+
+// GetByteCount parses the number of bytes stored in the extension key.
+//
+// Returns:
+// - The number of bytes.
+// - An error if the extension is missing, not a number or negative.
+func (event *CefEvent) GetByteCount(key string) (int64, error) {
+
+	n, err := event.GetInt(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("byte count %s must not be negative, got %d", key, n)
+	}
+
+	return n, nil
+}
+
+// AddDuration stores d as whole milliseconds in the extension key, the unit
+// CEF uses for all times. Fractions of a millisecond are truncated.
+//
+// Parameters:
+// - key: The extension key, e.g. a custom number extension such as "cn1".
+// - d: The duration.
+func (event *CefEvent) AddDuration(key string, d time.Duration) {
+	event.AddInt(key, d.Milliseconds())
+}
+
+// GetDuration parses the duration stored in the extension key, either as
+// milliseconds as written by AddDuration or in the notation of
+// time.ParseDuration, e.g. "1m30s", as some devices emit it.
+//
+// Returns:
+// - The duration.
+// - An error if the extension is missing or not a duration.
+func (event *CefEvent) GetDuration(key string) (time.Duration, error) {
+
+	value, ok := event.Extensions[key]
+	if !ok {
+		return 0, fmt.Errorf("extension %s is not set", key)
+	}
+
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(millis) * time.Millisecond, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("extension %s is not a duration: %w", key, err)
+	}
+
+	return d, nil
+}
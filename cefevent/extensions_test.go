@@ -0,0 +1,102 @@
+package cefevent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAddBinary(t *testing.T) {
+
+	payload := []byte{0xd4, 0xc3, 0xb2, 0xa1, 0x00, 0xff, '=', '\n'}
+
+	var binaryEvent CefEvent
+	if err := binaryEvent.AddBinary("cs1", payload); err != nil {
+		t.Fatalf("AddBinary() = %v", err)
+	}
+
+	got, err := binaryEvent.GetBinary("cs1")
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Errorf("GetBinary() = %v, %v, want %v", got, err, payload)
+	}
+
+	if err := binaryEvent.AddBinary("cs2", make([]byte, MaxBinaryExtensionSize+1)); err == nil {
+		t.Errorf("AddBinary() should fail for an oversized payload")
+	}
+}
+
+func TestGetBinary(t *testing.T) {
+
+	binaryEvent := CefEvent{Extensions: map[string]string{
+		"padded":  "aGk=",
+		"invalid": "not*base64",
+		"huge":    string(bytes.Repeat([]byte("A"), MaxBinaryExtensionSize*2)),
+	}}
+
+	if got, err := binaryEvent.GetBinary("padded"); err != nil || string(got) != "hi" {
+		t.Errorf("GetBinary() = %q, %v, want %q", got, err, "hi")
+	}
+
+	for _, key := range []string{"missing", "invalid", "huge"} {
+		if _, err := binaryEvent.GetBinary(key); err == nil {
+			t.Errorf("GetBinary(%q) should fail", key)
+		}
+	}
+}
+
+func TestIntExtensions(t *testing.T) {
+
+	var counted CefEvent
+	counted.AddInt("cnt", -42)
+	if err := counted.AddByteCount("in", 1<<40); err != nil {
+		t.Fatalf("AddByteCount() = %v", err)
+	}
+
+	if counted.Extensions["cnt"] != "-42" || counted.Extensions["in"] != "1099511627776" {
+		t.Errorf("extensions = %v", counted.Extensions)
+	}
+
+	if n, err := counted.GetInt("cnt"); err != nil || n != -42 {
+		t.Errorf("GetInt() = %d, %v, want -42", n, err)
+	}
+	if n, err := counted.GetByteCount("in"); err != nil || n != 1<<40 {
+		t.Errorf("GetByteCount() = %d, %v, want %d", n, err, int64(1<<40))
+	}
+
+	if err := counted.AddByteCount("out", -1); err == nil {
+		t.Errorf("AddByteCount() should fail for a negative count")
+	}
+	if _, err := counted.GetByteCount("cnt"); err == nil {
+		t.Errorf("GetByteCount() should fail for a negative count")
+	}
+	if _, err := counted.GetInt("missing"); err == nil {
+		t.Errorf("GetInt() should fail for a missing extension")
+	}
+
+	counted.Extensions["fsize"] = "1.5e3"
+	if _, err := counted.GetInt("fsize"); err == nil {
+		t.Errorf("GetInt() should fail for a value which is not a decimal number")
+	}
+}
+
+func TestDurationExtensions(t *testing.T) {
+
+	var timed CefEvent
+	timed.AddDuration("cn1", 90*time.Second+500*time.Microsecond)
+
+	if timed.Extensions["cn1"] != "90000" {
+		t.Errorf("AddDuration() = %q, want %q", timed.Extensions["cn1"], "90000")
+	}
+
+	timed.Extensions["cn2"] = "1m30s"
+	for _, key := range []string{"cn1", "cn2"} {
+		if d, err := timed.GetDuration(key); err != nil || d != 90*time.Second {
+			t.Errorf("GetDuration(%q) = %v, %v, want 1m30s", key, d, err)
+		}
+	}
+
+	timed.Extensions["cn3"] = "soon"
+	if _, err := timed.GetDuration("cn3"); err == nil {
+		t.Errorf("GetDuration() should fail for an invalid value")
+	}
+}
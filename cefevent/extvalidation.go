@@ -0,0 +1,93 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ExtensionPolicy tells ValidateExtensions what to do with extensions
+// violating the dictionary of the CEF specification.
+type ExtensionPolicy int
+
+const (
+	ExtensionPolicyWarn  ExtensionPolicy = iota // ExtensionPolicyWarn reports the violations and keeps the extensions.
+	ExtensionPolicyDrop                         // ExtensionPolicyDrop reports the violations and removes the extensions from the event.
+	ExtensionPolicyError                        // ExtensionPolicyError reports the violations and returns them as error.
+)
+
+// String returns "warn", "drop" or "error".
+func (p ExtensionPolicy) String() string {
+
+	switch p {
+	case ExtensionPolicyWarn:
+		return "warn"
+	case ExtensionPolicyDrop:
+		return "drop"
+	case ExtensionPolicyError:
+		return "error"
+	}
+
+	return fmt.Sprintf("ExtensionPolicy(%d)", int(p))
+}
+
+// ValidateExtensions checks the extensions of the event against the
+// dictionary of the CEF specification, see Dictionary, for consumers such
+// as ArcSight which drop or misread extensions they do not know. Keys the
+// dictionary does not define, e.g. vendor specific ones or full names such
+// as "sourceAddress" instead of "src", values not matching the type of
+// their extension and values exceeding its length are violations.
+//
+// Parameters:
+// - policy: Whether violating extensions are kept, removed from the event or returned as error.
+//
+// Returns:
+// - An Issue for every violation, sorted by key, of level IssueError for ExtensionPolicyError, IssueWarning otherwise.
+// - For ExtensionPolicyError, an error joining the violations, each wrapping ErrMalformedExtension.
+func (event *CefEvent) ValidateExtensions(policy ExtensionPolicy) ([]Issue, error) {
+
+	level := IssueWarning
+	var err error
+	if policy == ExtensionPolicyError {
+		level, err = IssueError, ErrMalformedExtension
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var issues []Issue
+	for _, key := range keys {
+		var violations []string
+
+		definition, ok := LookupExtension(key)
+		switch {
+		case !ok:
+			violations = []string{"key is not defined by the CEF specification"}
+		case definition.Key != key:
+			violations = []string{fmt.Sprintf("key is the full name of %s", definition.Key)}
+		default:
+			violations = dictionaryViolations(definition, event.Extensions[key])
+		}
+
+		for _, violation := range violations {
+			issues = append(issues, Issue{Level: level, Field: key, Message: violation, Err: err})
+		}
+		if len(violations) > 0 && policy == ExtensionPolicyDrop {
+			delete(event.Extensions, key)
+		}
+	}
+
+	if policy != ExtensionPolicyError || len(issues) == 0 {
+		return issues, nil
+	}
+
+	errs := make([]error, len(issues))
+	for i, issue := range issues {
+		errs[i] = fmt.Errorf("extension %s: %s: %w", issue.Field, issue.Message, issue.Err)
+	}
+
+	return issues, errors.Join(errs...)
+}
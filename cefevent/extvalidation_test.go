@@ -0,0 +1,69 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCefEventValidateExtensions(t *testing.T) {
+
+	extensions := func() map[string]string {
+		return map[string]string{
+			"src":           "127.0.0.1",
+			"spt":           "http",
+			"act":           strings.Repeat("a", 64),
+			"sourceAddress": "10.0.0.1",
+			"vendorKey":     "value",
+		}
+	}
+
+	wantFields := []string{"act", "sourceAddress", "spt", "vendorKey"}
+	fields := func(issues []Issue) []string {
+		var fields []string
+		for _, issue := range issues {
+			fields = append(fields, issue.Field)
+		}
+		return fields
+	}
+
+	e := event
+	e.Extensions = extensions()
+	issues, err := e.ValidateExtensions(ExtensionPolicyWarn)
+	if err != nil || !reflect.DeepEqual(fields(issues), wantFields) || len(e.Extensions) != 5 {
+		t.Errorf("ValidateExtensions(warn) = %v, %v, extensions %v, want issues of %v", issues, err, e.Extensions, wantFields)
+	}
+	for _, issue := range issues {
+		if issue.Level != IssueWarning || issue.Err != nil {
+			t.Errorf("ValidateExtensions(warn) issue = %+v, want a warning", issue)
+		}
+	}
+	if issues[1].Message != "key is the full name of src" {
+		t.Errorf("ValidateExtensions() issue = %q, want the key of the full name", issues[1].Message)
+	}
+
+	e.Extensions = extensions()
+	issues, err = e.ValidateExtensions(ExtensionPolicyDrop)
+	if err != nil || len(issues) != 4 || !reflect.DeepEqual(e.Extensions, map[string]string{"src": "127.0.0.1"}) {
+		t.Errorf("ValidateExtensions(drop) = %v, %v, extensions %v, want only src kept", issues, err, e.Extensions)
+	}
+
+	e.Extensions = extensions()
+	issues, err = e.ValidateExtensions(ExtensionPolicyError)
+	if !errors.Is(err, ErrMalformedExtension) || len(issues) != 4 || issues[0].Level != IssueError || len(e.Extensions) != 5 {
+		t.Errorf("ValidateExtensions(error) = %v, %v, want errors wrapping ErrMalformedExtension", issues, err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "extension spt: value \"http\" is no Integer") {
+		t.Errorf("ValidateExtensions(error) = %q, want the violation of spt", err)
+	}
+
+	e.Extensions = map[string]string{"src": "127.0.0.1", "cs1": "value", "cs1Label": "label"}
+	if issues, err := e.ValidateExtensions(ExtensionPolicyError); err != nil || len(issues) != 0 {
+		t.Errorf("ValidateExtensions() of valid extensions = %v, %v", issues, err)
+	}
+
+	if got := ExtensionPolicyDrop.String(); got != "drop" {
+		t.Errorf("String() = %q, want drop", got)
+	}
+}
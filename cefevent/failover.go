@@ -0,0 +1,267 @@
+package cefevent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// BalanceMode decides how a FailoverSink distributes messages over its endpoints.
+type BalanceMode int
+
+const (
+	// BalanceFailover sends every message to the first healthy endpoint, the
+	// following endpoints are only used while the preceding ones are down.
+	BalanceFailover BalanceMode = iota
+	// BalanceRoundRobin spreads the messages evenly over all healthy endpoints.
+	BalanceRoundRobin
+)
+
+// String returns the name of the BalanceMode as accepted by ParseBalanceMode.
+func (m BalanceMode) String() string {
+	switch m {
+	case BalanceFailover:
+		return "failover"
+	case BalanceRoundRobin:
+		return "round_robin"
+	}
+	return fmt.Sprintf("BalanceMode(%d)", int(m))
+}
+
+// ParseBalanceMode returns the BalanceMode with the given name, an empty
+// name selects BalanceFailover.
+//
+// Returns:
+// - The BalanceMode matching the name.
+// - An error if no BalanceMode with that name exists.
+func ParseBalanceMode(name string) (BalanceMode, error) {
+
+	switch name {
+	case "", "failover":
+		return BalanceFailover, nil
+	case "round_robin":
+		return BalanceRoundRobin, nil
+	}
+
+	return BalanceFailover, fmt.Errorf("unknown balance mode %q", name)
+}
+
+// FailoverOptions configures a FailoverSink.
+type FailoverOptions struct {
+	Mode          BalanceMode   // Mode selects failover or round-robin distribution.
+	RetryInterval time.Duration // RetryInterval is how long a failed endpoint is skipped before it is tried again, defaults to 30 seconds.
+}
+
+// FailoverSink is a Sink distributing messages over multiple endpoints, so
+// that the outage of a single collector does not stall the delivery.
+//
+// Endpoints are health-checked passively: an endpoint whose Send fails is
+// marked down and skipped for the retry interval, the message is sent to the
+// next healthy endpoint instead. Once the interval has passed the endpoint
+// receives traffic again and is marked healthy when it succeeds. A collector
+// which accepts connections but stops reading fails over as well, as the
+// writes of TCPSink time out, see TCPSinkOptions.WriteTimeout.
+type FailoverSink struct {
+	mu        sync.Mutex
+	endpoints []Sink
+	downUntil []time.Time
+	next      int
+	opts      FailoverOptions
+	now       func() time.Time
+}
+
+// NewFailoverSink returns a Sink distributing messages over the endpoints.
+//
+// Parameters:
+// - endpoints: The sinks of the individual collectors, which all must use the same Format.
+// - opts: The balance mode and retry interval, zero values select the defaults.
+//
+// Returns:
+// - A pointer to a FailoverSink.
+// - An error if no endpoints are given or their formats differ.
+func NewFailoverSink(endpoints []Sink, opts FailoverOptions) (*FailoverSink, error) {
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("failover sink has no endpoints")
+	}
+
+	for _, endpoint := range endpoints[1:] {
+		if endpoint.Format() != endpoints[0].Format() {
+			return nil, errors.New("failover sink endpoints use different formats")
+		}
+	}
+
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 30 * time.Second
+	}
+
+	return &FailoverSink{
+		endpoints: endpoints,
+		downUntil: make([]time.Time, len(endpoints)),
+		opts:      opts,
+		now:       time.Now,
+	}, nil
+}
+
+// Format returns the wire format shared by all endpoints.
+func (s *FailoverSink) Format() Format {
+	return s.endpoints[0].Format()
+}
+
+// MaxMessageSize returns the smallest message size limit of the endpoints,
+// so the Emitter trims messages to fit any endpoint they may be sent to.
+func (s *FailoverSink) MaxMessageSize() int {
+
+	limit := 0
+	for _, endpoint := range s.endpoints {
+		if limited, ok := endpoint.(SizeLimitedSink); ok {
+			if l := limited.MaxMessageSize(); l > 0 && (limit == 0 || l < limit) {
+				limit = l
+			}
+		}
+	}
+
+	return limit
+}
+
+// Send delivers the message to the first healthy endpoint in the order of
+// the balance mode. When all endpoints are down the one which failed first
+// is tried regardless, so messages are never dropped without an attempt.
+//
+// Returns:
+// - The errors of all endpoints when none of them accepted the message; otherwise, returns nil.
+func (s *FailoverSink) Send(message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := 0
+	if s.opts.Mode == BalanceRoundRobin {
+		start = s.next
+		s.next = (s.next + 1) % len(s.endpoints)
+	}
+
+	now := s.now()
+	var errs []error
+	attempted := false
+
+	for i := range s.endpoints {
+		n := (start + i) % len(s.endpoints)
+		if now.Before(s.downUntil[n]) {
+			continue
+		}
+
+		attempted = true
+		if err := s.endpoints[n].Send(message); err != nil {
+			s.downUntil[n] = now.Add(s.opts.RetryInterval)
+			errs = append(errs, err)
+			continue
+		}
+
+		s.downUntil[n] = time.Time{}
+		return nil
+	}
+
+	if !attempted {
+		n := s.earliestRetry()
+		if err := s.endpoints[n].Send(message); err != nil {
+			s.downUntil[n] = now.Add(s.opts.RetryInterval)
+			return err
+		}
+		s.downUntil[n] = time.Time{}
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// earliestRetry returns the index of the endpoint which is due to be retried first.
+func (s *FailoverSink) earliestRetry() int {
+
+	earliest := 0
+	for n := range s.downUntil {
+		if s.downUntil[n].Before(s.downUntil[earliest]) {
+			earliest = n
+		}
+	}
+
+	return earliest
+}
+
+// Healthy reports for every endpoint, in the order they were given, whether it is currently considered up.
+func (s *FailoverSink) Healthy() []bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	healthy := make([]bool, len(s.endpoints))
+	for n := range s.endpoints {
+		healthy[n] = !now.Before(s.downUntil[n])
+	}
+
+	return healthy
+}
+
+// Close closes all endpoints which implement io.Closer.
+func (s *FailoverSink) Close() error {
+
+	var errs []error
+	for _, endpoint := range s.endpoints {
+		if closer, ok := endpoint.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ResolveEndpoints resolves the host of address and returns one address per
+// A or AAAA record, so that a collector cluster behind a single DNS name
+// can be used as endpoints of a FailoverSink. IP literals are returned as is.
+//
+// Parameters:
+// - address: The address of the collectors, e.g. "collectors.example.com:514".
+// - family: Restricts the returned addresses to one IP version.
+//
+// Returns:
+// - The resolved "ip:port" addresses.
+// - An error if the address is malformed or the host could not be resolved.
+func ResolveEndpoints(address string, family AddressFamily) ([]string, error) {
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []netip.Addr
+	if ip, err := netip.ParseAddr(host); err == nil {
+		ips = append(ips, ip)
+	} else {
+		resolved, err := net.DefaultResolver.LookupNetIP(context.Background(), "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		ips = resolved
+	}
+
+	var endpoints []string
+	for _, ip := range ips {
+		isIPv4 := ip.Unmap().Is4()
+		if (family == FamilyIPv4 && !isIPv4) || (family == FamilyIPv6 && isIPv4) {
+			continue
+		}
+		endpoints = append(endpoints, net.JoinHostPort(ip.Unmap().String(), port))
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no %s addresses found for %s", family, host)
+	}
+
+	return endpoints, nil
+}
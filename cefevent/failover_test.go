@@ -0,0 +1,158 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeEndpoint struct {
+	down     bool
+	messages []string
+}
+
+func (e *fakeEndpoint) Format() Format {
+	return FormatCEF
+}
+
+func (e *fakeEndpoint) Send(message string) error {
+	if e.down {
+		return errors.New("endpoint is down")
+	}
+	e.messages = append(e.messages, message)
+	return nil
+}
+
+func TestFailoverSink(t *testing.T) {
+
+	primary, secondary := &fakeEndpoint{}, &fakeEndpoint{}
+	sink, err := NewFailoverSink([]Sink{primary, secondary}, FailoverOptions{RetryInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(0, 0)
+	sink.now = func() time.Time { return now }
+
+	sink.Send("a")
+	primary.down = true
+	sink.Send("b")
+	primary.down = false
+	sink.Send("c")
+
+	if !reflect.DeepEqual(primary.messages, []string{"a"}) || !reflect.DeepEqual(secondary.messages, []string{"b", "c"}) {
+		t.Errorf("endpoints received %v and %v, want [a] and [b c]", primary.messages, secondary.messages)
+	}
+
+	if want := []bool{false, true}; !reflect.DeepEqual(sink.Healthy(), want) {
+		t.Errorf("Healthy() = %v, want %v", sink.Healthy(), want)
+	}
+
+	// the primary endpoint is retried once the retry interval has passed.
+	now = now.Add(time.Minute)
+	sink.Send("d")
+	if !reflect.DeepEqual(primary.messages, []string{"a", "d"}) {
+		t.Errorf("primary received %v after the retry interval, want [a d]", primary.messages)
+	}
+
+	primary.down, secondary.down = true, true
+	if err := sink.Send("e"); err == nil {
+		t.Errorf("Send() should fail when all endpoints are down")
+	}
+}
+
+func TestFailoverSinkStalledCollector(t *testing.T) {
+
+	listener := stalledListener(t)
+
+	primary, err := NewTCPSink(listener.Addr().String(), TCPSinkOptions{WriteTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewTCPSink() = %v", err)
+	}
+	defer primary.Close()
+
+	secondary := &fakeEndpoint{}
+	sink, err := NewFailoverSink([]Sink{primary, secondary}, FailoverOptions{RetryInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages fill the socket buffers until the primary times out.
+	message := strings.Repeat("x", 1024*1024)
+	for i := 0; i < 256 && len(secondary.messages) == 0; i++ {
+		if err := sink.Send(message); err != nil {
+			t.Fatalf("Send() = %v, want the message to fail over", err)
+		}
+	}
+
+	if len(secondary.messages) == 0 {
+		t.Fatalf("no message failed over to the secondary endpoint")
+	}
+	if want := []bool{false, true}; !reflect.DeepEqual(sink.Healthy(), want) {
+		t.Errorf("Healthy() = %v, want %v", sink.Healthy(), want)
+	}
+}
+
+func TestFailoverSinkRoundRobin(t *testing.T) {
+
+	first, second := &fakeEndpoint{}, &fakeEndpoint{}
+	sink, _ := NewFailoverSink([]Sink{first, second}, FailoverOptions{Mode: BalanceRoundRobin})
+
+	for _, message := range []string{"a", "b", "c"} {
+		sink.Send(message)
+	}
+
+	if !reflect.DeepEqual(first.messages, []string{"a", "c"}) || !reflect.DeepEqual(second.messages, []string{"b"}) {
+		t.Errorf("endpoints received %v and %v, want [a c] and [b]", first.messages, second.messages)
+	}
+}
+
+func TestNewFailoverSinkFormats(t *testing.T) {
+
+	if _, err := NewFailoverSink(nil, FailoverOptions{}); err == nil {
+		t.Errorf("NewFailoverSink() should fail without endpoints")
+	}
+
+	if _, err := NewFailoverSink([]Sink{&fakeEndpoint{}, NewWriterSink(nil, FormatLEEF)}, FailoverOptions{}); err == nil {
+		t.Errorf("NewFailoverSink() should fail for endpoints with different formats")
+	}
+}
+
+func TestResolveEndpoints(t *testing.T) {
+
+	got, err := ResolveEndpoints("[fe80::1%eth0]:514", FamilyAny)
+	if err != nil || !reflect.DeepEqual(got, []string{"[fe80::1%eth0]:514"}) {
+		t.Errorf("ResolveEndpoints() = %v, %v, want the literal address", got, err)
+	}
+
+	if _, err := ResolveEndpoints("127.0.0.1:514", FamilyIPv6); err == nil {
+		t.Errorf("ResolveEndpoints() should fail when no address of the family exists")
+	}
+
+	if _, err := ResolveEndpoints("localhost", FamilyAny); err == nil {
+		t.Errorf("ResolveEndpoints() should fail for an address without port")
+	}
+}
+
+func TestNetworkSinkConfig(t *testing.T) {
+
+	sink, err := sinkFactories["tcp"](SinkConfig{
+		Address:   "127.0.0.1:1",
+		Addresses: []string{"127.0.0.1:2"},
+		Balance:   "round_robin",
+	}, FormatCEF)
+	if err != nil {
+		t.Fatalf("tcp sink factory = %v", err)
+	}
+	defer sink.(*FailoverSink).Close()
+
+	if got := len(sink.(*FailoverSink).endpoints); got != 2 {
+		t.Errorf("failover sink has %d endpoints, want 2", got)
+	}
+
+	if _, err := sinkFactories["udp"](SinkConfig{Address: "127.0.0.1:1", Addresses: []string{"127.0.0.1:2"}, Balance: "random"}, FormatCEF); err == nil {
+		t.Errorf("udp sink factory should fail for an unknown balance mode")
+	}
+}
@@ -0,0 +1,199 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// FileOptions control the permissions and ownership of the files written
+// by file sinks, quarantines and failure captures. Security logs often
+// have to be readable by a dedicated group only, the zero value keeps the
+// defaults of the writer and the ownership of the running process.
+type FileOptions struct {
+	Mode  os.FileMode // Mode is the permission of the file, it is enforced on existing files as well. Zero keeps the default.
+	Owner string      // Owner is the user name or numeric user ID the file is handed to, empty keeps the owner.
+	Group string      // Group is the group name or numeric group ID the file is handed to, empty keeps the group.
+	// Atomic writes the file under a temporary name and renames it onto
+	// its path when it is closed, so readers never see a partial file.
+	// An existing file is replaced instead of appended to. Only file sinks
+	// support it.
+	Atomic bool
+}
+
+// ids resolves the owner and group, -1 leaves them unchanged.
+func (o FileOptions) ids() (int, int, error) {
+
+	uid, gid := -1, -1
+
+	if o.Owner != "" {
+		id := o.Owner
+		if _, err := strconv.Atoi(id); err != nil {
+			u, err := user.Lookup(o.Owner)
+			if err != nil {
+				return 0, 0, err
+			}
+			id = u.Uid
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, 0, fmt.Errorf("owner %s has no numeric user ID", o.Owner)
+		}
+		uid = n
+	}
+
+	if o.Group != "" {
+		id := o.Group
+		if _, err := strconv.Atoi(id); err != nil {
+			g, err := user.LookupGroup(o.Group)
+			if err != nil {
+				return 0, 0, err
+			}
+			id = g.Gid
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, 0, fmt.Errorf("group %s has no numeric group ID", o.Group)
+		}
+		gid = n
+	}
+
+	return uid, gid, nil
+}
+
+// apply enforces the mode and ownership on an open file.
+func (o FileOptions) apply(file *os.File, mode os.FileMode) error {
+
+	if o.Mode != 0 {
+		mode = o.Mode
+	}
+	if err := file.Chmod(mode.Perm()); err != nil {
+		return err
+	}
+
+	uid, gid, err := o.ids()
+	if err != nil {
+		return err
+	}
+	if uid != -1 || gid != -1 {
+		return file.Chown(uid, gid)
+	}
+
+	return nil
+}
+
+// openAppend opens the file at path for appending, creating it when needed,
+// and enforces the options on it.
+func openAppend(path string, opts FileOptions, mode os.FileMode) (*os.File, error) {
+
+	if opts.Mode != 0 {
+		mode = opts.Mode
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode.Perm())
+	if err != nil {
+		return nil, err
+	}
+
+	// the permission of OpenFile is reduced by the umask and does not
+	// apply to existing files, which is only acceptable for the defaults.
+	if opts.Mode == 0 && opts.Owner == "" && opts.Group == "" {
+		return file, nil
+	}
+
+	if err := opts.apply(file, mode); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// atomicFile is a temporary file which is renamed onto its path when it is closed.
+type atomicFile struct {
+	*os.File
+	path string
+	opts FileOptions
+	mode os.FileMode
+}
+
+// createAtomic creates a temporary file in the directory of path, which
+// replaces the file at path when it is closed.
+func createAtomic(path string, opts FileOptions, mode os.FileMode) (*atomicFile, error) {
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &atomicFile{File: file, path: path, opts: opts, mode: mode}, nil
+}
+
+// Close applies the options to the temporary file and renames it onto its
+// path. The temporary file is removed when that fails.
+func (f *atomicFile) Close() error {
+
+	err := f.opts.apply(f.File, f.mode)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// writeFileAtomic writes the file through a temporary file in the same
+// directory, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte, opts FileOptions, mode os.FileMode) error {
+
+	file, err := createAtomic(path, opts, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.File.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	return file.Close()
+}
+
+// FileConfig holds the FileOptions of a pipeline configuration.
+type FileConfig struct {
+	Mode  string `json:"mode,omitempty"`  // Mode is the octal permission of written files, e.g. "0640".
+	Owner string `json:"owner,omitempty"` // Owner is the user name or ID written files are handed to.
+	Group string `json:"group,omitempty"` // Group is the group name or ID written files are handed to.
+}
+
+// options converts the configuration into FileOptions.
+func (c FileConfig) options() (FileOptions, error) {
+
+	opts := FileOptions{Owner: c.Owner, Group: c.Group}
+
+	if c.Mode != "" {
+		mode, err := strconv.ParseUint(c.Mode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return FileOptions{}, fmt.Errorf("file mode %q is no octal permission", c.Mode)
+		}
+		if mode == 0 {
+			return FileOptions{}, errors.New("file mode 0 makes the files inaccessible")
+		}
+		opts.Mode = os.FileMode(mode)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,119 @@
+package cefevent
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestFileSinkWithOptions(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "events.log")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := FileOptions{Mode: 0o640, Owner: strconv.Itoa(os.Getuid()), Group: strconv.Itoa(os.Getgid())}
+	sink, err := NewFileSinkWithOptions(path, FormatCEF, opts)
+	if err != nil {
+		t.Fatalf("NewFileSinkWithOptions() = %v", err)
+	}
+	sink.Close()
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, %v, want %v", info.Mode().Perm(), err, os.FileMode(0o640))
+	}
+
+	if _, err := NewFileSinkWithOptions(path, FormatCEF, FileOptions{Owner: "no-such-user-for-cef"}); err == nil {
+		t.Errorf("NewFileSinkWithOptions() should fail for an unknown owner")
+	}
+}
+
+func TestFileSinkAtomic(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "events.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sink, err := NewFileSinkWithOptions(path, FormatCEF, FileOptions{Atomic: true, Mode: 0o600})
+	if err != nil {
+		t.Fatalf("NewFileSinkWithOptions() = %v", err)
+	}
+	sink.Send("new")
+
+	if data, _ := os.ReadFile(path); string(data) != "old\n" {
+		t.Errorf("file = %q before Close(), want the old content", data)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("file = %q after Close(), want %q", data, "new\n")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want the file only", len(entries))
+	}
+
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+			t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+		}
+	}
+}
+
+func TestQuarantineWithOptions(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "quarantine.jsonl")
+
+	if _, err := NewQuarantineWithOptions(path, 0, FileOptions{Atomic: true}); err == nil {
+		t.Errorf("NewQuarantineWithOptions() should fail for atomic files")
+	}
+
+	q, err := NewQuarantineWithOptions(path, 0, FileOptions{Mode: 0o640})
+	if err != nil {
+		t.Fatalf("NewQuarantineWithOptions() = %v", err)
+	}
+	q.Close()
+
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+			t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o640))
+		}
+	}
+}
+
+func TestFileConfigOptions(t *testing.T) {
+
+	var tests = []struct {
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0640", 0o640, false},
+		{"600", 0o600, false},
+		{"0", 0, true},
+		{"0999", 0, true},
+		{"01777", 0, true},
+		{"rw-r-----", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := FileConfig{Mode: tt.mode}.options()
+		if (err != nil) != tt.wantErr || got.Mode != tt.want {
+			t.Errorf("options(%q) = %v, %v, want %v", tt.mode, got.Mode, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package cefevent
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// FieldValue returns the value of a header field, addressed by its struct
+// field name (e.g. "DeviceVendor" or "Severity"), or of an extension key.
+//
+// Parameters:
+// - name: The header field name or extension key.
+//
+// Returns:
+// - The value of the field.
+// - Whether the field is set on the event.
+func (event *CefEvent) FieldValue(name string) (string, bool) {
+
+	switch name {
+	case "Version":
+		return strconv.Itoa(event.Version), true
+	case "DeviceVendor":
+		return event.DeviceVendor, true
+	case "DeviceProduct":
+		return event.DeviceProduct, true
+	case "DeviceVersion":
+		return event.DeviceVersion, true
+	case "DeviceEventClassId":
+		return event.DeviceEventClassId, true
+	case "Name":
+		return event.Name, true
+	case "Severity":
+		return event.Severity, true
+	}
+
+	value, ok := event.Extensions[name]
+	return value, ok
+}
+
+// Where returns a Middleware which only passes on events of which the
+// header field or extension has exactly the given value, all other events
+// are dropped.
+//
+// Parameters:
+// - field: The header field name or extension key, see FieldValue.
+// - value: The value the field must have.
+//
+// Returns:
+// - A Middleware filtering the events passing through it.
+func Where(field string, value string) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			if got, ok := event.FieldValue(field); !ok || got != value {
+				return nil
+			}
+			return next(event)
+		}
+	}
+}
+
+// SetExtension returns a Middleware which sets the extension key to value on
+// every event, overwriting any existing value.
+//
+// Returns:
+// - A Middleware transforming the events passing through it.
+func SetExtension(key string, value string) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			extensions := cloneExtensions(event.Extensions)
+			extensions[key] = value
+			event.Extensions = extensions
+			return next(event)
+		}
+	}
+}
+
+// writeError marks errors of the output writer of RunFilter, which stop
+// the filter in contrast to events that could not be rendered.
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string { return e.err.Error() }
+func (e *writeError) Unwrap() error { return e.err }
+
+// RunFilter reads CEF messages from r, passes every event through the
+// middlewares and writes the surviving events in the given format to w,
+// one per line. It is the library counterpart of Unix filter programs and
+// of the "cef filter" command.
+//
+// Reading and writing happen in lockstep, so a slow consumer of w applies
+// backpressure to the producer of r instead of events being buffered in
+// memory. Lines which are not valid CEF and events which can not be rendered
+// are skipped.
+//
+// Parameters:
+// - r: The input providing newline separated CEF messages, e.g. os.Stdin.
+// - w: The output the rendered events are written to, e.g. os.Stdout.
+// - format: The Format of the written events.
+// - middlewares: The transforms and filters applied to each event, in order.
+//
+// Returns:
+// - An error if reading r or writing w failed, nil when the input was consumed completely.
+func RunFilter(r io.Reader, w io.Writer, format Format, middlewares ...Middleware) error {
+
+	out := bufio.NewWriter(w)
+
+	handler := chain(func(event CefEvent) error {
+
+		message, err := event.Render(format)
+		if err != nil {
+			return nil
+		}
+
+		if _, err := out.WriteString(message + "\n"); err != nil {
+			return &writeError{err}
+		}
+
+		if err := out.Flush(); err != nil {
+			return &writeError{err}
+		}
+
+		return nil
+	}, middlewares)
+
+	decoder := NewDecoder(r)
+
+	for {
+		line, err := decoder.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		parsed, err := Parse(line)
+		if err != nil {
+			continue
+		}
+
+		var werr *writeError
+		if err := handler(parsed); errors.As(err, &werr) {
+			return werr.err
+		}
+	}
+}
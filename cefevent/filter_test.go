@@ -0,0 +1,72 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCefEventFieldValue(t *testing.T) {
+
+	var tests = []struct {
+		field string
+		want  string
+		ok    bool
+	}{
+		{"Version", "0", true},
+		{"DeviceEventClassId", "COOL_THING", true},
+		{"src", "127.0.0.1", true},
+		{"dst", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := event.FieldValue(tt.field)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FieldValue(%q) = %q, %v, want %q, %v", tt.field, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+
+	input := eventLine + "\nbroken\n" + strings.Replace(eventLine, "COOL_THING", "OTHER_THING", 1) + "\n"
+
+	var buf bytes.Buffer
+	err := RunFilter(strings.NewReader(input), &buf, FormatCEF, Where("DeviceEventClassId", "COOL_THING"), SetExtension("src", "10.0.0.1"))
+	if err != nil {
+		t.Fatalf("RunFilter() = %v", err)
+	}
+
+	want := strings.Replace(eventLine, "127.0.0.1", "10.0.0.1", 1) + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("RunFilter() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunFilterLineTooLong(t *testing.T) {
+
+	long := "CEF:0|Vendor|Product|1.0|100|Flood|9|msg=" + strings.Repeat("x", maxDecoderLineSize)
+
+	var buf bytes.Buffer
+	if err := RunFilter(strings.NewReader(long+"\n"+eventLine+"\n"), &buf, FormatCEF); err != nil {
+		t.Fatalf("RunFilter() = %v, want the long line to be skipped", err)
+	}
+
+	if got := buf.String(); got != eventLine+"\n" {
+		t.Errorf("RunFilter() wrote %q, want %q", got, eventLine+"\n")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestRunFilterWriteError(t *testing.T) {
+
+	if err := RunFilter(strings.NewReader(eventLine+"\n"), failingWriter{}, FormatCEF); err == nil {
+		t.Errorf("RunFilter() should fail when the output can not be written")
+	}
+}
@@ -0,0 +1,102 @@
+package cefevent
+
+import "fmt"
+
+// Format identifies the wire format an event is rendered in before it is
+// handed to a Sink.
+type Format int
+
+const (
+	// FormatCEF renders the plain CEF message as produced by String().
+	FormatCEF Format = iota
+	// FormatSyslog renders the CEF message prefixed with a RFC 3164 syslog header.
+	FormatSyslog
+	// FormatECS renders the event as Elastic Common Schema JSON document.
+	FormatECS
+	// FormatLEEF renders the event as IBM QRadar LEEF 2.0 message.
+	FormatLEEF
+	// FormatJSON renders the event as JSON document as produced by ToJSON().
+	FormatJSON
+	// FormatOCSF renders the event as Open Cybersecurity Schema Framework JSON document.
+	FormatOCSF
+	// FormatRFC5424 renders the event as RFC 5424 syslog message with structured data as produced by ToRFC5424().
+	FormatRFC5424
+	// FormatLogfmt renders the event as logfmt line as produced by ToLogfmt().
+	FormatLogfmt
+)
+
+// String returns the human readable name of the Format.
+func (f Format) String() string {
+	switch f {
+	case FormatCEF:
+		return "cef"
+	case FormatSyslog:
+		return "syslog"
+	case FormatECS:
+		return "ecs"
+	case FormatLEEF:
+		return "leef"
+	case FormatJSON:
+		return "json"
+	case FormatOCSF:
+		return "ocsf"
+	case FormatRFC5424:
+		return "rfc5424"
+	case FormatLogfmt:
+		return "logfmt"
+	}
+	if _, ok := lookupTemplateFormat(f); ok {
+		return fmt.Sprintf("template%d", int(f-firstTemplateFormat)+1)
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Render renders the event in the requested wire format. It allows a single
+// in-memory event to be delivered to destinations that all expect a
+// different representation of it.
+//
+// Parameters:
+// - format: The Format the event should be rendered in.
+//
+// Returns:
+// - A string containing the rendered event.
+// - An error if the event is not valid or the format is unknown.
+func (event *CefEvent) Render(format Format) (string, error) {
+
+	switch format {
+	case FormatCEF:
+		return event.String()
+	case FormatSyslog:
+		return event.ToSyslog()
+	case FormatECS:
+		return event.ToECSJSON()
+	case FormatLEEF:
+		return event.ToLEEF()
+	case FormatJSON:
+		return event.ToJSON()
+	case FormatOCSF:
+		return event.ToOCSFJSON()
+	case FormatRFC5424:
+		return event.ToRFC5424(RFC5424Options{})
+	case FormatLogfmt:
+		return event.ToLogfmt()
+	}
+
+	return event.renderTemplateFormat(format)
+}
+
+// ParseFormat returns the Format with the given name as returned by Format.String().
+//
+// Returns:
+// - The Format matching the name.
+// - An error if no Format with that name exists.
+func ParseFormat(name string) (Format, error) {
+
+	for _, format := range []Format{FormatCEF, FormatSyslog, FormatECS, FormatLEEF, FormatJSON, FormatOCSF, FormatRFC5424, FormatLogfmt} {
+		if format.String() == name {
+			return format, nil
+		}
+	}
+
+	return FormatCEF, fmt.Errorf("unknown output format %q", name)
+}
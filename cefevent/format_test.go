@@ -0,0 +1,63 @@
+package cefevent
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCefEventRender(t *testing.T) {
+
+	syslogLine := regexp.MustCompile(`^<14>[A-Z][a-z]{2} [ 0-9]\d \d{2}:\d{2}:\d{2} \S+ ` + regexp.QuoteMeta(eventLine) + `$`)
+
+	var tests = []struct {
+		format Format
+		match  func(string) bool
+	}{
+		{FormatCEF, func(s string) bool { return s == eventLine }},
+		{FormatSyslog, syslogLine.MatchString},
+		{FormatLEEF, func(s string) bool {
+			return s == "LEEF:2.0|Cool Vendor|Cool Product|1.0|COOL_THING|x09|name=Something cool happened.\tsev=Unknown\tsrc=127.0.0.1"
+		}},
+		{FormatECS, func(s string) bool {
+			return s == `{"cef.version":0,"event.code":"COOL_THING","event.severity":"Unknown","message":"Something cool happened.","observer.product":"Cool Product","observer.vendor":"Cool Vendor","observer.version":"1.0","source.ip":"127.0.0.1"}`
+		}},
+	}
+
+	for _, tt := range tests {
+		got, err := event.Render(tt.format)
+		if err != nil {
+			t.Errorf("Render(%v) = %v, want nil error", tt.format, err)
+		}
+		if !tt.match(got) {
+			t.Errorf("Render(%v) = %q", tt.format, got)
+		}
+	}
+}
+
+func TestCefEventRenderUnknownFormat(t *testing.T) {
+
+	if _, err := event.Render(Format(42)); err == nil {
+		t.Errorf("Render() should fail for an unknown format")
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+
+	var tests = []struct {
+		severity string
+		want     int
+	}{
+		{"0", 6},
+		{"5", 4},
+		{"8", 3},
+		{"10", 2},
+		{"Very-High", 2},
+		{"Unknown", 6},
+	}
+
+	for _, tt := range tests {
+		if got := syslogSeverity(tt.severity); got != tt.want {
+			t.Errorf("syslogSeverity(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
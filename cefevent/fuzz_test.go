@@ -0,0 +1,136 @@
+package cefevent
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent/testingcef"
+)
+
+func FuzzParse(f *testing.F) {
+
+	testingcef.AddSeeds(f)
+
+	f.Fuzz(func(t *testing.T, line string) {
+
+		event, err := Parse(line)
+		if err != nil {
+			return
+		}
+
+		rendered, err := event.String()
+		if err != nil {
+			return
+		}
+
+		reparsed, err := Parse(rendered)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v for the rendering of %+v", rendered, err, event)
+		}
+
+		// trailing spaces of the last value are indistinguishable from the
+		// separator and can not be represented.
+		for k, v := range event.Extensions {
+			event.Extensions[k] = strings.TrimRight(v, " ")
+			reparsed.Extensions[k] = strings.TrimRight(reparsed.Extensions[k], " ")
+		}
+		if !reflect.DeepEqual(reparsed, event) {
+			t.Fatalf("Parse(%q) = %+v, want %+v", rendered, reparsed, event)
+		}
+	})
+}
+
+func FuzzParseBytes(f *testing.F) {
+
+	testingcef.AddSeedBytes(f)
+
+	f.Fuzz(func(t *testing.T, line []byte) {
+
+		// ParseBytes ignores the trailing newline of a record.
+		want, wantErr := Parse(strings.TrimRight(string(line), "\r\n"))
+		got, err := ParseBytes(line)
+
+		if (err == nil) != (wantErr == nil) || (err == nil && !reflect.DeepEqual(got, want)) {
+			t.Fatalf("ParseBytes(%q) = %+v, %v, want %+v, %v", line, got, err, want, wantErr)
+		}
+	})
+}
+
+func FuzzParseWithOptions(f *testing.F) {
+
+	testingcef.AddSeeds(f)
+
+	f.Fuzz(func(t *testing.T, line string) {
+
+		ParseWithOptions(line, ParseOptions{Strict: true, MaxExtensions: 2, UTF8: UTF8Reject})
+		ParseWithOptions(line, ParseOptions{AllowMissingFields: true, AllowTruncated: true, AllowUnknownVersion: true, UTF8: UTF8Replace})
+		ParseSyslog(line)
+		extensionValue(line, "rt")
+
+		var tok Tokenizer
+		if tok.Reset([]byte(line)) == nil {
+			for tok.Next() {
+			}
+		}
+	})
+}
+
+func FuzzScanCEF(f *testing.F) {
+
+	testingcef.AddSeedBytes(f)
+	f.Add([]byte("5 CEF:0\n12 CEF:0|a|b|c|d\r\n\n3"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64), maxDecoderLineSize)
+		scanner.Split(ScanCEF)
+
+		total := 0
+		for scanner.Scan() {
+			if len(scanner.Bytes()) == 0 {
+				t.Fatalf("ScanCEF() returned an empty record of %q", data)
+			}
+			total += len(scanner.Bytes())
+		}
+		if total > len(data) {
+			t.Fatalf("ScanCEF() returned %d bytes of records from %d bytes of %q", total, len(data), data)
+		}
+	})
+}
+
+func TestParseRoundTrip(t *testing.T) {
+
+	var tests = []struct {
+		extensions string
+		want       map[string]string
+	}{
+		{`msg=line one\nline two\r`, map[string]string{"msg": "line one\nline two\r"}},
+		{"=0 src=10.0.0.1", map[string]string{"src": "10.0.0.1"}},
+		{"x = src=10.0.0.1", map[string]string{"src": "10.0.0.1"}},
+		{`path\\=C:\\ src=10.0.0.1`, map[string]string{`path\`: `C:\`, "src": "10.0.0.1"}},
+		{"msg=a\t", map[string]string{"msg": "a\t"}},
+	}
+
+	for _, test := range tests {
+		line := "CEF:0|Vendor|Product|1.0|100|name|10|" + test.extensions
+
+		got, err := Parse(line)
+		if err != nil || !reflect.DeepEqual(got.Extensions, test.want) {
+			t.Errorf("Parse(%q) = %q, %v, want %q", line, got.Extensions, err, test.want)
+			continue
+		}
+
+		if bytesEvent, err := ParseBytes([]byte(line)); err != nil || !reflect.DeepEqual(bytesEvent, got) {
+			t.Errorf("ParseBytes(%q) = %+v, %v, want %+v", line, bytesEvent, err, got)
+		}
+
+		rendered, _ := got.String()
+		if reparsed, err := Parse(rendered); err != nil || !reflect.DeepEqual(reparsed, got) {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", rendered, reparsed, err, got)
+		}
+	}
+}
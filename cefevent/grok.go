@@ -0,0 +1,238 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ErrNoGrokMatch is returned by GrokConverter.Convert for lines matching none of its rules.
+var ErrNoGrokMatch = errors.New("line matches no grok rule")
+
+// maxGrokDepth limits the nesting of grok patterns, which also stops
+// patterns referencing themselves.
+const maxGrokDepth = 16
+
+// grokReference matches %{NAME}, %{NAME:capture} and %{NAME:capture:type}
+// references, the type is accepted for compatibility but ignored.
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::(\w+))?(?::\w+)?\}`)
+
+var (
+	grokMu       sync.RWMutex
+	grokPatterns = map[string]string{
+		"INT":               `[+-]?[0-9]+`,
+		"POSINT":            `\b[1-9][0-9]*\b`,
+		"NUMBER":            `[+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)`,
+		"WORD":              `\b\w+\b`,
+		"NOTSPACE":          `\S+`,
+		"SPACE":             `\s*`,
+		"DATA":              `.*?`,
+		"GREEDYDATA":        `.*`,
+		"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+		"USERNAME":          `[a-zA-Z0-9._-]+`,
+		"USER":              `%{USERNAME}`,
+		"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]{1,2})\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]{1,2})`,
+		"IPV6":              `[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}(?:%{IPV4})?`,
+		"IP":                `(?:%{IPV4}|%{IPV6})`,
+		"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+		"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+		"MAC":               `(?:[0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}`,
+		"PATH":              `(?:/[^/\s]*)+`,
+		"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]une?|[Jj]uly?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+		"MONTHDAY":          `(?:0?[1-9]|[12][0-9]|3[01])`,
+		"TIME":              `[0-2]?[0-9]:[0-5][0-9](?::[0-6][0-9](?:[.,][0-9]+)?)?`,
+		"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+		"TIMESTAMP_ISO8601": `[0-9]{4}-[0-9]{2}-[0-9]{2}[T ]%{TIME}(?:Z|[+-][0-9]{2}:?[0-9]{2})?`,
+		"LOGLEVEL":          `(?i:trace|debug|info|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|alert|emerg(?:ency)?|fatal)`,
+	}
+)
+
+// RegisterGrokPattern makes a named pattern available to the grok patterns
+// of every GrokConverter, an existing pattern with the same name is
+// replaced. The pattern is a regular expression which may reference other
+// patterns itself.
+func RegisterGrokPattern(name string, pattern string) {
+
+	grokMu.Lock()
+	defer grokMu.Unlock()
+
+	grokPatterns[name] = pattern
+}
+
+// GrokRule maps the captures of a pattern onto the fields of an event.
+type GrokRule struct {
+	// Pattern is a grok pattern such as "%{IP:client} %{WORD:method}" or a
+	// regular expression with named groups such as "(?P<client>\S+)".
+	Pattern string `json:"pattern"`
+	// Fields maps header field names (e.g. "DeviceVendor" or "Severity",
+	// see FieldValue) and extension keys onto templates of their values.
+	// Templates reference captures as $name or ${name}, any other text is
+	// taken literally, e.g. to set the vendor of all matched lines.
+	// Extensions whose template expands to the empty string are omitted.
+	Fields map[string]string `json:"fields"`
+}
+
+// GrokConfig is the configuration of a GrokConverter.
+type GrokConfig struct {
+	Patterns map[string]string `json:"patterns,omitempty"` // Patterns are named patterns available to the rules of this configuration only.
+	Rules    []GrokRule        `json:"rules"`              // Rules are tried in order, the first matching one converts a line.
+}
+
+// LoadGrokConfig reads a JSON grok configuration.
+//
+// Returns:
+// - The decoded GrokConfig.
+// - An error if the configuration is not valid JSON or has no rules.
+func LoadGrokConfig(r io.Reader) (GrokConfig, error) {
+
+	var config GrokConfig
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return GrokConfig{}, err
+	}
+
+	if len(config.Rules) == 0 {
+		return GrokConfig{}, errors.New("grok configuration has no rules")
+	}
+
+	return config, nil
+}
+
+// compiledGrokRule is a GrokRule with its pattern compiled.
+type compiledGrokRule struct {
+	re     *regexp.Regexp
+	fields map[string]string
+}
+
+// GrokConverter converts unstructured log lines into CEF events by
+// matching them against grok patterns or regular expressions, so legacy
+// text logs can be converted through configuration alone. A GrokConverter
+// is safe for concurrent use.
+type GrokConverter struct {
+	rules []compiledGrokRule
+}
+
+// NewGrokConverter compiles the rules of the configuration.
+//
+// Returns:
+// - A pointer to a GrokConverter.
+// - An error if a pattern references an unknown pattern or is no valid regular expression.
+func NewGrokConverter(config GrokConfig) (*GrokConverter, error) {
+
+	grokMu.RLock()
+	patterns := make(map[string]string, len(grokPatterns)+len(config.Patterns))
+	for name, pattern := range grokPatterns {
+		patterns[name] = pattern
+	}
+	grokMu.RUnlock()
+
+	for name, pattern := range config.Patterns {
+		patterns[name] = pattern
+	}
+
+	converter := &GrokConverter{}
+
+	for i, rule := range config.Rules {
+		expanded, err := expandGrok(rule.Pattern, patterns, 0)
+		if err != nil {
+			return nil, fmt.Errorf("grok rule %d: %w", i+1, err)
+		}
+		re, err := regexp.Compile(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("grok rule %d: %w", i+1, err)
+		}
+		converter.rules = append(converter.rules, compiledGrokRule{re: re, fields: rule.Fields})
+	}
+
+	return converter, nil
+}
+
+// expandGrok replaces the pattern references of a grok pattern with the
+// regular expressions they stand for, named references become named groups.
+func expandGrok(pattern string, patterns map[string]string, depth int) (string, error) {
+
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("grok patterns are nested deeper than %d levels", maxGrokDepth)
+	}
+
+	var err error
+
+	expanded := grokReference.ReplaceAllStringFunc(pattern, func(reference string) string {
+
+		if err != nil {
+			return ""
+		}
+
+		match := grokReference.FindStringSubmatch(reference)
+		definition, ok := patterns[match[1]]
+		if !ok {
+			err = fmt.Errorf("unknown grok pattern %s", match[1])
+			return ""
+		}
+
+		var inner string
+		inner, err = expandGrok(definition, patterns, depth+1)
+		if match[2] != "" {
+			return "(?P<" + match[2] + ">" + inner + ")"
+		}
+		return "(?:" + inner + ")"
+	})
+
+	return expanded, err
+}
+
+// Convert converts a log line with the first rule whose pattern matches it.
+//
+// Returns:
+// - The converted CefEvent.
+// - ErrNoGrokMatch if no rule matches, or an error if a mapped Version is no number.
+func (c *GrokConverter) Convert(line string) (CefEvent, error) {
+
+	for _, rule := range c.rules {
+		match := rule.re.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+
+		event := CefEvent{Extensions: make(map[string]string)}
+
+		for field, template := range rule.fields {
+			value := string(rule.re.ExpandString(nil, template, line, match))
+
+			switch field {
+			case "Version":
+				version, err := strconv.Atoi(value)
+				if err != nil {
+					return CefEvent{}, fmt.Errorf("grok field Version is no number: %w", err)
+				}
+				event.Version = version
+			case "DeviceVendor":
+				event.DeviceVendor = value
+			case "DeviceProduct":
+				event.DeviceProduct = value
+			case "DeviceVersion":
+				event.DeviceVersion = value
+			case "DeviceEventClassId":
+				event.DeviceEventClassId = value
+			case "Name":
+				event.Name = value
+			case "Severity":
+				event.Severity = value
+			default:
+				if value != "" {
+					event.Extensions[field] = value
+				}
+			}
+		}
+
+		return event, nil
+	}
+
+	return CefEvent{}, ErrNoGrokMatch
+}
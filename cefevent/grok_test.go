@@ -0,0 +1,130 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrokConverter(t *testing.T) {
+
+	RegisterGrokPattern("SSHD_PREFIX", `%{SYSLOGTIMESTAMP} %{HOSTNAME:host} sshd\[%{POSINT:pid}\]:`)
+
+	converter, err := NewGrokConverter(GrokConfig{
+		Patterns: map[string]string{"AUTH_RESULT": `Accepted|Failed`},
+		Rules: []GrokRule{
+			{
+				Pattern: `%{SSHD_PREFIX} %{AUTH_RESULT:result} password for %{USERNAME:user} from %{IP:src} port %{INT:port}`,
+				Fields: map[string]string{
+					"DeviceVendor":       "OpenBSD",
+					"DeviceProduct":      "sshd",
+					"DeviceVersion":      "1.0",
+					"DeviceEventClassId": "ssh-${result}",
+					"Name":               "$result login",
+					"Severity":           "5",
+					"suser":              "$user",
+					"src":                "$src",
+					"spt":                "$port",
+					"dvchost":            "$host",
+					"dvcpid":             "$pid",
+				},
+			},
+			{
+				Pattern: `^(?P<level>[A-Z]+) (?P<message>.*)$`,
+				Fields: map[string]string{
+					"Version":            "1",
+					"DeviceVendor":       "Legacy",
+					"DeviceProduct":      "App",
+					"DeviceVersion":      "2",
+					"DeviceEventClassId": "$level",
+					"Name":               "$message",
+					"Severity":           "3",
+					"msg":                "$message",
+					"cs1":                "$missing",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewGrokConverter() = %v", err)
+	}
+
+	var tests = []struct {
+		line string
+		want CefEvent
+		err  error
+	}{
+		{
+			"Oct 15 10:01:02 gw01 sshd[4242]: Failed password for root from 10.0.0.7 port 51234 ssh2",
+			CefEvent{
+				DeviceVendor:       "OpenBSD",
+				DeviceProduct:      "sshd",
+				DeviceVersion:      "1.0",
+				DeviceEventClassId: "ssh-Failed",
+				Name:               "Failed login",
+				Severity:           "5",
+				Extensions:         map[string]string{"suser": "root", "src": "10.0.0.7", "spt": "51234", "dvchost": "gw01", "dvcpid": "4242"},
+			},
+			nil,
+		},
+		{
+			"WARN disk almost full",
+			CefEvent{
+				Version:            1,
+				DeviceVendor:       "Legacy",
+				DeviceProduct:      "App",
+				DeviceVersion:      "2",
+				DeviceEventClassId: "WARN",
+				Name:               "disk almost full",
+				Severity:           "3",
+				Extensions:         map[string]string{"msg": "disk almost full"},
+			},
+			nil,
+		},
+		{"not a known line", CefEvent{}, ErrNoGrokMatch},
+	}
+
+	for _, test := range tests {
+		got, err := converter.Convert(test.line)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Convert(%q) error = %v, want %v", test.line, err, test.err)
+			continue
+		}
+		if test.err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Convert(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestNewGrokConverterErrors(t *testing.T) {
+
+	var tests = []struct {
+		config GrokConfig
+		want   string
+	}{
+		{GrokConfig{Rules: []GrokRule{{Pattern: "%{NOPE:x}"}}}, "unknown grok pattern NOPE"},
+		{GrokConfig{Patterns: map[string]string{"LOOP": "a%{LOOP}"}, Rules: []GrokRule{{Pattern: "%{LOOP}"}}}, "nested deeper"},
+		{GrokConfig{Rules: []GrokRule{{Pattern: "(unclosed"}}}, "grok rule 1"},
+	}
+
+	for _, test := range tests {
+		if _, err := NewGrokConverter(test.config); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("NewGrokConverter(%+v) = %v, want an error containing %q", test.config, err, test.want)
+		}
+	}
+}
+
+func TestLoadGrokConfig(t *testing.T) {
+
+	config, err := LoadGrokConfig(strings.NewReader(`{"rules": [{"pattern": "%{WORD:w}", "fields": {"msg": "$w"}}]}`))
+	if err != nil || len(config.Rules) != 1 || config.Rules[0].Fields["msg"] != "$w" {
+		t.Errorf("LoadGrokConfig() = %+v, %v", config, err)
+	}
+
+	for _, input := range []string{`{"rules": []}`, `{"rules": [], "unknown": 1}`, `not json`} {
+		if _, err := LoadGrokConfig(strings.NewReader(input)); err == nil {
+			t.Errorf("LoadGrokConfig(%q) = nil, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultHealthWindow is the period the parse error rate of a Pipeline is
+// measured over when HealthOptions.Window is not set.
+const DefaultHealthWindow = time.Minute
+
+// HealthOptions is the error budget of a Pipeline, a Pipeline exceeding it
+// is reported unhealthy so orchestrators can restart it.
+type HealthOptions struct {
+	// MaxParseErrorRate is the fraction of received lines which may fail to
+	// parse within the Window, zero disables the check.
+	MaxParseErrorRate float64
+	// MaxDeliveryAge is how long the delivery of events may keep failing
+	// without any event being delivered, zero disables the check.
+	MaxDeliveryAge time.Duration
+	// Window is the period the parse error rate is measured over, defaults
+	// to DefaultHealthWindow.
+	Window time.Duration
+}
+
+// SinkHealth is the state of a single sink of a Pipeline.
+type SinkHealth struct {
+	Sink      string `json:"sink"`                // Sink is the Go type of the sink.
+	Healthy   bool   `json:"healthy"`             // Healthy is false when none of the endpoints of the sink is up.
+	Endpoints []bool `json:"endpoints,omitempty"` // Endpoints are the states of the endpoints of a FailoverSink.
+}
+
+// Health is the report of a Pipeline served by its HealthHandler.
+type Health struct {
+	Healthy          bool         `json:"healthy"`                 // Healthy is false when the Pipeline exceeds its error budget.
+	Ready            bool         `json:"ready"`                   // Ready is true while the Pipeline runs and every sink is healthy.
+	Problems         []string     `json:"problems,omitempty"`      // Problems describe why the Pipeline is not healthy or ready.
+	Received         uint64       `json:"received"`                // Received is the number of lines received since the Pipeline was created.
+	ParseErrors      uint64       `json:"parse_errors"`            // ParseErrors is the number of received lines which could not be parsed.
+	DeliveryFailures uint64       `json:"delivery_failures"`       // DeliveryFailures is the number of events the Emitter failed to deliver.
+	ParseErrorRate   float64      `json:"parse_error_rate"`        // ParseErrorRate is the fraction of lines which could not be parsed within the window.
+	LastDelivery     time.Time    `json:"last_delivery,omitempty"` // LastDelivery is when an event was last delivered successfully.
+	QueueDepth       int          `json:"queue_depth"`             // QueueDepth is the number of events waiting in the sources.
+	Sinks            []SinkHealth `json:"sinks"`                   // Sinks are the states of the sinks.
+}
+
+// queueDepther is implemented by sources buffering received events, e.g.
+// TCPSource, a custom Source implements QueueDepth to be covered by the
+// QueueDepth of the Health report.
+type queueDepther interface {
+	QueueDepth() int
+}
+
+// pipelineStats counts the lines a Pipeline handled, the parse error rate
+// is taken over the current and the previous window.
+type pipelineStats struct {
+	mu           sync.Mutex
+	now          func() time.Time
+	running      int
+	received     uint64
+	parseErrors  uint64
+	failures     uint64
+	firstPending time.Time
+	lastDelivery time.Time
+
+	windowStart  time.Time
+	window       [2]struct{ received, errors uint64 }
+	windowLength time.Duration
+}
+
+// newPipelineStats returns empty statistics.
+func newPipelineStats() *pipelineStats {
+
+	return &pipelineStats{now: time.Now}
+}
+
+// rotate starts a new window once the current one has passed, it must be
+// called with the lock held.
+func (s *pipelineStats) rotate(now time.Time, length time.Duration) {
+
+	if length <= 0 {
+		length = DefaultHealthWindow
+	}
+	s.windowLength = length
+
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+
+	switch elapsed := now.Sub(s.windowStart); {
+	case elapsed >= 2*length:
+		s.window = [2]struct{ received, errors uint64 }{}
+		s.windowStart = now
+	case elapsed >= length:
+		s.window[0] = s.window[1]
+		s.window[1] = struct{ received, errors uint64 }{}
+		s.windowStart = s.windowStart.Add(length)
+	}
+}
+
+// record counts a received line, whether it could be parsed and whether
+// its event was delivered.
+func (s *pipelineStats) record(parseErr error, deliveryErr error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.rotate(now, s.windowLength)
+
+	s.received++
+	s.window[1].received++
+
+	switch {
+	case parseErr != nil:
+		s.parseErrors++
+		s.window[1].errors++
+	case deliveryErr != nil:
+		s.failures++
+		if s.firstPending.IsZero() {
+			s.firstPending = now
+		}
+	default:
+		s.lastDelivery = now
+		s.firstPending = time.Time{}
+	}
+}
+
+// setRunning marks the start and the end of a Run.
+func (s *pipelineStats) setRunning(running bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if running {
+		s.running++
+	} else {
+		s.running--
+	}
+}
+
+// Health reports the state of the Pipeline: the lines it received and
+// could not parse, the events it failed to deliver, the events waiting in
+// its sources and the health of its sinks. The Pipeline is unhealthy when
+// it exceeds the error budget of the options, i.e. the parse error rate
+// within the window or the time deliveries keep failing, and ready while it runs and every sink is healthy.
+//
+// Parameters:
+// - opts: The error budget.
+//
+// Returns:
+// - The Health report.
+func (p *Pipeline) Health(opts HealthOptions) Health {
+
+	s := p.stats
+	s.mu.Lock()
+	now := s.now()
+	s.rotate(now, opts.Window)
+	health := Health{
+		Healthy:          true,
+		Ready:            s.running > 0,
+		Received:         s.received,
+		ParseErrors:      s.parseErrors,
+		DeliveryFailures: s.failures,
+		LastDelivery:     s.lastDelivery,
+	}
+	if received := s.window[0].received + s.window[1].received; received > 0 {
+		health.ParseErrorRate = float64(s.window[0].errors+s.window[1].errors) / float64(received)
+	}
+	firstPending := s.firstPending
+	s.mu.Unlock()
+
+	if !health.Ready {
+		health.Problems = append(health.Problems, "pipeline is not running")
+	}
+	if opts.MaxParseErrorRate > 0 && health.ParseErrorRate > opts.MaxParseErrorRate {
+		health.Healthy = false
+		health.Problems = append(health.Problems, fmt.Sprintf("parse error rate %.3f exceeds %.3f", health.ParseErrorRate, opts.MaxParseErrorRate))
+	}
+	if opts.MaxDeliveryAge > 0 && !firstPending.IsZero() && now.Sub(firstPending) > opts.MaxDeliveryAge {
+		health.Healthy = false
+		health.Problems = append(health.Problems, fmt.Sprintf("no event delivered for %v", now.Sub(firstPending).Round(time.Second)))
+	}
+
+	for _, source := range p.sources {
+		if q, ok := source.(queueDepther); ok {
+			health.QueueDepth += q.QueueDepth()
+		}
+	}
+
+	p.emitter.mu.Lock()
+	sinks := append([]Sink(nil), p.emitter.sinks...)
+	p.emitter.mu.Unlock()
+
+	health.Sinks = make([]SinkHealth, 0, len(sinks))
+	for _, sink := range sinks {
+		sh := SinkHealth{Sink: fmt.Sprintf("%T", sink), Healthy: true}
+		if fs, ok := sink.(*FailoverSink); ok {
+			sh.Endpoints = fs.Healthy()
+			sh.Healthy = false
+			for _, up := range sh.Endpoints {
+				sh.Healthy = sh.Healthy || up
+			}
+		}
+		if !sh.Healthy {
+			health.Ready = false
+			health.Problems = append(health.Problems, fmt.Sprintf("sink %s has no healthy endpoint", sh.Sink))
+		}
+		health.Sinks = append(health.Sinks, sh)
+	}
+
+	return health
+}
+
+// HealthHandler returns an http.Handler serving the Health report of the
+// Pipeline as JSON, for the liveness and readiness probes of orchestrators
+// such as Kubernetes. "/healthz" answers 503 Service Unavailable while the
+// Pipeline is unhealthy, "/readyz" while it is not ready, 200 OK otherwise.
+//
+// Parameters:
+// - opts: The error budget, see Health.
+//
+// Returns:
+// - The http.Handler.
+func (p *Pipeline) HealthHandler(opts HealthOptions) http.Handler {
+
+	serve := func(ok func(Health) bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			health := p.Health(opts)
+			w.Header().Set("Content-Type", "application/json")
+			if !ok(health) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			json.NewEncoder(w).Encode(health)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", serve(func(h Health) bool { return h.Healthy }))
+	mux.Handle("/readyz", serve(func(h Health) bool { return h.Ready }))
+
+	return mux
+}
+
+// HealthConfig describes the health endpoint of a pipeline configuration.
+type HealthConfig struct {
+	Address           string  `json:"address"`                        // Address is the listen address of the HTTP endpoint, e.g. ":8080".
+	MaxParseErrorRate float64 `json:"max_parse_error_rate,omitempty"` // MaxParseErrorRate is the tolerated fraction of unparsable lines.
+	MaxDeliveryAge    string  `json:"max_delivery_age,omitempty"`     // MaxDeliveryAge is the time.Duration deliveries may keep failing.
+	Window            string  `json:"window,omitempty"`               // Window is the time.Duration the parse error rate is measured over.
+}
+
+// options converts the configuration into HealthOptions.
+func (c HealthConfig) options() (HealthOptions, error) {
+
+	age, err := parseOptionalDuration(c.MaxDeliveryAge)
+	if err != nil {
+		return HealthOptions{}, err
+	}
+	window, err := parseOptionalDuration(c.Window)
+	if err != nil {
+		return HealthOptions{}, err
+	}
+
+	return HealthOptions{MaxParseErrorRate: c.MaxParseErrorRate, MaxDeliveryAge: age, Window: window}, nil
+}
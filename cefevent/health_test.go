@@ -0,0 +1,119 @@
+package cefevent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineHealth(t *testing.T) {
+
+	endpoint := &fakeEndpoint{}
+	input := eventLine + "\nnot a CEF line\n" + eventLine + "\n" + eventLine + "\n"
+	pipeline := NewPipeline(NewSinkEmitter(endpoint), NewReaderSource(strings.NewReader(input), "test"))
+
+	now := time.Unix(1700000000, 0)
+	pipeline.stats.now = func() time.Time { return now }
+
+	if health := pipeline.Health(HealthOptions{}); health.Ready || !health.Healthy {
+		t.Errorf("Health() = %+v, want healthy but not ready before Run", health)
+	}
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	health := pipeline.Health(HealthOptions{MaxParseErrorRate: 0.2})
+	if health.Received != 4 || health.ParseErrors != 1 || health.ParseErrorRate != 0.25 || !health.LastDelivery.Equal(now) {
+		t.Errorf("Health() = %+v, want 4 received lines, 1 parse error", health)
+	}
+	if health.Healthy || len(health.Problems) == 0 {
+		t.Errorf("Health() = %+v, want the parse error rate over budget", health)
+	}
+
+	// the errors leave the window after two windows.
+	now = now.Add(2 * DefaultHealthWindow)
+	if health := pipeline.Health(HealthOptions{MaxParseErrorRate: 0.2}); !health.Healthy || health.ParseErrorRate != 0 {
+		t.Errorf("Health() = %+v, want the parse errors of past windows forgotten", health)
+	}
+
+	endpoint.down = true
+	pipeline.sources = []Source{NewReaderSource(strings.NewReader(eventLine+"\n"), "test")}
+	pipeline.Run(context.Background())
+	now = now.Add(time.Minute)
+	health = pipeline.Health(HealthOptions{MaxDeliveryAge: 30 * time.Second})
+	if health.Healthy || health.DeliveryFailures != 1 {
+		t.Errorf("Health() = %+v, want unhealthy after deliveries kept failing", health)
+	}
+}
+
+func TestPipelineHealthHandler(t *testing.T) {
+
+	primary := &fakeEndpoint{down: true}
+	sink, err := NewFailoverSink([]Sink{primary}, FailoverOptions{RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Send("a")
+
+	source, err := NewTCPSource("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewTCPSource() = %v", err)
+	}
+	pipeline := NewPipeline(NewSinkEmitter(sink), source)
+	defer pipeline.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+
+	server := httptest.NewServer(pipeline.HealthHandler(HealthOptions{}))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var health Health
+		err = json.NewDecoder(resp.Body).Decode(&health)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != tt.want {
+			t.Errorf("GET %s = %d, %v, want %d", tt.path, resp.StatusCode, err, tt.want)
+		}
+		if len(health.Sinks) != 1 || health.Sinks[0].Healthy {
+			t.Errorf("GET %s = %+v, want the failover sink reported down", tt.path, health)
+		}
+	}
+}
+
+func TestPipelineConfigHealth(t *testing.T) {
+
+	config := PipelineConfig{
+		Sources: []SourceConfig{{Type: "stdin"}},
+		Sinks:   []SinkConfig{{Type: "stdout"}},
+		Health:  &HealthConfig{Address: "127.0.0.1:0", MaxDeliveryAge: "5m"},
+	}
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	pipeline.Close()
+
+	config.Health.Window = "soon"
+	if _, err := config.Build(); err == nil {
+		t.Error("Build() = nil, want an error for an invalid window")
+	}
+}
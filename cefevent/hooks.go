@@ -0,0 +1,47 @@
+package cefevent
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDropped is reported to Hooks.OnDropped for events a middleware dropped
+// by not passing them on.
+var ErrDropped = errors.New("event was dropped by a middleware")
+
+// Hooks observe the lifecycle of the events passing through an Emitter,
+// e.g. to feed APM traces or metrics, without wrapping every sink.
+//
+// All hooks are optional. They are called synchronously while the Emitter
+// delivers the event and must therefore be fast, safe for concurrent use
+// and must not emit events on the same Emitter.
+type Hooks struct {
+	// OnBuilt is called with the event as it leaves the middleware chain,
+	// once it has been validated and before it is rendered.
+	OnBuilt func(event CefEvent)
+	// OnRendered is called once per distinct Format the event is rendered in.
+	OnRendered func(event CefEvent, format Format, message string)
+	// OnSent is called after every sink handled the event, with the time the
+	// sink took and its error, nil when the delivery succeeded.
+	OnSent func(event CefEvent, sink Sink, elapsed time.Duration, err error)
+	// OnDropped is called for events which are not delivered to any sink,
+	// with ErrDropped when a middleware dropped the event or the validation
+	// or rendering error otherwise.
+	OnDropped func(event CefEvent, reason error)
+}
+
+// SetHooks replaces the lifecycle hooks of the Emitter, it may be called
+// while other goroutines are emitting events.
+//
+// Detecting events dropped by middlewares requires the middlewares to call
+// the next Handler before they return, as all middlewares of this package do.
+// An event is reported as dropped when no event left the middleware chain
+// while it passed through, a drop overlapping the delivery of an event
+// emitted concurrently may therefore go unreported.
+func (e *Emitter) SetHooks(hooks Hooks) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.hooks = hooks
+}
@@ -0,0 +1,91 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEmitterHooks(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewSinkEmitter(NewWriterSink(&buf, FormatCEF), NewWriterSink(&buf, FormatCEF), NewWriterSink(&buf, FormatLEEF))
+
+	var calls []string
+	var dropped []error
+	emitter.SetHooks(Hooks{
+		OnBuilt: func(event CefEvent) {
+			calls = append(calls, "built")
+		},
+		OnRendered: func(event CefEvent, format Format, message string) {
+			calls = append(calls, "rendered "+format.String())
+		},
+		OnSent: func(event CefEvent, sink Sink, elapsed time.Duration, err error) {
+			calls = append(calls, "sent "+sink.Format().String())
+		},
+		OnDropped: func(event CefEvent, reason error) {
+			dropped = append(dropped, reason)
+		},
+	})
+
+	emitter.Use(Where("Severity", "Unknown"))
+
+	if err := emitter.Emit(event); err != nil {
+		t.Fatalf("Emit() = %v", err)
+	}
+
+	want := []string{"built", "rendered cef", "sent cef", "sent cef", "rendered leef", "sent leef"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks were called as %v, want %v", calls, want)
+	}
+
+	filtered := event
+	filtered.Severity = "10"
+	emitter.Emit(filtered)
+
+	invalid := event
+	invalid.Name = ""
+	emitter.Emit(invalid)
+
+	if len(dropped) != 2 || !errors.Is(dropped[0], ErrDropped) || errors.Is(dropped[1], ErrDropped) {
+		t.Errorf("OnDropped() received %v, want ErrDropped and a validation error", dropped)
+	}
+}
+
+func TestEmitterHooksChainBuiltOnce(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	dropped := 0
+	emitter.SetHooks(Hooks{OnDropped: func(event CefEvent, reason error) {
+		dropped++
+	}})
+
+	builds := 0
+	emitter.Use(func(next Handler) Handler {
+		builds++
+		// passes every second event on, its state must survive across Emits.
+		seen := 0
+		return func(event CefEvent) error {
+			seen++
+			if seen%2 == 0 {
+				return nil
+			}
+			return next(event)
+		}
+	})
+
+	for i := 0; i < 200; i++ {
+		emitter.Emit(event)
+	}
+
+	if builds != 1 {
+		t.Errorf("the middleware chain was built %d times for 200 events, want 1", builds)
+	}
+	if dropped != 100 || bytes.Count(buf.Bytes(), []byte("\n")) != 100 {
+		t.Errorf("OnDropped() was called %d times for %d delivered events, want 100 each", dropped, bytes.Count(buf.Bytes(), []byte("\n")))
+	}
+}
@@ -0,0 +1,370 @@
+package cefevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultMetadataEndpoint is the link-local address under which AWS, GCP and
+// Azure serve the instance metadata.
+const DefaultMetadataEndpoint = "http://169.254.169.254"
+
+// kubernetesNamespaceFile holds the namespace of the pod when the service
+// account token is mounted.
+const kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// HostMetadata describes the host, cloud instance and container an event
+// originates from. Empty fields are unknown.
+type HostMetadata struct {
+	Hostname      string // Hostname is the name of the host, the instance or the pod.
+	Address       string // Address is the primary IP address of the host.
+	CloudProvider string // CloudProvider is one of "aws", "gcp" or "azure".
+	InstanceID    string // InstanceID is the ID of the cloud instance.
+	Region        string // Region is the cloud region the instance runs in.
+	Zone          string // Zone is the availability zone the instance runs in.
+	ContainerID   string // ContainerID is the ID of the container the process runs in.
+	PodName       string // PodName is the name of the Kubernetes pod.
+	PodNamespace  string // PodNamespace is the namespace of the Kubernetes pod.
+}
+
+// HostMetadataOptions configures the detection of DetectHostMetadata.
+type HostMetadataOptions struct {
+	Endpoint     string        // Endpoint is the base URL of the instance metadata service, defaults to DefaultMetadataEndpoint.
+	Timeout      time.Duration // Timeout limits the queries of the metadata service, defaults to one second.
+	DisableCloud bool          // DisableCloud skips the queries of the metadata service, e.g. outside of cloud environments.
+	Client       *http.Client  // Client is used for the queries, defaults to a client without proxy.
+}
+
+// DetectHostMetadata collects the metadata of the host the process runs on.
+// The instance metadata services of AWS (IMDSv2), GCP and Azure are queried
+// concurrently and the first answer is used, the container ID is read from
+// the cgroups of the process and the pod identity from the environment
+// Kubernetes provides. Detection never fails, metadata which is not
+// available is left empty.
+//
+// Detection queries the network and the file system, it is meant to run
+// once at startup with the result passed to EnrichHost.
+//
+// Parameters:
+// - ctx: The context bounding the queries of the metadata service.
+// - opts: The detection options, zero values select the defaults.
+//
+// Returns:
+// - The detected HostMetadata.
+func DetectHostMetadata(ctx context.Context, opts HostMetadataOptions) HostMetadata {
+
+	if opts.Endpoint == "" {
+		opts.Endpoint = DefaultMetadataEndpoint
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Transport: &http.Transport{Proxy: nil}}
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+
+	var meta HostMetadata
+	meta.Hostname, _ = os.Hostname()
+
+	if !opts.DisableCloud {
+		if cloud, ok := queryCloudMetadata(ctx, opts); ok {
+			meta.CloudProvider = cloud.CloudProvider
+			meta.InstanceID = cloud.InstanceID
+			meta.Region = cloud.Region
+			meta.Zone = cloud.Zone
+			meta.Address = cloud.Address
+			if cloud.Hostname != "" {
+				meta.Hostname = cloud.Hostname
+			}
+		}
+	}
+
+	if meta.Address == "" {
+		meta.Address = localAddress()
+	}
+
+	if cgroup, err := os.ReadFile("/proc/self/cgroup"); err == nil {
+		meta.ContainerID = containerID(string(cgroup))
+	}
+	if meta.ContainerID == "" {
+		if mountinfo, err := os.ReadFile("/proc/self/mountinfo"); err == nil {
+			meta.ContainerID = containerID(string(mountinfo))
+		}
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		meta.PodName = os.Getenv("POD_NAME")
+		if meta.PodName == "" {
+			// the hostname of a pod is its name unless overridden in the spec.
+			meta.PodName, _ = os.Hostname()
+		}
+		meta.PodNamespace = os.Getenv("POD_NAMESPACE")
+		if meta.PodNamespace == "" {
+			if namespace, err := os.ReadFile(kubernetesNamespaceFile); err == nil {
+				meta.PodNamespace = strings.TrimSpace(string(namespace))
+			}
+		}
+	}
+
+	return meta
+}
+
+// Extensions returns the metadata as CEF extensions, the hostname and address
+// as dvchost and dvc, the cloud and container identity as custom extensions.
+// Unknown fields are omitted.
+func (m HostMetadata) Extensions() map[string]string {
+
+	extensions := make(map[string]string)
+	for k, v := range map[string]string{
+		"dvchost":         m.Hostname,
+		"dvc":             m.Address,
+		"cloudProvider":   m.CloudProvider,
+		"cloudInstanceId": m.InstanceID,
+		"cloudRegion":     m.Region,
+		"cloudZone":       m.Zone,
+		"containerId":     m.ContainerID,
+		"podName":         m.PodName,
+		"podNamespace":    m.PodNamespace,
+	} {
+		if v != "" {
+			extensions[k] = v
+		}
+	}
+
+	return extensions
+}
+
+// EnrichHost returns a Middleware which adds the host metadata to every
+// event, so events identify their origin in dynamic infrastructure.
+// Extensions already present on the event are not overwritten.
+//
+// Parameters:
+// - meta: The metadata, usually obtained once from DetectHostMetadata.
+//
+// Returns:
+// - A Middleware enriching the events passing through it.
+func EnrichHost(meta HostMetadata) Middleware {
+	return Enrich(meta.Extensions())
+}
+
+// cloudQuery fetches the instance metadata of a single cloud provider.
+type cloudQuery func(ctx context.Context, opts HostMetadataOptions) (HostMetadata, error)
+
+// queryCloudMetadata queries all cloud providers concurrently and returns
+// the metadata of the first one answering.
+func queryCloudMetadata(ctx context.Context, opts HostMetadataOptions) (HostMetadata, bool) {
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	queries := []cloudQuery{queryAWS, queryGCP, queryAzure}
+	results := make(chan HostMetadata, len(queries))
+
+	for _, query := range queries {
+		go func(query cloudQuery) {
+			meta, err := query(ctx, opts)
+			if err != nil {
+				meta = HostMetadata{}
+			}
+			results <- meta
+		}(query)
+	}
+
+	for range queries {
+		if meta := <-results; meta.CloudProvider != "" {
+			return meta, true
+		}
+	}
+
+	return HostMetadata{}, false
+}
+
+// metadataRequest performs a request against the metadata service and decodes the JSON answer into v.
+func metadataRequest(ctx context.Context, opts HostMetadataOptions, method, path string, header map[string]string, v interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, opts.Endpoint+path, nil)
+	if err != nil {
+		return err
+	}
+	for k, value := range header {
+		req.Header.Set(k, value)
+	}
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("metadata service returned %s for %s", resp.Status, path)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if s, ok := v.(*string); ok {
+		*s = strings.TrimSpace(string(body))
+		return nil
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// queryAWS reads the instance identity document of EC2 using an IMDSv2 session token.
+func queryAWS(ctx context.Context, opts HostMetadataOptions) (HostMetadata, error) {
+
+	var token string
+	if err := metadataRequest(ctx, opts, http.MethodPut, "/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"}, &token); err != nil {
+		return HostMetadata{}, err
+	}
+	header := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		PrivateIP        string `json:"privateIp"`
+	}
+	if err := metadataRequest(ctx, opts, http.MethodGet, "/latest/dynamic/instance-identity/document", header, &doc); err != nil {
+		return HostMetadata{}, err
+	}
+
+	meta := HostMetadata{
+		CloudProvider: "aws",
+		InstanceID:    doc.InstanceID,
+		Region:        doc.Region,
+		Zone:          doc.AvailabilityZone,
+		Address:       doc.PrivateIP,
+	}
+	// the hostname is optional, e.g. it is missing for instances without a private DNS name.
+	_ = metadataRequest(ctx, opts, http.MethodGet, "/latest/meta-data/local-hostname", header, &meta.Hostname)
+
+	return meta, nil
+}
+
+// queryGCP reads the instance metadata of Compute Engine.
+func queryGCP(ctx context.Context, opts HostMetadataOptions) (HostMetadata, error) {
+
+	var doc struct {
+		ID                json.Number `json:"id"`
+		Hostname          string      `json:"hostname"`
+		Zone              string      `json:"zone"`
+		NetworkInterfaces []struct {
+			IP string `json:"ip"`
+		} `json:"networkInterfaces"`
+	}
+	if err := metadataRequest(ctx, opts, http.MethodGet, "/computeMetadata/v1/instance/?recursive=true",
+		map[string]string{"Metadata-Flavor": "Google"}, &doc); err != nil {
+		return HostMetadata{}, err
+	}
+
+	// the zone is given as "projects/<number>/zones/<zone>", the region is the zone without its suffix.
+	zone := doc.Zone[strings.LastIndexByte(doc.Zone, '/')+1:]
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+
+	meta := HostMetadata{
+		CloudProvider: "gcp",
+		InstanceID:    doc.ID.String(),
+		Hostname:      doc.Hostname,
+		Region:        region,
+		Zone:          zone,
+	}
+	if len(doc.NetworkInterfaces) > 0 {
+		meta.Address = doc.NetworkInterfaces[0].IP
+	}
+
+	return meta, nil
+}
+
+// queryAzure reads the instance metadata of Azure virtual machines.
+func queryAzure(ctx context.Context, opts HostMetadataOptions) (HostMetadata, error) {
+
+	var doc struct {
+		Compute struct {
+			VMID     string `json:"vmId"`
+			Name     string `json:"name"`
+			Location string `json:"location"`
+			Zone     string `json:"zone"`
+		} `json:"compute"`
+		Network struct {
+			Interface []struct {
+				IPv4 struct {
+					IPAddress []struct {
+						PrivateIPAddress string `json:"privateIpAddress"`
+					} `json:"ipAddress"`
+				} `json:"ipv4"`
+			} `json:"interface"`
+		} `json:"network"`
+	}
+	if err := metadataRequest(ctx, opts, http.MethodGet, "/metadata/instance?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"}, &doc); err != nil {
+		return HostMetadata{}, err
+	}
+
+	meta := HostMetadata{
+		CloudProvider: "azure",
+		InstanceID:    doc.Compute.VMID,
+		Hostname:      doc.Compute.Name,
+		Region:        doc.Compute.Location,
+		Zone:          doc.Compute.Zone,
+	}
+	if len(doc.Network.Interface) > 0 && len(doc.Network.Interface[0].IPv4.IPAddress) > 0 {
+		meta.Address = doc.Network.Interface[0].IPv4.IPAddress[0].PrivateIPAddress
+	}
+
+	return meta, nil
+}
+
+// containerIDPattern matches the 64 hex digit container IDs of Docker,
+// containerd and CRI-O in cgroup paths.
+var containerIDPattern = regexp.MustCompile(`(?:^|[/-])([0-9a-f]{64})(?:\.scope)?(?:/|$)`)
+
+// containerID extracts the container ID from the contents of /proc/self/cgroup
+// or /proc/self/mountinfo, an empty string is returned outside of containers.
+func containerID(cgroup string) string {
+
+	for _, line := range strings.Split(cgroup, "\n") {
+		for _, field := range strings.Fields(line) {
+			for _, part := range strings.Split(field, ":") {
+				if m := containerIDPattern.FindStringSubmatch(part); m != nil {
+					return m[1]
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// localAddress returns the first global unicast address of the host's interfaces.
+func localAddress() string {
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() {
+			return ipnet.IP.String()
+		}
+	}
+
+	return ""
+}
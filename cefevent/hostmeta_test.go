@@ -0,0 +1,129 @@
+package cefevent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDetectHostMetadataCloud(t *testing.T) {
+
+	var tests = []struct {
+		name    string
+		handler http.HandlerFunc
+		want    HostMetadata
+	}{
+		{
+			"aws",
+			func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+					w.Write([]byte("token"))
+				case r.Header.Get("X-aws-ec2-metadata-token") != "token":
+					w.WriteHeader(http.StatusUnauthorized)
+				case r.URL.Path == "/latest/dynamic/instance-identity/document":
+					w.Write([]byte(`{"instanceId":"i-0123","region":"eu-west-1","availabilityZone":"eu-west-1a","privateIp":"10.0.0.5"}`))
+				case r.URL.Path == "/latest/meta-data/local-hostname":
+					w.Write([]byte("ip-10-0-0-5.internal\n"))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			},
+			HostMetadata{Hostname: "ip-10-0-0-5.internal", Address: "10.0.0.5", CloudProvider: "aws", InstanceID: "i-0123", Region: "eu-west-1", Zone: "eu-west-1a"},
+		},
+		{
+			"gcp",
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/computeMetadata/v1/instance/" || r.Header.Get("Metadata-Flavor") != "Google" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(`{"id":4520031799277581759,"hostname":"vm.c.project.internal","zone":"projects/123/zones/us-central1-a","networkInterfaces":[{"ip":"10.128.0.2"}]}`))
+			},
+			HostMetadata{Hostname: "vm.c.project.internal", Address: "10.128.0.2", CloudProvider: "gcp", InstanceID: "4520031799277581759", Region: "us-central1", Zone: "us-central1-a"},
+		},
+		{
+			"azure",
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/metadata/instance" || r.Header.Get("Metadata") != "true" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(`{"compute":{"vmId":"02aab8a4","name":"vm01","location":"westeurope","zone":"1"},"network":{"interface":[{"ipv4":{"ipAddress":[{"privateIpAddress":"10.1.0.4"}]}}]}}`))
+			},
+			HostMetadata{Hostname: "vm01", Address: "10.1.0.4", CloudProvider: "azure", InstanceID: "02aab8a4", Region: "westeurope", Zone: "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			got := DetectHostMetadata(context.Background(), HostMetadataOptions{Endpoint: server.URL})
+			got.ContainerID, got.PodName, got.PodNamespace = "", "", ""
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectHostMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectHostMetadataKubernetes(t *testing.T) {
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	t.Setenv("POD_NAME", "collector-7d9f")
+	t.Setenv("POD_NAMESPACE", "logging")
+
+	got := DetectHostMetadata(context.Background(), HostMetadataOptions{DisableCloud: true})
+
+	if got.PodName != "collector-7d9f" || got.PodNamespace != "logging" || got.CloudProvider != "" {
+		t.Errorf("DetectHostMetadata() = %+v, want the pod identity", got)
+	}
+}
+
+func TestContainerID(t *testing.T) {
+
+	id := "3f4e5d6c7b8a99887766554433221100ffeeddccbbaa00112233445566778899"
+
+	var tests = []struct {
+		cgroup string
+		want   string
+	}{
+		{"12:memory:/docker/" + id + "\n0::/", id},
+		{"0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + id + ".scope", id},
+		{"1509 1480 0:53 /docker/containers/" + id + "/hostname /etc/hostname rw", id},
+		{"0::/user.slice/user-1000.slice/session-2.scope", ""},
+	}
+
+	for _, tt := range tests {
+		if got := containerID(tt.cgroup); got != tt.want {
+			t.Errorf("containerID(%q) = %q, want %q", tt.cgroup, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichHost(t *testing.T) {
+
+	meta := HostMetadata{Hostname: "vm01", Address: "10.1.0.4", CloudProvider: "azure", ContainerID: "abc"}
+
+	want := map[string]string{"dvchost": "vm01", "dvc": "10.1.0.4", "cloudProvider": "azure", "containerId": "abc"}
+	if got := meta.Extensions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %v, want %v", got, want)
+	}
+
+	var got CefEvent
+	handler := EnrichHost(meta)(func(e CefEvent) error {
+		got = e
+		return nil
+	})
+	handler(CefEvent{Extensions: map[string]string{"dvchost": "original"}})
+
+	if got.Extensions["dvchost"] != "original" || got.Extensions["cloudProvider"] != "azure" {
+		t.Errorf("EnrichHost() = %v, want existing extensions kept", got.Extensions)
+	}
+}
@@ -0,0 +1,269 @@
+package cefevent
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// interopFields maps the normalized field names used by other Go CEF and
+// LEEF packages onto the CefEvent field they correspond to. Names are
+// compared in lower case without underscores.
+var interopFields = map[string]string{
+	"version":            "Version",
+	"cefversion":         "Version",
+	"devicevendor":       "DeviceVendor",
+	"vendor":             "DeviceVendor",
+	"deviceproduct":      "DeviceProduct",
+	"product":            "DeviceProduct",
+	"productname":        "DeviceProduct",
+	"deviceversion":      "DeviceVersion",
+	"productversion":     "DeviceVersion",
+	"deviceeventclassid": "DeviceEventClassId",
+	"eventclassid":       "DeviceEventClassId",
+	"signatureid":        "DeviceEventClassId",
+	"eventid":            "DeviceEventClassId",
+	"name":               "Name",
+	"eventname":          "Name",
+	"severity":           "Severity",
+	"sev":                "Severity",
+	"extensions":         "Extensions",
+	"extension":          "Extensions",
+	"ext":                "Extensions",
+	"attributes":         "Extensions",
+	"attrs":              "Extensions",
+	"fields":             "Extensions",
+}
+
+// interopField returns the CefEvent field the struct field name corresponds to.
+func interopField(name string) (string, bool) {
+
+	field, ok := interopFields[strings.ToLower(strings.ReplaceAll(name, "_", ""))]
+
+	return field, ok
+}
+
+// interopStruct dereferences v down to a struct value.
+func interopStruct(v interface{}) (reflect.Value, error) {
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("can not convert a nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("can not convert %T, it is no struct", v)
+	}
+
+	return rv, nil
+}
+
+// interopString formats a scalar field value as string.
+func interopString(v reflect.Value) (string, bool) {
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return "", true
+		}
+		return interopString(v.Elem())
+	}
+
+	return "", false
+}
+
+// ConvertFrom converts the event type of another CEF or LEEF package into
+// a CefEvent, so code using that package can be migrated call site by call
+// site. The fields of src are matched by name, ignoring case and
+// underscores, against the CefEvent fields and their common aliases, e.g.
+// Vendor, Product, ProductVersion, SignatureID or EventID, Attributes.
+// Numeric fields are formatted as strings, the extensions may be any map
+// with string keys. LEEF events carry the name and severity as "name" and
+// "sev" attributes, which are moved into the header when it lacks them.
+//
+// Parameters:
+// - src: A struct or pointer to a struct of the other package.
+//
+// Returns:
+// - The converted CefEvent.
+// - An error if src is no struct or a field could not be converted.
+func ConvertFrom(src interface{}) (CefEvent, error) {
+
+	rv, err := interopStruct(src)
+	if err != nil {
+		return CefEvent{}, err
+	}
+
+	event := CefEvent{Extensions: map[string]string{}}
+	header := reflect.ValueOf(&event).Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+
+		sf := rv.Type().Field(i)
+		target, ok := interopField(sf.Name)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		value := rv.Field(i)
+
+		if target == "Extensions" {
+			for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+				value = value.Elem()
+			}
+			if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+				return CefEvent{}, fmt.Errorf("can not convert field %s of type %s into extensions", sf.Name, sf.Type)
+			}
+			iter := value.MapRange()
+			for iter.Next() {
+				s, ok := interopString(iter.Value())
+				if !ok {
+					return CefEvent{}, fmt.Errorf("can not convert extension %s of type %s", iter.Key().String(), iter.Value().Type())
+				}
+				event.Extensions[iter.Key().String()] = s
+			}
+			continue
+		}
+
+		s, ok := interopString(value)
+		if !ok {
+			return CefEvent{}, fmt.Errorf("can not convert field %s of type %s", sf.Name, sf.Type)
+		}
+
+		if target == "Version" {
+			if s == "" {
+				continue
+			}
+			version, err := strconv.Atoi(s)
+			if err != nil {
+				return CefEvent{}, fmt.Errorf("field %s is no CEF version: %w", sf.Name, err)
+			}
+			event.Version = version
+			continue
+		}
+
+		header.FieldByName(target).SetString(s)
+	}
+
+	for key, target := range map[string]*string{"name": &event.Name, "sev": &event.Severity} {
+		if value, ok := event.Extensions[key]; ok && *target == "" {
+			*target = value
+			delete(event.Extensions, key)
+		}
+	}
+
+	return event, nil
+}
+
+// ConvertTo converts the CefEvent into the event type of another CEF or
+// LEEF package, matching the fields of dst like ConvertFrom. Integer fields
+// are parsed from the string values, e.g. a numeric severity, and the
+// extensions are copied into a new map of the type of the dst field.
+// Fields of dst without a CefEvent counterpart are left untouched.
+//
+// Parameters:
+// - dst: A pointer to a struct of the other package.
+//
+// Returns:
+// - An error if dst is no pointer to a struct or a value does not fit into its field.
+func (event *CefEvent) ConvertTo(dst interface{}) error {
+
+	if rv := reflect.ValueOf(dst); rv.Kind() != reflect.Pointer {
+		return fmt.Errorf("can not convert into %T, it is no pointer", dst)
+	}
+
+	rv, err := interopStruct(dst)
+	if err != nil {
+		return err
+	}
+
+	header := reflect.ValueOf(event).Elem()
+
+	for i := 0; i < rv.NumField(); i++ {
+
+		sf := rv.Type().Field(i)
+		target, ok := interopField(sf.Name)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		field := rv.Field(i)
+
+		if target == "Extensions" {
+			if field.Kind() != reflect.Map || field.Type().Key().Kind() != reflect.String {
+				return fmt.Errorf("can not convert extensions into field %s of type %s", sf.Name, sf.Type)
+			}
+			m := reflect.MakeMapWithSize(field.Type(), len(event.Extensions))
+			for k, v := range event.Extensions {
+				value := reflect.New(field.Type().Elem()).Elem()
+				if err := interopSet(value, v); err != nil {
+					return fmt.Errorf("can not convert extension %s: %w", k, err)
+				}
+				m.SetMapIndex(reflect.ValueOf(k).Convert(field.Type().Key()), value)
+			}
+			field.Set(m)
+			continue
+		}
+
+		var s string
+		if target == "Version" {
+			s = strconv.Itoa(event.Version)
+		} else {
+			s = header.FieldByName(target).String()
+		}
+
+		if err := interopSet(field, s); err != nil {
+			return fmt.Errorf("can not convert into field %s: %w", sf.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// interopSet stores the string s in the scalar field v, parsing it when v is numeric.
+func interopSet(v reflect.Value, s string) error {
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Interface:
+		if !reflect.TypeOf(s).AssignableTo(v.Type()) {
+			return fmt.Errorf("string is not assignable to %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
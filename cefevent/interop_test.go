@@ -0,0 +1,110 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+)
+
+// otherCEF mimics the event type of another CEF package.
+type otherCEF struct {
+	CEFVersion    int
+	Vendor        string
+	Product       string
+	SignatureID   string
+	Name          string
+	Severity      int
+	Extension     map[string]interface{}
+	ReceivedAtUTC string
+}
+
+// otherLEEF mimics the event type of a LEEF package.
+type otherLEEF struct {
+	Vendor         string
+	ProductName    string
+	ProductVersion string
+	EventID        string
+	Attributes     map[string]string
+}
+
+func TestConvertFrom(t *testing.T) {
+
+	leef := otherLEEF{
+		Vendor:         "Cool Vendor",
+		ProductName:    "Cool Product",
+		ProductVersion: "1.0",
+		EventID:        "COOL_THING",
+		Attributes:     map[string]string{"name": "Something cool happened.", "sev": "Unknown", "src": "127.0.0.1"},
+	}
+
+	got, err := ConvertFrom(&leef)
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("ConvertFrom() = %+v, %v, want %+v", got, err, event)
+	}
+
+	other := otherCEF{
+		CEFVersion:  1,
+		Vendor:      "Cool Vendor",
+		SignatureID: "100",
+		Name:        "worm stopped",
+		Severity:    10,
+		Extension:   map[string]interface{}{"spt": 1232, "src": "10.0.0.1"},
+	}
+
+	want := CefEvent{
+		Version:            1,
+		DeviceVendor:       "Cool Vendor",
+		DeviceEventClassId: "100",
+		Name:               "worm stopped",
+		Severity:           "10",
+		Extensions:         map[string]string{"spt": "1232", "src": "10.0.0.1"},
+	}
+
+	got, err = ConvertFrom(other)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ConvertFrom() = %+v, %v, want %+v", got, err, want)
+	}
+
+	for _, src := range []interface{}{nil, "CEF:0", (*otherLEEF)(nil), struct{ Version string }{"2.0"}} {
+		if _, err := ConvertFrom(src); err == nil {
+			t.Errorf("ConvertFrom(%#v) should fail", src)
+		}
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+
+	var leef otherLEEF
+	if err := event.ConvertTo(&leef); err != nil {
+		t.Fatalf("ConvertTo() = %v", err)
+	}
+
+	got, err := ConvertFrom(leef)
+	if err != nil {
+		t.Fatalf("ConvertFrom() = %v", err)
+	}
+
+	want := event
+	want.Extensions = map[string]string{"src": "127.0.0.1"}
+	want.Name, want.Severity = "", ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConvertFrom(ConvertTo()) = %+v, want %+v", got, want)
+	}
+
+	numeric := event
+	numeric.Severity = "7"
+
+	other := otherCEF{ReceivedAtUTC: "kept"}
+	if err := numeric.ConvertTo(&other); err != nil {
+		t.Fatalf("ConvertTo() = %v", err)
+	}
+	if other.Severity != 7 || other.Extension["src"] != "127.0.0.1" || other.ReceivedAtUTC != "kept" {
+		t.Errorf("ConvertTo() = %+v", other)
+	}
+
+	if err := event.ConvertTo(&other); err == nil {
+		t.Errorf("ConvertTo() should fail for a non-numeric severity")
+	}
+	if err := event.ConvertTo(other); err == nil {
+		t.Errorf("ConvertTo() should fail for a non-pointer")
+	}
+}
@@ -0,0 +1,133 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JSONNaming selects the keys of the JSON documents written by ToJSONWithOptions.
+type JSONNaming int
+
+const (
+	// JSONPascalCase names the header fields like the fields of CefEvent,
+	// e.g. "DeviceVendor", and keeps the extension keys as they are. It is
+	// the naming of ToJSON.
+	JSONPascalCase JSONNaming = iota
+	// JSONCamelCase names the header fields in camelCase, e.g.
+	// "deviceVendor", and the extensions by the full names of the
+	// dictionary, e.g. "sourceAddress" for "src".
+	JSONCamelCase
+	// JSONSnakeCase names the header fields and the full names of the
+	// extensions in snake_case, e.g. "device_vendor" and "source_address".
+	JSONSnakeCase
+	// JSONAbbreviated names the header fields in camelCase and the
+	// extensions by their CEF keys, e.g. "src" for "sourceAddress".
+	JSONAbbreviated
+)
+
+// String returns the name of the JSONNaming.
+func (n JSONNaming) String() string {
+
+	switch n {
+	case JSONCamelCase:
+		return "camel"
+	case JSONSnakeCase:
+		return "snake"
+	case JSONAbbreviated:
+		return "abbreviated"
+	}
+
+	return "pascal"
+}
+
+// JSONOptions configures ToJSONWithOptions.
+type JSONOptions struct {
+	Naming JSONNaming // Naming selects the keys of the document, JSONPascalCase by default.
+}
+
+// jsonKey returns the key of a header field or extension under the naming.
+//
+// Parameters:
+// - name: The struct field name of a header field or an extension key.
+// - extension: Whether name is an extension key.
+func (n JSONNaming) jsonKey(name string, extension bool) string {
+
+	if n == JSONPascalCase {
+		return name
+	}
+
+	if !extension {
+		if n == JSONSnakeCase {
+			return KeySnakeCase(name)
+		}
+		return KeyCamelCase(name)
+	}
+
+	definition, ok := LookupExtension(name)
+	switch {
+	case !ok && n == JSONSnakeCase:
+		return KeySnakeCase(name)
+	case !ok:
+		return name
+	case n == JSONAbbreviated:
+		return definition.Key
+	case n == JSONSnakeCase:
+		return KeySnakeCase(definition.Name)
+	}
+
+	return definition.Name
+}
+
+// ToJSONWithOptions converts the CefEvent instance to a JSON document like
+// ToJSON, with the keys named as the index mappings of the destination
+// expect, see JSONNaming.
+//
+// Parameters:
+// - opts: The naming of the keys.
+//
+// Returns:
+// - The JSON document.
+// - An error if the CefEvent is not valid or two extensions map to the same key, e.g. "src" and "sourceAddress".
+func (event *CefEvent) ToJSONWithOptions(opts JSONOptions) (string, error) {
+
+	if opts.Naming == JSONPascalCase {
+		return event.ToJSON()
+	}
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	extensions := make(map[string]string, len(keys))
+	names := make(map[string]string, len(keys))
+	for _, k := range keys {
+		key := opts.Naming.jsonKey(k, true)
+		if other, ok := names[key]; ok {
+			return "", fmt.Errorf("extensions %q and %q are both named %q", other, k, key)
+		}
+		names[key] = k
+		extensions[key] = event.Extensions[k]
+	}
+
+	doc := map[string]interface{}{
+		opts.Naming.jsonKey(headerFieldNames[0], false): event.Version,
+		opts.Naming.jsonKey(extensionsTable, false):     extensions,
+	}
+	for _, name := range headerFieldNames[1:] {
+		doc[opts.Naming.jsonKey(name, false)] = *documentHeader(event, name)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,32 @@
+package cefevent
+
+import "testing"
+
+func TestToJSONWithOptions(t *testing.T) {
+
+	e := event
+	e.Extensions = map[string]string{"src": "127.0.0.1", "destinationAddress": "10.0.0.1", "ad.userName": "alice"}
+
+	tests := []struct {
+		naming JSONNaming
+		want   string
+	}{
+		{JSONPascalCase, `{"Version":0,"DeviceVendor":"Cool Vendor","DeviceProduct":"Cool Product","DeviceVersion":"1.0","DeviceEventClassId":"COOL_THING","Name":"Something cool happened.","Severity":"Unknown","Extensions":{"ad.userName":"alice","destinationAddress":"10.0.0.1","src":"127.0.0.1"}}`},
+		{JSONCamelCase, `{"deviceEventClassId":"COOL_THING","deviceProduct":"Cool Product","deviceVendor":"Cool Vendor","deviceVersion":"1.0","extensions":{"ad.userName":"alice","destinationAddress":"10.0.0.1","sourceAddress":"127.0.0.1"},"name":"Something cool happened.","severity":"Unknown","version":0}`},
+		{JSONSnakeCase, `{"device_event_class_id":"COOL_THING","device_product":"Cool Product","device_vendor":"Cool Vendor","device_version":"1.0","extensions":{"ad_user_name":"alice","destination_address":"10.0.0.1","source_address":"127.0.0.1"},"name":"Something cool happened.","severity":"Unknown","version":0}`},
+		{JSONAbbreviated, `{"deviceEventClassId":"COOL_THING","deviceProduct":"Cool Product","deviceVendor":"Cool Vendor","deviceVersion":"1.0","extensions":{"ad.userName":"alice","dst":"10.0.0.1","src":"127.0.0.1"},"name":"Something cool happened.","severity":"Unknown","version":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.naming.String(), func(t *testing.T) {
+			if got, err := e.ToJSONWithOptions(JSONOptions{Naming: tt.naming}); err != nil || got != tt.want {
+				t.Errorf("ToJSONWithOptions() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	e.Extensions = map[string]string{"src": "127.0.0.1", "sourceAddress": "10.0.0.1"}
+	if _, err := e.ToJSONWithOptions(JSONOptions{Naming: JSONCamelCase}); err == nil {
+		t.Error("ToJSONWithOptions() = nil, want an error for extensions with the same name")
+	}
+}
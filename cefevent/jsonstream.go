@@ -0,0 +1,145 @@
+package cefevent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FieldMapping maps header field names (e.g. "DeviceVendor" or "Severity",
+// see FieldValue) and extension keys onto templates of their values, like
+// the fields of a GrokRule. Templates reference the values of a JSON record
+// as $name or ${path}, where the path selects nested objects and array
+// elements separated by dots, e.g. ${user.name} or ${tags.0}. Any other
+// text is taken literally, e.g. to set the vendor of all records:
+//
+//	cefevent.FieldMapping{
+//		"DeviceVendor": "Acme", "DeviceProduct": "Gateway", "DeviceVersion": "2.1",
+//		"DeviceEventClassId": "$type", "Name": "$message", "Severity": "${level}",
+//		"src": "${client.ip}", "suser": "${client.user}",
+//	}
+//
+// Numbers and booleans are rendered as in the record, objects and arrays as
+// JSON, missing values and null as the empty string. Extensions whose
+// template expands to the empty string are omitted.
+type FieldMapping map[string]string
+
+// ConvertJSONStream converts a stream of JSON records, e.g. NDJSON log
+// files, into CEF lines by applying the mapping to each record, so custom
+// JSON logs can be migrated into a CEF pipeline. Records may be separated
+// by any whitespace.
+//
+// Parameters:
+// - r: The io.Reader providing the JSON records.
+// - mapping: The mapping of the record values onto the fields of the events.
+// - w: The io.Writer receiving one CEF line per record.
+//
+// Returns:
+// - An error if the input is not a stream of JSON objects, a record does not map onto a
+// valid event, naming the record by its number counted from 1, or writing fails.
+func ConvertJSONStream(r io.Reader, mapping FieldMapping, w io.Writer) error {
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	out := bufio.NewWriter(w)
+
+	for n := 1; ; n++ {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("record %d is not a JSON object: %w", n, err)
+		}
+
+		event, err := mapping.convert(record)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+
+		line, err := event.String()
+		if err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		if _, err := out.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+// convert applies the mapping to a decoded JSON record.
+func (m FieldMapping) convert(record map[string]interface{}) (CefEvent, error) {
+
+	event := CefEvent{Extensions: make(map[string]string)}
+
+	for field, template := range m {
+		value := os.Expand(template, func(path string) string {
+			return jsonPathValue(record, path)
+		})
+
+		if field == "Version" {
+			version, err := strconv.Atoi(value)
+			if err != nil {
+				return CefEvent{}, fmt.Errorf("mapped field Version is no number: %w", err)
+			}
+			event.Version = version
+			continue
+		}
+
+		if header := documentHeader(&event, field); header != nil {
+			*header = value
+		} else if value != "" {
+			event.Extensions[field] = value
+		}
+	}
+
+	if err := event.Validate(); err != nil {
+		return CefEvent{}, err
+	}
+
+	return event, nil
+}
+
+// jsonPathValue returns the value of a JSON record at a dot separated path
+// as string, see FieldMapping.
+func jsonPathValue(record map[string]interface{}, path string) string {
+
+	var value interface{} = record
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return ""
+			}
+			value = v[i]
+		default:
+			return ""
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
@@ -0,0 +1,78 @@
+package cefevent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertJSONStream(t *testing.T) {
+
+	mapping := FieldMapping{
+		"DeviceVendor":       "Acme",
+		"DeviceProduct":      "Gateway",
+		"DeviceVersion":      "2.1",
+		"DeviceEventClassId": "$type",
+		"Name":               "${message}",
+		"Severity":           "${level}",
+		"src":                "${client.ip}",
+		"suser":              "${client.user}",
+		"cs1":                "${tags.1}",
+		"cs2":                "${client}",
+		"cn1":                "$count",
+		"cs3":                "${missing.path}",
+		"msg":                "blocked: $blocked",
+	}
+
+	input := `{"type":"deny","message":"Request | denied","level":7,"client":{"ip":"10.0.0.1","user":null},"tags":["a","b"],"count":42,"blocked":true}
+{"type": "allow", "message": "Request allowed", "level": "3", "client": {"ip": "10.0.0.2"}}
+`
+
+	var b strings.Builder
+	if err := ConvertJSONStream(strings.NewReader(input), mapping, &b); err != nil {
+		t.Fatalf("ConvertJSONStream() = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("ConvertJSONStream() wrote %q, want 2 lines", b.String())
+	}
+
+	first, err := Parse(lines[0])
+	if err != nil {
+		t.Fatalf("Parse(%q) = %v", lines[0], err)
+	}
+	want := map[string]string{"src": "10.0.0.1", "cs1": "b", "cs2": `{"ip":"10.0.0.1","user":null}`, "cn1": "42", "msg": "blocked: true"}
+	if first.DeviceEventClassId != "deny" || first.Name != "Request | denied" || first.Severity != "7" || len(first.Extensions) != len(want) {
+		t.Errorf("first event = %#v, want extensions %v", first, want)
+	}
+	for k, v := range want {
+		if first.Extensions[k] != v {
+			t.Errorf("extension %s = %q, want %q", k, first.Extensions[k], v)
+		}
+	}
+
+	if !strings.HasPrefix(lines[1], "CEF:0|Acme|Gateway|2.1|allow|Request allowed|3|") {
+		t.Errorf("second line = %q", lines[1])
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		mapping FieldMapping
+		want    string
+	}{
+		{"malformed JSON", `{"type":"deny","message":"m","level":1} {"type":`, mapping, "record 2"},
+		{"no object", `["deny"]`, mapping, "record 1"},
+		{"invalid event", `{"type":"deny"}`, mapping, "record 1"},
+		{"invalid version", `{}`, FieldMapping{"Version": "$v"}, "Version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ConvertJSONStream(strings.NewReader(tt.input), tt.mapping, &strings.Builder{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ConvertJSONStream() = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package cefevent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeyNormalizer rewrites an extension key which is not defined by the CEF
+// specification, e.g. the field name of a producer, into the convention a
+// consumer expects. ArcSight for instance expects such keys in camelCase or
+// prefixed with the vendor.
+type KeyNormalizer func(key string) string
+
+// splitKeyWords splits a key into its words at underscores, hyphens, dots,
+// spaces and lower to upper case transitions.
+func splitKeyWords(key string) []string {
+
+	var words []string
+	var word strings.Builder
+	var prev rune
+
+	for _, r := range key {
+		switch {
+		case r == '_' || r == '-' || r == '.' || r == ' ':
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) && word.Len() > 0:
+			words = append(words, word.String())
+			word.Reset()
+			word.WriteRune(r)
+		default:
+			word.WriteRune(r)
+		}
+		prev = r
+	}
+
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+
+	return words
+}
+
+// KeyPassthrough leaves keys as they are, it is the default.
+func KeyPassthrough(key string) string {
+	return key
+}
+
+// KeyCamelCase rewrites keys in camelCase, e.g. "source_user" and
+// "Source-User" become "sourceUser". Acronyms are kept unless they start
+// the key, "IP_address" becomes "ipAddress".
+func KeyCamelCase(key string) string {
+
+	var b strings.Builder
+	for i, word := range splitKeyWords(key) {
+		if i == 0 && strings.ToUpper(word) == word {
+			word = strings.ToLower(word)
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		if i == 0 {
+			r = unicode.ToLower(r)
+		} else {
+			r = unicode.ToUpper(r)
+		}
+		b.WriteRune(r)
+		b.WriteString(word[size:])
+	}
+
+	return b.String()
+}
+
+// KeySnakeCase rewrites keys in snake_case, e.g. "sourceUser" and
+// "source-user" become "source_user".
+func KeySnakeCase(key string) string {
+
+	words := splitKeyWords(key)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+
+	return strings.Join(words, "_")
+}
+
+// KeyPrefix returns a KeyNormalizer prefixing keys with prefix, e.g. "ad."
+// for the additional data of ArcSight. Keys which already carry the prefix
+// are left as they are.
+func KeyPrefix(prefix string) KeyNormalizer {
+
+	return func(key string) string {
+		if strings.HasPrefix(key, prefix) {
+			return key
+		}
+		return prefix + key
+	}
+}
+
+var (
+	keyNormalizersMu sync.RWMutex
+	keyNormalizers   = map[string]KeyNormalizer{
+		"passthrough": KeyPassthrough,
+		"camel":       KeyCamelCase,
+		"snake":       KeySnakeCase,
+		"ad":          KeyPrefix("ad."),
+	}
+)
+
+// RegisterKeyNormalizer makes a KeyNormalizer available by name, e.g. to
+// pipeline configurations, an existing registration with the same name is
+// replaced. "passthrough", "camel", "snake" and "ad" are registered by default.
+func RegisterKeyNormalizer(name string, normalizer KeyNormalizer) {
+
+	keyNormalizersMu.Lock()
+	defer keyNormalizersMu.Unlock()
+
+	keyNormalizers[name] = normalizer
+}
+
+// LookupKeyNormalizer returns the KeyNormalizer registered under name, an
+// empty name selects KeyPassthrough.
+//
+// Returns:
+// - The KeyNormalizer.
+// - An error if no KeyNormalizer with that name is registered.
+func LookupKeyNormalizer(name string) (KeyNormalizer, error) {
+
+	if name == "" {
+		return KeyPassthrough, nil
+	}
+
+	keyNormalizersMu.RLock()
+	defer keyNormalizersMu.RUnlock()
+
+	normalizer, ok := keyNormalizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown key normalizer %q, known normalizers are %v", name, registeredTypes(keyNormalizers))
+	}
+
+	return normalizer, nil
+}
+
+// NormalizeKeys rewrites every extension key of the event which is not
+// defined by the CEF specification with the normalizer. A key is kept as it
+// is if its normalized form is empty or already used by another extension,
+// so no value is lost.
+//
+// Returns:
+// - Whether a key was rewritten.
+func (event *CefEvent) NormalizeKeys(normalizer KeyNormalizer) bool {
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		if !IsDictionaryKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	// the order decides which of two keys normalized alike is rewritten.
+	sort.Strings(keys)
+
+	changed := false
+	for _, k := range keys {
+		normalized := normalizer(k)
+		if normalized == k || normalized == "" {
+			continue
+		}
+		if _, taken := event.Extensions[normalized]; taken {
+			continue
+		}
+		event.Extensions[normalized] = event.Extensions[k]
+		delete(event.Extensions, k)
+		changed = true
+	}
+
+	return changed
+}
+
+// NormalizeKeys returns a Middleware rewriting the extension keys of all
+// events which are not defined by the CEF specification with the normalizer.
+//
+// Parameters:
+// - normalizer: The KeyNormalizer, e.g. KeyCamelCase or KeyPrefix("ad.").
+//
+// Returns:
+// - A Middleware normalizing the events passing through it.
+func NormalizeKeys(normalizer KeyNormalizer) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			event.Extensions = cloneExtensions(event.Extensions)
+			event.NormalizeKeys(normalizer)
+			return next(event)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyNormalizers(t *testing.T) {
+
+	var tests = []struct {
+		normalizer KeyNormalizer
+		key        string
+		want       string
+	}{
+		{KeyPassthrough, "source_user", "source_user"},
+		{KeyCamelCase, "source_user", "sourceUser"},
+		{KeyCamelCase, "Source-User", "sourceUser"},
+		{KeyCamelCase, "IP_address", "ipAddress"},
+		{KeyCamelCase, "http.requestID", "httpRequestID"},
+		{KeyCamelCase, "sourceUser", "sourceUser"},
+		{KeySnakeCase, "sourceUser", "source_user"},
+		{KeySnakeCase, "source-user.name", "source_user_name"},
+		{KeySnakeCase, "source_user", "source_user"},
+		{KeyPrefix("ad."), "threatScore", "ad.threatScore"},
+		{KeyPrefix("ad."), "ad.threatScore", "ad.threatScore"},
+	}
+
+	for _, test := range tests {
+		if got := test.normalizer(test.key); got != test.want {
+			t.Errorf("normalizer(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeKeys(t *testing.T) {
+
+	e := event
+	e.Extensions = map[string]string{"src": "10.0.0.1", "threat_score": "7", "user-name": "alice", "userName": "bob"}
+
+	var got CefEvent
+	handler := NormalizeKeys(KeyCamelCase)(func(event CefEvent) error {
+		got = event
+		return nil
+	})
+	handler(e)
+
+	// user-name is kept as userName is already taken.
+	want := map[string]string{"src": "10.0.0.1", "threatScore": "7", "user-name": "alice", "userName": "bob"}
+	if !reflect.DeepEqual(got.Extensions, want) {
+		t.Errorf("NormalizeKeys() = %v, want %v", got.Extensions, want)
+	}
+	if _, ok := e.Extensions["threat_score"]; !ok {
+		t.Errorf("NormalizeKeys() modified the extensions of the original event")
+	}
+
+	e.Extensions = map[string]string{"src": "10.0.0.1"}
+	if e.NormalizeKeys(KeyPrefix("ad.")) {
+		t.Errorf("NormalizeKeys() rewrote the dictionary key src")
+	}
+}
+
+func TestLookupKeyNormalizer(t *testing.T) {
+
+	RegisterKeyNormalizer("upper", func(key string) string { return "X" + key })
+
+	for name, want := range map[string]string{"": "my_key", "camel": "myKey", "ad": "ad.my_key", "upper": "Xmy_key"} {
+		normalizer, err := LookupKeyNormalizer(name)
+		if err != nil || normalizer("my_key") != want {
+			t.Errorf("LookupKeyNormalizer(%q) = %v, want a normalizer returning %q", name, err, want)
+		}
+	}
+
+	if _, err := LookupKeyNormalizer("kebab"); err == nil {
+		t.Errorf("LookupKeyNormalizer(kebab) = nil, want an error")
+	}
+}
@@ -0,0 +1,82 @@
+package cefevent
+
+import (
+	"fmt"
+	"time"
+)
+
+// LateArrivalKey is the extension tagging late events with their age at
+// receipt, e.g. "26h3m0s", see LateArrival.
+const LateArrivalKey = "cefLateArrival"
+
+// LateArrivalOptions configures the LateArrival middleware.
+type LateArrivalOptions struct {
+	MaxAge time.Duration // MaxAge is the age at receipt above which an event is late.
+	Drop   bool          // Drop drops late events instead of tagging them with LateArrivalKey.
+}
+
+// LateArrival returns a Middleware handling events whose event time, see
+// EventTime, is older than opts.MaxAge when they pass through it, e.g.
+// events replayed from a buffer or sent by devices with a skewed clock,
+// which would otherwise show up in real-time dashboards. Late events are
+// tagged with their age in the LateArrivalKey extension or dropped, events
+// without an event time pass through unchanged.
+//
+// Within a Pipeline the middleware runs as soon as an event was received,
+// so the time it sees an event at stands in for its receipt time.
+//
+// Parameters:
+// - opts: The threshold and whether late events are dropped.
+//
+// Returns:
+// - A Middleware tagging or dropping the late events passing through it.
+func LateArrival(opts LateArrivalOptions) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			t, ok := event.EventTime()
+			if !ok {
+				return next(event)
+			}
+
+			age := time.Since(t)
+			if age <= opts.MaxAge {
+				return next(event)
+			}
+			if opts.Drop {
+				return nil
+			}
+
+			event.Extensions = cloneExtensions(event.Extensions)
+			event.Extensions[LateArrivalKey] = age.Truncate(time.Second).String()
+			return next(event)
+		}
+	}
+}
+
+// LateArrivalConfig describes the late-arrival policy of a pipeline configuration.
+type LateArrivalConfig struct {
+	MaxAge string `json:"max_age"`          // MaxAge is the time.Duration above which events are late.
+	Action string `json:"action,omitempty"` // Action is "tag", the default, or "drop".
+}
+
+// options converts the configuration into LateArrivalOptions.
+func (c LateArrivalConfig) options() (LateArrivalOptions, error) {
+
+	maxAge, err := time.ParseDuration(c.MaxAge)
+	if err != nil {
+		return LateArrivalOptions{}, fmt.Errorf("invalid max_age: %w", err)
+	}
+	if maxAge <= 0 {
+		return LateArrivalOptions{}, fmt.Errorf("max_age %s is not positive", c.MaxAge)
+	}
+
+	switch c.Action {
+	case "", "tag":
+		return LateArrivalOptions{MaxAge: maxAge}, nil
+	case "drop":
+		return LateArrivalOptions{MaxAge: maxAge, Drop: true}, nil
+	}
+
+	return LateArrivalOptions{}, fmt.Errorf("unknown late-arrival action %q, known actions are [drop tag]", c.Action)
+}
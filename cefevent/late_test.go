@@ -0,0 +1,58 @@
+package cefevent
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLateArrival(t *testing.T) {
+
+	at := func(age time.Duration) CefEvent {
+		e := event
+		e.Extensions = map[string]string{"rt": strconv.FormatInt(time.Now().Add(-age).UnixMilli(), 10)}
+		return e
+	}
+
+	tests := []struct {
+		name  string
+		event CefEvent
+		drop  bool
+		want  string // want is the LateArrivalKey extension, "-" if the event is dropped.
+	}{
+		{"recent", at(time.Minute), false, ""},
+		{"without event time", event, true, ""},
+		{"late", at(26 * time.Hour), false, "26h0m0s"},
+		{"late dropped", at(26 * time.Hour), true, "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *CefEvent
+			handler := LateArrival(LateArrivalOptions{MaxAge: time.Hour, Drop: tt.drop})(func(e CefEvent) error {
+				got = &e
+				return nil
+			})
+			handler(tt.event)
+
+			if got == nil {
+				if tt.want != "-" {
+					t.Fatalf("LateArrival() dropped the event")
+				}
+				return
+			}
+			if tt.want == "-" {
+				t.Fatalf("LateArrival() passed the event on, want it dropped")
+			}
+			if value := got.Extensions[LateArrivalKey]; value != tt.want {
+				t.Errorf("%s = %q, want %q", LateArrivalKey, value, tt.want)
+			}
+		})
+	}
+
+	late := at(26 * time.Hour)
+	LateArrival(LateArrivalOptions{MaxAge: time.Hour})(func(CefEvent) error { return nil })(late)
+	if _, ok := late.Extensions[LateArrivalKey]; ok {
+		t.Errorf("LateArrival() modified the extensions of the caller")
+	}
+}
@@ -0,0 +1,220 @@
+package cefevent
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// leefEscapeHeader escapes the pipes and backslashes in LEEF header fields.
+func leefEscapeHeader(field string) string {
+
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		"|", "\\|",
+		"\n", " ",
+	)
+
+	return replacer.Replace(field)
+}
+
+// leefEscapeAttribute makes sure attribute values do not contain the tab
+// delimiter nor line breaks, which would break the LEEF attribute list.
+func leefEscapeAttribute(value string) string {
+
+	replacer := strings.NewReplacer(
+		"\t", " ",
+		"\n", " ",
+		"\r", " ",
+	)
+
+	return replacer.Replace(value)
+}
+
+// ToLEEF renders the CefEvent as LEEF 2.0 message, the log format preferred by IBM QRadar.
+//
+// The vendor, product, version and event class ID are mapped onto the LEEF header,
+// the name and severity of the event become the "name" and "sev" attributes and
+// all extensions are added as tab delimited attributes.
+//
+// Returns:
+// - A string containing the LEEF message.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToLEEF() (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	attributes := map[string]string{
+		"name": event.Name,
+		"sev":  event.Severity,
+	}
+
+	for k, v := range event.Extensions {
+		attributes[k] = v
+	}
+
+	var keys []string
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, leefEscapeAttribute(k)+"="+leefEscapeAttribute(attributes[k]))
+	}
+
+	return fmt.Sprintf(
+		"LEEF:2.0|%s|%s|%s|%s|x09|%s",
+		leefEscapeHeader(event.DeviceVendor),
+		leefEscapeHeader(event.DeviceProduct),
+		leefEscapeHeader(event.DeviceVersion),
+		leefEscapeHeader(event.DeviceEventClassId),
+		strings.Join(pairs, "\t"),
+	), nil
+}
+
+// leefHeaderFieldNames are the CefEvent fields the header fields of a LEEF
+// message, following the version, are mapped onto.
+var leefHeaderFieldNames = []string{"DeviceVendor", "DeviceProduct", "DeviceVersion", "DeviceEventClassId"}
+
+// splitLEEFHeader splits the LEEF message, without the "LEEF:" prefix, into
+// n pipe separated header fields and the remaining attributes, replacing
+// the escape sequences "\|" and "\\" written by ToLEEF.
+func splitLEEFHeader(message string, n int) ([]string, string, bool) {
+
+	fields := make([]string, 0, n)
+	var field strings.Builder
+
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		switch {
+		case c == '\\' && i+1 < len(message) && (message[i+1] == '|' || message[i+1] == '\\'):
+			field.WriteByte(message[i+1])
+			i++
+		case c == '|':
+			fields = append(fields, field.String())
+			field.Reset()
+			if len(fields) == n {
+				return fields, message[i+1:], true
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+
+	return append(fields, field.String()), "", false
+}
+
+// leefDelimiter returns the attribute delimiter declared in the header of a
+// LEEF 2.0 message, either the character itself or its hex code such as
+// "x09" or "0x5E". The delimiter defaults to a tab.
+func leefDelimiter(field string) (string, bool) {
+
+	switch {
+	case field == "":
+		return "\t", true
+	case len(field) == 1:
+		return field, true
+	}
+
+	lower := strings.ToLower(field)
+	hex := strings.TrimPrefix(strings.TrimPrefix(lower, "0x"), "x")
+	if len(hex) == len(lower) {
+		return "", false
+	}
+	code, err := strconv.ParseUint(hex, 16, 8)
+	if err != nil {
+		return "", false
+	}
+
+	return string(rune(code)), true
+}
+
+// FromLEEF parses a LEEF 1.0 or 2.0 message, e.g. received from or meant
+// for IBM QRadar, into a CefEvent, reversing the mapping of ToLEEF.
+//
+// The vendor, product, version and event ID of the LEEF header become the
+// corresponding CEF header fields. The "name" and "sev" attributes become
+// the name and severity of the event, which default to the event ID and
+// "Unknown" when the message lacks them, all other attributes become
+// extensions. The CEF version of the event is 0.
+//
+// Parameters:
+// - line: The LEEF message, e.g. "LEEF:2.0|Vendor|Product|1.0|100|x09|src=10.0.0.1".
+//
+// Returns:
+// - The CefEvent.
+// - A *ParseError if the message is not a valid LEEF message.
+func FromLEEF(line string) (CefEvent, error) {
+
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "LEEF:") {
+		return CefEvent{}, newParseError(0, "", ErrMissingPrefix, "message does not start with \"LEEF:\"")
+	}
+
+	message := line[len("LEEF:"):]
+	version, _, _ := strings.Cut(message, "|")
+	headerFields := 5
+	switch version {
+	case "1.0":
+	case "2.0":
+		headerFields = 6
+	default:
+		return CefEvent{}, newParseError(len("LEEF:"), "Version", ErrInvalidVersion, fmt.Sprintf("LEEF version %q is not 1.0 or 2.0", version))
+	}
+
+	header, attributes, ok := splitLEEFHeader(message, headerFields)
+	if !ok {
+		// LEEF 1.0 messages without attributes may omit the last pipe.
+		if headerFields != 5 || len(header) != 5 {
+			return CefEvent{}, newParseError(len(line), "", ErrIncompleteHeader, fmt.Sprintf("header has %d of %d fields", len(header), headerFields))
+		}
+	}
+	for i, name := range leefHeaderFieldNames {
+		if header[i+1] == "" {
+			return CefEvent{}, newParseError(len("LEEF:"), name, ErrMissingField, "")
+		}
+	}
+
+	delimiter := "\t"
+	if headerFields == 6 {
+		if delimiter, ok = leefDelimiter(header[5]); !ok {
+			return CefEvent{}, newParseError(len("LEEF:"), "", ErrIncompleteHeader, fmt.Sprintf("attribute delimiter %q is invalid", header[5]))
+		}
+	}
+
+	event := CefEvent{
+		DeviceVendor:       header[1],
+		DeviceProduct:      header[2],
+		DeviceVersion:      header[3],
+		DeviceEventClassId: header[4],
+		Name:               header[4],
+		Severity:           "Unknown",
+		Extensions:         make(map[string]string),
+	}
+
+	offset := len(line) - len(attributes)
+	for _, attribute := range strings.Split(attributes, delimiter) {
+		if strings.TrimSpace(attribute) != "" {
+			key, value, found := strings.Cut(attribute, "=")
+			if !found || key == "" {
+				return CefEvent{}, newParseError(offset, key, ErrMalformedExtension, fmt.Sprintf("attribute %q is no key=value pair", attribute))
+			}
+			switch key {
+			case "name":
+				event.Name = value
+			case "sev":
+				event.Severity = value
+			default:
+				event.Extensions[key] = value
+			}
+		}
+		offset += len(attribute) + len(delimiter)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,92 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFromLEEF(t *testing.T) {
+
+	tests := []struct {
+		name string
+		line string
+		want CefEvent
+	}{
+		{
+			"leef 2.0",
+			"LEEF:2.0|Cool Vendor|Cool Product|1.0|COOL_THING|x09|name=Something cool happened.\tsev=Unknown\tsrc=127.0.0.1\r\n",
+			event,
+		},
+		{
+			"leef 2.0 caret delimiter",
+			"LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=5^cat=anomaly^msg=there are spaces in this message",
+			CefEvent{DeviceVendor: "Lancope", DeviceProduct: "StealthWatch", DeviceVersion: "1.0", DeviceEventClassId: "41", Name: "41", Severity: "5",
+				Extensions: map[string]string{"src": "10.0.1.8", "dst": "10.0.0.5", "cat": "anomaly", "msg": "there are spaces in this message"}},
+		},
+		{
+			"leef 2.0 hex delimiter",
+			"LEEF:2.0|V|P|1|100|0x7C|a=1|b=x=y",
+			CefEvent{DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "100", Name: "100", Severity: "Unknown",
+				Extensions: map[string]string{"a": "1", "b": "x=y"}},
+		},
+		{
+			"leef 1.0",
+			"LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5",
+			CefEvent{DeviceVendor: "Microsoft", DeviceProduct: "MSExchange", DeviceVersion: "4.0 SP1", DeviceEventClassId: "15345", Name: "15345", Severity: "5",
+				Extensions: map[string]string{"src": "192.0.2.0", "dst": "172.50.123.1"}},
+		},
+		{
+			"leef 1.0 without attributes",
+			`LEEF:1.0|Pipe\|Vendor|P|1|7`,
+			CefEvent{DeviceVendor: "Pipe|Vendor", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "7", Name: "7", Severity: "Unknown", Extensions: map[string]string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromLEEF(tt.line)
+			if err != nil {
+				t.Fatalf("FromLEEF() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromLEEF() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	errs := []struct {
+		line string
+		want error
+	}{
+		{"CEF:0|V|P|1|100|x09|a=1", ErrMissingPrefix},
+		{"LEEF:3.0|V|P|1|100|x09|a=1", ErrInvalidVersion},
+		{"LEEF:2.0|V|P|1|100", ErrIncompleteHeader},
+		{"LEEF:2.0|V|P|1|100|tab|a=1", ErrIncompleteHeader},
+		{"LEEF:2.0|V||1|100|x09|a=1", ErrMissingField},
+		{"LEEF:2.0|V|P|1|100|x09|a=1\tjunk", ErrMalformedExtension},
+	}
+
+	for _, tt := range errs {
+		var perr *ParseError
+		if _, err := FromLEEF(tt.line); !errors.As(err, &perr) || !errors.Is(err, tt.want) {
+			t.Errorf("FromLEEF(%q) = %v, want a *ParseError wrapping %v", tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestLEEFRoundTrip(t *testing.T) {
+
+	e := event
+	e.DeviceVendor = `Pipe|Back\slash`
+	e.Extensions = map[string]string{"src": "10.0.0.1", "msg": "a=b"}
+
+	line, err := e.ToLEEF()
+	if err != nil {
+		t.Fatalf("ToLEEF() = %v", err)
+	}
+	got, err := FromLEEF(line)
+	if err != nil || !reflect.DeepEqual(got, e) {
+		t.Errorf("FromLEEF(%q) = %+v, %v, want %+v", line, got, err, e)
+	}
+}
@@ -0,0 +1,361 @@
+package cefevent
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxDatagramSize is the largest UDP payload a UDPSource reads at once.
+const maxDatagramSize = 65535
+
+// UDPSource is a Source receiving CEF messages as UDP datagrams, as commonly
+// sent by syslog forwarders. A datagram may contain multiple newline
+// separated messages.
+type UDPSource struct {
+	mu      sync.Mutex
+	conn    net.PacketConn
+	opts    ListenOptions
+	buf     []byte
+	pending []Envelope
+}
+
+// NewUDPSource listens for datagrams on the given address, a wildcard
+// address such as ":514" accepts IPv4 and IPv6 datagrams.
+//
+// Parameters:
+// - address: The local address to listen on, e.g. ":514", "127.0.0.1:0" or "[::1]:0".
+//
+// Returns:
+// - A pointer to a UDPSource.
+// - An error if the address could not be bound.
+func NewUDPSource(address string) (*UDPSource, error) {
+	return ListenUDP(address, ListenOptions{})
+}
+
+// ListenUDP listens for datagrams on the given address restricted to the
+// address family of the options. Datagrams from senders outside the Allow
+// list of the options are dropped.
+//
+// Parameters:
+// - address: The local address to listen on, e.g. ":514" or "[fe80::1%eth0]:514".
+// - opts: The address family and access options.
+//
+// Returns:
+// - A pointer to a UDPSource.
+// - An error if the address could not be bound.
+func ListenUDP(address string, opts ListenOptions) (*UDPSource, error) {
+
+	conn, err := net.ListenPacket(opts.Family.network("udp"), address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UDPSource{conn: conn, opts: opts, buf: make([]byte, maxDatagramSize)}, nil
+}
+
+// Addr returns the local address the UDPSource is listening on.
+func (s *UDPSource) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Receive blocks until the next event has been received.
+func (s *UDPSource) Receive() (Envelope, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.pending) == 0 {
+		n, addr, err := s.conn.ReadFrom(s.buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return Envelope{}, ErrSourceClosed
+			}
+			return Envelope{}, err
+		}
+
+		if !s.opts.allowed(addr) {
+			continue
+		}
+
+		profile := s.opts.profile(addr, "")
+		for _, line := range splitLines(string(s.buf[:n])) {
+			env := profile.envelope(line, addr.String())
+			if len(s.opts.Allow) > 0 {
+				if ap, ok := addrPort(addr); ok {
+					tagIdentity(&env, ap.Addr().String())
+				}
+			}
+			s.pending = append(s.pending, env)
+		}
+	}
+
+	env := s.pending[0]
+	s.pending = s.pending[1:]
+
+	return env, nil
+}
+
+// Close stops listening and unblocks a pending Receive.
+func (s *UDPSource) Close() error {
+	return s.conn.Close()
+}
+
+// TCPSource is a Source accepting TCP connections on which newline
+// separated CEF messages are sent. Events of all connections are
+// multiplexed into a single stream.
+type TCPSource struct {
+	listener net.Listener
+	opts     ListenOptions
+	workers  chan struct{}
+	events   chan Envelope
+	done     chan struct{}
+	once     sync.Once
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewTCPSource listens for TCP connections on the given address, a wildcard
+// address such as ":1514" accepts IPv4 and IPv6 connections.
+//
+// Parameters:
+// - address: The local address to listen on, e.g. ":1514", "127.0.0.1:0" or "[::1]:0".
+//
+// Returns:
+// - A pointer to a TCPSource.
+// - An error if the address could not be bound.
+func NewTCPSource(address string) (*TCPSource, error) {
+	return ListenTCP(address, ListenOptions{})
+}
+
+// ListenTCP listens for TCP connections on the given address restricted to
+// the address family of the options, the limits of the options protect the
+// source from misbehaving devices, see ListenOptions. Connections from
+// senders outside the Allow list are closed right away and with TLS set
+// only clients completing the handshake are read.
+//
+// Parameters:
+// - address: The local address to listen on, e.g. ":1514" or "[fe80::1%eth0]:1514".
+// - opts: The address family, limit and access options.
+//
+// Returns:
+// - A pointer to a TCPSource.
+// - An error if the address could not be bound.
+func ListenTCP(address string, opts ListenOptions) (*TCPSource, error) {
+
+	listener, err := net.Listen(opts.Family.network("tcp"), address)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = maxDecoderLineSize
+	}
+
+	s := &TCPSource{
+		listener: listener,
+		opts:     opts,
+		events:   make(chan Envelope, opts.QueueSize),
+		done:     make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	if opts.MaxConnections > 0 {
+		s.workers = make(chan struct{}, opts.MaxConnections)
+	}
+
+	s.wg.Add(1)
+	go s.accept()
+
+	return s, nil
+}
+
+// Addr returns the local address the TCPSource is listening on.
+func (s *TCPSource) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// accept accepts connections until the listener is closed. Once all
+// workers are busy, connections are not accepted before a worker is free,
+// so they wait in the accept backlog of the kernel.
+func (s *TCPSource) accept() {
+
+	defer s.wg.Done()
+
+	for {
+		if s.workers != nil {
+			select {
+			case s.workers <- struct{}{}:
+			case <-s.done:
+				return
+			}
+		}
+
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		if !s.opts.allowed(conn.RemoteAddr()) {
+			conn.Close()
+			if s.workers != nil {
+				<-s.workers
+			}
+			continue
+		}
+		if s.opts.TLS != nil {
+			conn = tls.Server(conn, s.opts.TLS)
+		}
+
+		// a connection accepted while Close runs is missed by it, it
+		// must not be served.
+		s.mu.Lock()
+		select {
+		case <-s.done:
+			s.mu.Unlock()
+			conn.Close()
+			if s.workers != nil {
+				<-s.workers
+			}
+			return
+		default:
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+// serve reads newline separated or octet-counted messages from a single connection.
+func (s *TCPSource) serve(conn net.Conn) {
+
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+		if s.workers != nil {
+			<-s.workers
+		}
+	}()
+
+	var limiter *pacer
+	if s.opts.MessagesPerSecond > 0 {
+		limiter = newPacer(s.opts.MessagesPerSecond, s.opts.Burst)
+		// a throttled connection must not delay Close.
+		limiter.sleep = func(d time.Duration) {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-s.done:
+			}
+		}
+	}
+
+	identity, err := s.opts.identify(conn)
+	if err != nil {
+		return
+	}
+
+	origin := conn.RemoteAddr().String()
+	profile := s.opts.profile(conn.RemoteAddr(), identity)
+	reader := &idleReader{conn: conn}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, min(64*1024, s.opts.MaxMessageSize)), s.opts.MaxMessageSize)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		// the incomplete message of a connection closed by the idle
+		// timeout is dropped.
+		if atEOF && reader.timedOut {
+			return len(data), nil, nil
+		}
+		return ScanCEF(data, atEOF)
+	})
+
+	for {
+		if s.opts.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.opts.IdleTimeout))
+		}
+		if !scanner.Scan() {
+			return
+		}
+		line := scanner.Text()
+
+		if limiter != nil {
+			limiter.wait()
+		}
+
+		env := profile.envelope(line, origin)
+		if s.opts.accessControlled() {
+			tagIdentity(&env, identity)
+		}
+
+		select {
+		case s.events <- env:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// idleReader reads from a connection and records whether a read ran into
+// the deadline set for the IdleTimeout.
+type idleReader struct {
+	conn     net.Conn
+	timedOut bool
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+
+	n, err := r.conn.Read(p)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		r.timedOut = true
+	}
+
+	return n, err
+}
+
+// QueueDepth returns the number of received events waiting for Receive.
+func (s *TCPSource) QueueDepth() int {
+	return len(s.events)
+}
+
+// Receive blocks until an event has been received on any connection.
+func (s *TCPSource) Receive() (Envelope, error) {
+
+	select {
+	case env := <-s.events:
+		return env, nil
+	case <-s.done:
+		return Envelope{}, ErrSourceClosed
+	}
+}
+
+// Close stops accepting connections, closes all open connections and
+// unblocks a pending Receive.
+func (s *TCPSource) Close() error {
+
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		err = s.listener.Close()
+
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+
+		s.wg.Wait()
+	})
+
+	return err
+}
@@ -0,0 +1,78 @@
+package cefevent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// logfmtHeaderKeys are the keys of the header fields in logfmt lines.
+var logfmtHeaderKeys = []string{"version", "vendor", "product", "device_version", "event_class_id", "name", "severity"}
+
+// ToLogfmt renders the event as logfmt line for logfmt-native stacks such
+// as Loki, e.g.
+//
+//	version=0 vendor="Cool Vendor" product="Cool Product" device_version=1.0 event_class_id=COOL_THING name="Something cool happened." severity=Unknown src=127.0.0.1
+//
+// The header fields come first, followed by the extensions sorted by key.
+// Values which are empty or contain spaces, equal signs, quotes or control
+// characters are quoted with Go escapes, characters keys must not contain
+// are replaced with "_".
+//
+// Returns:
+// - The logfmt line.
+// - An error if the event is not valid.
+func (event *CefEvent) ToLogfmt() (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	header := []string{strconv.Itoa(event.Version), event.DeviceVendor, event.DeviceProduct, event.DeviceVersion, event.DeviceEventClassId, event.Name, event.Severity}
+	for i, key := range logfmtHeaderKeys {
+		writeLogfmtPair(&b, key, header[i])
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		writeLogfmtPair(&b, logfmtKey(k), event.Extensions[k])
+	}
+
+	return b.String(), nil
+}
+
+// writeLogfmtPair writes the key and its value, separated from a preceding pair by a space.
+func writeLogfmtPair(b *strings.Builder, key string, value string) {
+
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return r == ' ' || r == '=' || r == '"' || r == '\\' || unicode.IsControl(r) || r == unicode.ReplacementChar
+	}) >= 0 {
+		value = strconv.Quote(value)
+	}
+	b.WriteString(value)
+}
+
+// logfmtKey replaces the characters of key which end a logfmt key.
+func logfmtKey(key string) string {
+
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
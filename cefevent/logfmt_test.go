@@ -0,0 +1,23 @@
+package cefevent
+
+import "testing"
+
+func TestToLogfmt(t *testing.T) {
+
+	want := `version=0 vendor="Cool Vendor" product="Cool Product" device_version=1.0 event_class_id=COOL_THING name="Something cool happened." severity=Unknown src=127.0.0.1`
+	if got, err := event.ToLogfmt(); err != nil || got != want {
+		t.Errorf("ToLogfmt() = %s, %v, want %s", got, err, want)
+	}
+
+	e := event
+	e.Name = "login"
+	e.Extensions = map[string]string{"msg": "a=b \"quoted\"\nline", "empty": "", "ad.path": `C:\Windows`, "suser": "ünïcode"}
+	want = `version=0 vendor="Cool Vendor" product="Cool Product" device_version=1.0 event_class_id=COOL_THING name=login severity=Unknown ad.path="C:\\Windows" empty="" msg="a=b \"quoted\"\nline" suser=ünïcode`
+	if got, err := e.Render(FormatLogfmt); err != nil || got != want {
+		t.Errorf("Render(FormatLogfmt) = %s, %v, want %s", got, err, want)
+	}
+
+	if _, err := (&CefEvent{}).ToLogfmt(); err == nil {
+		t.Error("ToLogfmt() of an invalid event = nil, want an error")
+	}
+}
@@ -0,0 +1,90 @@
+package cefevent
+
+// Handler processes a single event, it is the building block of the
+// middleware chain of an Emitter.
+type Handler func(event CefEvent) error
+
+// Middleware wraps a Handler with additional behaviour. A middleware may
+// modify the event before passing it on to next, observe the result of next
+// or drop the event by returning without calling next at all.
+//
+// Middlewares are called concurrently when the Emitter is used from multiple
+// goroutines and must therefore be safe for concurrent use. The Extensions map
+// of the event is shared with the caller, middlewares changing extensions must
+// work on a copy, e.g. obtained through maps.Clone.
+type Middleware func(next Handler) Handler
+
+// chain wraps the handler with the given middlewares so that the first
+// middleware is the outermost one and thereby sees the event first.
+func chain(handler Handler, middlewares []Middleware) Handler {
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// cloneExtensions returns a copy of the extensions map which can be
+// modified without affecting the original event.
+func cloneExtensions(extensions map[string]string) map[string]string {
+
+	cloned := make(map[string]string, len(extensions))
+	for k, v := range extensions {
+		cloned[k] = v
+	}
+
+	return cloned
+}
+
+// Enrich returns a Middleware which adds the given extensions to every event.
+// Extensions already present on the event are not overwritten.
+//
+// Parameters:
+// - extensions: The extension keys and values to add to each event.
+//
+// Returns:
+// - A Middleware enriching the events passing through it.
+func Enrich(extensions map[string]string) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+
+			enriched := cloneExtensions(event.Extensions)
+			for k, v := range extensions {
+				if _, ok := enriched[k]; !ok {
+					enriched[k] = v
+				}
+			}
+			event.Extensions = enriched
+
+			return next(event)
+		}
+	}
+}
+
+// Redact returns a Middleware which replaces the values of the given
+// extension keys with a fixed placeholder before the event is delivered.
+//
+// Parameters:
+// - keys: The extension keys whose values must not leave the process.
+//
+// Returns:
+// - A Middleware redacting the events passing through it.
+func Redact(keys ...string) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+
+			redacted := cloneExtensions(event.Extensions)
+			for _, k := range keys {
+				if _, ok := redacted[k]; ok {
+					redacted[k] = "[REDACTED]"
+				}
+			}
+			event.Extensions = redacted
+
+			return next(event)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEmitterMiddlewareOrder(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(event CefEvent) error {
+				order = append(order, name)
+				return next(event)
+			}
+		}
+	}
+
+	emitter.Use(record("first"), record("second"))
+	emitter.Use(record("third"))
+
+	if err := emitter.Emit(event); err != nil {
+		t.Fatalf("Emit() = %v, want nil", err)
+	}
+
+	if want := []string{"first", "second", "third"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+}
+
+func TestEmitterMiddlewareDrop(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	emitter.Use(func(next Handler) Handler {
+		return func(event CefEvent) error {
+			return nil
+		}
+	})
+
+	if err := emitter.Emit(event); err != nil {
+		t.Fatalf("Emit() = %v, want nil", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("dropped event was written: %q", buf.String())
+	}
+}
+
+func TestEmitterMiddlewareError(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+
+	var seen error
+	emitter.Use(func(next Handler) Handler {
+		return func(event CefEvent) error {
+			seen = next(event)
+			return seen
+		}
+	})
+
+	brokenEvent := event
+	brokenEvent.Severity = ""
+
+	err := emitter.Emit(brokenEvent)
+	if err == nil || !errors.Is(err, seen) {
+		t.Errorf("Emit() = %v, want the error observed by the middleware", err)
+	}
+}
+
+func TestEnrichAndRedact(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+	emitter.Use(
+		Enrich(map[string]string{"dvchost": "sensor01", "src": "10.0.0.1"}),
+		Redact("suser"),
+	)
+
+	secretEvent := event
+	secretEvent.Extensions = map[string]string{"src": "127.0.0.1", "suser": "alice"}
+
+	if err := emitter.Emit(secretEvent); err != nil {
+		t.Fatalf("Emit() = %v, want nil", err)
+	}
+
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|dvchost=sensor01 src=127.0.0.1 suser=[REDACTED]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Emit() wrote %q, want %q", got, want)
+	}
+
+	if secretEvent.Extensions["suser"] != "alice" || len(secretEvent.Extensions) != 2 {
+		t.Errorf("middleware modified the extensions of the caller: %v", secretEvent.Extensions)
+	}
+}
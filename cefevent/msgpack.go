@@ -0,0 +1,343 @@
+package cefevent
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// errMsgpackTruncated is remembered by a msgpackReader for data ending within a value.
+var errMsgpackTruncated = errors.New("MessagePack encoding is truncated")
+
+// ToMsgpack converts the CefEvent instance to MessagePack, the compact
+// binary counterpart of ToJSON for the interchange between collector nodes.
+// The event is a map holding the header fields by their JSON names, Version
+// as integer, and the Extensions map sorted by key.
+//
+// Returns:
+// - The MessagePack encoded event.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToMsgpack() ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := appendMsgpackMapHeader(nil, len(headerFieldNames)+1)
+	buf = appendMsgpackString(buf, headerFieldNames[0])
+	buf = appendMsgpackInt(buf, int64(event.Version))
+	for _, name := range headerFieldNames[1:] {
+		buf = appendMsgpackString(buf, name)
+		buf = appendMsgpackString(buf, *documentHeader(event, name))
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf = appendMsgpackString(buf, extensionsTable)
+	buf = appendMsgpackMapHeader(buf, len(keys))
+	for _, k := range keys {
+		buf = appendMsgpackString(buf, k)
+		buf = appendMsgpackString(buf, event.Extensions[k])
+	}
+
+	return buf, nil
+}
+
+// FromMsgpack is the inverse of ToMsgpack, it reads an event from a
+// MessagePack map and validates it like FromJSON. The fields may come in
+// any order, Extensions may be nil and Version any integer format, other
+// types and unknown fields are rejected.
+//
+// Parameters:
+// - data: The MessagePack encoded event.
+//
+// Returns:
+// - The CefEvent.
+// - A *DocumentError with Format "MessagePack" if the data is malformed,
+// followed by further data or violates the schema, it wraps
+// ErrMalformedDocument, ErrMissingField, ErrInvalidVersion or ErrMalformedExtension.
+func FromMsgpack(data []byte) (CefEvent, error) {
+
+	d := newDocumentDecoder("MessagePack")
+	r := msgpackReader{data: data}
+
+	fields, ok := r.mapHeader()
+	if !ok {
+		return CefEvent{}, r.failure(d, "", ErrMalformedDocument, "event is not a map")
+	}
+
+	for i := 0; i < fields; i++ {
+		name, ok := r.string()
+		if !ok {
+			return CefEvent{}, r.failure(d, "", ErrMalformedDocument, "field name is not a string")
+		}
+
+		var err error
+		switch name {
+		case headerFieldNames[0]:
+			version, ok := r.int()
+			if !ok {
+				return CefEvent{}, r.failure(d, name, ErrInvalidVersion, "version must be an integer")
+			}
+			err = d.header(name, strconv.FormatInt(version, 10))
+		case extensionsTable:
+			err = d.msgpackExtensions(&r)
+		default:
+			if documentHeader(&d.event, name) == nil {
+				return CefEvent{}, d.errorf(name, ErrMalformedDocument, "unknown field")
+			}
+			value, ok := r.string()
+			if !ok {
+				return CefEvent{}, r.failure(d, name, ErrMalformedDocument, "value is not a string")
+			}
+			err = d.header(name, value)
+		}
+		if err != nil {
+			return CefEvent{}, err
+		}
+	}
+
+	if len(r.data) > 0 {
+		return CefEvent{}, d.errorf("", ErrMalformedDocument, "unexpected data after the event")
+	}
+
+	return d.finish()
+}
+
+// msgpackExtensions reads the Extensions map, or nil, of the event.
+func (d *documentDecoder) msgpackExtensions(r *msgpackReader) error {
+
+	if d.seen[extensionsTable] {
+		return d.errorf(extensionsTable, ErrMalformedDocument, "duplicate field")
+	}
+	d.seen[extensionsTable] = true
+
+	if r.nil() {
+		return nil
+	}
+	n, ok := r.mapHeader()
+	if !ok {
+		return r.failure(d, extensionsTable, ErrMalformedDocument, "extensions must be a map")
+	}
+
+	for i := 0; i < n; i++ {
+		key, ok := r.string()
+		if !ok {
+			return r.failure(d, extensionsTable, ErrMalformedExtension, "key is not a string")
+		}
+		value, ok := r.string()
+		if !ok {
+			return r.failure(d, key, ErrMalformedExtension, "value is not a string")
+		}
+		if err := d.extension(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendMsgpackMapHeader appends the header of a map with n entries to buf.
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	}
+
+	return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+}
+
+// appendMsgpackString appends s in the shortest string format to buf.
+func appendMsgpackString(buf []byte, s string) []byte {
+
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+
+	return append(buf, s...)
+}
+
+// appendMsgpackInt appends v in the shortest integer format to buf.
+func appendMsgpackInt(buf []byte, v int64) []byte {
+
+	switch {
+	case v >= 0 && v <= math.MaxInt8, v < 0 && v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+// msgpackReader decodes the values of a MessagePack encoding, err is set
+// when a read goes beyond the data.
+type msgpackReader struct {
+	data []byte
+	err  error
+}
+
+// failure returns a DocumentError, reporting truncated data as such.
+func (r *msgpackReader) failure(d *documentDecoder, field string, err error, reason string) error {
+
+	if r.err != nil {
+		return d.errorf(field, ErrMalformedDocument, "event is truncated")
+	}
+
+	return d.errorf(field, err, "%s", reason)
+}
+
+// take consumes the first n bytes of the data.
+//
+// Returns:
+// - The bytes.
+// - Whether the data holds n bytes.
+func (r *msgpackReader) take(n uint64) ([]byte, bool) {
+
+	if n > uint64(len(r.data)) {
+		r.err = errMsgpackTruncated
+		return nil, false
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b, true
+}
+
+// length consumes the big-endian length of size bytes following the format byte.
+func (r *msgpackReader) length(size int) (uint64, bool) {
+
+	b, ok := r.take(uint64(1 + size))
+	if !ok {
+		return 0, false
+	}
+
+	switch size {
+	case 1:
+		return uint64(b[1]), true
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b[1:])), true
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b[1:])), true
+	}
+
+	return binary.BigEndian.Uint64(b[1:]), true
+}
+
+// nil consumes a nil value.
+func (r *msgpackReader) nil() bool {
+
+	if len(r.data) == 0 || r.data[0] != 0xc0 {
+		return false
+	}
+	r.data = r.data[1:]
+
+	return true
+}
+
+// mapHeader consumes the header of a map and returns its number of entries.
+func (r *msgpackReader) mapHeader() (int, bool) {
+
+	if len(r.data) == 0 {
+		r.err = errMsgpackTruncated
+		return 0, false
+	}
+
+	var n uint64
+	var ok bool
+	switch format := r.data[0]; {
+	case format&0xf0 == 0x80:
+		n, ok = uint64(format&0x0f), true
+		r.data = r.data[1:]
+	case format == 0xde:
+		n, ok = r.length(2)
+	case format == 0xdf:
+		n, ok = r.length(4)
+	}
+
+	// every entry takes at least two bytes, larger counts are truncated.
+	if ok && n > uint64(len(r.data))/2 {
+		r.err = errMsgpackTruncated
+		return 0, false
+	}
+
+	return int(n), ok
+}
+
+// string consumes a string.
+func (r *msgpackReader) string() (string, bool) {
+
+	if len(r.data) == 0 {
+		r.err = errMsgpackTruncated
+		return "", false
+	}
+
+	var n uint64
+	var ok bool
+	switch format := r.data[0]; {
+	case format&0xe0 == 0xa0:
+		n, ok = uint64(format&0x1f), true
+		r.data = r.data[1:]
+	case format == 0xd9:
+		n, ok = r.length(1)
+	case format == 0xda:
+		n, ok = r.length(2)
+	case format == 0xdb:
+		n, ok = r.length(4)
+	}
+	if !ok {
+		return "", false
+	}
+
+	b, ok := r.take(n)
+
+	return string(b), ok
+}
+
+// int consumes an integer of any format which fits into an int64.
+func (r *msgpackReader) int() (int64, bool) {
+
+	if len(r.data) == 0 {
+		r.err = errMsgpackTruncated
+		return 0, false
+	}
+
+	format := r.data[0]
+	switch {
+	case format <= 0x7f, format >= 0xe0:
+		r.data = r.data[1:]
+		return int64(int8(format)), true
+	case format >= 0xcc && format <= 0xcf:
+		v, ok := r.length(1 << (format - 0xcc))
+		return int64(v), ok && v <= math.MaxInt64
+	case format >= 0xd0 && format <= 0xd3:
+		size := 1 << (format - 0xd0)
+		v, ok := r.length(size)
+		// sign extend the value of size bytes.
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, ok
+	}
+
+	return 0, false
+}
@@ -0,0 +1,75 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCefEventMsgpack(t *testing.T) {
+
+	tricky := event
+	tricky.Name = "ünïcode | " + strings.Repeat("long ", 100)
+	tricky.Extensions = map[string]string{"src": "127.0.0.1", "msg": strings.Repeat("x", 70000), "empty": ""}
+	for i := 0; i < 20; i++ {
+		tricky.Extensions["cs"+string(rune('a'+i))] = "value"
+	}
+
+	for _, e := range []CefEvent{event, tricky} {
+		data, err := e.ToMsgpack()
+		if err != nil {
+			t.Fatalf("ToMsgpack() = %v", err)
+		}
+		got, err := FromMsgpack(data)
+		if err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("FromMsgpack() = %#v, %v, want %#v", got, err, e)
+		}
+	}
+
+	str := func(s string) []byte { return appendMsgpackString(nil, s) }
+	field := func(name, value string) []byte { return append(str(name), str(value)...) }
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	header := join(field("DeviceVendor", "V"), field("DeviceProduct", "P"), field("DeviceVersion", "1"), field("DeviceEventClassId", "2"), field("Name", "N"), field("Severity", "5"))
+	small := CefEvent{Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "2", Name: "N", Severity: "5"}
+
+	want := join([]byte{0x88}, str("Version"), []byte{0x01}, header, str("Extensions"), []byte{0x80})
+	if got, _ := small.ToMsgpack(); !bytes.Equal(got, want) {
+		t.Errorf("ToMsgpack() = %x, want %x", got, want)
+	}
+
+	// other encoders may order the fields differently, use wider integers and nil.
+	foreign := join([]byte{0x88}, str("Extensions"), []byte{0xc0}, header, str("Version"), []byte{0xd2, 0, 0, 0, 1})
+	if got, err := FromMsgpack(foreign); err != nil || !reflect.DeepEqual(got, small) {
+		t.Errorf("FromMsgpack(%x) = %#v, %v, want %#v", foreign, got, err, small)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrMalformedDocument},
+		{"no map", str("event"), ErrMalformedDocument},
+		{"truncated", want[:len(want)-4], ErrMalformedDocument},
+		{"trailing data", append(append([]byte(nil), want...), 0xc0), ErrMalformedDocument},
+		{"unknown field", join([]byte{0x88}, field("Vendor", "V")), ErrMalformedDocument},
+		{"string version", join([]byte{0x87}, field("Version", "0"), header), ErrInvalidVersion},
+		{"invalid version", join([]byte{0x87}, str("Version"), []byte{0x05}, header), ErrInvalidVersion},
+		{"missing field", join([]byte{0x87}, str("Version"), []byte{0x00}, header[:len(header)-len(field("Severity", "5"))], str("Extensions"), []byte{0x80}), ErrMissingField},
+		{"integer value", join([]byte{0x88}, str("Version"), []byte{0x00}, header, str("Extensions"), []byte{0x81}, str("cnt"), []byte{0x05}), ErrMalformedExtension},
+		{"malformed key", join([]byte{0x88}, str("Version"), []byte{0x00}, header, str("Extensions"), []byte{0x81}, field("a b", "c")), ErrMalformedExtension},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromMsgpack(tt.data)
+			var docErr *DocumentError
+			if !errors.Is(err, tt.want) || !errors.As(err, &docErr) || docErr.Format != "MessagePack" {
+				t.Errorf("FromMsgpack() = %v, want a DocumentError wrapping %v", err, tt.want)
+			}
+		})
+	}
+}
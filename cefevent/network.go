@@ -0,0 +1,131 @@
+package cefevent
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// AddressFamily selects the IP version network sources and sinks use.
+type AddressFamily int
+
+const (
+	// FamilyAny uses IPv4 and IPv6, listeners are dual-stack and dialers race
+	// both address families ("happy eyeballs") when a name resolves to both.
+	FamilyAny AddressFamily = iota
+	// FamilyIPv4 restricts listening and dialing to IPv4.
+	FamilyIPv4
+	// FamilyIPv6 restricts listening and dialing to IPv6, e.g. for IPv6-only collectors.
+	FamilyIPv6
+)
+
+// String returns the name of the AddressFamily as accepted by ParseAddressFamily.
+func (f AddressFamily) String() string {
+	switch f {
+	case FamilyAny:
+		return "any"
+	case FamilyIPv4:
+		return "ipv4"
+	case FamilyIPv6:
+		return "ipv6"
+	}
+	return fmt.Sprintf("AddressFamily(%d)", int(f))
+}
+
+// network returns the name of the transport in the net package restricted
+// to the address family, e.g. "udp6" for FamilyIPv6 and "udp".
+func (f AddressFamily) network(transport string) string {
+	switch f {
+	case FamilyIPv4:
+		return transport + "4"
+	case FamilyIPv6:
+		return transport + "6"
+	}
+	return transport
+}
+
+// ParseAddressFamily returns the AddressFamily with the given name, an empty
+// name selects FamilyAny.
+//
+// Returns:
+// - The AddressFamily matching the name.
+// - An error if no AddressFamily with that name exists.
+func ParseAddressFamily(name string) (AddressFamily, error) {
+
+	switch strings.ToLower(name) {
+	case "", "any", "dual":
+		return FamilyAny, nil
+	case "ipv4", "4":
+		return FamilyIPv4, nil
+	case "ipv6", "6":
+		return FamilyIPv6, nil
+	}
+
+	return FamilyAny, fmt.Errorf("unknown address family %q", name)
+}
+
+// ListenOptions configures the address family of network sources, the
+// limits protecting a TCP collector from misbehaving devices and the
+// senders it accepts. With Allow or TLS set, every accepted event is tagged
+// with the verified identity of its sender, see SourceIdentityKey.
+//
+// Addresses may be IPv4 or IPv6 literals, IPv6 literals are written in
+// brackets and may carry a zone, e.g. "[::1]:514" or "[fe80::1%eth0]:514".
+//
+// The limits apply to TCPSources, their zero values disable them. A
+// TCPSource reads every connection in a worker of its own, connections
+// which are not read, because of a full pool, their rate limit or a slow
+// consumer, are throttled by TCP flow control instead of being buffered.
+// MaxConnections and MaxMessageSize therefore bound the memory of the
+// source. UDPSources read a single datagram at a time when Receive is
+// called, datagrams arriving faster are dropped by the kernel.
+type ListenOptions struct {
+	Family            AddressFamily // Family restricts the listener to one IP version, by default it is dual-stack.
+	MaxConnections    int           // MaxConnections bounds the connections read at once, further connections wait in the accept backlog.
+	MessagesPerSecond float64       // MessagesPerSecond limits the rate each connection is read at.
+	Burst             int           // Burst is the number of messages a connection may send at once before MessagesPerSecond applies.
+	IdleTimeout       time.Duration // IdleTimeout closes connections not completing a message in time, which frees the workers held by stalled devices.
+	MaxMessageSize    int           // MaxMessageSize closes connections sending longer messages, it defaults to 1 MiB.
+	QueueSize         int           // QueueSize is the number of received events buffered for a consumer calling Receive late.
+
+	// Allow restricts the senders to the given networks, connections and
+	// datagrams from other addresses are dropped. Every sender is allowed
+	// when it is empty.
+	Allow []netip.Prefix
+	// TLS makes TCPSources accept TLS connections only, with ClientAuth set
+	// to tls.RequireAndVerifyClientCert clients must authenticate mutually.
+	TLS *tls.Config
+	// Profiles are the parsing profiles of the senders, the first profile
+	// matching a sender applies to all its messages, the messages of other
+	// senders are parsed with Parse.
+	Profiles []ParsingProfile
+}
+
+// DialOptions configures how network sinks connect to their destination.
+//
+// Addresses may be host names, IPv4 or IPv6 literals, IPv6 literals are
+// written in brackets and may carry a zone, e.g. "[fe80::1%eth0]:514".
+type DialOptions struct {
+	Family        AddressFamily // Family restricts the destination addresses to one IP version.
+	Timeout       time.Duration // Timeout limits the time a connection attempt may take, defaults to 10 seconds.
+	FallbackDelay time.Duration // FallbackDelay is the head start of IPv6 before IPv4 is tried in parallel, zero selects the net package default of 300ms.
+}
+
+// defaultDialTimeout limits connection attempts when DialOptions.Timeout is not set.
+const defaultDialTimeout = 10 * time.Second
+
+// dial connects to the address using the transport restricted to the address family.
+func (o DialOptions) dial(transport string, address string) (net.Conn, error) {
+
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	dialer := net.Dialer{Timeout: timeout, FallbackDelay: o.FallbackDelay}
+
+	return dialer.Dial(o.Family.network(transport), address)
+}
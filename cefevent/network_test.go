@@ -0,0 +1,161 @@
+package cefevent
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAddressFamily(t *testing.T) {
+
+	var tests = []struct {
+		name string
+		want AddressFamily
+	}{
+		{"", FamilyAny},
+		{"any", FamilyAny},
+		{"ipv4", FamilyIPv4},
+		{"IPv6", FamilyIPv6},
+		{"6", FamilyIPv6},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAddressFamily(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAddressFamily(%q) = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseAddressFamily("ipx"); err == nil {
+		t.Errorf("ParseAddressFamily() should fail for an unknown family")
+	}
+}
+
+// skipWithoutIPv6 skips the test when the IPv6 loopback is not available.
+func skipWithoutIPv6(t *testing.T) {
+
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback is not available")
+	}
+	listener.Close()
+}
+
+func TestIPv6UDP(t *testing.T) {
+
+	skipWithoutIPv6(t)
+
+	source, err := ListenUDP("[::1]:0", ListenOptions{Family: FamilyIPv6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	sink, err := NewUDPSink(source.Addr().String(), UDPSinkOptions{Dial: DialOptions{Family: FamilyIPv6}})
+	if err != nil {
+		t.Fatalf("NewUDPSink() = %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(eventLine); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	env, err := source.Receive()
+	if err != nil || env.Raw != eventLine {
+		t.Errorf("Receive() = %q, %v, want %q", env.Raw, err, eventLine)
+	}
+
+	if !strings.HasPrefix(env.Origin, "[::1]:") {
+		t.Errorf("Origin = %q, want an IPv6 address", env.Origin)
+	}
+}
+
+func TestIPv6TCP(t *testing.T) {
+
+	skipWithoutIPv6(t)
+
+	source, err := ListenTCP("[::1]:0", ListenOptions{Family: FamilyIPv6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	sink, err := NewTCPSink(source.Addr().String(), TCPSinkOptions{})
+	if err != nil {
+		t.Fatalf("NewTCPSink() = %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(eventLine); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	env, err := source.Receive()
+	if err != nil || env.Raw != eventLine {
+		t.Errorf("Receive() = %q, %v, want %q", env.Raw, err, eventLine)
+	}
+}
+
+// stalledListener accepts connections on loopback but never reads from them.
+func stalledListener(t *testing.T) net.Listener {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	return listener
+}
+
+func TestTCPSinkWriteTimeout(t *testing.T) {
+
+	listener := stalledListener(t)
+
+	sink, err := NewTCPSink(listener.Addr().String(), TCPSinkOptions{WriteTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewTCPSink() = %v", err)
+	}
+	defer sink.Close()
+
+	// the messages fill the socket buffers until the write times out.
+	message := strings.Repeat("x", 1024*1024)
+	for i := 0; i < 256; i++ {
+		if err = sink.Send(message); err != nil {
+			break
+		}
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Send() = %v, want a timeout once the collector stopped reading", err)
+	}
+}
+
+func TestAddressFamilyRestriction(t *testing.T) {
+
+	if _, err := NewUDPSink("127.0.0.1:514", UDPSinkOptions{Dial: DialOptions{Family: FamilyIPv6}}); err == nil {
+		t.Errorf("NewUDPSink() should fail for an IPv4 address restricted to IPv6")
+	}
+
+	if _, err := ListenTCP("[::1]:0", ListenOptions{Family: FamilyIPv4}); err == nil {
+		t.Errorf("ListenTCP() should fail for an IPv6 address restricted to IPv4")
+	}
+}
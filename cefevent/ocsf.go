@@ -0,0 +1,243 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// OCSFVersion is the version of the Open Cybersecurity Schema Framework
+// the documents of ToOCSF conform to.
+const OCSFVersion = "1.1.0"
+
+// OCSFClass is an event class of the Open Cybersecurity Schema Framework
+// together with the activity events of the class are reported with.
+type OCSFClass struct {
+	UID          int    // UID is the class_uid, e.g. 4001.
+	Name         string // Name is the class_name, e.g. "Network Activity".
+	CategoryUID  int    // CategoryUID is the category_uid, e.g. 4.
+	CategoryName string // CategoryName is the category_name, e.g. "Network Activity".
+	ActivityID   int    // ActivityID is the activity_id, 0 when it is unknown.
+}
+
+// The OCSF classes CEF events commonly map onto.
+var (
+	OCSFBaseEvent          = OCSFClass{UID: 0, Name: "Base Event", CategoryUID: 0, CategoryName: "Uncategorized"}
+	OCSFFileSystemActivity = OCSFClass{UID: 1001, Name: "File System Activity", CategoryUID: 1, CategoryName: "System Activity"}
+	OCSFDetectionFinding   = OCSFClass{UID: 2004, Name: "Detection Finding", CategoryUID: 2, CategoryName: "Findings", ActivityID: 1}
+	OCSFAuthentication     = OCSFClass{UID: 3002, Name: "Authentication", CategoryUID: 3, CategoryName: "Identity & Access Management", ActivityID: 1}
+	OCSFNetworkActivity    = OCSFClass{UID: 4001, Name: "Network Activity", CategoryUID: 4, CategoryName: "Network Activity", ActivityID: 6}
+	OCSFHTTPActivity       = OCSFClass{UID: 4002, Name: "HTTP Activity", CategoryUID: 4, CategoryName: "Network Activity"}
+)
+
+var (
+	ocsfClassesMu sync.RWMutex
+	ocsfClasses   = map[string]OCSFClass{}
+)
+
+// RegisterOCSFClass selects the OCSF class ToOCSF maps the events with the
+// device event class ID onto, an existing registration is replaced.
+// Events of class IDs which are not registered map onto OCSFBaseEvent.
+//
+// Parameters:
+// - deviceEventClassID: The DeviceEventClassId of the events, e.g. "4625".
+// - class: The OCSFClass, e.g. OCSFAuthentication.
+func RegisterOCSFClass(deviceEventClassID string, class OCSFClass) {
+
+	ocsfClassesMu.Lock()
+	defer ocsfClassesMu.Unlock()
+
+	ocsfClasses[deviceEventClassID] = class
+}
+
+// LookupOCSFClass returns the OCSF class registered for the device event
+// class ID, OCSFBaseEvent when none is.
+func LookupOCSFClass(deviceEventClassID string) OCSFClass {
+
+	ocsfClassesMu.RLock()
+	defer ocsfClassesMu.RUnlock()
+
+	if class, ok := ocsfClasses[deviceEventClassID]; ok {
+		return class
+	}
+
+	return OCSFBaseEvent
+}
+
+// The kinds of values of the OCSF attributes CEF extensions map onto.
+const (
+	ocsfString = iota
+	ocsfInt
+	ocsfTime
+)
+
+// ocsfExtensionAttributes maps the CEF extension keys of the specification
+// onto the path of their OCSF attribute and the kind of its value.
+var ocsfExtensionAttributes = map[string]struct {
+	path []string
+	kind int
+}{
+	"src":                      {[]string{"src_endpoint", "ip"}, ocsfString},
+	"spt":                      {[]string{"src_endpoint", "port"}, ocsfInt},
+	"shost":                    {[]string{"src_endpoint", "hostname"}, ocsfString},
+	"smac":                     {[]string{"src_endpoint", "mac"}, ocsfString},
+	"dst":                      {[]string{"dst_endpoint", "ip"}, ocsfString},
+	"dpt":                      {[]string{"dst_endpoint", "port"}, ocsfInt},
+	"dhost":                    {[]string{"dst_endpoint", "hostname"}, ocsfString},
+	"dmac":                     {[]string{"dst_endpoint", "mac"}, ocsfString},
+	"suser":                    {[]string{"actor", "user", "name"}, ocsfString},
+	"duser":                    {[]string{"user", "name"}, ocsfString},
+	"dvc":                      {[]string{"device", "ip"}, ocsfString},
+	"dvchost":                  {[]string{"device", "hostname"}, ocsfString},
+	"proto":                    {[]string{"connection_info", "protocol_name"}, ocsfString},
+	"in":                       {[]string{"traffic", "bytes_in"}, ocsfInt},
+	"out":                      {[]string{"traffic", "bytes_out"}, ocsfInt},
+	"fname":                    {[]string{"file", "name"}, ocsfString},
+	"filePath":                 {[]string{"file", "path"}, ocsfString},
+	"fsize":                    {[]string{"file", "size"}, ocsfInt},
+	"request":                  {[]string{"http_request", "url", "url_string"}, ocsfString},
+	"requestMethod":            {[]string{"http_request", "http_method"}, ocsfString},
+	"requestClientApplication": {[]string{"http_request", "user_agent"}, ocsfString},
+	"rt":                       {[]string{"time"}, ocsfTime},
+	"start":                    {[]string{"start_time"}, ocsfTime},
+	"end":                      {[]string{"end_time"}, ocsfTime},
+}
+
+// ocsfSeverity maps the CEF severity onto the OCSF severity_id and severity.
+func ocsfSeverity(severity string) (int, string) {
+
+	switch severity {
+	case "Low", "Medium", "High", "Very-High":
+	default:
+		if _, err := strconv.Atoi(severity); err != nil {
+			return 0, "Unknown"
+		}
+	}
+
+	switch level := severityLevel(severity); {
+	case level == 0:
+		return 1, "Informational"
+	case level <= 3:
+		return 2, "Low"
+	case level <= 6:
+		return 3, "Medium"
+	case level <= 8:
+		return 4, "High"
+	default:
+		return 5, "Critical"
+	}
+}
+
+// ocsfSet sets the value at the path of nested objects in the document.
+func ocsfSet(doc map[string]interface{}, path []string, value interface{}) {
+
+	for _, name := range path[:len(path)-1] {
+		child, ok := doc[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			doc[name] = child
+		}
+		doc = child
+	}
+
+	doc[path[len(path)-1]] = value
+}
+
+// ToOCSF maps the CefEvent onto a document of the Open Cybersecurity Schema
+// Framework (OCSF), which many cloud SIEMs standardize on.
+//
+// The class of the document is selected by the DeviceEventClassId, see
+// RegisterOCSFClass. The vendor, product and version become the product of
+// the metadata, the class ID its event_code, the name the message and the
+// severity the severity_id. The extensions of the specification are mapped
+// onto their OCSF attributes, e.g. src onto src_endpoint.ip and rt onto
+// time in epoch milliseconds, all remaining extensions and values which can
+// not be converted are kept in unmapped so that no data is lost.
+//
+// Returns:
+// - A map holding the nested OCSF document.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToOCSF() (map[string]interface{}, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	class := LookupOCSFClass(event.DeviceEventClassId)
+	severityID, severity := ocsfSeverity(event.Severity)
+
+	doc := map[string]interface{}{
+		"class_uid":     class.UID,
+		"class_name":    class.Name,
+		"category_uid":  class.CategoryUID,
+		"category_name": class.CategoryName,
+		"activity_id":   class.ActivityID,
+		"type_uid":      class.UID*100 + class.ActivityID,
+		"severity_id":   severityID,
+		"severity":      severity,
+		"message":       event.Name,
+		"metadata": map[string]interface{}{
+			"version":    OCSFVersion,
+			"event_code": event.DeviceEventClassId,
+			"product": map[string]interface{}{
+				"vendor_name": event.DeviceVendor,
+				"name":        event.DeviceProduct,
+				"version":     event.DeviceVersion,
+			},
+		},
+	}
+
+	unmapped := make(map[string]interface{})
+	for k, v := range event.Extensions {
+		attribute, ok := ocsfExtensionAttributes[k]
+		if !ok {
+			unmapped[k] = v
+			continue
+		}
+
+		switch attribute.kind {
+		case ocsfInt:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				unmapped[k] = v
+				continue
+			}
+			ocsfSet(doc, attribute.path, n)
+		case ocsfTime:
+			t, err := ParseTimestamp(v)
+			if err != nil {
+				unmapped[k] = v
+				continue
+			}
+			ocsfSet(doc, attribute.path, t.UnixMilli())
+		default:
+			ocsfSet(doc, attribute.path, v)
+		}
+	}
+	if len(unmapped) > 0 {
+		doc["unmapped"] = unmapped
+	}
+
+	return doc, nil
+}
+
+// ToOCSFJSON converts the CefEvent into an OCSF JSON document using the
+// mapping of ToOCSF.
+//
+// Returns:
+// - A JSON string with the OCSF representation of the CefEvent.
+// - An error if the CefEvent is not valid or could not be marshaled.
+func (event *CefEvent) ToOCSFJSON() (string, error) {
+
+	doc, err := event.ToOCSF()
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonData), nil
+}
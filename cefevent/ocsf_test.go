@@ -0,0 +1,83 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToOCSF(t *testing.T) {
+
+	RegisterOCSFClass("4625", OCSFAuthentication)
+
+	e := event
+	e.DeviceEventClassId = "4625"
+	e.Severity = "8"
+	e.Extensions = map[string]string{
+		"src":   "10.0.0.1",
+		"spt":   "51234",
+		"dpt":   "smb",
+		"duser": "alice",
+		"rt":    "1700000000000",
+		"cs1":   "custom",
+	}
+
+	got, err := e.ToOCSF()
+	if err != nil {
+		t.Fatalf("ToOCSF() = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"class_uid":     3002,
+		"class_name":    "Authentication",
+		"category_uid":  3,
+		"category_name": "Identity & Access Management",
+		"activity_id":   1,
+		"type_uid":      300201,
+		"severity_id":   4,
+		"severity":      "High",
+		"message":       "Something cool happened.",
+		"time":          int64(1700000000000),
+		"metadata": map[string]interface{}{
+			"version":    OCSFVersion,
+			"event_code": "4625",
+			"product": map[string]interface{}{
+				"vendor_name": "Cool Vendor",
+				"name":        "Cool Product",
+				"version":     "1.0",
+			},
+		},
+		"src_endpoint": map[string]interface{}{"ip": "10.0.0.1", "port": int64(51234)},
+		"user":         map[string]interface{}{"name": "alice"},
+		"unmapped":     map[string]interface{}{"dpt": "smb", "cs1": "custom"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToOCSF() = %v, want %v", got, want)
+	}
+
+	// class IDs which are not registered map onto the base event.
+	line, err := event.Render(FormatOCSF)
+	if err != nil {
+		t.Fatalf("Render(FormatOCSF) = %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &doc); err != nil || doc["class_uid"] != float64(0) || doc["severity_id"] != float64(0) {
+		t.Errorf("Render(FormatOCSF) = %s, %v, want a base event of unknown severity", line, err)
+	}
+}
+
+func TestOCSFSeverity(t *testing.T) {
+
+	tests := []struct {
+		severity string
+		id       int
+	}{
+		{"0", 1}, {"3", 2}, {"Low", 2}, {"5", 3}, {"Medium", 3}, {"7", 4}, {"High", 4}, {"10", 5}, {"Very-High", 5}, {"Unknown", 0},
+	}
+
+	for _, tt := range tests {
+		if id, _ := ocsfSeverity(tt.severity); id != tt.id {
+			t.Errorf("ocsfSeverity(%q) = %d, want %d", tt.severity, id, tt.id)
+		}
+	}
+}
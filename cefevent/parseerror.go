@@ -0,0 +1,138 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors wrapped by a ParseError, they tell the kind of the failure
+// apart and can be checked with errors.Is.
+var (
+	ErrMissingPrefix      = errors.New("message does not start with \"CEF:\"")
+	ErrInvalidVersion     = errors.New("invalid CEF version")
+	ErrIncompleteHeader   = errors.New("incomplete CEF header")
+	ErrMissingField       = errors.New("mandatory CEF field is empty")
+	ErrMalformedExtension = errors.New("malformed CEF extension")
+	ErrInvalidUTF8        = errors.New("invalid UTF-8")
+	ErrLimitExceeded      = errors.New("parse limit exceeded")
+)
+
+// headerFieldNames are the names of the header fields in message order,
+// they match the fields of the CefEvent struct.
+var headerFieldNames = [cefHeaderFields]string{
+	"Version",
+	"DeviceVendor",
+	"DeviceProduct",
+	"DeviceVersion",
+	"DeviceEventClassId",
+	"Name",
+	"Severity",
+}
+
+// ParseError describes why and where a CEF message could not be parsed.
+type ParseError struct {
+	Offset int    // Offset is the byte offset in the message at which the failure was detected.
+	Field  string // Field is the header field name or extension key the failure relates to, empty if none.
+	Reason string // Reason describes the failure.
+	Err    error  // Err is one of the Err* kinds of parse failures or the underlying error.
+	Fields int    // Fields is the number of complete header fields found, it is only set for ErrIncompleteHeader.
+}
+
+// Error returns the reason, field and offset of the failure.
+func (e *ParseError) Error() string {
+
+	if e.Field == "" {
+		return fmt.Sprintf("not a valid CEF message: %s at offset %d", e.Reason, e.Offset)
+	}
+
+	return fmt.Sprintf("not a valid CEF message: %s in %s at offset %d", e.Reason, e.Field, e.Offset)
+}
+
+// Unwrap returns the kind of the failure.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// TruncatedError is returned by ReadWithOptions with AllowTruncated set,
+// together with the parsed portion of a message which was cut off.
+type TruncatedError struct {
+	Offset int    // Offset is the length of the message, where it was cut off.
+	Field  string // Field is the header field name or extension key the message was cut off in.
+	Err    error  // Err is ErrIncompleteHeader or ErrMalformedExtension.
+}
+
+// Error returns the field and offset at which the message was cut off.
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("truncated CEF message: cut off in %s at offset %d", e.Field, e.Offset)
+}
+
+// Unwrap returns the kind of the truncation.
+func (e *TruncatedError) Unwrap() error {
+	return e.Err
+}
+
+// JSONError describes why a JSON document is not a valid event, see FromJSON.
+type JSONError struct {
+	Offset int64  // Offset is the byte offset in the document at which the failure was detected, -1 if unknown.
+	Field  string // Field is the header field name or extension key the failure relates to, empty if none.
+	Reason string // Reason describes the failure.
+	Err    error  // Err is one of ErrMissingField, ErrInvalidVersion and ErrMalformedExtension or the error of encoding/json.
+}
+
+// Error returns the reason and field of the failure.
+func (e *JSONError) Error() string {
+
+	if e.Field == "" {
+		return fmt.Sprintf("not a valid CEF JSON document: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("not a valid CEF JSON document: %s in %s", e.Reason, e.Field)
+}
+
+// Unwrap returns the kind of the failure.
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a ParseError whose reason defaults to the message of err.
+func newParseError(offset int, field string, err error, reason string) *ParseError {
+
+	if reason == "" {
+		reason = err.Error()
+	}
+
+	return &ParseError{Offset: offset, Field: field, Reason: reason, Err: err}
+}
+
+// incompleteHeaderError returns the ParseError of a message which ends
+// after the given number of complete header fields.
+func incompleteHeaderError(offset int, fields int) *ParseError {
+
+	err := newParseError(offset, headerFieldNames[fields], ErrIncompleteHeader,
+		fmt.Sprintf("header has %d of %d fields", fields, cefHeaderFields))
+	err.Fields = fields
+
+	return err
+}
+
+// headerFieldOffset returns the byte offset of the n-th header field of
+// the message without the "CEF:" prefix, escaped pipes are skipped.
+func headerFieldOffset[T string | []byte](message T, n int) int {
+
+	if n == 0 {
+		return 0
+	}
+
+	for i := 0; i < len(message); i++ {
+		switch message[i] {
+		case '\\':
+			i++
+		case '|':
+			if n--; n == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(message)
+}
@@ -0,0 +1,140 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+
+	var tests = []struct {
+		line   string
+		opts   ParseOptions
+		kind   error
+		field  string
+		offset int
+	}{
+		{"LEEF:2.0|a|b|c|d|", ParseOptions{}, ErrMissingPrefix, "", 0},
+		{"CEF:x|a|b|c|d|e|f|", ParseOptions{}, ErrInvalidVersion, "Version", 4},
+		{"CEF:42|a|b|c|d|e|f|", ParseOptions{}, ErrInvalidVersion, "Version", 4},
+		{"CEF:-1|a|b|c|d|e||", ParseOptions{AllowUnknownVersion: true}, ErrMissingField, "Severity", 17},
+		{"CEF:0|a|b|c", ParseOptions{}, ErrIncompleteHeader, "DeviceVersion", 11},
+		{"CEF:0|a|b||d|e|f|", ParseOptions{}, ErrMissingField, "DeviceVersion", 10},
+		{`CEF:0|a\|b|c|d|e|f||`, ParseOptions{}, ErrMissingField, "Severity", 19},
+		{"CEF:0|a|b|c|d|e|f|garbage src=1", ParseOptions{Strict: true}, ErrMalformedExtension, "", 18},
+		{"CEF:0|a|b|c|d|e|f|src=1 src=2", ParseOptions{Strict: true}, ErrMalformedExtension, "src", 24},
+	}
+
+	for _, tt := range tests {
+		_, err := new(CefEvent).ReadWithOptions(tt.line, tt.opts)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("ReadWithOptions(%q) = %v, want a *ParseError", tt.line, err)
+			continue
+		}
+
+		if !errors.Is(err, tt.kind) || parseErr.Field != tt.field || parseErr.Offset != tt.offset {
+			t.Errorf("ReadWithOptions(%q) = %+v, want %v in %q at offset %d", tt.line, parseErr, tt.kind, tt.field, tt.offset)
+		}
+	}
+}
+
+func TestParseErrorIncompleteHeader(t *testing.T) {
+
+	for fields := 0; fields < cefHeaderFields; fields++ {
+		line := "CEF:" + strings.Repeat("1|", fields) + "x"
+
+		_, err := new(CefEvent).Read(line)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, ErrIncompleteHeader) || parseErr.Fields != fields || parseErr.Field != headerFieldNames[fields] {
+			t.Errorf("Read(%q) = %+v, want ErrIncompleteHeader with %d fields", line, err, fields)
+		}
+
+		_, err = ParseBytes([]byte(line))
+		if !errors.As(err, &parseErr) || parseErr.Fields != fields {
+			t.Errorf("ParseBytes(%q) = %+v, want ErrIncompleteHeader with %d fields", line, err, fields)
+		}
+	}
+}
+
+func TestParseMalformedNoPanic(t *testing.T) {
+
+	lines := []string{
+		`CEF:0|Cool\|Vendor\|x|1.0|100|name|10|src=1 msg=a\=b\ filePath=C:\\ cefCompressed=rawEvent rawEvent=%%`,
+		`<134>1 2024-01-12T10:00:00Z host app 1 - [a b="\]"] CEF:0|a|b|c|d|e|f|rt=Jan 12 2024 10:00:00 UTC`,
+		"<134>Jan 12 10:00:00 host app[1]: CEF:1|a|b|c|d|e|f|==  =x x= \\",
+	}
+
+	for _, line := range lines {
+		for i := 0; i <= len(line); i++ {
+			prefix := line[:i]
+			new(CefEvent).Read(prefix)
+			new(CefEvent).ReadWithOptions(prefix, ParseOptions{Strict: true, MaxExtensions: 1})
+			new(CefEvent).ReadWithOptions(prefix, ParseOptions{AllowMissingFields: true, AllowTruncated: true, AllowUnknownVersion: true})
+			ParseBytes([]byte(prefix))
+			ParseSyslog(prefix)
+			extensionValue(prefix, "rt")
+		}
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+
+	err := newParseError(11, "DeviceVersion", ErrIncompleteHeader, "header has 3 of 7 fields")
+
+	want := "not a valid CEF message: header has 3 of 7 fields in DeviceVersion at offset 11"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+
+	var tests = []struct {
+		line  string
+		want  CefEvent
+		kind  error
+		field string
+	}{
+		{
+			"CEF:0|Vendor|Prod",
+			CefEvent{DeviceVendor: "Vendor", Extensions: map[string]string{}},
+			ErrIncompleteHeader, "DeviceProduct",
+		},
+		{
+			"CEF:0|a|b|c|d|e|f|sr",
+			CefEvent{DeviceVendor: "a", DeviceProduct: "b", DeviceVersion: "c", DeviceEventClassId: "d", Name: "e", Severity: "f",
+				Extensions: map[string]string{}},
+			ErrMalformedExtension, "sr",
+		},
+		{
+			`CEF:0|a|b|c|d|e|f|src=10.0.0.1 filePath=C:\\Windows\`,
+			CefEvent{DeviceVendor: "a", DeviceProduct: "b", DeviceVersion: "c", DeviceEventClassId: "d", Name: "e", Severity: "f",
+				Extensions: map[string]string{"src": "10.0.0.1"}},
+			ErrMalformedExtension, "filePath",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := new(CefEvent).ReadWithOptions(tt.line, ParseOptions{AllowTruncated: true, Strict: true})
+
+		var truncated *TruncatedError
+		if !errors.As(err, &truncated) || !errors.Is(err, tt.kind) || truncated.Field != tt.field || truncated.Offset != len(tt.line) {
+			t.Errorf("ReadWithOptions(%q) = %v, want a truncation in %s", tt.line, err, tt.field)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadWithOptions(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+
+	// complete messages are not affected.
+	if got, err := new(CefEvent).ReadWithOptions(`CEF:0|a|b|c|d|e|f|filePath=C:\\`, ParseOptions{AllowTruncated: true}); err != nil || got.Extensions["filePath"] != `C:\` {
+		t.Errorf("ReadWithOptions() = %+v, %v", got, err)
+	}
+	if _, err := new(CefEvent).Read("CEF:0|Vendor|Prod"); !errors.Is(err, ErrIncompleteHeader) {
+		t.Errorf("Read() = %v, want ErrIncompleteHeader without AllowTruncated", err)
+	}
+}
@@ -0,0 +1,170 @@
+package cefevent
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// ParsingProfile is the CEF dialect of a group of devices. Network sources
+// parse the messages of the senders matching a profile with its options
+// and normalize them with its key aliases and severity table, so devices
+// of different vendors can send to a single collector.
+type ParsingProfile struct {
+	Name string // Name identifies the profile in configurations and errors.
+	// Sources are the networks of the senders the profile applies to.
+	Sources []netip.Prefix
+	// Identities are the verified identities of the senders the profile
+	// applies to, i.e. the value of their SourceIdentityKey extension.
+	Identities []string
+	// Options are the ParseOptions the messages are parsed with, e.g.
+	// AllowMissingFields for devices sending incomplete headers.
+	Options ParseOptions
+	// KeyAliases renames extension keys of the device to the keys of the
+	// collector, e.g. "src_ip" to "src". An alias is kept as it is if its
+	// key is already used by another extension, so no value is lost.
+	KeyAliases map[string]string
+	// Severities replaces the severities of the device, e.g. "critical"
+	// with "10". Severities missing from the table are kept.
+	Severities map[string]string
+}
+
+// matches reports whether the profile applies to the sender with the
+// address and identity.
+func (p *ParsingProfile) matches(addr netip.Addr, identity string) bool {
+
+	for _, prefix := range p.Sources {
+		if addr.IsValid() && prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	for _, id := range p.Identities {
+		if identity != "" && id == identity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize applies the key aliases and the severity table of the profile.
+func (p *ParsingProfile) normalize(event *CefEvent) {
+
+	aliases := make([]string, 0, len(p.KeyAliases))
+	for alias := range p.KeyAliases {
+		aliases = append(aliases, alias)
+	}
+	// the order decides which of two aliases of the same key is renamed.
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		key := p.KeyAliases[alias]
+		value, ok := event.Extensions[alias]
+		if !ok || alias == key {
+			continue
+		}
+		if _, taken := event.Extensions[key]; taken {
+			continue
+		}
+		event.Extensions[key] = value
+		delete(event.Extensions, alias)
+	}
+
+	if severity, ok := p.Severities[event.Severity]; ok {
+		event.Severity = severity
+	}
+}
+
+// envelope parses the raw line with the profile and wraps the result in
+// an Envelope, a nil profile parses the line like newEnvelope.
+func (p *ParsingProfile) envelope(raw string, origin string) Envelope {
+
+	if p == nil {
+		return newEnvelope(raw, origin)
+	}
+
+	env := Envelope{Raw: raw, Origin: origin, Received: time.Now()}
+	env.Event, env.Err = ParseWithOptions(raw, p.Options)
+	if env.Err == nil {
+		p.normalize(&env.Event)
+	}
+
+	return env
+}
+
+// profile returns the first of the profiles of the options matching the
+// sender, or nil when none does.
+func (o ListenOptions) profile(addr net.Addr, identity string) *ParsingProfile {
+
+	ap, _ := addrPort(addr)
+	for i := range o.Profiles {
+		if o.Profiles[i].matches(ap.Addr(), identity) {
+			return &o.Profiles[i]
+		}
+	}
+
+	return nil
+}
+
+// ParsingProfileConfig describes a ParsingProfile of a network source in a
+// pipeline configuration.
+type ParsingProfileConfig struct {
+	Name                string            `json:"name"`                            // Name identifies the profile.
+	Sources             []string          `json:"sources,omitempty"`               // Sources lists the IP addresses and CIDR prefixes of the senders.
+	Identities          []string          `json:"identities,omitempty"`            // Identities lists the verified identities of the senders.
+	Strict              bool              `json:"strict,omitempty"`                // Strict rejects malformed extensions, see ParseOptions.
+	AllowMissingFields  bool              `json:"allow_missing_fields,omitempty"`  // AllowMissingFields accepts incomplete headers.
+	AllowTruncated      bool              `json:"allow_truncated,omitempty"`       // AllowTruncated keeps the parsed portion of cut off messages.
+	AllowUnknownVersion bool              `json:"allow_unknown_version,omitempty"` // AllowUnknownVersion accepts CEF versions other than 0 and 1.
+	TimeZone            string            `json:"time_zone,omitempty"`             // TimeZone is the IANA zone of dates lacking one, e.g. "Europe/Berlin".
+	KeyAliases          map[string]string `json:"key_aliases,omitempty"`           // KeyAliases renames extension keys of the devices.
+	Severities          map[string]string `json:"severities,omitempty"`            // Severities replaces severities of the devices.
+	Quirks              []string          `json:"quirks,omitempty"`                // Quirks are the names of the registered Quirks corrected, see RegisterQuirk.
+}
+
+// profile converts the configuration into a ParsingProfile.
+func (c ParsingProfileConfig) profile() (ParsingProfile, error) {
+
+	sources, err := parseAllowList(c.Sources)
+	if err != nil {
+		return ParsingProfile{}, fmt.Errorf("profile %q: %w", c.Name, err)
+	}
+	if len(sources) == 0 && len(c.Identities) == 0 {
+		return ParsingProfile{}, fmt.Errorf("profile %q matches no sender, sources or identities are required", c.Name)
+	}
+
+	var location *time.Location
+	if c.TimeZone != "" {
+		if location, err = time.LoadLocation(c.TimeZone); err != nil {
+			return ParsingProfile{}, fmt.Errorf("profile %q: %w", c.Name, err)
+		}
+	}
+
+	quirks := make([]Quirk, 0, len(c.Quirks))
+	for _, name := range c.Quirks {
+		quirk, err := LookupQuirk(name)
+		if err != nil {
+			return ParsingProfile{}, fmt.Errorf("profile %q: %w", c.Name, err)
+		}
+		quirks = append(quirks, quirk)
+	}
+
+	return ParsingProfile{
+		Name:       c.Name,
+		Sources:    sources,
+		Identities: c.Identities,
+		Options: ParseOptions{
+			Strict:              c.Strict,
+			AllowMissingFields:  c.AllowMissingFields,
+			AllowTruncated:      c.AllowTruncated,
+			AllowUnknownVersion: c.AllowUnknownVersion,
+			Location:            location,
+			Quirks:              quirks,
+		},
+		KeyAliases: c.KeyAliases,
+		Severities: c.Severities,
+	}, nil
+}
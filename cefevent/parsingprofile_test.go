@@ -0,0 +1,113 @@
+package cefevent
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsingProfile(t *testing.T) {
+
+	profile := &ParsingProfile{
+		Name:       "legacy-firewall",
+		Sources:    []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Identities: []string{"fw01.example.com"},
+		Options:    ParseOptions{AllowMissingFields: true},
+		KeyAliases: map[string]string{"src_ip": "src", "source": "src", "dst_ip": "dst", "dst": "dst"},
+		Severities: map[string]string{"critical": "10"},
+	}
+
+	env := profile.envelope("CEF:0|Vendor|Firewall|1.0|100|Blocked|critical|src_ip=10.0.0.1 source=10.0.0.2 dst_ip=10.0.0.3", "test")
+	if env.Err != nil {
+		t.Fatalf("envelope() = %v", env.Err)
+	}
+	want := map[string]string{"src": "10.0.0.2", "src_ip": "10.0.0.1", "dst": "10.0.0.3"}
+	if env.Event.Severity != "10" || !reflect.DeepEqual(env.Event.Extensions, want) {
+		t.Errorf("envelope() = %q %v, want %q %v", env.Event.Severity, env.Event.Extensions, "10", want)
+	}
+
+	// the profile accepts the incomplete header Parse rejects.
+	if env := profile.envelope("CEF:0|Vendor|Firewall", "test"); env.Err != nil {
+		t.Errorf("envelope() = %v, want the options of the profile applied", env.Err)
+	}
+	if env := (*ParsingProfile)(nil).envelope("CEF:0|Vendor|Firewall", "test"); env.Err == nil {
+		t.Error("envelope() of no profile = nil, want the error of Parse")
+	}
+
+	tests := []struct {
+		addr     string
+		identity string
+		want     bool
+	}{
+		{"10.1.2.3", "", true},
+		{"192.0.2.1", "fw01.example.com", true},
+		{"192.0.2.1", "fw02.example.com", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		addr, _ := netip.ParseAddr(tt.addr)
+		if got := profile.matches(addr, tt.identity); got != tt.want {
+			t.Errorf("matches(%q, %q) = %v, want %v", tt.addr, tt.identity, got, tt.want)
+		}
+	}
+}
+
+func TestUDPSourceProfiles(t *testing.T) {
+
+	source, err := ListenUDP("127.0.0.1:0", ListenOptions{Profiles: []ParsingProfile{
+		{Name: "other", Sources: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, Severities: map[string]string{"Unknown": "1"}},
+		{Name: "local", Sources: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}, Severities: map[string]string{"Unknown": "5"}},
+	}})
+	if err != nil {
+		t.Fatalf("ListenUDP() = %v", err)
+	}
+	defer source.Close()
+
+	conn, err := net.Dial("udp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(eventLine))
+
+	env, err := receiveWithin(source, time.Second)
+	if err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+	if env.Event.Severity != "5" {
+		t.Errorf("Receive() Severity = %q, want the profile of the sender applied", env.Event.Severity)
+	}
+}
+
+func TestParsingProfileConfig(t *testing.T) {
+
+	config := SourceConfig{Type: "tcp", Profiles: []ParsingProfileConfig{{
+		Name:               "windows",
+		Sources:            []string{"192.0.2.0/24"},
+		AllowMissingFields: true,
+		TimeZone:           "Europe/Berlin",
+		Severities:         map[string]string{"High": "8"},
+	}}}
+
+	opts, err := config.listenOptions()
+	if err != nil {
+		t.Fatalf("listenOptions() = %v", err)
+	}
+	profile := opts.Profiles[0]
+	if !profile.Options.AllowMissingFields || profile.Options.Location.String() != "Europe/Berlin" || profile.Severities["High"] != "8" {
+		t.Errorf("listenOptions() = %+v", profile)
+	}
+
+	for _, pc := range []ParsingProfileConfig{
+		{Name: "nobody"},
+		{Name: "bad-source", Sources: []string{"192.0.2.0/33"}},
+		{Name: "bad-zone", Identities: []string{"fw01"}, TimeZone: "Mars/Olympus"},
+	} {
+		if _, err := (SourceConfig{Profiles: []ParsingProfileConfig{pc}}).listenOptions(); err == nil {
+			t.Errorf("listenOptions(%+v) = nil, want an error", pc)
+		}
+	}
+}
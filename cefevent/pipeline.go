@@ -0,0 +1,645 @@
+package cefevent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pipeline moves the events of any number of sources through an Emitter
+// to its sinks.
+type Pipeline struct {
+	sources   []Source
+	emitter   *Emitter
+	onError   func(line string, err error)
+	onFailure func(line string, event CefEvent, err error)
+	closers   []io.Closer
+	dryRun    []*DryRunSink
+	stats     *pipelineStats
+}
+
+// NewPipeline returns a Pipeline delivering the events of all sources to the emitter.
+//
+// Parameters:
+// - emitter: The Emitter, including its middlewares and sinks, events are delivered to.
+// - sources: The inputs the events are read from.
+//
+// Returns:
+// - A pointer to a Pipeline.
+func NewPipeline(emitter *Emitter, sources ...Source) *Pipeline {
+	return &Pipeline{sources: sources, emitter: emitter, stats: newPipelineStats()}
+}
+
+// Emitter returns the Emitter of the Pipeline, e.g. to add middlewares to it.
+func (p *Pipeline) Emitter() *Emitter {
+	return p.emitter
+}
+
+// DryRunSinks returns the sinks standing in for the configured destinations
+// of a Pipeline built from a configuration with DryRun set, nil otherwise.
+// Their reports show what the Pipeline would have delivered.
+func (p *Pipeline) DryRunSinks() []*DryRunSink {
+	return p.dryRun
+}
+
+// OnError registers a callback which is called for every received line
+// that can not be parsed, instead of silently skipping it. The callback may
+// be called concurrently for lines of different sources.
+//
+// Parameters:
+// - fn: The callback receiving the malformed line and its parse error.
+func (p *Pipeline) OnError(fn func(line string, err error)) {
+	p.onError = fn
+}
+
+// OnFailure registers a callback which is called for every event whose
+// emission failed, because a middleware returned an error or a sink could
+// not deliver it. The callback may be called concurrently.
+//
+// Parameters:
+// - fn: The callback receiving the raw line, the parsed event and the error of the Emitter.
+func (p *Pipeline) OnFailure(fn func(line string, event CefEvent, err error)) {
+	p.onFailure = fn
+}
+
+// Run reads all sources concurrently and emits their events until every
+// source is exhausted or the context is cancelled, in which case all
+// sources are closed.
+//
+// Lines which could not be parsed and events which could not be delivered
+// are skipped, they do not stop the pipeline. Malformed lines are reported
+// to the OnError callback when one is registered.
+//
+// Returns:
+// - The errors of the sources which failed, nil when all sources ended regularly.
+func (p *Pipeline) Run(ctx context.Context) error {
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	p.stats.setRunning(true)
+	defer p.stats.setRunning(false)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, source := range p.sources {
+				source.Close()
+			}
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(p.sources))
+
+	for i, source := range p.sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			errs[i] = p.consume(source)
+		}(i, source)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// consume emits the events of a single source until it ends.
+func (p *Pipeline) consume(source Source) error {
+
+	for {
+		env, err := source.Receive()
+		if errors.Is(err, io.EOF) || errors.Is(err, ErrSourceClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if env.Err != nil {
+			p.stats.record(env.Err, nil)
+			if p.onError != nil {
+				p.onError(env.Raw, env.Err)
+			}
+			continue
+		}
+
+		err = p.emitter.Emit(env.Event)
+		p.stats.record(nil, err)
+		if err != nil && p.onFailure != nil {
+			p.onFailure(env.Raw, env.Event, err)
+		}
+	}
+}
+
+// Close closes all sources and sinks of the Pipeline.
+func (p *Pipeline) Close() error {
+
+	var errs []error
+	for _, source := range p.sources {
+		if err := source.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, p.emitter.Close())
+
+	for _, closer := range p.closers {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// SourceConfig describes a single input of a pipeline configuration.
+type SourceConfig struct {
+	Type         string `json:"type"`                    // Type is the registered source type, e.g. "file", "tail", "stdin", "udp" or "tcp".
+	Path         string `json:"path,omitempty"`          // Path is the file read by file and tail sources.
+	Address      string `json:"address,omitempty"`       // Address is the listen address of network sources.
+	PollInterval string `json:"poll_interval,omitempty"` // PollInterval is the time.Duration between polls of tail sources.
+	Family       string `json:"family,omitempty"`        // Family restricts network sources to "ipv4" or "ipv6", by default they are dual-stack.
+
+	// The limits of tcp sources, see ListenOptions.
+	MaxConnections    int     `json:"max_connections,omitempty"`     // MaxConnections bounds the connections read at once.
+	MessagesPerSecond float64 `json:"messages_per_second,omitempty"` // MessagesPerSecond limits the rate each connection is read at.
+	Burst             int     `json:"burst,omitempty"`               // Burst is the number of messages a connection may send at once.
+	IdleTimeout       string  `json:"idle_timeout,omitempty"`        // IdleTimeout is the time.Duration after which stalled connections are closed.
+	MaxMessageSize    int     `json:"max_message_size,omitempty"`    // MaxMessageSize closes connections sending longer messages.
+	QueueSize         int     `json:"queue_size,omitempty"`          // QueueSize is the number of received events buffered for the pipeline.
+
+	// The senders accepted by network sources, see ListenOptions.
+	Allow       []string `json:"allow,omitempty"`         // Allow lists the IP addresses and CIDR prefixes of accepted senders.
+	TLSCert     string   `json:"tls_cert,omitempty"`      // TLSCert is the PEM certificate file tcp sources serve TLS with.
+	TLSKey      string   `json:"tls_key,omitempty"`       // TLSKey is the PEM private key file of TLSCert.
+	TLSClientCA string   `json:"tls_client_ca,omitempty"` // TLSClientCA is the PEM file of the CAs client certificates must be signed by.
+
+	// Profiles are the parsing profiles of network sources, see ParsingProfile.
+	Profiles []ParsingProfileConfig `json:"profiles,omitempty"`
+}
+
+// listenOptions converts the configuration of network sources into ListenOptions.
+func (c SourceConfig) listenOptions() (ListenOptions, error) {
+
+	family, err := ParseAddressFamily(c.Family)
+	if err != nil {
+		return ListenOptions{}, err
+	}
+
+	idle, err := parseOptionalDuration(c.IdleTimeout)
+	if err != nil {
+		return ListenOptions{}, err
+	}
+
+	allow, err := parseAllowList(c.Allow)
+	if err != nil {
+		return ListenOptions{}, err
+	}
+
+	tlsConfig, err := serverTLSConfig(c.TLSCert, c.TLSKey, c.TLSClientCA)
+	if err != nil {
+		return ListenOptions{}, err
+	}
+
+	profiles := make([]ParsingProfile, 0, len(c.Profiles))
+	for _, pc := range c.Profiles {
+		profile, err := pc.profile()
+		if err != nil {
+			return ListenOptions{}, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return ListenOptions{
+		Family:            family,
+		MaxConnections:    c.MaxConnections,
+		MessagesPerSecond: c.MessagesPerSecond,
+		Burst:             c.Burst,
+		IdleTimeout:       idle,
+		MaxMessageSize:    c.MaxMessageSize,
+		QueueSize:         c.QueueSize,
+		Allow:             allow,
+		TLS:               tlsConfig,
+		Profiles:          profiles,
+	}, nil
+}
+
+// SinkConfig describes a single output of a pipeline configuration.
+type SinkConfig struct {
+	Type             string   `json:"type"`                         // Type is the registered sink type, e.g. "stdout", "stderr", "file", "udp" or "tcp".
+	Format           string   `json:"format,omitempty"`             // Format is the name of the wire Format, defaults to "cef".
+	Path             string   `json:"path,omitempty"`               // Path is the file written by file sinks.
+	Address          string   `json:"address,omitempty"`            // Address is the destination of network sinks.
+	Addresses        []string `json:"addresses,omitempty"`          // Addresses are further destinations network sinks fail over to.
+	ResolveAll       bool     `json:"resolve_all,omitempty"`        // ResolveAll uses every A and AAAA record of the destinations as a separate endpoint.
+	Balance          string   `json:"balance,omitempty"`            // Balance is "failover" or "round_robin" for network sinks with multiple endpoints.
+	RetryInterval    string   `json:"retry_interval,omitempty"`     // RetryInterval is the time.Duration failed endpoints are skipped.
+	PacketsPerSecond float64  `json:"packets_per_second,omitempty"` // PacketsPerSecond paces udp sinks.
+	Burst            int      `json:"burst,omitempty"`              // Burst is the pacing burst size of udp sinks.
+	MaxDatagramSize  int      `json:"max_datagram_size,omitempty"`  // MaxDatagramSize limits the message size of udp sinks.
+	Family           string   `json:"family,omitempty"`             // Family restricts network sinks to "ipv4" or "ipv6" destinations.
+	Timestamps       string   `json:"timestamps,omitempty"`         // Timestamps is the TimestampFormat of time-typed extensions, "keep", "millis" or "date".
+	Atomic           bool     `json:"atomic,omitempty"`             // Atomic makes file sinks write their file under a temporary name and rename it when closed.
+	FileConfig                // FileConfig sets the permission and ownership of the file written by file sinks.
+}
+
+// CaptureConfig describes where a pipeline writes the FailureBundle files
+// of events which could not be delivered.
+type CaptureConfig struct {
+	Dir        string `json:"dir"`                   // Dir is the directory the bundles are written to.
+	MaxBundles int    `json:"max_bundles,omitempty"` // MaxBundles caps the number of bundles, zero means unlimited.
+	FileConfig        // FileConfig sets the permission and ownership of the bundles.
+}
+
+// QuarantineConfig describes where a pipeline preserves input lines which could not be parsed.
+type QuarantineConfig struct {
+	Path       string `json:"path"`                // Path is the quarantine file.
+	MaxBytes   int64  `json:"max_bytes,omitempty"` // MaxBytes caps the size of the quarantine file, zero means unlimited.
+	FileConfig        // FileConfig sets the permission and ownership of the quarantine file.
+}
+
+// PipelineConfig describes the inputs and outputs of a Pipeline.
+type PipelineConfig struct {
+	Sources    []SourceConfig    `json:"sources"`
+	Sinks      []SinkConfig      `json:"sinks"`
+	Quarantine *QuarantineConfig `json:"quarantine,omitempty"`
+	Capture    *CaptureConfig    `json:"capture,omitempty"`
+	Health     *HealthConfig     `json:"health,omitempty"`
+	// DryRun replaces every sink with a DryRunSink, the configured sinks are
+	// not created, and disables the quarantine. A configuration can thereby
+	// be validated against real input without delivering anything.
+	DryRun bool `json:"dry_run,omitempty"`
+	// KeyNormalizer is the name of the registered KeyNormalizer applied to
+	// the extension keys which are not defined by the CEF specification,
+	// e.g. "camel", "snake" or "ad", see RegisterKeyNormalizer.
+	KeyNormalizer string `json:"key_normalizer,omitempty"`
+	// LateArrival tags or drops events whose event time is too old when
+	// they are received, see LateArrival.
+	LateArrival *LateArrivalConfig `json:"late_arrival,omitempty"`
+}
+
+// SourceFactory creates a Source from its configuration.
+type SourceFactory func(config SourceConfig) (Source, error)
+
+// SinkFactory creates a Sink from its configuration and the requested Format.
+type SinkFactory func(config SinkConfig, format Format) (Sink, error)
+
+var (
+	registryMu      sync.RWMutex
+	sourceFactories = map[string]SourceFactory{
+		"file": func(c SourceConfig) (Source, error) {
+			return NewFileSource(c.Path)
+		},
+		"tail": func(c SourceConfig) (Source, error) {
+			interval, err := parseOptionalDuration(c.PollInterval)
+			if err != nil {
+				return nil, err
+			}
+			return NewTailSource(c.Path, interval)
+		},
+		"stdin": func(c SourceConfig) (Source, error) {
+			return NewStdinSource(), nil
+		},
+		"udp": func(c SourceConfig) (Source, error) {
+			opts, err := c.listenOptions()
+			if err != nil {
+				return nil, err
+			}
+			return ListenUDP(c.Address, opts)
+		},
+		"tcp": func(c SourceConfig) (Source, error) {
+			opts, err := c.listenOptions()
+			if err != nil {
+				return nil, err
+			}
+			return ListenTCP(c.Address, opts)
+		},
+	}
+	sinkFactories = map[string]SinkFactory{
+		"stdout": func(c SinkConfig, format Format) (Sink, error) {
+			return NewWriterSink(os.Stdout, format), nil
+		},
+		"stderr": func(c SinkConfig, format Format) (Sink, error) {
+			return NewWriterSink(os.Stderr, format), nil
+		},
+		"file": func(c SinkConfig, format Format) (Sink, error) {
+			opts, err := c.options()
+			if err != nil {
+				return nil, err
+			}
+			opts.Atomic = c.Atomic
+			return NewFileSinkWithOptions(c.Path, format, opts)
+		},
+		"udp": func(c SinkConfig, format Format) (Sink, error) {
+			return newNetworkSink(c, func(address string, family AddressFamily, failover bool) (Sink, error) {
+				return NewUDPSink(address, UDPSinkOptions{
+					Format:           format,
+					Dial:             DialOptions{Family: family},
+					PacketsPerSecond: c.PacketsPerSecond,
+					Burst:            c.Burst,
+					MaxDatagramSize:  c.MaxDatagramSize,
+				})
+			})
+		},
+		"tcp": func(c SinkConfig, format Format) (Sink, error) {
+			return newNetworkSink(c, func(address string, family AddressFamily, failover bool) (Sink, error) {
+				return NewTCPSink(address, TCPSinkOptions{Format: format, Dial: DialOptions{Family: family}, Lazy: failover})
+			})
+		},
+	}
+)
+
+// RegisterSource makes a source type available to pipeline configurations,
+// an existing registration with the same name is replaced.
+func RegisterSource(name string, factory SourceFactory) {
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	sourceFactories[name] = factory
+}
+
+// RegisterSink makes a sink type available to pipeline configurations,
+// an existing registration with the same name is replaced.
+func RegisterSink(name string, factory SinkFactory) {
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	sinkFactories[name] = factory
+}
+
+// registeredTypes returns the sorted names of a factory registry.
+func registeredTypes[T any](factories map[string]T) []string {
+
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// sinkDescription describes the destination of a sink configuration.
+func sinkDescription(c SinkConfig) string {
+
+	destinations := c.Addresses
+	if c.Address != "" {
+		destinations = append([]string{c.Address}, destinations...)
+	}
+	if c.Path != "" {
+		destinations = append([]string{c.Path}, destinations...)
+	}
+
+	if len(destinations) == 0 {
+		return c.Type
+	}
+
+	return c.Type + " " + strings.Join(destinations, ",")
+}
+
+// newNetworkSink creates a network sink for every destination of the
+// configuration and combines them into a FailoverSink when there is more
+// than one, the create callback is told whether the sink is part of one.
+func newNetworkSink(c SinkConfig, create func(address string, family AddressFamily, failover bool) (Sink, error)) (Sink, error) {
+
+	family, err := ParseAddressFamily(c.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := c.Addresses
+	if c.Address != "" {
+		addresses = append([]string{c.Address}, addresses...)
+	}
+
+	if c.ResolveAll {
+		var resolved []string
+		for _, address := range addresses {
+			endpoints, err := ResolveEndpoints(address, family)
+			if err != nil {
+				return nil, err
+			}
+			resolved = append(resolved, endpoints...)
+		}
+		addresses = resolved
+	}
+
+	switch len(addresses) {
+	case 0:
+		return nil, errors.New("network sink has no address")
+	case 1:
+		return create(addresses[0], family, false)
+	}
+
+	mode, err := ParseBalanceMode(c.Balance)
+	if err != nil {
+		return nil, err
+	}
+
+	retry, err := parseOptionalDuration(c.RetryInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []Sink
+	for _, address := range addresses {
+		endpoint, err := create(address, family, true)
+		if err != nil {
+			NewSinkEmitter(endpoints...).Close()
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return NewFailoverSink(endpoints, FailoverOptions{Mode: mode, RetryInterval: retry})
+}
+
+// parseOptionalDuration parses a time.Duration, an empty string results in zero.
+func parseOptionalDuration(value string) (time.Duration, error) {
+
+	if value == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// LoadPipelineConfig reads a JSON pipeline configuration.
+//
+// Returns:
+// - The decoded PipelineConfig.
+// - An error if the configuration is not valid JSON or lacks sources or sinks.
+func LoadPipelineConfig(r io.Reader) (PipelineConfig, error) {
+
+	var config PipelineConfig
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return PipelineConfig{}, err
+	}
+
+	if len(config.Sources) == 0 {
+		return PipelineConfig{}, errors.New("pipeline configuration has no sources")
+	}
+
+	if len(config.Sinks) == 0 {
+		return PipelineConfig{}, errors.New("pipeline configuration has no sinks")
+	}
+
+	return config, nil
+}
+
+// Build creates the sources and sinks of the configuration and combines
+// them into a Pipeline. Already created sources and sinks are closed again
+// when one of them fails.
+//
+// Returns:
+// - A pointer to the Pipeline ready to Run.
+// - An error if a source or sink type is unknown or could not be created.
+func (c PipelineConfig) Build() (*Pipeline, error) {
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	normalizer, err := LookupKeyNormalizer(c.KeyNormalizer)
+	if err != nil {
+		return nil, err
+	}
+
+	var late LateArrivalOptions
+	if c.LateArrival != nil {
+		if late, err = c.LateArrival.options(); err != nil {
+			return nil, fmt.Errorf("invalid late-arrival policy: %w", err)
+		}
+	}
+
+	var sources []Source
+	var sinks []Sink
+	var dryRun []*DryRunSink
+
+	cleanup := func() {
+		NewPipeline(NewSinkEmitter(sinks...), sources...).Close()
+	}
+
+	for _, sc := range c.Sources {
+		factory, ok := sourceFactories[sc.Type]
+		if !ok {
+			cleanup()
+			return nil, fmt.Errorf("unknown source type %q, known types are %v", sc.Type, registeredTypes(sourceFactories))
+		}
+
+		source, err := factory(sc)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("unable to create %s source: %w", sc.Type, err)
+		}
+		sources = append(sources, source)
+	}
+
+	for _, sc := range c.Sinks {
+		factory, ok := sinkFactories[sc.Type]
+		if !ok {
+			cleanup()
+			return nil, fmt.Errorf("unknown sink type %q, known types are %v", sc.Type, registeredTypes(sinkFactories))
+		}
+
+		format := FormatCEF
+		if sc.Format != "" {
+			var err error
+			if format, err = ParseFormat(sc.Format); err != nil {
+				cleanup()
+				return nil, err
+			}
+		}
+
+		tf, err := ParseTimestampFormat(sc.Timestamps)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		var sink Sink
+		if c.DryRun {
+			preview := NewDryRunSink(sinkDescription(sc), format, DefaultDryRunPreview)
+			dryRun = append(dryRun, preview)
+			sink = preview
+		} else if sink, err = factory(sc, format); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("unable to create %s sink: %w", sc.Type, err)
+		}
+		if tf != TimestampKeep {
+			sink = WithTimestampFormat(sink, tf)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	pipeline := NewPipeline(NewSinkEmitter(sinks...), sources...)
+	pipeline.dryRun = dryRun
+	if c.KeyNormalizer != "" {
+		pipeline.emitter.Use(NormalizeKeys(normalizer))
+	}
+	if c.LateArrival != nil {
+		pipeline.emitter.Use(LateArrival(late))
+	}
+
+	if c.Quarantine != nil && !c.DryRun {
+		opts, err := c.Quarantine.options()
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("invalid quarantine: %w", err)
+		}
+		quarantine, err := NewQuarantineWithOptions(c.Quarantine.Path, c.Quarantine.MaxBytes, opts)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("unable to open quarantine: %w", err)
+		}
+		pipeline.OnError(quarantine.Record)
+		pipeline.closers = append(pipeline.closers, quarantine)
+	}
+
+	if c.Capture != nil && !c.DryRun {
+		opts, err := c.Capture.options()
+		if err != nil {
+			pipeline.Close()
+			return nil, fmt.Errorf("invalid failure capture: %w", err)
+		}
+		capture, err := NewFailureCapture(c.Capture.Dir, c, c.Capture.MaxBundles)
+		if err != nil {
+			pipeline.Close()
+			return nil, fmt.Errorf("unable to create failure capture: %w", err)
+		}
+		capture.SetFileOptions(opts)
+		pipeline.OnFailure(capture.Capture)
+	}
+
+	if c.Health != nil {
+		opts, err := c.Health.options()
+		if err != nil {
+			pipeline.Close()
+			return nil, fmt.Errorf("invalid health endpoint: %w", err)
+		}
+		listener, err := net.Listen("tcp", c.Health.Address)
+		if err != nil {
+			pipeline.Close()
+			return nil, fmt.Errorf("unable to serve health endpoint: %w", err)
+		}
+		server := &http.Server{Handler: pipeline.HealthHandler(opts), ReadHeaderTimeout: 10 * time.Second}
+		go server.Serve(listener)
+		pipeline.closers = append(pipeline.closers, server)
+	}
+
+	return pipeline, nil
+}
@@ -0,0 +1,176 @@
+package cefevent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPipelineRun(t *testing.T) {
+
+	var buf bytes.Buffer
+	pipeline := NewPipeline(
+		NewEmitter(&buf),
+		NewReaderSource(strings.NewReader(eventLine+"\nbroken\n"), "first"),
+		NewReaderSource(strings.NewReader(eventLine+"\n"), "second"),
+	)
+
+	var malformed []string
+	pipeline.OnError(func(line string, err error) {
+		malformed = append(malformed, line)
+	})
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	if got, want := buf.String(), strings.Repeat(eventLine+"\n", 2); got != want {
+		t.Errorf("Run() wrote %q, want %q", got, want)
+	}
+
+	if len(malformed) != 1 || malformed[0] != "broken" {
+		t.Errorf("OnError() received %v, want [broken]", malformed)
+	}
+}
+
+func TestPipelineRunLineTooLong(t *testing.T) {
+
+	long := "CEF:0|Vendor|Product|1.0|100|Flood|9|msg=" + strings.Repeat("x", maxDecoderLineSize)
+
+	var buf bytes.Buffer
+	pipeline := NewPipeline(NewEmitter(&buf), NewReaderSource(strings.NewReader(long+"\n"+eventLine+"\n"), "input"))
+
+	var malformed []error
+	pipeline.OnError(func(line string, err error) {
+		if !strings.HasPrefix(long, line) {
+			t.Errorf("OnError() received %d bytes, want the start of the long line", len(line))
+		}
+		malformed = append(malformed, err)
+	})
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	if got := buf.String(); got != eventLine+"\n" {
+		t.Errorf("Run() wrote %q, want %q", got, eventLine+"\n")
+	}
+	if len(malformed) != 1 || !errors.Is(malformed[0], ErrLimitExceeded) {
+		t.Errorf("OnError() received %v, want ErrLimitExceeded", malformed)
+	}
+}
+
+func TestPipelineRunCancel(t *testing.T) {
+
+	source, err := NewUDPSource("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	pipeline := NewPipeline(NewEmitter(&buf), source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := pipeline.Run(ctx); err != nil {
+		t.Errorf("Run() = %v, want nil after cancellation", err)
+	}
+}
+
+func TestPipelineConfig(t *testing.T) {
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.log")
+	output := filepath.Join(dir, "out.log")
+	os.WriteFile(input, []byte(eventLine+"\n"), 0o644)
+
+	config, err := LoadPipelineConfig(strings.NewReader(`{
+		"sources": [{"type": "file", "path": "` + input + `"}],
+		"sinks": [{"type": "file", "format": "leef", "path": "` + output + `"}]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPipelineConfig() = %v", err)
+	}
+
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+
+	if err := pipeline.Run(context.Background()); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	pipeline.Close()
+
+	want, _ := event.ToLEEF()
+	got, _ := os.ReadFile(output)
+	if string(got) != want+"\n" {
+		t.Errorf("pipeline wrote %q, want %q", got, want+"\n")
+	}
+}
+
+func TestPipelineConfigErrors(t *testing.T) {
+
+	var tests = []string{
+		`{"sinks": [{"type": "stdout"}]}`,
+		`{"sources": [{"type": "stdin"}]}`,
+		`{"sources": [{"type": "stdin"}], "sinks": [{"type": "stdout"}], "unknown": true}`,
+	}
+
+	for _, tt := range tests {
+		if _, err := LoadPipelineConfig(strings.NewReader(tt)); err == nil {
+			t.Errorf("LoadPipelineConfig(%s) should fail", tt)
+		}
+	}
+
+	var builds = []PipelineConfig{
+		{Sources: []SourceConfig{{Type: "carrier-pigeon"}}, Sinks: []SinkConfig{{Type: "stdout"}}},
+		{Sources: []SourceConfig{{Type: "stdin"}}, Sinks: []SinkConfig{{Type: "stdout", Format: "xml"}}},
+		{Sources: []SourceConfig{{Type: "stdin"}}, Sinks: []SinkConfig{{Type: "stdout", Timestamps: "iso"}}},
+		{Sources: []SourceConfig{{Type: "stdin"}}, Sinks: []SinkConfig{{Type: "stdout"}}, KeyNormalizer: "kebab"},
+		{Sources: []SourceConfig{{Type: "stdin"}}, Sinks: []SinkConfig{{Type: "stdout"}}, LateArrival: &LateArrivalConfig{MaxAge: "1h", Action: "quarantine"}},
+		{Sources: []SourceConfig{{Type: "stdin"}}, Sinks: []SinkConfig{{Type: "stdout"}}, LateArrival: &LateArrivalConfig{MaxAge: "0s"}},
+		{Sources: []SourceConfig{{Type: "tcp", Address: "127.0.0.1:0", IdleTimeout: "soon"}}, Sinks: []SinkConfig{{Type: "stdout"}}},
+		{Sources: []SourceConfig{{Type: "udp", Address: "127.0.0.1:0", Allow: []string{"10.0.0.0/33"}}}, Sinks: []SinkConfig{{Type: "stdout"}}},
+		{Sources: []SourceConfig{{Type: "tcp", Address: "127.0.0.1:0", TLSCert: "cert.pem"}}, Sinks: []SinkConfig{{Type: "stdout"}}},
+		{Sources: []SourceConfig{{Type: "file", Path: filepath.Join(t.TempDir(), "missing")}}, Sinks: []SinkConfig{{Type: "stdout"}}},
+	}
+
+	for _, config := range builds {
+		if _, err := config.Build(); err == nil {
+			t.Errorf("Build(%+v) should fail", config)
+		}
+	}
+}
+
+func TestRegisterSource(t *testing.T) {
+
+	RegisterSource("test-inline", func(c SourceConfig) (Source, error) {
+		return NewReaderSource(strings.NewReader(c.Path), "inline"), nil
+	})
+
+	var buf bytes.Buffer
+	RegisterSink("test-buffer", func(c SinkConfig, format Format) (Sink, error) {
+		return NewWriterSink(&buf, format), nil
+	})
+
+	config := PipelineConfig{
+		Sources: []SourceConfig{{Type: "test-inline", Path: eventLine}},
+		Sinks:   []SinkConfig{{Type: "test-buffer"}},
+	}
+
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	pipeline.Run(context.Background())
+
+	if got := buf.String(); got != eventLine+"\n" {
+		t.Errorf("pipeline wrote %q, want %q", got, eventLine+"\n")
+	}
+}
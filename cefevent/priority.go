@@ -0,0 +1,160 @@
+package cefevent
+
+import (
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Extension priorities decide which extensions are removed first when an
+// event has to be trimmed to fit a size limit, extensions with a lower
+// priority are removed before those with a higher priority.
+const (
+	PriorityLow    = 0  // PriorityLow is used for bulky payload extensions such as rawEvent and msg.
+	PriorityNormal = 10 // PriorityNormal is used for all extensions without an explicit priority.
+	PriorityHigh   = 20 // PriorityHigh is used for the extensions identifying who did what, where and when.
+)
+
+var (
+	priorityMu          sync.RWMutex
+	extensionPriorities = map[string]int{
+		"rawEvent":       PriorityLow,
+		"requestContext": PriorityLow,
+		"requestCookies": PriorityLow,
+		"msg":            PriorityLow,
+		"request":        PriorityLow,
+		CompressedMarker: PriorityHigh,
+		"act":            PriorityHigh,
+		"app":            PriorityHigh,
+		"cat":            PriorityHigh,
+		"dhost":          PriorityHigh,
+		"dpt":            PriorityHigh,
+		"dst":            PriorityHigh,
+		"duser":          PriorityHigh,
+		"dvc":            PriorityHigh,
+		"dvchost":        PriorityHigh,
+		"end":            PriorityHigh,
+		"externalId":     PriorityHigh,
+		"outcome":        PriorityHigh,
+		"proto":          PriorityHigh,
+		"rt":             PriorityHigh,
+		"shost":          PriorityHigh,
+		"spt":            PriorityHigh,
+		"src":            PriorityHigh,
+		"start":          PriorityHigh,
+		"suser":          PriorityHigh,
+	}
+)
+
+// ExtensionPriority returns the priority of the extension key.
+func ExtensionPriority(key string) int {
+
+	priorityMu.RLock()
+	defer priorityMu.RUnlock()
+
+	if priority, ok := extensionPriorities[key]; ok {
+		return priority
+	}
+
+	return PriorityNormal
+}
+
+// SetExtensionPriority overrides the priority of the extension key for all
+// events, e.g. to protect a custom extension from being trimmed.
+func SetExtensionPriority(key string, priority int) {
+
+	priorityMu.Lock()
+	defer priorityMu.Unlock()
+
+	extensionPriorities[key] = priority
+}
+
+// TrimToSize removes extensions, lowest priority and within the same
+// priority largest first, until the event rendered in format is at most
+// maxSize bytes long.
+//
+// Parameters:
+// - format: The Format the size is measured in.
+// - maxSize: The maximum size of the rendered event in bytes.
+//
+// Returns:
+// - The rendered, possibly trimmed, event.
+// - Whether the message fits maxSize, which is not the case when even the event without extensions is too large.
+// - An error if the event could not be rendered.
+func (event *CefEvent) TrimToSize(format Format, maxSize int) (string, bool, error) {
+
+	message, err := event.Render(format)
+	if err != nil || len(message) <= maxSize {
+		return message, err == nil, err
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := ExtensionPriority(keys[i]), ExtensionPriority(keys[j])
+		if pi != pj {
+			return pi < pj
+		}
+		li, lj := len(event.Extensions[keys[i]]), len(event.Extensions[keys[j]])
+		if li != lj {
+			return li > lj
+		}
+		return keys[i] < keys[j]
+	})
+
+	trimmed := *event
+	trimmed.Extensions = cloneExtensions(event.Extensions)
+
+	if format == FormatCEF {
+		// the size is known without rendering, the event is rendered once
+		// enough extensions are removed.
+		size := event.EstimateSize()
+		for _, k := range keys {
+			if size <= maxSize {
+				break
+			}
+			size -= extensionSize(k, trimmed.Extensions[k])
+			if len(trimmed.Extensions) > 1 {
+				size--
+			}
+			delete(trimmed.Extensions, k)
+		}
+		if message, err = trimmed.Render(format); err != nil {
+			return "", false, err
+		}
+		return message, len(message) <= maxSize, nil
+	}
+
+	for _, k := range keys {
+		delete(trimmed.Extensions, k)
+
+		if message, err = trimmed.Render(format); err != nil {
+			return "", false, err
+		}
+
+		if len(message) <= maxSize {
+			return message, true, nil
+		}
+	}
+
+	return message, false, nil
+}
+
+// truncateMessage cuts the message to at most maxSize bytes without
+// splitting a multi-byte UTF-8 character.
+func truncateMessage(message string, maxSize int) string {
+
+	if len(message) <= maxSize {
+		return message
+	}
+
+	cut := maxSize
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+
+	return message[:cut]
+}
@@ -0,0 +1,93 @@
+package cefevent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCefEventTrimToSize(t *testing.T) {
+
+	bulkyEvent := event
+	bulkyEvent.Extensions = map[string]string{
+		"src":      "127.0.0.1",
+		"cs1":      "custom",
+		"msg":      strings.Repeat("m", 100),
+		"rawEvent": strings.Repeat("r", 50),
+	}
+
+	full, _ := bulkyEvent.String()
+
+	var tests = []struct {
+		maxSize int
+		want    string
+		fits    bool
+	}{
+		{len(full), full, true},
+		{len(full) - 1, eventLine[:len(eventLine)-len("src=127.0.0.1")] + "cs1=custom rawEvent=" + strings.Repeat("r", 50) + " src=127.0.0.1", true},
+		{len(eventLine) + 11, eventLine[:len(eventLine)-len("src=127.0.0.1")] + "cs1=custom src=127.0.0.1", true},
+		{len(eventLine), eventLine, true},
+		{10, strings.TrimSuffix(eventLine, "src=127.0.0.1"), false},
+	}
+
+	for _, tt := range tests {
+		got, fits, err := bulkyEvent.TrimToSize(FormatCEF, tt.maxSize)
+		if err != nil {
+			t.Fatalf("TrimToSize(%d) = %v", tt.maxSize, err)
+		}
+		if got != tt.want || fits != tt.fits {
+			t.Errorf("TrimToSize(%d) = %q, %v, want %q, %v", tt.maxSize, got, fits, tt.want, tt.fits)
+		}
+	}
+
+	if len(bulkyEvent.Extensions) != 4 {
+		t.Errorf("TrimToSize() modified the extensions of the event")
+	}
+}
+
+func TestSetExtensionPriority(t *testing.T) {
+
+	if ExtensionPriority("unknownKey") != PriorityNormal {
+		t.Errorf("ExtensionPriority() = %d, want %d", ExtensionPriority("unknownKey"), PriorityNormal)
+	}
+
+	SetExtensionPriority("unknownKey", PriorityHigh)
+	defer SetExtensionPriority("unknownKey", PriorityNormal)
+
+	if ExtensionPriority("unknownKey") != PriorityHigh {
+		t.Errorf("ExtensionPriority() = %d, want %d", ExtensionPriority("unknownKey"), PriorityHigh)
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+
+	if got := truncateMessage("aé", 2); got != "a" {
+		t.Errorf("truncateMessage() = %q, want %q", got, "a")
+	}
+}
+
+type limitedSink struct {
+	*WriterSink
+	limit int
+}
+
+func (s limitedSink) MaxMessageSize() int {
+	return s.limit
+}
+
+func TestEmitterTrimsForSizeLimitedSink(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewSinkEmitter(limitedSink{NewWriterSink(&buf, FormatCEF), len(eventLine)})
+
+	bulkyEvent := event
+	bulkyEvent.Extensions = map[string]string{"src": "127.0.0.1", "msg": strings.Repeat("m", 2000)}
+
+	if err := emitter.Emit(bulkyEvent); err != nil {
+		t.Fatalf("Emit() = %v", err)
+	}
+
+	if got := buf.String(); got != eventLine+"\n" {
+		t.Errorf("Emit() wrote %q, want %q", got, eventLine+"\n")
+	}
+}
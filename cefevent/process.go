@@ -0,0 +1,76 @@
+package cefevent
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// ProcessMetadata describes the local process emitting events, it is used
+// by host based agents reporting their own activity. Empty fields are unknown.
+type ProcessMetadata struct {
+	PID        int    // PID is the process ID.
+	Executable string // Executable is the absolute path of the executable.
+	User       string // User is the name of the effective user.
+}
+
+// DetectProcessMetadata collects the metadata of the current process. The
+// effective user is looked up by its user ID and falls back to the user ID
+// itself when it has no name, e.g. in minimal containers without /etc/passwd.
+//
+// Returns:
+// - The detected ProcessMetadata.
+func DetectProcessMetadata() ProcessMetadata {
+
+	meta := ProcessMetadata{PID: os.Getpid()}
+
+	if executable, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+			executable = resolved
+		}
+		meta.Executable = executable
+	}
+
+	if uid := os.Geteuid(); uid >= 0 {
+		meta.User = strconv.Itoa(uid)
+		if u, err := user.LookupId(meta.User); err == nil {
+			meta.User = u.Username
+		}
+	} else if u, err := user.Current(); err == nil {
+		// Geteuid is not supported on Windows.
+		meta.User = u.Username
+	}
+
+	return meta
+}
+
+// Extensions returns the metadata as the CEF extensions dpid,
+// deviceProcessName and duser. Unknown fields are omitted.
+func (m ProcessMetadata) Extensions() map[string]string {
+
+	extensions := make(map[string]string)
+	if m.PID > 0 {
+		extensions["dpid"] = strconv.Itoa(m.PID)
+	}
+	if m.Executable != "" {
+		extensions["deviceProcessName"] = m.Executable
+	}
+	if m.User != "" {
+		extensions["duser"] = m.User
+	}
+
+	return extensions
+}
+
+// EnrichProcess returns a Middleware which adds the process metadata to
+// every event. Extensions already present on the event are not overwritten.
+//
+// Parameters:
+// - meta: The metadata, usually obtained once from DetectProcessMetadata.
+//
+// Returns:
+// - A Middleware enriching the events passing through it.
+func EnrichProcess(meta ProcessMetadata) Middleware {
+	return Enrich(meta.Extensions())
+}
@@ -0,0 +1,42 @@
+package cefevent
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDetectProcessMetadata(t *testing.T) {
+
+	meta := DetectProcessMetadata()
+
+	if meta.PID != os.Getpid() || meta.Executable == "" || meta.User == "" {
+		t.Errorf("DetectProcessMetadata() = %+v, want pid, executable and user", meta)
+	}
+
+	if got := meta.Extensions()["dpid"]; got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Extensions()[dpid] = %q, want %d", got, os.Getpid())
+	}
+}
+
+func TestEnrichProcess(t *testing.T) {
+
+	meta := ProcessMetadata{PID: 4711, User: "root"}
+
+	want := map[string]string{"dpid": "4711", "duser": "root"}
+	if got := meta.Extensions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %v, want %v", got, want)
+	}
+
+	var got CefEvent
+	handler := EnrichProcess(meta)(func(e CefEvent) error {
+		got = e
+		return nil
+	})
+	handler(CefEvent{Extensions: map[string]string{"duser": "alice"}})
+
+	if got.Extensions["duser"] != "alice" || got.Extensions["dpid"] != "4711" {
+		t.Errorf("EnrichProcess() = %v, want existing extensions kept", got.Extensions)
+	}
+}
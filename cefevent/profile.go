@@ -0,0 +1,248 @@
+package cefevent
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+)
+
+// DefaultProfileDistinct is the number of distinct values a Profiler
+// tracks per extension when no limit is given.
+const DefaultProfileDistinct = 10000
+
+// The types a Profiler infers for extension values, from the most to the
+// least specific. A field has the first type all of its values conform to.
+const (
+	FieldTypeInt       = "int"
+	FieldTypeFloat     = "float"
+	FieldTypeBool      = "bool"
+	FieldTypeTimestamp = "timestamp"
+	FieldTypeIP        = "ip"
+	FieldTypeMAC       = "mac"
+	FieldTypeString    = "string"
+)
+
+// fieldTypes are the inferable types in order of preference, each with the
+// check whether a value conforms to it.
+var fieldTypes = []struct {
+	name  string
+	check func(value string) bool
+}{
+	{FieldTypeInt, func(v string) bool { _, err := strconv.ParseInt(v, 10, 64); return err == nil }},
+	{FieldTypeFloat, func(v string) bool { _, err := strconv.ParseFloat(v, 64); return err == nil }},
+	{FieldTypeBool, func(v string) bool { _, err := strconv.ParseBool(v); return err == nil }},
+	{FieldTypeTimestamp, func(v string) bool { _, err := ParseTimestamp(v); return err == nil }},
+	{FieldTypeIP, func(v string) bool { return net.ParseIP(v) != nil }},
+	{FieldTypeMAC, func(v string) bool { _, err := net.ParseMAC(v); return err == nil }},
+}
+
+// FieldProfile are the statistics of a single extension key.
+type FieldProfile struct {
+	Key         string  `json:"key"`
+	Count       uint64  `json:"count"`       // Count is the number of events carrying the extension.
+	Coverage    float64 `json:"coverage"`    // Coverage is the fraction of all profiled events carrying the extension.
+	Distinct    int     `json:"distinct"`    // Distinct is the number of distinct values, a lower bound when Capped is set.
+	Capped      bool    `json:"capped"`      // Capped tells that more distinct values were seen than the Profiler tracks.
+	Cardinality float64 `json:"cardinality"` // Cardinality is Distinct relative to Count, near 1 for identifiers and near 0 for enumerations.
+	Type        string  `json:"type"`        // Type is the inferred type of the values, one of the FieldType* constants.
+	MinLength   int     `json:"min_length"`  // MinLength is the length of the shortest value in bytes.
+	MaxLength   int     `json:"max_length"`  // MaxLength is the length of the longest value in bytes.
+	MeanLength  float64 `json:"mean_length"` // MeanLength is the average value length in bytes.
+	P50Length   int     `json:"p50_length"`  // P50Length is the median value length in bytes.
+	P95Length   int     `json:"p95_length"`  // P95Length is the 95th percentile of the value lengths in bytes.
+}
+
+// fieldStats collects the statistics of a single extension key.
+type fieldStats struct {
+	count   uint64
+	values  map[string]struct{}
+	capped  bool
+	types   []bool
+	lengths map[int]uint64
+	total   uint64
+}
+
+// Profiler collects per extension statistics of a sample of events, i.e.
+// how often an extension is present, its cardinality, the distribution of
+// its value lengths and the type of its values. They help to decide the
+// column schema of analytical sinks such as Parquet files, ClickHouse or
+// BigQuery. A Profiler is safe for concurrent use.
+type Profiler struct {
+	mu          sync.Mutex
+	maxDistinct int
+	events      uint64
+	fields      map[string]*fieldStats
+}
+
+// NewProfiler returns an empty Profiler.
+//
+// Parameters:
+// - maxDistinct: The number of distinct values tracked per extension, DefaultProfileDistinct when zero or less.
+//
+// Returns:
+// - A pointer to a Profiler.
+func NewProfiler(maxDistinct int) *Profiler {
+
+	if maxDistinct <= 0 {
+		maxDistinct = DefaultProfileDistinct
+	}
+
+	return &Profiler{maxDistinct: maxDistinct, fields: make(map[string]*fieldStats)}
+}
+
+// Add profiles the extensions of the event.
+func (p *Profiler) Add(event CefEvent) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events++
+
+	for k, v := range event.Extensions {
+		stats, ok := p.fields[k]
+		if !ok {
+			stats = &fieldStats{values: make(map[string]struct{}), lengths: make(map[int]uint64), types: make([]bool, len(fieldTypes))}
+			for i := range stats.types {
+				stats.types[i] = true
+			}
+			p.fields[k] = stats
+		}
+
+		stats.count++
+		stats.lengths[len(v)]++
+		stats.total += uint64(len(v))
+
+		if _, seen := stats.values[v]; !seen {
+			if len(stats.values) < p.maxDistinct {
+				stats.values[v] = struct{}{}
+			} else {
+				stats.capped = true
+			}
+		}
+
+		for i, t := range fieldTypes {
+			if stats.types[i] && !t.check(v) {
+				stats.types[i] = false
+			}
+		}
+	}
+}
+
+// Middleware returns a Middleware profiling every event passing through it.
+func (p *Profiler) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			p.Add(event)
+			return next(event)
+		}
+	}
+}
+
+// Events returns the number of profiled events.
+func (p *Profiler) Events() uint64 {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.events
+}
+
+// Profiles returns the statistics of every extension seen, sorted by key.
+func (p *Profiler) Profiles() []FieldProfile {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	profiles := make([]FieldProfile, 0, len(p.fields))
+	for k, stats := range p.fields {
+
+		profile := FieldProfile{
+			Key:         k,
+			Count:       stats.count,
+			Coverage:    float64(stats.count) / float64(p.events),
+			Distinct:    len(stats.values),
+			Capped:      stats.capped,
+			Type:        FieldTypeString,
+			MeanLength:  float64(stats.total) / float64(stats.count),
+			Cardinality: float64(len(stats.values)) / float64(stats.count),
+		}
+
+		for i, t := range fieldTypes {
+			if stats.types[i] {
+				profile.Type = t.name
+				break
+			}
+		}
+		// epoch milliseconds of the time-typed extensions are timestamps, not counters.
+		if profile.Type == FieldTypeInt && isTimestampExtension(k) {
+			profile.Type = FieldTypeTimestamp
+		}
+
+		lengths := make([]int, 0, len(stats.lengths))
+		for l := range stats.lengths {
+			lengths = append(lengths, l)
+		}
+		sort.Ints(lengths)
+
+		profile.MinLength = lengths[0]
+		profile.MaxLength = lengths[len(lengths)-1]
+		profile.P50Length = lengthPercentile(lengths, stats.lengths, stats.count, 0.5)
+		profile.P95Length = lengthPercentile(lengths, stats.lengths, stats.count, 0.95)
+
+		profiles = append(profiles, profile)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Key < profiles[j].Key })
+
+	return profiles
+}
+
+// lengthPercentile returns the smallest length of the sorted lengths which
+// at least the fraction q of all count values does not exceed.
+func lengthPercentile(lengths []int, histogram map[int]uint64, count uint64, q float64) int {
+
+	rank := uint64(q*float64(count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+
+	seen := uint64(0)
+	for _, l := range lengths {
+		seen += histogram[l]
+		if seen >= rank {
+			return l
+		}
+	}
+
+	return lengths[len(lengths)-1]
+}
+
+// WriteReport writes the profiles as a table, one extension per row.
+func (p *Profiler) WriteReport(w io.Writer) error {
+
+	profiles := p.Profiles()
+
+	if _, err := fmt.Fprintf(w, "%d events, %d extensions\n", p.Events(), len(profiles)); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tCOVERAGE\tDISTINCT\tMIN\tP50\tP95\tMAX")
+
+	for _, profile := range profiles {
+		distinct := strconv.Itoa(profile.Distinct)
+		if profile.Capped {
+			distinct = ">" + distinct
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\t%s\t%d\t%d\t%d\t%d\n",
+			profile.Key, profile.Type, profile.Coverage*100, distinct,
+			profile.MinLength, profile.P50Length, profile.P95Length, profile.MaxLength)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,67 @@
+package cefevent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProfiler(t *testing.T) {
+
+	profiler := NewProfiler(2)
+
+	for _, extensions := range []map[string]string{
+		{"src": "10.0.0.1", "spt": "80", "msg": "a", "rt": "1700000000000"},
+		{"src": "10.0.0.2", "spt": "443", "msg": "hello world"},
+		{"src": "::1", "spt": "8.5", "msg": "hello world", "act": "true"},
+		{"src": "10.0.0.1", "spt": "22", "msg": "abcd", "smac": "00:0d:83:b1:c0:8e"},
+	} {
+		e := event
+		e.Extensions = extensions
+		profiler.Add(e)
+	}
+
+	profiles := make(map[string]FieldProfile)
+	for _, p := range profiler.Profiles() {
+		profiles[p.Key] = p
+	}
+
+	var tests = []struct {
+		key      string
+		typ      string
+		coverage float64
+		distinct int
+		capped   bool
+	}{
+		{"act", FieldTypeBool, 0.25, 1, false},
+		{"msg", FieldTypeString, 1, 2, true},
+		{"rt", FieldTypeTimestamp, 0.25, 1, false},
+		{"smac", FieldTypeMAC, 0.25, 1, false},
+		{"spt", FieldTypeFloat, 1, 2, true},
+		{"src", FieldTypeIP, 1, 2, true},
+	}
+
+	if len(profiles) != len(tests) {
+		t.Errorf("Profiles() = %v, want %d extensions", profiles, len(tests))
+	}
+
+	for _, tt := range tests {
+		got := profiles[tt.key]
+		if got.Type != tt.typ || got.Coverage != tt.coverage || got.Distinct != tt.distinct || got.Capped != tt.capped {
+			t.Errorf("profile of %s = %+v, want type %s, coverage %v, %d distinct, capped %v", tt.key, got, tt.typ, tt.coverage, tt.distinct, tt.capped)
+		}
+	}
+
+	msg := profiles["msg"]
+	if msg.MinLength != 1 || msg.MaxLength != 11 || msg.P50Length != 4 || msg.P95Length != 11 || msg.MeanLength != 6.75 {
+		t.Errorf("lengths of msg = %+v, want 1, 4, 11, 11 and a mean of 6.75", msg)
+	}
+
+	var report bytes.Buffer
+	if err := profiler.WriteReport(&report); err != nil {
+		t.Fatalf("WriteReport() = %v", err)
+	}
+	if !strings.HasPrefix(report.String(), "4 events, 6 extensions\n") || !strings.Contains(report.String(), ">2") {
+		t.Errorf("WriteReport() = %q", report.String())
+	}
+}
@@ -0,0 +1,177 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// quarantineRecord is a single rejected line as written to a Quarantine.
+type quarantineRecord struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	Raw    string    `json:"raw"`
+	RawB64 []byte    `json:"raw_b64,omitempty"`
+}
+
+// Quarantine preserves rejected raw input lines together with the reason of
+// their rejection, so data quality issues can be analysed offline.
+//
+// Each record is written as a JSON line with the fields "time", "reason" and
+// "raw". JSON strings can not hold invalid UTF-8, lines which are not valid
+// UTF-8 therefore carry their exact bytes base64 encoded in "raw_b64" as
+// well. A Quarantine is size capped: once the configured number of bytes
+// has been written, further records are dropped and only counted.
+// A Quarantine is safe for concurrent use.
+type Quarantine struct {
+	mu       sync.Mutex
+	w        io.Writer
+	maxBytes int64
+	written  int64
+	records  uint64
+	dropped  uint64
+}
+
+// NewQuarantine opens the file at path for appending, creating it when
+// needed, and returns a Quarantine writing to it. The size of an existing
+// file counts against the size cap.
+//
+// Parameters:
+// - path: The path of the quarantine file.
+// - maxBytes: The maximum size of the quarantine file, zero or less means unlimited.
+//
+// Returns:
+// - A pointer to a Quarantine which closes the file when it is closed.
+// - An error if the file could not be opened.
+func NewQuarantine(path string, maxBytes int64) (*Quarantine, error) {
+	return NewQuarantineWithOptions(path, maxBytes, FileOptions{})
+}
+
+// NewQuarantineWithOptions opens the file at path like NewQuarantine, the
+// options set its permission, which defaults to 0600, and ownership.
+//
+// Parameters:
+// - path: The path of the quarantine file.
+// - maxBytes: The maximum size of the quarantine file, zero or less means unlimited.
+// - opts: The FileOptions of the file, Atomic is not supported.
+//
+// Returns:
+// - A pointer to a Quarantine which closes the file when it is closed.
+// - An error if the file could not be opened or the options could not be applied.
+func NewQuarantineWithOptions(path string, maxBytes int64, opts FileOptions) (*Quarantine, error) {
+
+	if opts.Atomic {
+		return nil, errors.New("quarantine files can not be written atomically")
+	}
+
+	file, err := openAppend(path, opts, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	q := NewQuarantineWriter(file, maxBytes)
+	q.written = info.Size()
+
+	return q, nil
+}
+
+// NewQuarantineWriter returns a Quarantine writing its records to w.
+//
+// Parameters:
+// - w: The io.Writer the records are written to.
+// - maxBytes: The maximum number of bytes written to w, zero or less means unlimited.
+//
+// Returns:
+// - A pointer to a Quarantine.
+func NewQuarantineWriter(w io.Writer, maxBytes int64) *Quarantine {
+	return &Quarantine{w: w, maxBytes: maxBytes}
+}
+
+// Record writes the rejected line and its reason to the quarantine. Its
+// signature matches the OnError callbacks of Decoder and Pipeline, so it
+// can be registered directly:
+//
+//	decoder.OnError(quarantine.Record)
+//
+// Records which would exceed the size cap, and records which could not be
+// written, are dropped and counted.
+func (q *Quarantine) Record(line string, reason error) {
+
+	record := quarantineRecord{Time: time.Now().UTC(), Raw: line}
+	if !utf8.ValidString(line) {
+		record.RawB64 = []byte(line)
+	}
+	if reason != nil {
+		record.Reason = reason.Error()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		q.mu.Lock()
+		q.dropped++
+		q.mu.Unlock()
+		return
+	}
+	data = append(data, '\n')
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.w == nil || (q.maxBytes > 0 && q.written+int64(len(data)) > q.maxBytes) {
+		q.dropped++
+		return
+	}
+
+	n, err := q.w.Write(data)
+	q.written += int64(n)
+	if err != nil {
+		q.dropped++
+		return
+	}
+
+	q.records++
+}
+
+// Records returns the number of lines written to the quarantine.
+func (q *Quarantine) Records() uint64 {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.records
+}
+
+// Dropped returns the number of lines which were not written because the
+// size cap was reached or the write failed.
+func (q *Quarantine) Dropped() uint64 {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.dropped
+}
+
+// Close closes the underlying writer if it implements io.Closer,
+// later records are dropped.
+func (q *Quarantine) Close() error {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	closer, ok := q.w.(io.Closer)
+	q.w = nil
+	if ok {
+		return closer.Close()
+	}
+
+	return nil
+}
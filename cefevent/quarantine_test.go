@@ -0,0 +1,106 @@
+package cefevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuarantineRecord(t *testing.T) {
+
+	var buf bytes.Buffer
+	quarantine := NewQuarantineWriter(&buf, 0)
+
+	quarantine.Record("garbage", errors.New("not a valid CEF message"))
+
+	var record quarantineRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("quarantine wrote invalid JSON %q: %v", buf.String(), err)
+	}
+
+	if record.Raw != "garbage" || record.Reason != "not a valid CEF message" || record.Time.IsZero() {
+		t.Errorf("quarantine wrote %+v", record)
+	}
+
+	if quarantine.Records() != 1 || quarantine.Dropped() != 0 {
+		t.Errorf("Records() = %d, Dropped() = %d, want 1, 0", quarantine.Records(), quarantine.Dropped())
+	}
+}
+
+func TestQuarantineRecordInvalidUTF8(t *testing.T) {
+
+	var buf bytes.Buffer
+	quarantine := NewQuarantineWriter(&buf, 0)
+
+	line := "CEF:0|Vendor|Product|1.0|100|Name|5|msg=caf\xe9 \xff"
+	quarantine.Record(line, ErrMalformedExtension)
+
+	var record quarantineRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("quarantine wrote invalid JSON %q: %v", buf.String(), err)
+	}
+
+	if string(record.RawB64) != line {
+		t.Errorf("quarantine preserved %q, want %q", record.RawB64, line)
+	}
+
+	buf.Reset()
+	quarantine.Record("garbage", ErrMissingPrefix)
+	if strings.Contains(buf.String(), "raw_b64") {
+		t.Errorf("quarantine wrote %q, want raw_b64 only for invalid UTF-8", buf.String())
+	}
+}
+
+func TestQuarantineSizeCap(t *testing.T) {
+
+	var buf bytes.Buffer
+	quarantine := NewQuarantineWriter(&buf, 200)
+
+	for i := 0; i < 10; i++ {
+		quarantine.Record(strings.Repeat("x", 50), errors.New("too short"))
+	}
+
+	if buf.Len() > 200 {
+		t.Errorf("quarantine wrote %d bytes, want at most 200", buf.Len())
+	}
+
+	if quarantine.Records()+quarantine.Dropped() != 10 || quarantine.Dropped() == 0 {
+		t.Errorf("Records() = %d, Dropped() = %d", quarantine.Records(), quarantine.Dropped())
+	}
+}
+
+func TestPipelineConfigQuarantine(t *testing.T) {
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.log")
+	quarantinePath := filepath.Join(dir, "quarantine.jsonl")
+	os.WriteFile(input, []byte(eventLine+"\nbroken line\n"), 0o644)
+
+	var out bytes.Buffer
+	RegisterSink("test-quarantine-buffer", func(c SinkConfig, format Format) (Sink, error) {
+		return NewWriterSink(&out, format), nil
+	})
+
+	config := PipelineConfig{
+		Sources:    []SourceConfig{{Type: "file", Path: input}},
+		Sinks:      []SinkConfig{{Type: "test-quarantine-buffer"}},
+		Quarantine: &QuarantineConfig{Path: quarantinePath},
+	}
+
+	pipeline, err := config.Build()
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	pipeline.Run(context.Background())
+	pipeline.Close()
+
+	data, _ := os.ReadFile(quarantinePath)
+	if !strings.Contains(string(data), `"raw":"broken line"`) {
+		t.Errorf("quarantine file contains %q, want the broken line", data)
+	}
+}
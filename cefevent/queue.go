@@ -0,0 +1,324 @@
+package cefevent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// QueueMessage is a single message received from a cloud message queue
+// such as Amazon SQS or a Google Pub/Sub subscription.
+type QueueMessage struct {
+	ID     string // ID is the message ID assigned by the queue.
+	Body   []byte // Body is the payload, one or more newline separated CEF messages.
+	Handle string // Handle is the receipt handle (SQS) or ack ID (Pub/Sub) used to acknowledge the message.
+}
+
+// QueueClient is the minimal pull interface of a cloud message queue.
+//
+// It is deliberately small so that the SQS and Pub/Sub SDK clients can be
+// adapted to it in a few lines, without this package depending on them:
+//   - Receive maps onto sqs.ReceiveMessage and the Pub/Sub pull API.
+//   - Ack maps onto sqs.DeleteMessageBatch and subscription acknowledgements.
+//   - Extend maps onto sqs.ChangeMessageVisibilityBatch and ModifyAckDeadline.
+type QueueClient interface {
+	Receive(ctx context.Context, max int) ([]QueueMessage, error)              // Receive pulls up to max messages, it may return fewer or none.
+	Ack(ctx context.Context, handles []string) error                           // Ack removes the processed messages from the queue.
+	Extend(ctx context.Context, handles []string, timeout time.Duration) error // Extend postpones the redelivery of in-flight messages.
+}
+
+// QueueSourceOptions configures the batching and acknowledgement behaviour of a QueueSource.
+type QueueSourceOptions struct {
+	BatchSize         int           // BatchSize is the maximum number of messages pulled at once, defaults to 10 (the SQS maximum).
+	VisibilityTimeout time.Duration // VisibilityTimeout is extended while messages are processed, defaults to 30 seconds.
+	PollInterval      time.Duration // PollInterval is the wait between empty receives, defaults to one second.
+}
+
+// QueueSource is a Source consuming CEF messages from a cloud message queue.
+//
+// Messages are acknowledged once all events they contain have been handed
+// out and the next event is requested, i.e. after the Pipeline emitted them,
+// which gives at-least-once delivery. While messages are in flight their
+// visibility timeout is extended in the background so they are not
+// redelivered to other consumers.
+type QueueSource struct {
+	client QueueClient
+	origin string
+	opts   QueueSourceOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	pending  []Envelope
+	inFlight []string
+	stopKeep chan struct{}
+}
+
+// NewQueueSource returns a Source consuming messages through client.
+//
+// Parameters:
+// - client: The adapter around the SQS or Pub/Sub client.
+// - origin: The description of the queue used as Origin of the envelopes, e.g. the queue URL.
+// - opts: The batching and visibility options, zero values select the defaults.
+//
+// Returns:
+// - A pointer to a QueueSource.
+func NewQueueSource(client QueueClient, origin string, opts QueueSourceOptions) *QueueSource {
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &QueueSource{client: client, origin: origin, opts: opts, ctx: ctx, cancel: cancel}
+}
+
+// Receive returns the next event, pulling a new batch of messages from the
+// queue when the previous batch has been handed out completely.
+func (s *QueueSource) Receive() (Envelope, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.pending) == 0 {
+
+		if err := s.ackInFlight(); err != nil {
+			return Envelope{}, err
+		}
+		if s.ctx.Err() != nil {
+			return Envelope{}, ErrSourceClosed
+		}
+
+		messages, err := s.client.Receive(s.ctx, s.opts.BatchSize)
+		if s.ctx.Err() != nil {
+			return Envelope{}, ErrSourceClosed
+		}
+		if err != nil {
+			return Envelope{}, err
+		}
+
+		if len(messages) == 0 {
+			select {
+			case <-s.ctx.Done():
+				return Envelope{}, ErrSourceClosed
+			case <-time.After(s.opts.PollInterval):
+			}
+			continue
+		}
+
+		for _, message := range messages {
+			s.inFlight = append(s.inFlight, message.Handle)
+			for _, line := range splitLines(string(message.Body)) {
+				s.pending = append(s.pending, newEnvelope(line, s.origin))
+			}
+		}
+
+		s.stopKeep = make(chan struct{})
+		go s.keepAlive(append([]string(nil), s.inFlight...), s.stopKeep)
+	}
+
+	env := s.pending[0]
+	s.pending = s.pending[1:]
+
+	return env, nil
+}
+
+// ackInFlight acknowledges the messages of the batch which was handed out
+// completely. The acknowledgement is not cancelled by Close, so the last
+// batch is not redelivered, but gives up after the visibility timeout, after
+// which the messages are redelivered anyway.
+func (s *QueueSource) ackInFlight() error {
+
+	if len(s.inFlight) == 0 {
+		return nil
+	}
+
+	close(s.stopKeep)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.VisibilityTimeout)
+	defer cancel()
+
+	err := s.client.Ack(ctx, s.inFlight)
+	s.inFlight = nil
+
+	return err
+}
+
+// keepAlive extends the visibility of the in-flight messages every half
+// visibility timeout until stop is closed.
+func (s *QueueSource) keepAlive(handles []string, stop chan struct{}) {
+
+	ticker := time.NewTicker(s.opts.VisibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.client.Extend(s.ctx, handles, s.opts.VisibilityTimeout)
+		}
+	}
+}
+
+// Close stops consuming. Messages which were not acknowledged yet become
+// visible again after their visibility timeout and are redelivered.
+func (s *QueueSource) Close() error {
+	s.cancel()
+	return nil
+}
+
+// QueuePublisher is the minimal publish interface of a cloud topic or queue,
+// mapping onto sns.PublishBatch, sqs.SendMessageBatch or Pub/Sub topic publishing.
+type QueuePublisher interface {
+	Publish(ctx context.Context, messages []string) error // Publish sends a batch of messages.
+}
+
+// QueueSinkOptions configures the batching of a QueueSink.
+type QueueSinkOptions struct {
+	Format        Format        // Format is the wire format of the published messages.
+	BatchSize     int           // BatchSize is the number of messages published at once, defaults to 10 (the SNS and SQS maximum).
+	FlushInterval time.Duration // FlushInterval publishes incomplete batches after this period, defaults to one second.
+}
+
+// QueueSink is a Sink publishing batches of messages to a cloud topic or queue.
+type QueueSink struct {
+	publisher QueuePublisher
+	opts      QueueSinkOptions
+
+	mu     sync.Mutex
+	batch  []string
+	err    error
+	done   chan struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewQueueSink returns a Sink publishing messages through publisher.
+//
+// Parameters:
+// - publisher: The adapter around the SNS, SQS or Pub/Sub client.
+// - opts: The format and batching options, zero values select the defaults.
+//
+// Returns:
+// - A pointer to a QueueSink, which must be closed to publish the last batch.
+func NewQueueSink(publisher QueuePublisher, opts QueueSinkOptions) *QueueSink {
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	s := &QueueSink{publisher: publisher, opts: opts, done: make(chan struct{})}
+
+	s.wg.Add(1)
+	go s.flushPeriodically()
+
+	return s
+}
+
+// Format returns the wire format of the QueueSink.
+func (s *QueueSink) Format() Format {
+	return s.opts.Format
+}
+
+// Send adds the message to the current batch and publishes the batch once it is full.
+// Errors of batches published in the background are returned by the next Send.
+func (s *QueueSink) Send(message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("sink is closed")
+	}
+
+	if err := s.err; err != nil {
+		s.err = nil
+		return err
+	}
+
+	s.batch = append(s.batch, message)
+	if len(s.batch) >= s.opts.BatchSize {
+		return s.flush()
+	}
+
+	return nil
+}
+
+// Flush publishes the current batch, even when it is not full.
+func (s *QueueSink) Flush() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// flush publishes the current batch, the caller must hold the lock.
+func (s *QueueSink) flush() error {
+
+	if len(s.batch) == 0 {
+		return nil
+	}
+
+	batch := s.batch
+	s.batch = nil
+
+	return s.publisher.Publish(context.Background(), batch)
+}
+
+// flushPeriodically publishes incomplete batches every flush interval.
+func (s *QueueSink) flushPeriodically() {
+
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if err := s.flush(); err != nil {
+				s.err = err
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close publishes the remaining batch and stops the background flushing.
+func (s *QueueSink) Close() error {
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return errors.Join(s.err, s.flush())
+}
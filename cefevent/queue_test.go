@@ -0,0 +1,172 @@
+package cefevent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeQueueClient struct {
+	mu       sync.Mutex
+	batches  [][]QueueMessage
+	acked    []string
+	extended int
+}
+
+func (c *fakeQueueClient) Receive(ctx context.Context, max int) ([]QueueMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.batches) == 0 {
+		return nil, nil
+	}
+	batch := c.batches[0]
+	c.batches = c.batches[1:]
+	return batch, nil
+}
+
+func (c *fakeQueueClient) Ack(ctx context.Context, handles []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.acked = append(c.acked, handles...)
+	return nil
+}
+
+func (c *fakeQueueClient) Extend(ctx context.Context, handles []string, timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.extended++
+	return nil
+}
+
+func TestQueueSource(t *testing.T) {
+
+	client := &fakeQueueClient{batches: [][]QueueMessage{
+		{{ID: "1", Body: []byte(eventLine + "\n" + eventLine), Handle: "h1"}, {ID: "2", Body: []byte(eventLine), Handle: "h2"}},
+		{{ID: "3", Body: []byte(eventLine), Handle: "h3"}},
+	}}
+	source := NewQueueSource(client, "queue", QueueSourceOptions{VisibilityTimeout: 20 * time.Millisecond, PollInterval: time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		env, err := source.Receive()
+		if err != nil || !reflect.DeepEqual(env.Event, event) {
+			t.Fatalf("Receive() = %+v, %v", env, err)
+		}
+	}
+
+	// give the keep alive a chance to extend the visibility of the first batch.
+	time.Sleep(30 * time.Millisecond)
+
+	client.mu.Lock()
+	if len(client.acked) != 0 {
+		t.Errorf("messages acknowledged before they were processed: %v", client.acked)
+	}
+	if client.extended == 0 {
+		t.Errorf("visibility of in-flight messages was not extended")
+	}
+	client.mu.Unlock()
+
+	if _, err := source.Receive(); err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+
+	client.mu.Lock()
+	if want := []string{"h1", "h2"}; !reflect.DeepEqual(client.acked, want) {
+		t.Errorf("acked = %v, want %v", client.acked, want)
+	}
+	client.mu.Unlock()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		source.Close()
+	}()
+
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() = %v, want ErrSourceClosed", err)
+	}
+}
+
+func TestQueueSourceCloseAcknowledges(t *testing.T) {
+
+	client := &fakeQueueClient{batches: [][]QueueMessage{
+		{{ID: "1", Body: []byte(eventLine), Handle: "h1"}},
+	}}
+	source := NewQueueSource(client, "queue", QueueSourceOptions{PollInterval: time.Millisecond})
+
+	if _, err := source.Receive(); err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+
+	source.Close()
+
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() after Close() = %v, want ErrSourceClosed", err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if want := []string{"h1"}; !reflect.DeepEqual(client.acked, want) {
+		t.Errorf("acked = %v, want %v after Close()", client.acked, want)
+	}
+}
+
+type fakePublisher struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, messages []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, messages)
+	return nil
+}
+
+func TestQueueSink(t *testing.T) {
+
+	publisher := &fakePublisher{}
+	sink := NewQueueSink(publisher, QueueSinkOptions{Format: FormatECS, BatchSize: 2, FlushInterval: time.Hour})
+
+	if sink.Format() != FormatECS {
+		t.Errorf("Format() = %v, want %v", sink.Format(), FormatECS)
+	}
+
+	for _, message := range []string{"a", "b", "c"} {
+		if err := sink.Send(message); err != nil {
+			t.Fatalf("Send() = %v", err)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	if want := [][]string{{"a", "b"}, {"c"}}; !reflect.DeepEqual(publisher.batches, want) {
+		t.Errorf("published %v, want %v", publisher.batches, want)
+	}
+
+	if err := sink.Send("d"); err == nil {
+		t.Errorf("Send() after Close() should fail")
+	}
+}
+
+func TestQueueSinkFlushInterval(t *testing.T) {
+
+	publisher := &fakePublisher{}
+	sink := NewQueueSink(publisher, QueueSinkOptions{BatchSize: 100, FlushInterval: 5 * time.Millisecond})
+	defer sink.Close()
+
+	sink.Send("a")
+	time.Sleep(30 * time.Millisecond)
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if len(publisher.batches) != 1 {
+		t.Errorf("incomplete batch was not flushed: %v", publisher.batches)
+	}
+}
@@ -0,0 +1,150 @@
+package cefevent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Quirk corrects a known deviation of a device from the CEF specification
+// in a message before it is parsed, see ParseOptions.Quirks. Messages
+// which do not show the deviation must be returned unchanged.
+type Quirk func(message string) string
+
+// splitPipes splits the message at its unescaped pipes.
+func splitPipes(message string) []string {
+
+	var parts []string
+	start := 0
+
+	for i := 0; i < len(message); i++ {
+		switch message[i] {
+		case '\\':
+			i++
+		case '|':
+			parts = append(parts, message[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, message[start:])
+}
+
+// knownSeverity reports whether s is a severity defined by the specification.
+func knownSeverity(s string) bool {
+
+	switch s {
+	case "Unknown", "Low", "Medium", "High", "Very-High", "10":
+		return true
+	}
+
+	return len(s) == 1 && s[0] >= '0' && s[0] <= '9'
+}
+
+// QuirkPipesInName corrects messages of devices which do not escape pipes
+// within the event name, e.g. "CEF:0|V|P|1|100|Login | logout|5|src=...".
+// When the field following the name is no severity, the name is extended
+// up to the first field which is one and its pipes are escaped. Pipes in
+// the other header fields can not be told apart and are left as they are.
+func QuirkPipesInName(message string) string {
+
+	parts := splitPipes(message)
+	if len(parts) <= cefHeaderFields+1 || knownSeverity(parts[6]) {
+		return message
+	}
+
+	for k := 7; k < len(parts)-1; k++ {
+		if knownSeverity(parts[k]) {
+			name := strings.Join(parts[5:k], `\|`)
+			return strings.Join(parts[:5], "|") + "|" + name + "|" + strings.Join(parts[k:], "|")
+		}
+	}
+
+	return message
+}
+
+// QuirkEqualsInValues corrects messages of devices which do not escape
+// equal signs within values, e.g. "msg=user=bob logged in": only keys of
+// the CEF dictionary, see IsDictionaryKey, start an extension, the equal
+// signs following any other word are escaped as part of the value. Devices
+// sending keys outside of the dictionary must not be parsed with it.
+func QuirkEqualsInValues(message string) string {
+
+	parts := splitPipes(message)
+	if len(parts) <= cefHeaderFields {
+		return message
+	}
+	// the extensions follow the seventh unescaped pipe.
+	offset := 0
+	for _, part := range parts[:cefHeaderFields] {
+		offset += len(part) + 1
+	}
+	extensions := message[offset:]
+
+	var b strings.Builder
+	first := true
+	wordStart := 0
+
+	for i := 0; i < len(extensions); i++ {
+		switch c := extensions[i]; c {
+		case '\\':
+			if i+1 < len(extensions) {
+				b.WriteByte(c)
+				i++
+				c = extensions[i]
+			}
+			b.WriteByte(c)
+			continue
+		case ' ':
+			wordStart = i + 1
+		case '=':
+			key := extensions[wordStart:i]
+			if !first && !IsDictionaryKey(key) {
+				b.WriteString(`\=`)
+				continue
+			}
+			first = false
+		}
+		b.WriteByte(extensions[i])
+	}
+
+	return message[:offset] + b.String()
+}
+
+var (
+	quirksMu sync.RWMutex
+	quirks   = map[string]Quirk{
+		"pipes-in-name":    QuirkPipesInName,
+		"equals-in-values": QuirkEqualsInValues,
+	}
+)
+
+// RegisterQuirk makes a Quirk available by name, e.g. to the parsing
+// profiles of pipeline configurations, an existing registration with the
+// same name is replaced. "pipes-in-name" and "equals-in-values" are
+// registered by default.
+func RegisterQuirk(name string, quirk Quirk) {
+
+	quirksMu.Lock()
+	defer quirksMu.Unlock()
+
+	quirks[name] = quirk
+}
+
+// LookupQuirk returns the Quirk registered under name.
+//
+// Returns:
+// - The Quirk.
+// - An error if no Quirk with that name is registered.
+func LookupQuirk(name string) (Quirk, error) {
+
+	quirksMu.RLock()
+	defer quirksMu.RUnlock()
+
+	quirk, ok := quirks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown quirk %q, known quirks are %v", name, registeredTypes(quirks))
+	}
+
+	return quirk, nil
+}
@@ -0,0 +1,73 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuirkPipesInName(t *testing.T) {
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"CEF:0|V|P|1|100|Login | logout|5|src=10.0.0.1", `CEF:0|V|P|1|100|Login \| logout|5|src=10.0.0.1`},
+		{"CEF:0|V|P|1|100|a|b|c|High|", `CEF:0|V|P|1|100|a\|b\|c|High|`},
+		{"CEF:0|V|P|1|100|Login|5|msg=a|b", "CEF:0|V|P|1|100|Login|5|msg=a|b"},
+		{"CEF:0|V|P|1|100|Login|bad|msg=a|b", "CEF:0|V|P|1|100|Login|bad|msg=a|b"},
+		{eventLine, eventLine},
+	}
+
+	for _, tt := range tests {
+		if got := QuirkPipesInName(tt.message); got != tt.want {
+			t.Errorf("QuirkPipesInName(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestQuirkEqualsInValues(t *testing.T) {
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"CEF:0|V|P|1|100|N|5|msg=user=bob logged in src=10.0.0.1", `CEF:0|V|P|1|100|N|5|msg=user\=bob logged in src=10.0.0.1`},
+		{"CEF:0|V|P|1|100|N|5|request=/?a=b c=d suser=bob", `CEF:0|V|P|1|100|N|5|request=/?a\=b c\=d suser=bob`},
+		{`CEF:0|V|P|1|100|N=x|5|msg=a\=b`, `CEF:0|V|P|1|100|N=x|5|msg=a\=b`},
+		{eventLine, eventLine},
+	}
+
+	for _, tt := range tests {
+		if got := QuirkEqualsInValues(tt.message); got != tt.want {
+			t.Errorf("QuirkEqualsInValues(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuirks(t *testing.T) {
+
+	quirk, err := LookupQuirk("pipes-in-name")
+	if err != nil {
+		t.Fatalf("LookupQuirk() = %v", err)
+	}
+	equals, _ := LookupQuirk("equals-in-values")
+
+	line := "CEF:0|V|P|1|100|Login | logout|5|msg=user=bob logged in src=10.0.0.1"
+	got, err := ParseWithOptions(line, ParseOptions{Quirks: []Quirk{quirk, equals}, KeepRaw: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() = %v", err)
+	}
+	if got.Name != "Login | logout" || got.Severity != "5" || got.Raw != line {
+		t.Errorf("ParseWithOptions() = %q, %q, raw %q", got.Name, got.Severity, got.Raw)
+	}
+	if want := map[string]string{"msg": "user=bob logged in", "src": "10.0.0.1"}; !reflect.DeepEqual(got.Extensions, want) {
+		t.Errorf("Extensions = %v, want %v", got.Extensions, want)
+	}
+
+	if _, err := LookupQuirk("sloppy"); err == nil {
+		t.Error("LookupQuirk(\"sloppy\") = nil, want an error")
+	}
+	if _, err := (ParsingProfileConfig{Name: "x", Sources: []string{"10.0.0.0/8"}, Quirks: []string{"sloppy"}}).profile(); err == nil {
+		t.Error("profile() = nil, want an error for an unknown quirk")
+	}
+}
@@ -0,0 +1,229 @@
+package cefevent
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportFormat is the markup of an incident report.
+type ReportFormat int
+
+const (
+	ReportMarkdown ReportFormat = iota // ReportMarkdown renders the report as GitHub flavoured Markdown.
+	ReportHTML                         // ReportHTML renders the report as standalone HTML document.
+)
+
+// ReportOptions configures WriteIncidentReport.
+type ReportOptions struct {
+	Title  string       // Title is the heading of the report, defaults to "Incident report".
+	Format ReportFormat // Format is the markup of the report.
+}
+
+// reportGroup holds the events of a single device event class.
+type reportGroup struct {
+	classID  string
+	events   []CefEvent
+	severity int
+	keys     []string
+}
+
+// groupReport groups the events by their class ID. The groups are sorted
+// by their highest severity and class ID, the events within each group by
+// their severity, keeping the order of events of equal severity.
+func groupReport(events []CefEvent) []*reportGroup {
+
+	byClass := make(map[string]*reportGroup)
+	var groups []*reportGroup
+
+	for _, event := range events {
+		group, ok := byClass[event.DeviceEventClassId]
+		if !ok {
+			group = &reportGroup{classID: event.DeviceEventClassId, severity: -1}
+			byClass[event.DeviceEventClassId] = group
+			groups = append(groups, group)
+		}
+		group.events = append(group.events, event)
+		if level := severityLevel(event.Severity); level > group.severity {
+			group.severity = level
+		}
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.events, func(i, j int) bool {
+			return severityLevel(group.events[i].Severity) > severityLevel(group.events[j].Severity)
+		})
+
+		seen := make(map[string]bool)
+		for _, event := range group.events {
+			for k := range event.Extensions {
+				if !seen[k] {
+					seen[k] = true
+					group.keys = append(group.keys, k)
+				}
+			}
+		}
+		sort.Strings(group.keys)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].severity != groups[j].severity {
+			return groups[i].severity > groups[j].severity
+		}
+		return groups[i].classID < groups[j].classID
+	})
+
+	return groups
+}
+
+// reportTimeRange returns the time span of the events, see EventTime.
+func reportTimeRange(events []CefEvent) (time.Time, time.Time, bool) {
+
+	var first, last time.Time
+	found := false
+
+	for _, event := range events {
+		t, ok := event.EventTime()
+		if !ok {
+			continue
+		}
+		if !found || t.Before(first) {
+			first = t
+		}
+		if !found || t.After(last) {
+			last = t
+		}
+		found = true
+	}
+
+	return first, last, found
+}
+
+// reportSummary is the introductory sentence of a report.
+func reportSummary(events []CefEvent, groups []*reportGroup) string {
+
+	summary := fmt.Sprintf("%d events in %d event classes", len(events), len(groups))
+	if first, last, ok := reportTimeRange(events); ok {
+		summary += fmt.Sprintf(" between %s and %s", first.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+
+	return summary + "."
+}
+
+// reportColumns are the header fields every report table starts with.
+var reportColumns = []string{"Severity", "Name", "Device"}
+
+// reportRow returns the cells of an event in the columns of its group.
+func reportRow(event CefEvent, keys []string) []string {
+
+	row := []string{
+		event.Severity,
+		event.Name,
+		strings.TrimSpace(event.DeviceVendor + " " + event.DeviceProduct + " " + event.DeviceVersion),
+	}
+	for _, k := range keys {
+		row = append(row, event.Extensions[k])
+	}
+
+	return row
+}
+
+// WriteIncidentReport renders the events as incident report for the
+// documentation of an investigation. The events are grouped by their
+// device event class ID, the groups and the events within them are sorted
+// by severity, highest first, and every group is a table of the header
+// fields and all extensions occurring in it.
+//
+// Parameters:
+// - w: The io.Writer the report is written to.
+// - events: The events selected for the report.
+// - opts: The title and markup of the report.
+//
+// Returns:
+// - An error if writing the report failed.
+func WriteIncidentReport(w io.Writer, events []CefEvent, opts ReportOptions) error {
+
+	if opts.Title == "" {
+		opts.Title = "Incident report"
+	}
+
+	groups := groupReport(events)
+	out := bufio.NewWriter(w)
+
+	switch opts.Format {
+	case ReportHTML:
+		writeHTMLReport(out, events, groups, opts.Title)
+	default:
+		writeMarkdownReport(out, events, groups, opts.Title)
+	}
+
+	return out.Flush()
+}
+
+// markdownCell escapes a value for a Markdown table cell, markup within
+// the values, which may stem from an attacker, is not rendered.
+var markdownCell = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>", "&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// writeMarkdownReport writes the report as Markdown.
+func writeMarkdownReport(w io.Writer, events []CefEvent, groups []*reportGroup, title string) {
+
+	fmt.Fprintf(w, "# %s\n\n%s\n", title, reportSummary(events, groups))
+
+	for _, group := range groups {
+		name := ""
+		if len(group.events) > 0 {
+			name = " " + group.events[0].Name
+		}
+		fmt.Fprintf(w, "\n## %s\n\nEvents: %d, highest severity: %d.\n\n", markdownCell.Replace(group.classID+name), len(group.events), group.severity)
+
+		columns := append(append([]string{}, reportColumns...), group.keys...)
+		for i := range columns {
+			columns[i] = markdownCell.Replace(columns[i])
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(columns, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(columns)))
+
+		for _, event := range group.events {
+			row := reportRow(event, group.keys)
+			for i := range row {
+				row[i] = markdownCell.Replace(row[i])
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		}
+	}
+}
+
+// writeHTMLReport writes the report as standalone HTML document.
+func writeHTMLReport(w io.Writer, events []CefEvent, groups []*reportGroup, title string) {
+
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	fmt.Fprintf(w, "<h1>%s</h1>\n<p>%s</p>\n", html.EscapeString(title), html.EscapeString(reportSummary(events, groups)))
+
+	for _, group := range groups {
+		heading := group.classID
+		if len(group.events) > 0 {
+			heading += " " + group.events[0].Name
+		}
+		fmt.Fprintf(w, "<h2>%s</h2>\n<p>Events: %d, highest severity: %d.</p>\n<table>\n<tr>", html.EscapeString(heading), len(group.events), group.severity)
+
+		for _, column := range append(append([]string{}, reportColumns...), group.keys...) {
+			fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(column))
+		}
+		fmt.Fprint(w, "</tr>\n")
+
+		for _, event := range group.events {
+			fmt.Fprint(w, "<tr>")
+			for _, cell := range reportRow(event, group.keys) {
+				fmt.Fprintf(w, "<td>%s</td>", strings.ReplaceAll(html.EscapeString(cell), "\n", "<br>"))
+			}
+			fmt.Fprint(w, "</tr>\n")
+		}
+		fmt.Fprint(w, "</table>\n")
+	}
+
+	fmt.Fprint(w, "</body>\n</html>\n")
+}
@@ -0,0 +1,86 @@
+package cefevent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var reportEvents = []CefEvent{
+	{DeviceVendor: "Vendor", DeviceProduct: "FW", DeviceVersion: "1", DeviceEventClassId: "DENY", Name: "Blocked", Severity: "3", Extensions: map[string]string{"src": "10.0.0.1", "rt": "1700000000000"}},
+	{DeviceVendor: "Vendor", DeviceProduct: "IDS", DeviceVersion: "2", DeviceEventClassId: "EXPLOIT", Name: "Exploit attempt", Severity: "9", Extensions: map[string]string{"src": "10.0.0.2", "msg": "a|b\nc <x>"}},
+	{DeviceVendor: "Vendor", DeviceProduct: "FW", DeviceVersion: "1", DeviceEventClassId: "DENY", Name: "Blocked", Severity: "High", Extensions: map[string]string{"dst": "10.0.0.3", "rt": "1700000060000"}},
+}
+
+func TestWriteIncidentReportMarkdown(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := WriteIncidentReport(&buf, reportEvents, ReportOptions{Title: "Case 42"}); err != nil {
+		t.Fatalf("WriteIncidentReport() = %v", err)
+	}
+
+	want := `# Case 42
+
+3 events in 2 event classes between 2023-11-14T22:13:20Z and 2023-11-14T22:14:20Z.
+
+## EXPLOIT Exploit attempt
+
+Events: 1, highest severity: 9.
+
+| Severity | Name | Device | msg | src |
+| --- | --- | --- | --- | --- |
+| 9 | Exploit attempt | Vendor IDS 2 | a\|b<br>c &lt;x&gt; | 10.0.0.2 |
+
+## DENY Blocked
+
+Events: 2, highest severity: 8.
+
+| Severity | Name | Device | dst | rt | src |
+| --- | --- | --- | --- | --- | --- |
+| High | Blocked | Vendor FW 1 | 10.0.0.3 | 1700000060000 |  |
+| 3 | Blocked | Vendor FW 1 |  | 1700000000000 | 10.0.0.1 |
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteIncidentReport() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteIncidentReportMarkdownEscaping(t *testing.T) {
+
+	events := []CefEvent{{DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "A|B", Name: "<b>x</b>\ny", Severity: "5", Extensions: map[string]string{"a|b": "1"}}}
+
+	var buf bytes.Buffer
+	if err := WriteIncidentReport(&buf, events, ReportOptions{}); err != nil {
+		t.Fatalf("WriteIncidentReport() = %v", err)
+	}
+
+	for _, want := range []string{"\n## A\\|B &lt;b&gt;x&lt;/b&gt;<br>y\n", "| Severity | Name | Device | a\\|b |\n| --- | --- | --- | --- |\n"} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("WriteIncidentReport() =\n%s\nwant it to contain %q", got, want)
+		}
+	}
+}
+
+func TestWriteIncidentReportHTML(t *testing.T) {
+
+	var buf bytes.Buffer
+	if err := WriteIncidentReport(&buf, reportEvents, ReportOptions{Format: ReportHTML}); err != nil {
+		t.Fatalf("WriteIncidentReport() = %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<title>Incident report</title>",
+		"<h2>EXPLOIT Exploit attempt</h2>",
+		"<th>Severity</th><th>Name</th><th>Device</th><th>msg</th><th>src</th>",
+		"<td>a|b<br>c &lt;x&gt;</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteIncidentReport() = %s, want it to contain %q", got, want)
+		}
+	}
+
+	if strings.Index(got, "EXPLOIT") > strings.Index(got, "DENY") {
+		t.Errorf("WriteIncidentReport() = %s, want the highest severity first", got)
+	}
+}
@@ -0,0 +1,322 @@
+package cefevent
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves an identifier to a friendly name, e.g. a user ID to the
+// display name of a directory or an asset address to its hostname in a CMDB.
+//
+// An unknown identifier is not an error, Resolve returns an empty name for
+// it, which is cached for the negative TTL. Errors, e.g. an unreachable
+// directory, are cached for the short error TTL, so the identifier is
+// looked up again shortly without every event waiting for the timeout.
+type Resolver interface {
+	Resolve(ctx context.Context, id string) (string, error) // Resolve returns the name of id, empty if it is unknown.
+}
+
+// ResolverFunc adapts a function to the Resolver interface, e.g. a CMDB client call.
+type ResolverFunc func(ctx context.Context, id string) (string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ctx context.Context, id string) (string, error) {
+	return f(ctx, id)
+}
+
+// ReverseDNS returns a Resolver resolving IP addresses to hostnames with
+// reverse DNS lookups, addresses without a PTR record are unknown.
+//
+// Parameters:
+// - resolver: The *net.Resolver queried, nil selects net.DefaultResolver.
+//
+// Returns:
+// - The Resolver, e.g. for the companion pair src and shost.
+func ReverseDNS(resolver *net.Resolver) Resolver {
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return ResolverFunc(func(ctx context.Context, id string) (string, error) {
+		names, err := resolver.LookupAddr(ctx, id)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "", nil
+		}
+		if err != nil || len(names) == 0 {
+			return "", err
+		}
+		return strings.TrimSuffix(names[0], "."), nil
+	})
+}
+
+// Resolution tells a NameEnricher which extension holds identifiers, where
+// to put their names and how to resolve them.
+type Resolution struct {
+	Key       string   // Key is the extension holding the identifier, e.g. "suid" or "src".
+	Companion string   // Companion is the extension the name is written to, e.g. "suser" or "shost".
+	Resolver  Resolver // Resolver looks the names up.
+}
+
+// ResolveOptions configures the caching of a NameEnricher.
+type ResolveOptions struct {
+	TTL         time.Duration // TTL is how long a resolved name is cached, defaults to ten minutes.
+	NegativeTTL time.Duration // NegativeTTL is how long an unknown identifier is cached, defaults to one minute.
+	ErrorTTL    time.Duration // ErrorTTL is how long a failed lookup is cached, defaults to five seconds.
+	Capacity    int           // Capacity bounds the number of cached identifiers, defaults to 10000.
+	Timeout     time.Duration // Timeout bounds every lookup, defaults to one second.
+}
+
+// resolveKey identifies a cached identifier of a Resolution.
+type resolveKey struct {
+	resolution int
+	id         string
+}
+
+// resolveEntry is a cached name, an empty name caches an unknown identifier.
+type resolveEntry struct {
+	key     resolveKey
+	name    string
+	err     error
+	expires time.Time
+}
+
+// resolveCall is a lookup in progress, concurrent lookups of the same
+// identifier wait for it instead of querying the Resolver again.
+type resolveCall struct {
+	done chan struct{}
+	name string
+	err  error
+}
+
+// NameEnricher populates companion extensions with the friendly names of
+// the identifiers of the events, so analysts read "suser=Alice Example"
+// next to "suid=S-1-5-21-1004", see Resolution.
+//
+// The names, unknown identifiers and failed lookups are cached for their
+// TTLs, the least recently used identifier is forgotten when the capacity
+// is reached. A companion extension which is already set is kept. A
+// NameEnricher is safe for concurrent use.
+type NameEnricher struct {
+	resolutions []Resolution
+	opts        ResolveOptions
+
+	mu       sync.Mutex
+	order    *list.List
+	entries  map[resolveKey]*list.Element
+	inflight map[resolveKey]*resolveCall
+	onError  func(event CefEvent, err error)
+	now      func() time.Time
+}
+
+// NewNameEnricher returns a NameEnricher for the resolutions.
+//
+// Parameters:
+// - opts: The TTLs, capacity and timeout, zero values select the defaults.
+// - resolutions: The extensions to resolve, e.g. Resolution{"src", "shost", ReverseDNS(nil)}.
+//
+// Returns:
+// - A pointer to a NameEnricher.
+func NewNameEnricher(opts ResolveOptions, resolutions ...Resolution) *NameEnricher {
+
+	if opts.TTL <= 0 {
+		opts.TTL = 10 * time.Minute
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = time.Minute
+	}
+	if opts.ErrorTTL <= 0 {
+		opts.ErrorTTL = 5 * time.Second
+	}
+	if opts.Capacity <= 0 {
+		opts.Capacity = 10000
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+
+	return &NameEnricher{
+		resolutions: resolutions,
+		opts:        opts,
+		order:       list.New(),
+		entries:     make(map[resolveKey]*list.Element),
+		inflight:    make(map[resolveKey]*resolveCall),
+		now:         time.Now,
+	}
+}
+
+// OnError registers a callback which is called with every event passing
+// through the Middleware whose lookups failed, e.g. to count or log the
+// failures, as the event is passed on without those companions.
+//
+// Parameters:
+// - fn: The callback receiving the event and the errors of its lookups.
+func (e *NameEnricher) OnError(fn func(event CefEvent, err error)) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.onError = fn
+}
+
+// cached returns the cached entry of the identifier, nil if it is not
+// cached or expired. It must be called with mu held.
+func (e *NameEnricher) cached(key resolveKey) *resolveEntry {
+
+	element, ok := e.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*resolveEntry)
+	if !e.now().Before(entry.expires) {
+		e.order.Remove(element)
+		delete(e.entries, key)
+		return nil
+	}
+	e.order.MoveToFront(element)
+
+	return entry
+}
+
+// store caches the name of the identifier, an empty name for the negative
+// TTL and an error for the error TTL. It must be called with mu held.
+func (e *NameEnricher) store(key resolveKey, name string, err error) {
+
+	ttl := e.opts.TTL
+	switch {
+	case err != nil:
+		ttl = e.opts.ErrorTTL
+	case name == "":
+		ttl = e.opts.NegativeTTL
+	}
+
+	entry := &resolveEntry{key: key, name: name, err: err, expires: e.now().Add(ttl)}
+	if element, ok := e.entries[key]; ok {
+		element.Value = entry
+		e.order.MoveToFront(element)
+		return
+	}
+	e.entries[key] = e.order.PushFront(entry)
+
+	for e.order.Len() > e.opts.Capacity {
+		oldest := e.order.Back()
+		e.order.Remove(oldest)
+		delete(e.entries, oldest.Value.(*resolveEntry).key)
+	}
+}
+
+// lookup returns the name of the identifier from the cache or the
+// Resolver, concurrent lookups of the same identifier share one query.
+func (e *NameEnricher) lookup(ctx context.Context, key resolveKey, resolver Resolver) (string, error) {
+
+	e.mu.Lock()
+	if entry := e.cached(key); entry != nil {
+		e.mu.Unlock()
+		return entry.name, entry.err
+	}
+	if call, ok := e.inflight[key]; ok {
+		e.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.name, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &resolveCall{done: make(chan struct{})}
+	e.inflight[key] = call
+	e.mu.Unlock()
+
+	lookupCtx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	call.name, call.err = resolver.Resolve(lookupCtx, key.id)
+	cancel()
+
+	e.mu.Lock()
+	delete(e.inflight, key)
+	e.store(key, call.name, call.err)
+	e.mu.Unlock()
+	close(call.done)
+
+	return call.name, call.err
+}
+
+// Enrich resolves the identifiers of the event and sets the companion
+// extensions of those which are known.
+//
+// Parameters:
+// - ctx: The context bounding the lookups, each is further limited by the timeout.
+// - event: The event, its extensions are not modified.
+//
+// Returns:
+// - The enriched event.
+// - The errors of the lookups which failed, the other companions are set nevertheless.
+func (e *NameEnricher) Enrich(ctx context.Context, event CefEvent) (CefEvent, error) {
+
+	var errs []error
+	cloned := false
+
+	for i, resolution := range e.resolutions {
+		id := event.Extensions[resolution.Key]
+		if id == "" {
+			continue
+		}
+		if _, set := event.Extensions[resolution.Companion]; set {
+			continue
+		}
+
+		name, err := e.lookup(ctx, resolveKey{resolution: i, id: id}, resolution.Resolver)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		if !cloned {
+			event.Extensions = cloneExtensions(event.Extensions)
+			cloned = true
+		}
+		event.Extensions[resolution.Companion] = name
+	}
+
+	return event, errors.Join(errs...)
+}
+
+// Len returns the number of cached identifiers, expired ones included
+// until they are looked up again.
+func (e *NameEnricher) Len() int {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.order.Len()
+}
+
+// Middleware returns a Middleware enriching the events passing through it,
+// events whose lookups failed are passed on without those companions and
+// reported to the callback registered with OnError.
+func (e *NameEnricher) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			enriched, err := e.Enrich(context.Background(), event)
+			if err != nil {
+				e.mu.Lock()
+				onError := e.onError
+				e.mu.Unlock()
+				if onError != nil {
+					onError(event, err)
+				}
+			}
+			return next(enriched)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package cefevent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNameEnricher(t *testing.T) {
+
+	lookups := map[string]int{}
+	directory := ResolverFunc(func(ctx context.Context, id string) (string, error) {
+		lookups[id]++
+		switch id {
+		case "1004":
+			return "Alice Example", nil
+		case "down":
+			return "", errors.New("directory unreachable")
+		}
+		return "", nil
+	})
+
+	now := time.Unix(0, 0)
+	enricher := NewNameEnricher(ResolveOptions{TTL: time.Hour, NegativeTTL: time.Minute}, Resolution{Key: "suid", Companion: "suser", Resolver: directory})
+	enricher.now = func() time.Time { return now }
+
+	withUser := func(id string) CefEvent {
+		e := event
+		e.Extensions = map[string]string{"suid": id}
+		return e
+	}
+
+	in := withUser("1004")
+	got, err := enricher.Enrich(context.Background(), in)
+	if err != nil || got.Extensions["suser"] != "Alice Example" {
+		t.Errorf("Enrich() = %v, %v, want suser=Alice Example", got.Extensions, err)
+	}
+	if _, ok := in.Extensions["suser"]; ok {
+		t.Error("Enrich() modified the extensions of the caller")
+	}
+
+	if got, _ := enricher.Enrich(context.Background(), withUser("9999")); got.Extensions["suser"] != "" {
+		t.Errorf("Enrich() = %v, want no suser for an unknown ID", got.Extensions)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := enricher.Enrich(context.Background(), withUser("down")); err == nil {
+			t.Error("Enrich() = nil, want the error of the lookup")
+		}
+	}
+	if lookups["down"] != 1 {
+		t.Errorf("lookups = %v, want the error to be cached", lookups)
+	}
+
+	kept := withUser("1004")
+	kept.Extensions["suser"] = "alice"
+	if got, _ := enricher.Enrich(context.Background(), kept); got.Extensions["suser"] != "alice" {
+		t.Errorf("Enrich() = %v, want the set companion to be kept", got.Extensions)
+	}
+
+	// the name is cached for the TTL, the unknown ID for the negative TTL and errors for the error TTL.
+	now = now.Add(2 * time.Minute)
+	for _, id := range []string{"1004", "9999", "down"} {
+		enricher.Enrich(context.Background(), withUser(id))
+	}
+	if lookups["1004"] != 1 || lookups["9999"] != 2 || lookups["down"] != 2 {
+		t.Errorf("lookups = %v, want 1004 once, 9999 and down twice", lookups)
+	}
+
+	small := NewNameEnricher(ResolveOptions{Capacity: 2}, Resolution{Key: "suid", Companion: "suser", Resolver: directory})
+	for _, id := range []string{"a", "b", "c"} {
+		small.Enrich(context.Background(), withUser(id))
+	}
+	if small.Len() != 2 {
+		t.Errorf("Len() = %d, want the capacity 2", small.Len())
+	}
+}
+
+func TestNameEnricherConcurrentLookups(t *testing.T) {
+
+	var lookups atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	directory := ResolverFunc(func(ctx context.Context, id string) (string, error) {
+		if lookups.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return "Alice Example", nil
+	})
+	enricher := NewNameEnricher(ResolveOptions{}, Resolution{Key: "suid", Companion: "suser", Resolver: directory})
+
+	e := event
+	e.Extensions = map[string]string{"suid": "1004"}
+
+	var wg sync.WaitGroup
+	names := make([]string, 8)
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got, _ := enricher.Enrich(context.Background(), e)
+			names[i] = got.Extensions["suser"]
+		}(i)
+	}
+	<-started
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if lookups.Load() != 1 {
+		t.Errorf("Resolve() was called %d times, want concurrent lookups to be coalesced", lookups.Load())
+	}
+	for _, name := range names {
+		if name != "Alice Example" {
+			t.Errorf("Enrich() = %q, want Alice Example for every caller", name)
+		}
+	}
+}
+
+func TestNameEnricherMiddlewareOnError(t *testing.T) {
+
+	failing := ResolverFunc(func(ctx context.Context, id string) (string, error) {
+		return "", errors.New("directory unreachable")
+	})
+	enricher := NewNameEnricher(ResolveOptions{}, Resolution{Key: "suid", Companion: "suser", Resolver: failing})
+
+	var reported []error
+	enricher.OnError(func(event CefEvent, err error) {
+		reported = append(reported, err)
+	})
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+	emitter.Use(enricher.Middleware())
+
+	e := event
+	e.Extensions = map[string]string{"suid": "1004"}
+	if err := emitter.Emit(e); err != nil || buf.Len() == 0 {
+		t.Errorf("Emit() = %v, want the event to be delivered", err)
+	}
+	if len(reported) != 1 {
+		t.Errorf("OnError() received %v, want the error of the lookup", reported)
+	}
+}
@@ -0,0 +1,167 @@
+package cefevent
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSDID is the SD-ID of the structured data element written by
+// ToRFC5424. Its enterprise number 32473 is reserved for documentation by
+// RFC 5612, deployments owning a private enterprise number should set their
+// own SD-ID.
+const DefaultSDID = "cef@32473"
+
+// rfc5424Timestamp is the layout of the TIMESTAMP, RFC 5424 allows at most
+// six digits of fractional seconds.
+const rfc5424Timestamp = "2006-01-02T15:04:05.999999Z07:00"
+
+// RFC5424Options configures the header of the messages written by ToRFC5424.
+type RFC5424Options struct {
+	SDID      string    // SDID is the SD-ID of the structured data element, DefaultSDID by default.
+	Timestamp time.Time // Timestamp is the TIMESTAMP of the header, the current time by default.
+	Hostname  string    // Hostname is the HOSTNAME of the header, the local hostname by default.
+	AppName   string    // AppName is the APP-NAME of the header, the nil value "-" by default.
+	ProcID    string    // ProcID is the PROCID of the header, the nil value "-" by default.
+	MsgID     string    // MsgID is the MSGID of the header, the nil value "-" by default.
+}
+
+// rfc5424HeaderParams are the SD-PARAM names of the header fields.
+var rfc5424HeaderParams = []string{"version", "deviceVendor", "deviceProduct", "deviceVersion", "deviceEventClassId", "name", "severity"}
+
+// validSDName reports whether s is a valid SD-ID or PARAM-NAME of RFC
+// 5424, i.e. 1 to 32 printable US-ASCII characters except "=", "]" and '"'.
+func validSDName(s string) bool {
+
+	if s == "" || len(s) > 32 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rfc5424HeaderField returns the value of a header field of the syslog
+// header, the nil value "-" if it is empty.
+func rfc5424HeaderField(value string, max int) string {
+
+	if value == "" {
+		return "-"
+	}
+
+	// header fields are printable US-ASCII without spaces.
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+
+	return value
+}
+
+// ToRFC5424 renders the event as RFC 5424 syslog message carrying the CEF
+// header fields and extensions as SD-PARAMs of a single SD-ELEMENT instead
+// of a CEF message, for destinations which index structured syslog
+// natively, e.g.
+//
+//	<14>1 2024-01-12T10:00:00Z host - - - [cef@32473 version="0" deviceVendor="Cool Vendor" ... src="127.0.0.1"] Something cool happened.
+//
+// The header fields are named in camelCase like the CEF specification
+// does, the extensions follow sorted by key and the event name is the MSG.
+// The syslog severity is derived from the CEF severity like ToSyslog does.
+//
+// Parameters:
+// - opts: The SD-ID and the fields of the syslog header.
+//
+// Returns:
+// - The syslog message.
+// - An error if the event is not valid or an extension key is no valid PARAM-NAME, i.e. longer than 32 characters or not printable US-ASCII.
+func (event *CefEvent) ToRFC5424(opts RFC5424Options) (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	if opts.SDID == "" {
+		opts.SDID = DefaultSDID
+	}
+	if !validSDName(opts.SDID) {
+		return "", fmt.Errorf("%q is no valid SD-ID", opts.SDID)
+	}
+	if opts.Timestamp.IsZero() {
+		opts.Timestamp = time.Now()
+	}
+	if opts.Hostname == "" {
+		opts.Hostname = syslogHostname()
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		if !validSDName(k) {
+			return "", fmt.Errorf("extension %q is no valid PARAM-NAME", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s [%s",
+		syslogFacilityUser*8+syslogSeverity(event.Severity),
+		opts.Timestamp.Format(rfc5424Timestamp),
+		rfc5424HeaderField(opts.Hostname, 255),
+		rfc5424HeaderField(opts.AppName, 48),
+		rfc5424HeaderField(opts.ProcID, 128),
+		rfc5424HeaderField(opts.MsgID, 32),
+		opts.SDID,
+	)
+
+	header := []string{strconv.Itoa(event.Version), event.DeviceVendor, event.DeviceProduct, event.DeviceVersion, event.DeviceEventClassId, event.Name, event.Severity}
+	for i, name := range rfc5424HeaderParams {
+		writeSDParam(&b, name, header[i])
+	}
+	for _, k := range keys {
+		writeSDParam(&b, k, event.Extensions[k])
+	}
+
+	b.WriteString("] ")
+	b.WriteString(rfc5424LineBreaks.Replace(event.Name))
+
+	return b.String(), nil
+}
+
+// rfc5424LineBreaks escapes the line breaks of the MSG like the CEF
+// encoding does, so messages stay on a single line for line-oriented transports.
+var rfc5424LineBreaks = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "\r", `\r`)
+
+// writeSDParam writes the SD-PARAM with its value escaped as RFC 5424
+// demands, '"', "\" and "]" are prefixed with a backslash. Line breaks are
+// written as "\n" and "\r" like the CEF encoding does.
+func writeSDParam(b *strings.Builder, name string, value string) {
+
+	b.WriteString(" " + name + `="`)
+	for _, r := range value {
+		if r == '"' || r == '\\' || r == ']' {
+			b.WriteByte('\\')
+		}
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
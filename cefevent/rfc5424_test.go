@@ -0,0 +1,58 @@
+package cefevent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToRFC5424(t *testing.T) {
+
+	e := event
+	e.Severity = "8"
+	e.Extensions = map[string]string{"src": "127.0.0.1", "msg": "a \"quoted\" [bracket] \\ and\nbreak"}
+
+	opts := RFC5424Options{
+		Timestamp: time.Date(2024, 1, 12, 10, 0, 0, 0, time.UTC),
+		Hostname:  "host",
+		AppName:   "cef",
+		MsgID:     "login event",
+	}
+	want := `<11>1 2024-01-12T10:00:00Z host cef - login_event [cef@32473 version="0" deviceVendor="Cool Vendor" deviceProduct="Cool Product" deviceVersion="1.0" deviceEventClassId="COOL_THING" name="Something cool happened." severity="8" msg="a \"quoted\" [bracket\] \\ and\nbreak" src="127.0.0.1"] Something cool happened.`
+	if got, err := e.ToRFC5424(opts); err != nil || got != want {
+		t.Errorf("ToRFC5424() = %s, %v, want %s", got, err, want)
+	}
+
+	// the structured data is skipped by ParseSyslog, the header is read.
+	got, _ := e.ToRFC5424(opts)
+	msg := SyslogMessage{Priority: -1}
+	if rest, err := msg.parseRFC5424(strings.TrimPrefix(got, "<11>1 ")); err != nil || rest != "Something cool happened." || msg.Hostname != "host" || msg.MsgID != "login_event" {
+		t.Errorf("parseRFC5424() = %q, %v, %+v", rest, err, msg)
+	}
+
+	if got, err := e.Render(FormatRFC5424); err != nil || !strings.Contains(got, " [cef@32473 version=\"0\"") {
+		t.Errorf("Render(FormatRFC5424) = %s, %v", got, err)
+	}
+
+	// TIME-SECFRAC has at most six digits.
+	precise := opts
+	precise.Timestamp = time.Date(2024, 1, 12, 10, 0, 0, 123456789, time.FixedZone("", 3600))
+	if got, err := e.ToRFC5424(precise); err != nil || !strings.HasPrefix(got, "<11>1 2024-01-12T10:00:00.123456+01:00 host ") {
+		t.Errorf("ToRFC5424() = %s, %v, want the timestamp in microseconds", got, err)
+	}
+
+	opts.SDID = "cef@example"
+	if got, err := e.ToRFC5424(opts); err != nil || !strings.Contains(got, "[cef@example ") {
+		t.Errorf("ToRFC5424() = %s, %v, want the SD-ID cef@example", got, err)
+	}
+
+	for _, invalid := range []map[string]string{
+		{"ad.a key with spaces": "x"},
+		{strings.Repeat("k", 33): "x"},
+	} {
+		e.Extensions = invalid
+		if _, err := e.ToRFC5424(opts); err == nil {
+			t.Errorf("ToRFC5424() of %v = nil, want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package cefevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// severityLevels is the number of numeric CEF severity levels, 0 to 10.
+const severityLevels = 11
+
+// severityLevel maps the CEF severity, either numeric (0-10) or one of Low,
+// Medium, High and Very-High, onto a numeric level. Unknown severities are
+// treated as the lowest level.
+func severityLevel(severity string) int {
+
+	switch severity {
+	case "Low":
+		return 3
+	case "Medium":
+		return 6
+	case "High":
+		return 8
+	case "Very-High":
+		return 10
+	}
+
+	level, err := strconv.Atoi(severity)
+	if err != nil || level < 0 {
+		return 0
+	}
+	if level > 10 {
+		return 10
+	}
+
+	return level
+}
+
+// SamplerOptions configures an AdaptiveSampler.
+type SamplerOptions struct {
+	Budget       int           // Budget is the number of events per window passed on before sampling kicks in.
+	Window       time.Duration // Window is the period the throughput is measured over, defaults to one second.
+	KeepSeverity int           // KeepSeverity is the lowest numeric severity which is never sampled, defaults to 7 (High).
+	RateKey      string        // RateKey is the extension recording the sample rate of sampled events, defaults to "sampleRate".
+	Audit        bool          // Audit stamps kept events standing in for dropped ones with the audit extensions, see AuditPolicyKey.
+}
+
+// AdaptiveSampler keeps all high severity events but progressively samples
+// the lower severities when the throughput exceeds a budget.
+//
+// The sampler keeps a histogram of the events per severity level for every
+// window. At the end of a window the sample rates of the next window are
+// derived from it: the budget left over by the never sampled severities is
+// handed out from the highest to the lowest remaining level, so the lowest
+// severities are thinned out first. An event passed on while its level is
+// sampled 1 in N carries N in the rate extension, so downstream counts can be
+// re-extrapolated by weighting every event with its rate.
+//
+// An AdaptiveSampler is safe for concurrent use.
+type AdaptiveSampler struct {
+	mu          sync.Mutex
+	opts        SamplerOptions
+	windowStart time.Time
+	histogram   [severityLevels]uint64
+	seen        [severityLevels]uint64
+	rates       [severityLevels]uint64
+	dropped     [severityLevels]uint64
+	sampled     uint64
+	now         func() time.Time
+}
+
+// NewAdaptiveSampler returns an AdaptiveSampler passing on all events until
+// the throughput of a window exceeds the budget.
+//
+// Parameters:
+// - opts: The budget and window, zero values select the defaults.
+//
+// Returns:
+// - A pointer to an AdaptiveSampler.
+func NewAdaptiveSampler(opts SamplerOptions) *AdaptiveSampler {
+
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	if opts.KeepSeverity <= 0 {
+		opts.KeepSeverity = 7
+	}
+	if opts.RateKey == "" {
+		opts.RateKey = "sampleRate"
+	}
+
+	s := &AdaptiveSampler{opts: opts, now: time.Now}
+	for level := range s.rates {
+		s.rates[level] = 1
+	}
+
+	return s
+}
+
+// Sample records the event in the histogram and decides whether it is kept.
+// With Audit enabled, a kept event whose level is sampled or after which
+// events of its level were dropped also carries the audit extensions.
+//
+// Returns:
+// - The event, carrying the rate extension when its severity is sampled.
+// - Whether the event is kept.
+func (s *AdaptiveSampler) Sample(event CefEvent) (CefEvent, bool) {
+
+	level := severityLevel(event.Severity)
+
+	s.mu.Lock()
+	s.advance()
+	s.histogram[level]++
+	rate := s.rates[level]
+	keep := s.seen[level]%rate == 0
+	s.seen[level]++
+	dropped := s.dropped[level]
+	if keep {
+		s.dropped[level] = 0
+	} else {
+		s.dropped[level]++
+		s.sampled++
+	}
+	s.mu.Unlock()
+
+	if keep && rate > 1 {
+		extensions := cloneExtensions(event.Extensions)
+		extensions[s.opts.RateKey] = strconv.FormatUint(rate, 10)
+		event.Extensions = extensions
+	}
+
+	if keep && s.opts.Audit && (rate > 1 || dropped > 0) {
+		event = stampAudit(event, AuditPolicySampling, rate, dropped, s.opts.Window)
+	}
+
+	return event, keep
+}
+
+// advance starts a new window when the current one has passed and derives
+// its sample rates from the histogram of the previous window, the caller
+// must hold the lock.
+func (s *AdaptiveSampler) advance() {
+
+	now := s.now()
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+		return
+	}
+
+	elapsed := now.Sub(s.windowStart)
+	if elapsed < s.opts.Window {
+		return
+	}
+
+	previous := s.histogram
+	if elapsed >= 2*s.opts.Window {
+		// nothing was seen in the window right before this one.
+		previous = [severityLevels]uint64{}
+	}
+
+	s.windowStart = now.Add(-(elapsed % s.opts.Window))
+	s.histogram = [severityLevels]uint64{}
+	s.seen = [severityLevels]uint64{}
+
+	remaining := uint64(0)
+	if s.opts.Budget > 0 {
+		remaining = uint64(s.opts.Budget)
+	}
+
+	for level := severityLevels - 1; level >= 0; level-- {
+		count := previous[level]
+
+		if level >= s.opts.KeepSeverity || count <= remaining {
+			s.rates[level] = 1
+			if count < remaining {
+				remaining -= count
+			} else {
+				remaining = 0
+			}
+			continue
+		}
+
+		share := remaining
+		if share == 0 {
+			share = 1
+		}
+		rate := (count + share - 1) / share
+		s.rates[level] = rate
+		remaining -= min(remaining, count/rate)
+	}
+}
+
+// Middleware returns a Middleware dropping the sampled out events and
+// stamping the kept ones with their sample rate.
+func (s *AdaptiveSampler) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			event, keep := s.Sample(event)
+			if !keep {
+				return nil
+			}
+			return next(event)
+		}
+	}
+}
+
+// Rates returns the current sample rate per numeric severity level, a rate
+// of N means that one in N events of that level is kept.
+func (s *AdaptiveSampler) Rates() map[int]uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates := make(map[int]uint64, severityLevels)
+	for level, rate := range s.rates {
+		rates[level] = rate
+	}
+
+	return rates
+}
+
+// Sampled returns the number of events which were dropped by sampling.
+func (s *AdaptiveSampler) Sampled() uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sampled
+}
+
+// samplerState is the saved state of an AdaptiveSampler.
+type samplerState struct {
+	WindowStart time.Time              `json:"windowStart"`
+	Histogram   [severityLevels]uint64 `json:"histogram"`
+	Seen        [severityLevels]uint64 `json:"seen"`
+	Rates       [severityLevels]uint64 `json:"rates"`
+	Dropped     [severityLevels]uint64 `json:"dropped"`
+	Sampled     uint64                 `json:"sampled"`
+}
+
+// SaveState saves the histogram of the current window, the sample rates
+// and the counters to the store, see PersistState.
+func (s *AdaptiveSampler) SaveState(ctx context.Context, store StateStore, key string) error {
+
+	s.mu.Lock()
+	state := samplerState{
+		WindowStart: s.windowStart,
+		Histogram:   s.histogram,
+		Seen:        s.seen,
+		Rates:       s.rates,
+		Dropped:     s.dropped,
+		Sampled:     s.sampled,
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return store.Save(ctx, key, data)
+}
+
+// LoadState replaces the window, the sample rates and the counters with
+// those saved to the store, a window which passed in the meantime is
+// concluded with the next event. Nothing is changed if no state was saved.
+func (s *AdaptiveSampler) LoadState(ctx context.Context, store StateStore, key string) error {
+
+	data, err := store.Load(ctx, key)
+	if err != nil || data == nil {
+		return err
+	}
+
+	var state samplerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("malformed sampler state: %w", err)
+	}
+	for level, rate := range state.Rates {
+		if rate == 0 {
+			// a zero rate would divide by zero when sampling.
+			state.Rates[level] = 1
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.windowStart = state.WindowStart
+	s.histogram = state.Histogram
+	s.seen = state.Seen
+	s.rates = state.Rates
+	s.dropped = state.Dropped
+	s.sampled = state.Sampled
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeverityLevel(t *testing.T) {
+
+	var tests = []struct {
+		severity string
+		want     int
+	}{
+		{"0", 0},
+		{"7", 7},
+		{"42", 10},
+		{"Low", 3},
+		{"Very-High", 10},
+		{"Unknown", 0},
+	}
+
+	for _, tt := range tests {
+		if got := severityLevel(tt.severity); got != tt.want {
+			t.Errorf("severityLevel(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestAdaptiveSampler(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	sampler := NewAdaptiveSampler(SamplerOptions{Budget: 10})
+	sampler.now = func() time.Time { return now }
+
+	withSeverity := func(severity string) CefEvent {
+		e := event
+		e.Severity = severity
+		return e
+	}
+
+	// the first window passes everything and builds the histogram.
+	for i := 0; i < 40; i++ {
+		if _, keep := sampler.Sample(withSeverity("2")); !keep {
+			t.Fatalf("Sample() dropped an event of the first window")
+		}
+	}
+	for i := 0; i < 20; i++ {
+		sampler.Sample(withSeverity("5"))
+	}
+	for i := 0; i < 5; i++ {
+		sampler.Sample(withSeverity("9"))
+	}
+
+	now = now.Add(time.Second)
+
+	kept := 0
+	for i := 0; i < 8; i++ {
+		if sampled, keep := sampler.Sample(withSeverity("5")); keep {
+			kept++
+			if sampled.Extensions["sampleRate"] != "4" {
+				t.Errorf("sampleRate = %q, want 4", sampled.Extensions["sampleRate"])
+			}
+		}
+	}
+	if kept != 2 {
+		t.Errorf("kept %d of 8 medium severity events, want 2", kept)
+	}
+
+	for i := 0; i < 10; i++ {
+		sampled, keep := sampler.Sample(withSeverity("9"))
+		if !keep || sampled.Extensions["sampleRate"] != "" {
+			t.Errorf("Sample() = %v, %v, high severity events must never be sampled", sampled.Extensions, keep)
+		}
+	}
+
+	if rates := sampler.Rates(); rates[2] != 40 || rates[5] != 4 || rates[9] != 1 {
+		t.Errorf("Rates() = %v, want 40 for level 2, 4 for level 5 and 1 for level 9", rates)
+	}
+
+	if sampler.Sampled() != 6 {
+		t.Errorf("Sampled() = %d, want 6", sampler.Sampled())
+	}
+
+	// after an idle window all levels are passed on again.
+	now = now.Add(3 * time.Second)
+	sampler.Sample(withSeverity("2"))
+	if rates := sampler.Rates(); rates[2] != 1 {
+		t.Errorf("Rates() = %v after an idle window, want 1 for level 2", rates)
+	}
+}
+
+func TestAdaptiveSamplerAudit(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	sampler := NewAdaptiveSampler(SamplerOptions{Budget: 1, Audit: true})
+	sampler.now = func() time.Time { return now }
+
+	low := event
+	low.Severity = "2"
+
+	for i := 0; i < 4; i++ {
+		if sampled, _ := sampler.Sample(low); sampled.Extensions[AuditPolicyKey] != "" {
+			t.Errorf("Sample() = %v, events of an unsampled window must not be stamped", sampled.Extensions)
+		}
+	}
+
+	now = now.Add(time.Second)
+
+	var stamped []map[string]string
+	for i := 0; i < 8; i++ {
+		if sampled, keep := sampler.Sample(low); keep {
+			stamped = append(stamped, sampled.Extensions)
+		}
+	}
+
+	want := []map[string]string{
+		{"src": "127.0.0.1", "sampleRate": "4", AuditPolicyKey: AuditPolicySampling, AuditRateKey: "4", AuditDroppedKey: "0", AuditWindowKey: "1000"},
+		{"src": "127.0.0.1", "sampleRate": "4", AuditPolicyKey: AuditPolicySampling, AuditRateKey: "4", AuditDroppedKey: "3", AuditWindowKey: "1000"},
+	}
+	if !reflect.DeepEqual(stamped, want) {
+		t.Errorf("kept events = %v, want %v", stamped, want)
+	}
+}
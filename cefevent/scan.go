@@ -0,0 +1,115 @@
+package cefevent
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// ErrTruncatedFrame is returned by ScanCEF when the input ends within an
+// octet-counted frame.
+var ErrTruncatedFrame = errors.New("input ends within an octet-counted frame")
+
+// maxFrameDigits is the number of digits of the largest octet count ScanCEF accepts.
+var maxFrameDigits = len(strconv.Itoa(maxDecoderLineSize))
+
+// ScanCEF is a bufio.SplitFunc splitting a stream into single CEF records.
+// It understands newline framing with LF or CRLF line endings as well as
+// the octet counting framing of RFC 6587, where every record is prefixed
+// with its length in bytes and a space, as used by syslog over TCP. Both
+// framings may be mixed within a stream. Line endings are removed from the
+// records and empty lines are skipped.
+//
+// Parameters:
+// - data: The buffered input which has not been split yet.
+// - atEOF: Whether the input is exhausted.
+//
+// Returns:
+// - The number of bytes consumed.
+// - The next record, nil if more input is needed.
+// - ErrTruncatedFrame if the input ends within an octet-counted frame.
+func ScanCEF(data []byte, atEOF bool) (advance int, token []byte, err error) {
+
+	// empty records are skipped here, the scanner stops at the end of the
+	// input when a split function returns no token.
+	for advance < len(data) {
+		n, record, err := scanRecord(data[advance:], atEOF)
+		if err != nil || n == 0 {
+			return 0, nil, err
+		}
+		advance += n
+		if record = bytes.TrimRight(record, "\r\n"); len(record) > 0 {
+			return advance, record, nil
+		}
+	}
+
+	return advance, nil, nil
+}
+
+// scanRecord splits the next octet-counted frame or line off data.
+//
+// Returns:
+// - The number of bytes consumed, 0 if more input is needed.
+// - The record without the octet count and the line ending.
+// - ErrTruncatedFrame if the input ends within an octet-counted frame.
+func scanRecord(data []byte, atEOF bool) (int, []byte, error) {
+
+	if length, start, ok := frameLength(data, atEOF); ok {
+		switch {
+		case length < 0:
+			return 0, nil, nil
+		case len(data) >= start+length:
+			return start + length, data[start : start+length], nil
+		case atEOF:
+			return 0, nil, ErrTruncatedFrame
+		default:
+			return 0, nil, nil
+		}
+	}
+
+	if end := bytes.IndexByte(data, '\n'); end >= 0 {
+		return end + 1, data[:end], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// frameLength detects the octet count prefix of an RFC 6587 frame.
+//
+// Returns:
+// - The length of the frame, -1 if more input is needed to decide.
+// - The offset of the frame after the prefix.
+// - Whether data starts with an octet count.
+func frameLength(data []byte, atEOF bool) (int, int, bool) {
+
+	digits := 0
+	for digits < len(data) && digits <= maxFrameDigits && data[digits] >= '0' && data[digits] <= '9' {
+		digits++
+	}
+
+	if digits == 0 || digits > maxFrameDigits || data[0] == '0' {
+		return 0, 0, false
+	}
+
+	if digits == len(data) {
+		if atEOF {
+			return 0, 0, false
+		}
+		return -1, 0, true
+	}
+
+	if data[digits] != ' ' {
+		return 0, 0, false
+	}
+
+	length, err := strconv.Atoi(string(data[:digits]))
+	if err != nil || length > maxDecoderLineSize {
+		return 0, 0, false
+	}
+
+	return length, digits + 1, true
+}
@@ -0,0 +1,84 @@
+package cefevent
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testingReader returns the input in chunks of n bytes, which makes the
+// scanner call ScanCEF with partial records.
+type testingReader struct {
+	data string
+	n    int
+}
+
+func (r *testingReader) Read(p []byte) (int, error) {
+
+	if r.data == "" {
+		return 0, io.EOF
+	}
+
+	n := copy(p[:min(len(p), r.n)], r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func scanAll(input string, chunk int) ([]string, error) {
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	if chunk > 0 {
+		scanner = bufio.NewScanner(&testingReader{data: input, n: chunk})
+	}
+	scanner.Split(ScanCEF)
+
+	var records []string
+	for scanner.Scan() {
+		records = append(records, scanner.Text())
+	}
+
+	return records, scanner.Err()
+}
+
+func TestScanCEF(t *testing.T) {
+
+	var tests = []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"LF", "CEF:0|a\nCEF:0|b\n", []string{"CEF:0|a", "CEF:0|b"}},
+		{"CRLF", "CEF:0|a\r\nCEF:0|b\r\n", []string{"CEF:0|a", "CEF:0|b"}},
+		{"no final line ending", "CEF:0|a\nCEF:0|b", []string{"CEF:0|a", "CEF:0|b"}},
+		{"empty lines", "\n\r\nCEF:0|a\n\n", []string{"CEF:0|a"}},
+		{"octet counting", "7 CEF:0|a9 CEF:0|b c", []string{"CEF:0|a", "CEF:0|b c"}},
+		{"octet counting with newline", "12 CEF:0|a\nb=c\n8 CEF:0|b\n", []string{"CEF:0|a\nb=c", "CEF:0|b"}},
+		{"mixed", "7 CEF:0|a\nCEF:0|b\r\n7 CEF:0|c", []string{"CEF:0|a", "CEF:0|b", "CEF:0|c"}},
+		{"syslog", "<134>Jan 12 10:00:00 fw01 CEF:0|a\n", []string{"<134>Jan 12 10:00:00 fw01 CEF:0|a"}},
+		{"leading digits", "2024-01-12T10:00:00Z CEF:0|a\n", []string{"2024-01-12T10:00:00Z CEF:0|a"}},
+	}
+
+	for _, tt := range tests {
+		for _, chunk := range []int{0, 1, 3} {
+			got, err := scanAll(tt.input, chunk)
+			if err != nil {
+				t.Errorf("%s: ScanCEF() error = %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: ScanCEF() in chunks of %d = %q, want %q", tt.name, chunk, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestScanCEFTruncated(t *testing.T) {
+
+	got, err := scanAll("7 CEF:0|a20 CEF:0|b", 0)
+	if !errors.Is(err, ErrTruncatedFrame) || !reflect.DeepEqual(got, []string{"CEF:0|a"}) {
+		t.Errorf("ScanCEF() = %q, %v, want the complete record and %v", got, err, ErrTruncatedFrame)
+	}
+}
@@ -0,0 +1,326 @@
+package cefevent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SchemaVersion is the version of the stored event serializations written
+// by MarshalStored and MarshalBinary. It is increased whenever the stored
+// representation changes, events of older versions are upgraded by the
+// registered migrations when they are read.
+const SchemaVersion = 1
+
+// schemaKey is the JSON key holding the schema version of stored events.
+const schemaKey = "schema"
+
+// binaryMagic starts every binary serialization of an event.
+var binaryMagic = []byte("CEFB")
+
+// Migration upgrades the JSON document of a stored event by a single schema
+// version, e.g. by renaming keys or converting values. The document holds
+// the header fields by their struct field names and the extensions below
+// "Extensions", exactly as written by MarshalStored.
+type Migration func(doc map[string]interface{}) error
+
+var (
+	migrationMu sync.RWMutex
+	migrations  = map[int]Migration{
+		// version 0 is the plain ToJSON output which lacks the schema marker
+		// but is otherwise identical to version 1.
+		0: func(doc map[string]interface{}) error { return nil },
+	}
+)
+
+// RegisterMigration registers the Migration upgrading stored events from the
+// given schema version to the next one, an existing registration for the
+// same version is replaced.
+//
+// Parameters:
+// - from: The schema version the migration upgrades from.
+// - migration: The function upgrading the document to version from+1.
+func RegisterMigration(from int, migration Migration) {
+
+	migrationMu.Lock()
+	defer migrationMu.Unlock()
+
+	migrations[from] = migration
+}
+
+// migrate upgrades the document from the given schema version to SchemaVersion.
+func migrate(doc map[string]interface{}, from int) error {
+
+	migrationMu.RLock()
+	defer migrationMu.RUnlock()
+
+	for version := from; version < SchemaVersion; version++ {
+		migration, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from schema version %d", version)
+		}
+		if err := migration(doc); err != nil {
+			return fmt.Errorf("migration from schema version %d failed: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// storedEvent is the JSON representation written by MarshalStored, the
+// event fields are inlined next to the schema version.
+type storedEvent struct {
+	Schema int `json:"schema"`
+	*cefEventFields
+}
+
+// MarshalStored serializes the event as JSON document for persistent
+// storage. Unlike ToJSON the document carries the schema version, so it can
+// still be read after the stored representation has changed.
+//
+// Returns:
+// - The JSON document.
+// - An error if the event is not valid or could not be marshaled.
+func (event *CefEvent) MarshalStored() ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(storedEvent{Schema: SchemaVersion, cefEventFields: (*cefEventFields)(event)})
+}
+
+// UnmarshalStored reads an event written by MarshalStored, MarshalBinary or
+// ToJSON of any schema version and upgrades it to the current one.
+//
+// Returns:
+// - The upgraded CefEvent.
+// - An error if the data is not a stored event, its schema version is newer than SchemaVersion or a migration failed.
+func UnmarshalStored(data []byte) (CefEvent, error) {
+
+	if bytes.HasPrefix(data, binaryMagic) {
+		var event CefEvent
+		err := event.UnmarshalBinary(data)
+		return event, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return CefEvent{}, err
+	}
+
+	version := 0
+	if value, ok := doc[schemaKey]; ok {
+		number, ok := value.(float64)
+		if !ok {
+			return CefEvent{}, errors.New("stored event has an invalid schema version")
+		}
+		version = int(number)
+	}
+
+	return fromDocument(doc, version)
+}
+
+// fromDocument upgrades the JSON document of the given schema version and decodes it.
+func fromDocument(doc map[string]interface{}, version int) (CefEvent, error) {
+
+	if version > SchemaVersion {
+		return CefEvent{}, fmt.Errorf("stored event has schema version %d, newer than the supported version %d", version, SchemaVersion)
+	}
+
+	if err := migrate(doc, version); err != nil {
+		return CefEvent{}, err
+	}
+	delete(doc, schemaKey)
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return CefEvent{}, err
+	}
+
+	var event CefEvent
+	if err := json.Unmarshal(upgraded, &event); err != nil {
+		return CefEvent{}, err
+	}
+
+	return event, nil
+}
+
+// MarshalBinary serializes the event in a compact binary form for
+// persistent storage, it implements encoding.BinaryMarshaler.
+//
+// The serialization starts with the "CEFB" magic and the schema version
+// followed by the CEF version, the header fields and the extensions sorted
+// by key, all strings are prefixed with their length.
+//
+// Returns:
+// - The binary serialization.
+// - An error if the event is not valid.
+func (event *CefEvent) MarshalBinary() ([]byte, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := append([]byte(nil), binaryMagic...)
+	buf = binary.AppendUvarint(buf, SchemaVersion)
+	buf = binary.AppendVarint(buf, int64(event.Version))
+
+	for _, field := range []string{
+		event.DeviceVendor,
+		event.DeviceProduct,
+		event.DeviceVersion,
+		event.DeviceEventClassId,
+		event.Name,
+		event.Severity,
+	} {
+		buf = appendBinaryString(buf, field)
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendBinaryString(buf, k)
+		buf = appendBinaryString(buf, event.Extensions[k])
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary reads an event written by MarshalBinary and upgrades it
+// to the current schema version, it implements encoding.BinaryUnmarshaler.
+//
+// Returns:
+// - An error if the data is not a binary serialization, it is truncated or a migration failed.
+func (event *CefEvent) UnmarshalBinary(data []byte) error {
+
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return errors.New("not a binary CEF event")
+	}
+
+	r := binaryReader{data: data[len(binaryMagic):]}
+
+	version := int(r.uvarint())
+	if version > SchemaVersion {
+		return fmt.Errorf("stored event has schema version %d, newer than the supported version %d", version, SchemaVersion)
+	}
+
+	decoded := CefEvent{Version: int(r.varint())}
+	decoded.DeviceVendor = r.string()
+	decoded.DeviceProduct = r.string()
+	decoded.DeviceVersion = r.string()
+	decoded.DeviceEventClassId = r.string()
+	decoded.Name = r.string()
+	decoded.Severity = r.string()
+
+	if n := r.uvarint(); n > 0 && r.err == nil {
+		decoded.Extensions = make(map[string]string)
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			k := r.string()
+			decoded.Extensions[k] = r.string()
+		}
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if version < SchemaVersion {
+		doc, err := toDocument(decoded)
+		if err != nil {
+			return err
+		}
+		if decoded, err = fromDocument(doc, version); err != nil {
+			return err
+		}
+	}
+
+	*event = decoded
+	return nil
+}
+
+// toDocument converts the event into the JSON document migrations work on.
+func toDocument(event CefEvent) (map[string]interface{}, error) {
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(data, &doc)
+
+	return doc, err
+}
+
+// appendBinaryString appends the length prefixed string to buf.
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// binaryReader decodes the fields of a binary serialization, the first
+// error is remembered and makes all further reads return zero values.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) uvarint() uint64 {
+
+	if r.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("binary CEF event is truncated")
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return value
+}
+
+func (r *binaryReader) varint() int64 {
+
+	if r.err != nil {
+		return 0
+	}
+
+	value, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errors.New("binary CEF event is truncated")
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return value
+}
+
+func (r *binaryReader) string() string {
+
+	length := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+
+	if length > uint64(len(r.data)) {
+		r.err = errors.New("binary CEF event is truncated")
+		return ""
+	}
+
+	s := string(r.data[:length])
+	r.data = r.data[length:]
+
+	return s
+}
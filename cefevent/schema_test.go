@@ -0,0 +1,82 @@
+package cefevent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalStored(t *testing.T) {
+
+	data, err := event.MarshalStored()
+	if err != nil {
+		t.Fatalf("MarshalStored() = %v", err)
+	}
+
+	if !strings.HasPrefix(string(data), `{"schema":1,`) {
+		t.Errorf("MarshalStored() = %s, want a schema marker", data)
+	}
+
+	got, err := UnmarshalStored(data)
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("UnmarshalStored() = %+v, %v, want %+v", got, err, event)
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+
+	data, err := event.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() = %v", err)
+	}
+
+	got, err := UnmarshalStored(data)
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("UnmarshalStored() = %+v, %v, want %+v", got, err, event)
+	}
+
+	var truncated CefEvent
+	if err := truncated.UnmarshalBinary(data[:len(data)-3]); err == nil {
+		t.Errorf("UnmarshalBinary() should fail for truncated data")
+	}
+
+	if _, err := (&CefEvent{}).MarshalBinary(); err == nil {
+		t.Errorf("MarshalBinary() should fail for an invalid event")
+	}
+}
+
+func TestUnmarshalStoredMigration(t *testing.T) {
+
+	// events stored with ToJSON carry no schema marker and are version 0.
+	legacy, _ := event.ToJSON()
+	legacy = strings.Replace(legacy, `"src"`, `"sourceAddress"`, 1)
+
+	RegisterMigration(0, func(doc map[string]interface{}) error {
+		extensions := doc["Extensions"].(map[string]interface{})
+		extensions["src"] = extensions["sourceAddress"]
+		delete(extensions, "sourceAddress")
+		return nil
+	})
+	defer RegisterMigration(0, func(doc map[string]interface{}) error { return nil })
+
+	got, err := UnmarshalStored([]byte(legacy))
+	if err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("UnmarshalStored() = %+v, %v, want %+v", got, err, event)
+	}
+}
+
+func TestUnmarshalStoredErrors(t *testing.T) {
+
+	var tests = []string{
+		`{"schema":99,"Version":0}`,
+		`{"schema":"one"}`,
+		`not json`,
+		`CEFB`,
+	}
+
+	for _, tt := range tests {
+		if _, err := UnmarshalStored([]byte(tt)); err == nil {
+			t.Errorf("UnmarshalStored(%q) should fail", tt)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package cefevent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sentinelColumns maps the CEF extension keys of the specification onto the
+// columns of the CommonSecurityLog table of Microsoft Sentinel, following
+// the CEF mapping of the Sentinel documentation.
+var sentinelColumns = func() map[string]string {
+
+	columns := map[string]string{
+		"act":                          "DeviceAction",
+		"app":                          "ApplicationProtocol",
+		"cat":                          "DeviceEventCategory",
+		"cnt":                          "EventCount",
+		"destinationDnsDomain":         "DestinationDnsDomain",
+		"destinationServiceName":       "DestinationServiceName",
+		"destinationTranslatedAddress": "DestinationTranslatedAddress",
+		"destinationTranslatedPort":    "DestinationTranslatedPort",
+		"deviceDirection":              "CommunicationDirection",
+		"deviceDnsDomain":              "DeviceDnsDomain",
+		"deviceExternalId":             "DeviceExternalID",
+		"deviceFacility":               "DeviceFacility",
+		"deviceInboundInterface":       "DeviceInboundInterface",
+		"deviceNtDomain":               "DeviceNtDomain",
+		"deviceOutboundInterface":      "DeviceOutboundInterface",
+		"devicePayloadId":              "DevicePayloadId",
+		"deviceProcessName":            "ProcessName",
+		"deviceTranslatedAddress":      "DeviceTranslatedAddress",
+		"dhost":                        "DestinationHostName",
+		"dmac":                         "DestinationMACAddress",
+		"dntdom":                       "DestinationNTDomain",
+		"dpid":                         "DestinationProcessId",
+		"dpriv":                        "DestinationUserPrivileges",
+		"dproc":                        "DestinationProcessName",
+		"dpt":                          "DestinationPort",
+		"dst":                          "DestinationIP",
+		"duid":                         "DestinationUserID",
+		"duser":                        "DestinationUserName",
+		"dvc":                          "DeviceAddress",
+		"dvchost":                      "DeviceName",
+		"dvcmac":                       "DeviceMacAddress",
+		"dvcpid":                       "ProcessID",
+		"end":                          "EndTime",
+		"externalId":                   "ExternalID",
+		"fileCreateTime":               "FileCreateTime",
+		"fileHash":                     "FileHash",
+		"fileId":                       "FileID",
+		"fileModificationTime":         "FileModificationTime",
+		"filePath":                     "FilePath",
+		"filePermission":               "FilePermission",
+		"fileType":                     "FileType",
+		"flexDate1":                    "FlexDate1",
+		"flexDate1Label":               "FlexDate1Label",
+		"fname":                        "FileName",
+		"fsize":                        "FileSize",
+		"in":                           "ReceivedBytes",
+		"msg":                          "Message",
+		"oldFileCreateTime":            "OldFileCreateTime",
+		"oldFileHash":                  "OldFileHash",
+		"oldFileId":                    "OldFileID",
+		"oldFileModificationTime":      "OldFileModificationTime",
+		"oldFileName":                  "OldFileName",
+		"oldFilePath":                  "OldFilePath",
+		"oldFilePermission":            "OldFilePermission",
+		"oldFileSize":                  "OldFileSize",
+		"oldFileType":                  "OldFileType",
+		"out":                          "SentBytes",
+		"outcome":                      "EventOutcome",
+		"proto":                        "Protocol",
+		"reason":                       "Reason",
+		"request":                      "RequestURL",
+		"requestClientApplication":     "RequestClientApplication",
+		"requestContext":               "RequestContext",
+		"requestCookies":               "RequestCookies",
+		"requestMethod":                "RequestMethod",
+		"rt":                           "ReceiptTime",
+		"shost":                        "SourceHostName",
+		"smac":                         "SourceMACAddress",
+		"sntdom":                       "SourceNTDomain",
+		"sourceDnsDomain":              "SourceDnsDomain",
+		"sourceServiceName":            "SourceServiceName",
+		"sourceTranslatedAddress":      "SourceTranslatedAddress",
+		"sourceTranslatedPort":         "SourceTranslatedPort",
+		"spid":                         "SourceProcessId",
+		"spriv":                        "SourceUserPrivileges",
+		"sproc":                        "SourceProcessName",
+		"spt":                          "SourcePort",
+		"src":                          "SourceIP",
+		"start":                        "StartTime",
+		"suid":                         "SourceUserID",
+		"suser":                        "SourceUserName",
+	}
+
+	// the custom extensions and their labels, e.g. cs1 onto DeviceCustomString1.
+	for _, custom := range []struct {
+		prefix string
+		column string
+		count  int
+	}{
+		{"cs", "DeviceCustomString", 6},
+		{"cn", "DeviceCustomNumber", 3},
+		{"cfp", "DeviceCustomFloatingPoint", 4},
+		{"c6a", "DeviceCustomIPv6Address", 4},
+		{"deviceCustomDate", "DeviceCustomDate", 2},
+		{"flexString", "FlexString", 2},
+		{"flexNumber", "FlexNumber", 2},
+	} {
+		for i := 1; i <= custom.count; i++ {
+			n := strconv.Itoa(i)
+			columns[custom.prefix+n] = custom.column + n
+			columns[custom.prefix+n+"Label"] = custom.column + n + "Label"
+		}
+	}
+
+	return columns
+}()
+
+// sentinelIntegerColumns lists the columns of CommonSecurityLog holding
+// integers instead of strings.
+var sentinelIntegerColumns = map[string]bool{
+	"EventCount":                true,
+	"ExternalID":                true,
+	"DestinationTranslatedPort": true,
+	"DestinationProcessId":      true,
+	"DestinationPort":           true,
+	"ProcessID":                 true,
+	"FileSize":                  true,
+	"OldFileSize":               true,
+	"ReceivedBytes":             true,
+	"SentBytes":                 true,
+	"SourceTranslatedPort":      true,
+	"SourceProcessId":           true,
+	"SourcePort":                true,
+	"DeviceCustomNumber1":       true,
+	"DeviceCustomNumber2":       true,
+	"DeviceCustomNumber3":       true,
+	"FlexNumber1":               true,
+	"FlexNumber2":               true,
+}
+
+// sentinelFloatColumns lists the columns of CommonSecurityLog holding real numbers.
+var sentinelFloatColumns = map[string]bool{
+	"DeviceCustomFloatingPoint1": true,
+	"DeviceCustomFloatingPoint2": true,
+	"DeviceCustomFloatingPoint3": true,
+	"DeviceCustomFloatingPoint4": true,
+}
+
+// sentinelDateColumns lists the columns of CommonSecurityLog holding dates,
+// their values are rendered in RFC 3339 as the Log Ingestion API expects them.
+var sentinelDateColumns = map[string]bool{
+	"StartTime": true,
+	"EndTime":   true,
+}
+
+// ToCommonSecurityLog maps the CefEvent onto the columns of the
+// CommonSecurityLog table of Microsoft Sentinel, ready to be sent to the Log
+// Ingestion API.
+//
+// The header fields become DeviceVendor, DeviceProduct, DeviceVersion,
+// DeviceEventClassID, Activity and LogSeverity, the extensions of the
+// specification their columns, e.g. src SourceIP and
+// requestClientApplication RequestClientApplication, and all remaining
+// extensions are joined into AdditionalExtensions as "key=value" pairs
+// separated by ";". Ports, counts and sizes become numbers, StartTime and
+// EndTime RFC 3339 dates in UTC, values which can not be converted are kept
+// as they are. TimeGenerated is the time of the event, see EventTime, or
+// the current time.
+//
+// Returns:
+// - A map with the CommonSecurityLog column names as keys.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToCommonSecurityLog() (map[string]interface{}, error) {
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	generated, ok := event.EventTime()
+	if !ok {
+		generated = time.Now()
+	}
+
+	row := map[string]interface{}{
+		"TimeGenerated":      generated.UTC().Format(time.RFC3339Nano),
+		"DeviceVendor":       event.DeviceVendor,
+		"DeviceProduct":      event.DeviceProduct,
+		"DeviceVersion":      event.DeviceVersion,
+		"DeviceEventClassID": event.DeviceEventClassId,
+		"Activity":           event.Name,
+		"LogSeverity":        event.Severity,
+	}
+
+	var additional []string
+	for k, v := range event.Extensions {
+
+		column, ok := sentinelColumns[k]
+		if !ok {
+			additional = append(additional, k+"="+v)
+			continue
+		}
+
+		if sentinelIntegerColumns[column] {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				row[column] = n
+				continue
+			}
+		}
+
+		if sentinelFloatColumns[column] {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				row[column] = f
+				continue
+			}
+		}
+
+		if sentinelDateColumns[column] {
+			if t, err := ParseTimestamp(v); err == nil {
+				row[column] = t.UTC().Format(time.RFC3339Nano)
+				continue
+			}
+		}
+
+		row[column] = v
+	}
+
+	if len(additional) > 0 {
+		sort.Strings(additional)
+		row["AdditionalExtensions"] = strings.Join(additional, ";")
+	}
+
+	return row, nil
+}
@@ -0,0 +1,64 @@
+package cefevent
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToCommonSecurityLog(t *testing.T) {
+
+	e := event
+	e.Severity = "7"
+	e.Extensions = map[string]string{
+		"src":                      "10.0.0.1",
+		"spt":                      "51234",
+		"dpt":                      "https",
+		"requestClientApplication": "curl/8.0",
+		"rt":                       "1700000000000",
+		"start":                    "Nov 14 2023 21:13:20.500 UTC",
+		"cs1":                      "custom",
+		"cs1Label":                 "Rule",
+		"cfp1":                     "1.5",
+		"ad.user":                  "alice",
+		"ad.domain":                "corp",
+	}
+
+	got, err := e.ToCommonSecurityLog()
+	if err != nil {
+		t.Fatalf("ToCommonSecurityLog() = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"TimeGenerated":              "2023-11-14T22:13:20Z",
+		"DeviceVendor":               "Cool Vendor",
+		"DeviceProduct":              "Cool Product",
+		"DeviceVersion":              "1.0",
+		"DeviceEventClassID":         "COOL_THING",
+		"Activity":                   "Something cool happened.",
+		"LogSeverity":                "7",
+		"SourceIP":                   "10.0.0.1",
+		"SourcePort":                 int64(51234),
+		"DestinationPort":            "https",
+		"RequestClientApplication":   "curl/8.0",
+		"ReceiptTime":                "1700000000000",
+		"StartTime":                  "2023-11-14T21:13:20.5Z",
+		"DeviceCustomString1":        "custom",
+		"DeviceCustomString1Label":   "Rule",
+		"DeviceCustomFloatingPoint1": 1.5,
+		"AdditionalExtensions":       "ad.domain=corp;ad.user=alice",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToCommonSecurityLog() = %v, want %v", got, want)
+	}
+
+	// without a time of its own the event is generated now.
+	got, _ = event.ToCommonSecurityLog()
+	if generated, err := time.Parse(time.RFC3339Nano, got["TimeGenerated"].(string)); err != nil || time.Since(generated) > time.Minute {
+		t.Errorf("TimeGenerated = %v, want the current time", got["TimeGenerated"])
+	}
+
+	if _, err := (&CefEvent{}).ToCommonSecurityLog(); err == nil {
+		t.Error("ToCommonSecurityLog() = nil, want an error for an invalid event")
+	}
+}
@@ -0,0 +1,355 @@
+package cefevent
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"time"
+)
+
+// The labels of the custom IPv6 extensions which carry IPv6 addresses, as
+// src, dst and dvc only hold IPv4 addresses.
+const (
+	deviceIPv6Label      = "Device IPv6 Address"
+	sourceIPv6Label      = "Source IPv6 Address"
+	destinationIPv6Label = "Destination IPv6 Address"
+)
+
+// setAddress stores an IPv4 address in key and an IPv6 address in the
+// custom IPv6 extension ipv6Key with its label, which must not be used
+// with another label yet. The address previously stored in the other
+// extension is removed.
+func (event *CefEvent) setAddress(key string, ipv6Key string, label string, ip net.IP) error {
+
+	if ip4 := ip.To4(); ip4 != nil {
+		event.setExtension(key, ip4.String())
+		if event.Extensions[ipv6Key+"Label"] == label {
+			delete(event.Extensions, ipv6Key)
+			delete(event.Extensions, ipv6Key+"Label")
+		}
+		return nil
+	}
+
+	if len(ip) != net.IPv6len {
+		return fmt.Errorf("address %s is not a valid IP address", key)
+	}
+
+	if err := event.setCustom(ipv6Key, label, ip.String()); err != nil {
+		return err
+	}
+	delete(event.Extensions, key)
+
+	return nil
+}
+
+// SetSourceAddress stores the address of the source, an IPv4 address in
+// src and an IPv6 address in c6a2 labeled "Source IPv6 Address".
+//
+// Returns:
+// - An error if ip is no valid IP address.
+func (event *CefEvent) SetSourceAddress(ip net.IP) error {
+	return event.setAddress("src", "c6a2", sourceIPv6Label, ip)
+}
+
+// SetDestinationAddress stores the address of the destination, an IPv4
+// address in dst and an IPv6 address in c6a3 labeled "Destination IPv6 Address".
+//
+// Returns:
+// - An error if ip is no valid IP address.
+func (event *CefEvent) SetDestinationAddress(ip net.IP) error {
+	return event.setAddress("dst", "c6a3", destinationIPv6Label, ip)
+}
+
+// SetDeviceAddress stores the address of the device generating the event,
+// an IPv4 address in dvc and an IPv6 address in c6a1 labeled "Device IPv6 Address".
+//
+// Returns:
+// - An error if ip is no valid IP address.
+func (event *CefEvent) SetDeviceAddress(ip net.IP) error {
+	return event.setAddress("dvc", "c6a1", deviceIPv6Label, ip)
+}
+
+// setPort stores a port number in key.
+func (event *CefEvent) setPort(key string, port int) error {
+
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("port %s must be between 0 and 65535, got %d", key, port)
+	}
+
+	event.AddInt(key, int64(port))
+
+	return nil
+}
+
+// SetSourcePort stores the port of the source in spt.
+//
+// Returns:
+// - An error if port is not between 0 and 65535.
+func (event *CefEvent) SetSourcePort(port int) error {
+	return event.setPort("spt", port)
+}
+
+// SetDestinationPort stores the port of the destination in dpt.
+//
+// Returns:
+// - An error if port is not between 0 and 65535.
+func (event *CefEvent) SetDestinationPort(port int) error {
+	return event.setPort("dpt", port)
+}
+
+// setMAC stores a MAC address in key in colon separated notation.
+func (event *CefEvent) setMAC(key string, mac net.HardwareAddr) error {
+
+	if len(mac) == 0 {
+		return fmt.Errorf("MAC address %s is empty", key)
+	}
+
+	event.setExtension(key, mac.String())
+
+	return nil
+}
+
+// SetSourceMAC stores the MAC address of the source in smac.
+//
+// Returns:
+// - An error if mac is empty.
+func (event *CefEvent) SetSourceMAC(mac net.HardwareAddr) error {
+	return event.setMAC("smac", mac)
+}
+
+// SetDestinationMAC stores the MAC address of the destination in dmac.
+//
+// Returns:
+// - An error if mac is empty.
+func (event *CefEvent) SetDestinationMAC(mac net.HardwareAddr) error {
+	return event.setMAC("dmac", mac)
+}
+
+// SetSourceHostName stores the host name of the source in shost.
+func (event *CefEvent) SetSourceHostName(name string) {
+	event.setExtension("shost", name)
+}
+
+// SetDestinationHostName stores the host name of the destination in dhost.
+func (event *CefEvent) SetDestinationHostName(name string) {
+	event.setExtension("dhost", name)
+}
+
+// SetSourceUserName stores the name of the source user in suser.
+func (event *CefEvent) SetSourceUserName(name string) {
+	event.setExtension("suser", name)
+}
+
+// SetDestinationUserName stores the name of the destination user in duser.
+func (event *CefEvent) SetDestinationUserName(name string) {
+	event.setExtension("duser", name)
+}
+
+// SetStartTime stores the time the activity of the event started in start,
+// as milliseconds since the epoch.
+func (event *CefEvent) SetStartTime(t time.Time) {
+	event.AddInt("start", t.UnixMilli())
+}
+
+// SetEndTime stores the time the activity of the event ended in end, as
+// milliseconds since the epoch.
+func (event *CefEvent) SetEndTime(t time.Time) {
+	event.AddInt("end", t.UnixMilli())
+}
+
+// SetReceiptTime stores the time the event was received in rt, as
+// milliseconds since the epoch, see EventTime.
+func (event *CefEvent) SetReceiptTime(t time.Time) {
+	event.AddInt("rt", t.UnixMilli())
+}
+
+// setByteCount stores a number of bytes in key, which is an Integer of at
+// most 2^31-1 by the CEF specification.
+func (event *CefEvent) setByteCount(key string, n uint64) error {
+
+	if n > math.MaxInt32 {
+		return fmt.Errorf("byte count %s must not exceed %d, got %d", key, math.MaxInt32, n)
+	}
+
+	event.setExtension(key, strconv.FormatUint(n, 10))
+
+	return nil
+}
+
+// SetBytesIn stores the number of bytes transferred inbound, relative to
+// the source, in in.
+//
+// Returns:
+// - An error if n exceeds 2^31-1, the largest Integer of the CEF specification.
+func (event *CefEvent) SetBytesIn(n uint64) error {
+	return event.setByteCount("in", n)
+}
+
+// SetBytesOut stores the number of bytes transferred outbound, relative to
+// the source, in out.
+//
+// Returns:
+// - An error if n exceeds 2^31-1, the largest Integer of the CEF specification.
+func (event *CefEvent) SetBytesOut(n uint64) error {
+	return event.setByteCount("out", n)
+}
+
+// SetEventCount stores how many times the event was observed in cnt.
+//
+// Returns:
+// - An error if n is negative.
+func (event *CefEvent) SetEventCount(n int) error {
+
+	if n < 0 {
+		return fmt.Errorf("event count must not be negative, got %d", n)
+	}
+
+	event.AddInt("cnt", int64(n))
+
+	return nil
+}
+
+// customSlots are the number of slots of the custom extensions by prefix.
+var customSlots = map[string]int{
+	"cs":               6,
+	"cn":               3,
+	"cfp":              4,
+	"c6a":              4,
+	"deviceCustomDate": 2,
+}
+
+// customKey returns the key of a slot of a custom extension, e.g. cs1.
+func customKey(prefix string, slot int) (string, error) {
+
+	if slot < 1 || slot > customSlots[prefix] {
+		return "", fmt.Errorf("custom extension %s has the slots 1 to %d, got %d", prefix, customSlots[prefix], slot)
+	}
+
+	return prefix + strconv.Itoa(slot), nil
+}
+
+// setCustom stores value in the custom extension key and label in its
+// label, e.g. cs1 and cs1Label, unless the extension already has another label.
+func (event *CefEvent) setCustom(key string, label string, value string) error {
+
+	if label == "" {
+		return fmt.Errorf("custom extension %s needs a label", key)
+	}
+	if existing, ok := event.Extensions[key+"Label"]; ok && existing != label {
+		return fmt.Errorf("custom extension %s is already labeled %q, not %q", key, existing, label)
+	}
+
+	event.setExtension(key, value)
+	event.setExtension(key+"Label", label)
+
+	return nil
+}
+
+// SetCustomString stores a value no other extension applies to in one of
+// the custom string extensions cs1 to cs6, together with its label in
+// cs1Label to cs6Label. A slot may be set again with the same label, e.g.
+// to replace its value.
+//
+// Parameters:
+// - slot: The slot, 1 to 6.
+// - label: The label naming the value, e.g. "Policy".
+// - value: The value.
+//
+// Returns:
+// - An error if the slot does not exist, the label is empty or the slot is labeled differently.
+func (event *CefEvent) SetCustomString(slot int, label string, value string) error {
+
+	key, err := customKey("cs", slot)
+	if err != nil {
+		return err
+	}
+
+	return event.setCustom(key, label, value)
+}
+
+// SetCustomNumber stores a number in one of the custom number extensions
+// cn1 to cn3 with its label, see SetCustomString.
+//
+// Parameters:
+// - slot: The slot, 1 to 3.
+// - label: The label naming the number.
+// - n: The number.
+//
+// Returns:
+// - An error if the slot does not exist, the label is empty or the slot is labeled differently.
+func (event *CefEvent) SetCustomNumber(slot int, label string, n int64) error {
+
+	key, err := customKey("cn", slot)
+	if err != nil {
+		return err
+	}
+
+	return event.setCustom(key, label, strconv.FormatInt(n, 10))
+}
+
+// SetCustomFloat stores a floating point number in one of the custom
+// floating point extensions cfp1 to cfp4 with its label, see SetCustomString.
+//
+// Parameters:
+// - slot: The slot, 1 to 4.
+// - label: The label naming the number.
+// - f: The number.
+//
+// Returns:
+// - An error if the slot does not exist, the label is empty or the slot is labeled differently.
+func (event *CefEvent) SetCustomFloat(slot int, label string, f float64) error {
+
+	key, err := customKey("cfp", slot)
+	if err != nil {
+		return err
+	}
+
+	return event.setCustom(key, label, strconv.FormatFloat(f, 'f', -1, 32))
+}
+
+// SetCustomDate stores a time in one of the custom date extensions
+// deviceCustomDate1 and deviceCustomDate2 with its label, as milliseconds
+// since the epoch, see SetCustomString.
+//
+// Parameters:
+// - slot: The slot, 1 or 2.
+// - label: The label naming the time, e.g. "Certificate Expiry".
+// - t: The time.
+//
+// Returns:
+// - An error if the slot does not exist, the label is empty or the slot is labeled differently.
+func (event *CefEvent) SetCustomDate(slot int, label string, t time.Time) error {
+
+	key, err := customKey("deviceCustomDate", slot)
+	if err != nil {
+		return err
+	}
+
+	return event.setCustom(key, label, strconv.FormatInt(t.UnixMilli(), 10))
+}
+
+// SetCustomIPv6 stores an IPv6 address in one of the custom IPv6
+// extensions c6a1 to c6a4 with its label, see SetCustomString. SetSourceAddress,
+// SetDestinationAddress and SetDeviceAddress use c6a2, c6a3 and c6a1 for
+// IPv6 addresses.
+//
+// Parameters:
+// - slot: The slot, 1 to 4.
+// - label: The label naming the address, e.g. "Tunnel Endpoint".
+// - ip: The IPv6 address.
+//
+// Returns:
+// - An error if ip is no IPv6 address, the slot does not exist, the label is empty or the slot is labeled differently.
+func (event *CefEvent) SetCustomIPv6(slot int, label string, ip net.IP) error {
+
+	key, err := customKey("c6a", slot)
+	if err != nil {
+		return err
+	}
+	if len(ip) != net.IPv6len || ip.To4() != nil {
+		return fmt.Errorf("address %s is not a valid IPv6 address", key)
+	}
+
+	return event.setCustom(key, label, ip.String())
+}
@@ -0,0 +1,128 @@
+package cefevent
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCefEventSetters(t *testing.T) {
+
+	var e CefEvent
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	at := time.Date(2024, 1, 2, 10, 0, 0, 123e6, time.UTC)
+
+	for _, err := range []error{
+		e.SetSourceAddress(net.ParseIP("10.0.0.1")),
+		e.SetDestinationAddress(net.ParseIP("2001:db8::2")),
+		e.SetDeviceAddress(net.ParseIP("::ffff:192.168.1.1")),
+		e.SetSourcePort(51234),
+		e.SetDestinationPort(443),
+		e.SetSourceMAC(mac),
+		e.SetEventCount(3),
+		e.SetBytesIn(1<<31 - 1),
+		e.SetBytesOut(0),
+	} {
+		if err != nil {
+			t.Fatalf("setter = %v", err)
+		}
+	}
+	e.SetSourceHostName("ws01")
+	e.SetDestinationHostName("example.com")
+	e.SetSourceUserName("alice")
+	e.SetDestinationUserName("bob")
+	e.SetStartTime(at)
+	e.SetEndTime(at.Add(time.Second))
+	e.SetReceiptTime(at)
+
+	want := map[string]string{
+		"src": "10.0.0.1", "c6a3": "2001:db8::2", "c6a3Label": "Destination IPv6 Address", "dvc": "192.168.1.1",
+		"spt": "51234", "dpt": "443", "smac": "00:1a:2b:3c:4d:5e", "cnt": "3",
+		"shost": "ws01", "dhost": "example.com", "suser": "alice", "duser": "bob",
+		"start": "1704189600123", "end": "1704189601123", "rt": "1704189600123", "in": "2147483647", "out": "0",
+	}
+	if !reflect.DeepEqual(e.Extensions, want) {
+		t.Errorf("Extensions = %v, want %v", e.Extensions, want)
+	}
+	if issues, err := e.ValidateExtensions(ExtensionPolicyWarn); err != nil || len(issues) != 0 {
+		t.Errorf("ValidateExtensions() = %v, %v, want no issues", issues, err)
+	}
+
+	// switching between IPv4 and IPv6 removes the previous address.
+	e.SetSourceAddress(net.ParseIP("2001:db8::1"))
+	e.SetDestinationAddress(net.ParseIP("10.0.0.2"))
+	if _, ok := e.Extensions["src"]; ok || e.Extensions["c6a2"] != "2001:db8::1" {
+		t.Errorf("SetSourceAddress() = %v, want only c6a2", e.Extensions)
+	}
+	if _, ok := e.Extensions["c6a3"]; ok || e.Extensions["c6a3Label"] != "" || e.Extensions["dst"] != "10.0.0.2" {
+		t.Errorf("SetDestinationAddress() = %v, want only dst", e.Extensions)
+	}
+
+	for name, err := range map[string]error{
+		"nil address":   e.SetSourceAddress(nil),
+		"short address": e.SetDestinationAddress(net.IP{1, 2, 3}),
+		"negative port": e.SetSourcePort(-1),
+		"large port":    e.SetDestinationPort(65536),
+		"empty MAC":     e.SetDestinationMAC(nil),
+		"negative cnt":  e.SetEventCount(-1),
+		"large in":      e.SetBytesIn(1 << 31),
+		"large out":     e.SetBytesOut(1 << 40),
+	} {
+		if err == nil {
+			t.Errorf("%s: setter = nil, want an error", name)
+		}
+	}
+}
+
+func TestCefEventSetCustom(t *testing.T) {
+
+	var e CefEvent
+	at := time.UnixMilli(1704189600123)
+
+	for _, err := range []error{
+		e.SetCustomString(1, "Policy", "default"),
+		e.SetCustomString(1, "Policy", "strict"),
+		e.SetCustomString(6, "Rule", "42"),
+		e.SetCustomNumber(3, "Retries", -2),
+		e.SetCustomFloat(4, "Score", 0.75),
+		e.SetCustomDate(2, "Certificate Expiry", at),
+		e.SetCustomIPv6(4, "Tunnel Endpoint", net.ParseIP("2001:db8::4")),
+	} {
+		if err != nil {
+			t.Fatalf("setter = %v", err)
+		}
+	}
+
+	want := map[string]string{
+		"cs1": "strict", "cs1Label": "Policy", "cs6": "42", "cs6Label": "Rule", "cn3": "-2", "cn3Label": "Retries",
+		"cfp4": "0.75", "cfp4Label": "Score", "deviceCustomDate2": "1704189600123", "deviceCustomDate2Label": "Certificate Expiry",
+		"c6a4": "2001:db8::4", "c6a4Label": "Tunnel Endpoint",
+	}
+	if !reflect.DeepEqual(e.Extensions, want) {
+		t.Errorf("Extensions = %v, want %v", e.Extensions, want)
+	}
+	if issues, err := e.ValidateExtensions(ExtensionPolicyError); err != nil {
+		t.Errorf("ValidateExtensions() = %v, %v", issues, err)
+	}
+
+	e.Extensions["c6a2Label"] = "Tunnel Endpoint"
+	for name, err := range map[string]error{
+		"reused slot":       e.SetCustomString(1, "Profile", "x"),
+		"reused IPv6 slot":  e.SetSourceAddress(net.ParseIP("2001:db8::1")),
+		"slot 0":            e.SetCustomString(0, "Policy", "x"),
+		"slot 7":            e.SetCustomString(7, "Policy", "x"),
+		"number slot 4":     e.SetCustomNumber(4, "Retries", 1),
+		"float slot 5":      e.SetCustomFloat(5, "Score", 1),
+		"date slot 3":       e.SetCustomDate(3, "Expiry", at),
+		"IPv4 in IPv6 slot": e.SetCustomIPv6(1, "Tunnel Endpoint", net.ParseIP("10.0.0.1")),
+		"empty label":       e.SetCustomString(2, "", "x"),
+	} {
+		if err == nil {
+			t.Errorf("%s: setter = nil, want an error", name)
+		}
+	}
+	if e.Extensions["cs1"] != "strict" || e.Extensions["c6a2"] != "" {
+		t.Errorf("Extensions = %v, want failed setters to leave them unchanged", e.Extensions)
+	}
+}
@@ -0,0 +1,120 @@
+package cefevent
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink is a destination for rendered events.
+//
+// A Sink declares the wire Format it prefers, the Emitter renders every event
+// in that format before handing it to Send. Sinks shared between multiple
+// emitters must be safe for concurrent use.
+type Sink interface {
+	Format() Format            // Format returns the wire format the sink expects.
+	Send(message string) error // Send delivers a single rendered message to the destination.
+}
+
+// SizeLimitedSink is implemented by sinks which can only deliver messages up
+// to a maximum size, such as UDP datagrams. The Emitter trims the extensions
+// of events exceeding the limit before handing them to the sink, see
+// CefEvent.TrimToSize, and cuts the message as a last resort.
+type SizeLimitedSink interface {
+	Sink
+	MaxMessageSize() int // MaxMessageSize returns the maximum message size in bytes, zero or less means unlimited.
+}
+
+// WriterSink is a Sink writing newline terminated messages to an io.Writer.
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format Format
+}
+
+// NewFileSink opens the file at path for appending, creating it when needed,
+// and returns a WriterSink writing messages in the given format to it.
+//
+// Returns:
+// - A pointer to a WriterSink which closes the file when it is closed.
+// - An error if the file could not be opened.
+func NewFileSink(path string, format Format) (*WriterSink, error) {
+	return NewFileSinkWithOptions(path, format, FileOptions{})
+}
+
+// NewFileSinkWithOptions opens the file at path like NewFileSink, the
+// options set its permission, which defaults to 0644, and ownership or
+// make the sink write the file atomically when it is closed.
+//
+// Parameters:
+// - path: The path of the file.
+// - format: The Format the messages are rendered in.
+// - opts: The FileOptions of the file.
+//
+// Returns:
+// - A pointer to a WriterSink which closes the file when it is closed.
+// - An error if the file could not be opened or the options could not be applied.
+func NewFileSinkWithOptions(path string, format Format, opts FileOptions) (*WriterSink, error) {
+
+	if opts.Atomic {
+		file, err := createAtomic(path, opts, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriterSink(file, format), nil
+	}
+
+	file, err := openAppend(path, opts, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterSink(file, format), nil
+}
+
+// NewWriterSink returns a WriterSink writing messages in the given format to w.
+//
+// Parameters:
+// - w: The io.Writer the messages are written to.
+// - format: The Format the messages are rendered in.
+//
+// Returns:
+// - A pointer to a WriterSink which is safe for concurrent use.
+func NewWriterSink(w io.Writer, format Format) *WriterSink {
+	return &WriterSink{w: w, format: format}
+}
+
+// Format returns the wire format of the WriterSink.
+func (s *WriterSink) Format() Format {
+	return s.format
+}
+
+// Send writes the message followed by a newline to the underlying writer.
+func (s *WriterSink) Send(message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return errors.New("sink has no writer")
+	}
+
+	_, err := io.WriteString(s.w, message+"\n")
+	return err
+}
+
+// Close closes the underlying writer if it implements io.Closer.
+func (s *WriterSink) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closer, ok := s.w.(io.Closer)
+	s.w = nil
+	if ok && closer != os.Stdout && closer != os.Stderr {
+		return closer.Close()
+	}
+
+	return nil
+}
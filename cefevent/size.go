@@ -0,0 +1,66 @@
+package cefevent
+
+// escapedLen returns the length of the value once the special characters
+// are escaped with a backslash, see cefEscapeField and cefEscapeExtension.
+func escapedLen(value string, special func(c byte) bool) int {
+
+	n := len(value)
+	for i := 0; i < len(value); i++ {
+		if special(value[i]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// fieldSpecial reports whether cefEscapeField escapes the byte.
+func fieldSpecial(c byte) bool {
+	return c == '\\' || c == '|' || c == '\n' || c == '\r'
+}
+
+// extensionSpecial reports whether cefEscapeExtension escapes the byte.
+func extensionSpecial(c byte) bool {
+	return c == '\\' || c == '=' || c == '\n' || c == '\r'
+}
+
+// extensionSize returns the length of the rendered "key=value" pair.
+func extensionSize(key, value string) int {
+	return escapedLen(key, extensionSpecial) + 1 + escapedLen(value, extensionSpecial)
+}
+
+// EstimateSize returns the length in bytes of the CEF message String()
+// renders for the event, escaping included, without building the message
+// or allocating, so batching layers can pack events up to the limits of
+// their transport. The size of events String() rejects as invalid is the
+// size they would have if they were valid.
+func (event *CefEvent) EstimateSize() int {
+
+	// the prefix, the version and the pipes following the seven header fields.
+	size := len("CEF:") + cefHeaderFields
+	version := event.Version
+	if version < 0 {
+		size++
+		version = -version
+	}
+	for size++; version >= 10; version /= 10 {
+		size++
+	}
+
+	for _, field := range [...]string{
+		event.DeviceVendor, event.DeviceProduct, event.DeviceVersion,
+		event.DeviceEventClassId, event.Name, event.Severity,
+	} {
+		size += escapedLen(field, fieldSpecial)
+	}
+
+	for k, v := range event.Extensions {
+		size += extensionSize(k, v) + 1
+	}
+	if len(event.Extensions) > 0 {
+		// the pairs are separated by spaces, the last one is not followed by one.
+		size--
+	}
+
+	return size
+}
@@ -0,0 +1,41 @@
+package cefevent
+
+import "testing"
+
+func TestEstimateSize(t *testing.T) {
+
+	escaped := event
+	escaped.Version = 1
+	escaped.DeviceVendor = "Pipe|Back\\slash"
+	escaped.Name = "line\r\nbreak"
+	escaped.Extensions = map[string]string{"msg": "a=b\\c\nd", "src": "10.0.0.1", "k=ey": ""}
+
+	tens := event
+	tens.Version = 10
+
+	tests := []struct {
+		name  string
+		event CefEvent
+	}{
+		{"plain", event},
+		{"escaped", escaped},
+		{"without extensions", CefEvent{DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "1", Name: "N", Severity: "5"}},
+		{"two digit version", tens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := tt.event.String()
+			if err != nil {
+				t.Fatalf("String() = %v", err)
+			}
+			if got := tt.event.EstimateSize(); got != len(message) {
+				t.Errorf("EstimateSize() = %d, want %d for %q", got, len(message), message)
+			}
+		})
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { escaped.EstimateSize() }); allocs != 0 {
+		t.Errorf("EstimateSize() allocates %v times, want 0", allocs)
+	}
+}
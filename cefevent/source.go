@@ -0,0 +1,370 @@
+package cefevent
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSourceClosed is returned by Receive once a Source has been closed.
+var ErrSourceClosed = errors.New("source is closed")
+
+// Envelope carries a received event together with the metadata of its reception.
+//
+// When the raw input could not be parsed, Err holds the parse error and Event
+// is the zero value, the Raw line is always populated so it can be inspected.
+type Envelope struct {
+	Event    CefEvent  // Event is the parsed CEF event.
+	Raw      string    // Raw is the line as it was received.
+	Origin   string    // Origin describes where the event came from, e.g. a file path or remote address.
+	Received time.Time // Received is the moment the event was read from the source.
+	Err      error     // Err is the parse error of Raw, if any.
+}
+
+// newEnvelope parses the raw line and wraps the result in an Envelope.
+func newEnvelope(raw string, origin string) Envelope {
+
+	env := Envelope{Raw: raw, Origin: origin, Received: time.Now()}
+	env.Event, env.Err = Parse(raw)
+
+	return env
+}
+
+// Source is an input producing events, it mirrors the Sink abstraction.
+//
+// Receive blocks until the next event is available. A source which is
+// exhausted returns io.EOF, a source which has been closed ErrSourceClosed.
+// Input which could not be parsed is not a Receive error, it is reported
+// through the Err field of the returned Envelope.
+type Source interface {
+	Receive() (Envelope, error) // Receive returns the next event of the source.
+	Close() error               // Close releases the resources of the source and unblocks Receive.
+}
+
+// ReaderSource is a Source reading newline separated CEF messages from an io.Reader.
+type ReaderSource struct {
+	mu      sync.Mutex
+	decoder *Decoder
+	origin  string
+	closer  io.Closer
+	closed  atomic.Bool
+}
+
+// NewReaderSource returns a Source reading CEF messages from r.
+//
+// Parameters:
+// - r: The io.Reader providing the CEF messages, if it is an io.Closer it is closed by Close.
+// - origin: The description of the input used as Origin of the envelopes.
+//
+// Returns:
+// - A pointer to a ReaderSource.
+func NewReaderSource(r io.Reader, origin string) *ReaderSource {
+
+	source := &ReaderSource{decoder: NewDecoder(r), origin: origin}
+	if closer, ok := r.(io.Closer); ok {
+		source.closer = closer
+	}
+
+	return source
+}
+
+// NewFileSource opens the file at path and returns a Source reading its CEF
+// messages. Compressed files are decompressed transparently, see DecompressReader.
+//
+// Returns:
+// - A pointer to a ReaderSource reading the file.
+// - An error if the file could not be opened or decompressed.
+func NewFileSource(path string) (*ReaderSource, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := DecompressReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return NewReaderSource(struct {
+		io.Reader
+		io.Closer
+	}{r, file}, path), nil
+}
+
+// NewStdinSource returns a Source reading CEF messages from the standard input.
+func NewStdinSource() *ReaderSource {
+	return NewReaderSource(io.NopCloser(os.Stdin), "stdin")
+}
+
+// Receive returns the next event read from the underlying reader.
+func (s *ReaderSource) Receive() (Envelope, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.Load() {
+		return Envelope{}, ErrSourceClosed
+	}
+
+	raw, err := s.decoder.next()
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return Envelope{Raw: raw, Origin: s.origin, Received: time.Now(), Err: err}, nil
+	}
+	if err != nil {
+		if s.closed.Load() {
+			return Envelope{}, ErrSourceClosed
+		}
+		return Envelope{}, err
+	}
+
+	return newEnvelope(raw, s.origin), nil
+}
+
+// Close closes the underlying reader if it implements io.Closer.
+func (s *ReaderSource) Close() error {
+
+	if s.closed.Swap(true) {
+		return nil
+	}
+
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+
+	return nil
+}
+
+// TailSource is a Source following a file as it grows, similar to "tail -F".
+// It starts reading at the end of the file and reopens the file when it has
+// been truncated or replaced by log rotation.
+type TailSource struct {
+	path     string
+	interval time.Duration
+	file     *os.File
+	reader   *bufio.Reader
+	partial  strings.Builder
+	mu       sync.Mutex
+	closed   bool
+	done     chan struct{}
+}
+
+// NewTailSource returns a Source following the file at path.
+//
+// Parameters:
+// - path: The path of the file to follow.
+// - interval: How often the file is polled for new data, defaults to one second when zero.
+//
+// Returns:
+// - A pointer to a TailSource positioned at the end of the file.
+// - An error if the file could not be opened.
+func NewTailSource(path string, interval time.Duration) (*TailSource, error) {
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &TailSource{
+		path:     path,
+		interval: interval,
+		file:     file,
+		reader:   bufio.NewReader(file),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Receive blocks until a complete new line has been appended to the file.
+func (s *TailSource) Receive() (Envelope, error) {
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return Envelope{}, err
+		}
+
+		if line != "" {
+			return newEnvelope(line, s.path), nil
+		}
+
+		select {
+		case <-s.done:
+			return Envelope{}, ErrSourceClosed
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// readLine returns the next complete non-empty line of the file or an empty
+// string when no complete line is available yet.
+func (s *TailSource) readLine() (string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.closed {
+			return "", ErrSourceClosed
+		}
+
+		chunk, err := s.reader.ReadString('\n')
+		s.partial.WriteString(chunk)
+
+		if err == nil {
+			line := strings.TrimRight(s.partial.String(), "\r\n")
+			s.partial.Reset()
+			if line == "" {
+				continue
+			}
+			return line, nil
+		}
+
+		if err != io.EOF {
+			return "", err
+		}
+
+		return "", s.reopenIfRotated()
+	}
+}
+
+// reopenIfRotated reopens the followed file when it has been replaced
+// and rewinds it when it has been truncated.
+func (s *TailSource) reopenIfRotated() error {
+
+	current, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	latest, err := os.Stat(s.path)
+	if err != nil {
+		// the file may be in the middle of a rotation, try again later.
+		return nil
+	}
+
+	if !os.SameFile(current, latest) {
+		file, err := os.Open(s.path)
+		if err != nil {
+			return nil
+		}
+		s.file.Close()
+		s.file = file
+		s.reader.Reset(file)
+		s.partial.Reset()
+		return nil
+	}
+
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if latest.Size() < offset {
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		s.reader.Reset(s.file)
+		s.partial.Reset()
+	}
+
+	return nil
+}
+
+// Close stops following the file and unblocks a pending Receive.
+func (s *TailSource) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+
+	return s.file.Close()
+}
+
+// MessageReader is the minimal interface of a message queue consumer, such
+// as a Kafka consumer group client, a Source can be built upon. Adapting a
+// client library to it only requires returning the payload of the next message.
+type MessageReader interface {
+	ReadMessage() ([]byte, error) // ReadMessage blocks until the next message payload is available.
+	Close() error                 // Close stops the consumer.
+}
+
+// MessageSource is a Source turning the payloads of a MessageReader into events.
+// A payload may contain multiple newline separated CEF messages.
+type MessageSource struct {
+	mu      sync.Mutex
+	reader  MessageReader
+	origin  string
+	pending []string
+}
+
+// NewMessageSource returns a Source consuming CEF messages from reader.
+//
+// Parameters:
+// - reader: The consumer delivering the message payloads, e.g. an adapter around a Kafka client.
+// - origin: The description of the input used as Origin of the envelopes, e.g. the topic name.
+//
+// Returns:
+// - A pointer to a MessageSource.
+func NewMessageSource(reader MessageReader, origin string) *MessageSource {
+	return &MessageSource{reader: reader, origin: origin}
+}
+
+// Receive returns the next event of the consumed messages.
+func (s *MessageSource) Receive() (Envelope, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.pending) == 0 {
+		payload, err := s.reader.ReadMessage()
+		if err != nil {
+			return Envelope{}, err
+		}
+		s.pending = splitLines(string(payload))
+	}
+
+	raw := s.pending[0]
+	s.pending = s.pending[1:]
+
+	return newEnvelope(raw, s.origin), nil
+}
+
+// Close closes the underlying MessageReader.
+func (s *MessageSource) Close() error {
+	return s.reader.Close()
+}
+
+// splitLines splits a payload into its non-empty lines.
+func splitLines(payload string) []string {
+
+	var lines []string
+	for _, line := range strings.Split(payload, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
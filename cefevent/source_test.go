@@ -0,0 +1,391 @@
+package cefevent
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReaderSource(t *testing.T) {
+
+	input := eventLine + "\n\nnot a CEF line\r\n" + eventLine + "\n"
+	source := NewReaderSource(strings.NewReader(input), "test")
+
+	var envs []Envelope
+	for {
+		env, err := source.Receive()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Receive() = %v, want nil", err)
+		}
+		envs = append(envs, env)
+	}
+
+	if len(envs) != 3 {
+		t.Fatalf("Receive() returned %d envelopes, want 3", len(envs))
+	}
+
+	if !reflect.DeepEqual(envs[0].Event, event) || envs[0].Origin != "test" || envs[0].Raw != eventLine {
+		t.Errorf("Receive() = %+v, want the parsed event", envs[0])
+	}
+
+	if envs[1].Err == nil || envs[1].Raw != "not a CEF line" {
+		t.Errorf("Receive() = %+v, want a parse error for the raw line", envs[1])
+	}
+}
+
+func TestReaderSourceClosed(t *testing.T) {
+
+	source := NewReaderSource(strings.NewReader(eventLine), "test")
+	source.Close()
+
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() = %v, want ErrSourceClosed", err)
+	}
+}
+
+type fakeMessageReader struct {
+	messages []string
+}
+
+func (r *fakeMessageReader) ReadMessage() ([]byte, error) {
+	if len(r.messages) == 0 {
+		return nil, io.EOF
+	}
+	message := r.messages[0]
+	r.messages = r.messages[1:]
+	return []byte(message), nil
+}
+
+func (r *fakeMessageReader) Close() error {
+	return nil
+}
+
+func TestMessageSource(t *testing.T) {
+
+	reader := &fakeMessageReader{messages: []string{eventLine + "\n" + eventLine, "", eventLine}}
+	source := NewMessageSource(reader, "topic")
+
+	for i := 0; i < 3; i++ {
+		env, err := source.Receive()
+		if err != nil || env.Err != nil || env.Origin != "topic" {
+			t.Fatalf("Receive() = %+v, %v", env, err)
+		}
+	}
+
+	if _, err := source.Receive(); err != io.EOF {
+		t.Errorf("Receive() = %v, want io.EOF", err)
+	}
+}
+
+func TestTailSource(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "events.log")
+	if err := os.WriteFile(path, []byte("CEF:0|old|line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewTailSource(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewTailSource() = %v", err)
+	}
+	defer source.Close()
+
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	file.WriteString(eventLine[:10])
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		file.WriteString(eventLine[10:] + "\n")
+		file.Close()
+	}()
+
+	env, err := source.Receive()
+	if err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+	if env.Raw != eventLine {
+		t.Errorf("Receive() = %q, want %q", env.Raw, eventLine)
+	}
+
+	// truncate the file and make sure tailing starts over.
+	os.WriteFile(path, []byte(eventLine+"\n"), 0o644)
+	if env, err = source.Receive(); err != nil || env.Raw != eventLine {
+		t.Errorf("Receive() after truncation = %q, %v", env.Raw, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		source.Close()
+	}()
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() = %v, want ErrSourceClosed", err)
+	}
+}
+
+func TestUDPSource(t *testing.T) {
+
+	source, err := NewUDPSource("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewUDPSource() = %v", err)
+	}
+	defer source.Close()
+
+	conn, err := net.Dial("udp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(eventLine + "\n" + eventLine))
+
+	for i := 0; i < 2; i++ {
+		env, err := source.Receive()
+		if err != nil || !reflect.DeepEqual(env.Event, event) {
+			t.Fatalf("Receive() = %+v, %v", env, err)
+		}
+	}
+
+	source.Close()
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() = %v, want ErrSourceClosed", err)
+	}
+}
+
+func TestTCPSource(t *testing.T) {
+
+	source, err := NewTCPSource("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewTCPSource() = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(eventLine + "\r\n" + eventLine + "\n"))
+
+	for i := 0; i < 2; i++ {
+		env, err := source.Receive()
+		if err != nil || !reflect.DeepEqual(env.Event, event) {
+			t.Fatalf("Receive() = %+v, %v", env, err)
+		}
+	}
+
+	source.Close()
+	if _, err := source.Receive(); !errors.Is(err, ErrSourceClosed) {
+		t.Errorf("Receive() = %v, want ErrSourceClosed", err)
+	}
+}
+
+// lateListener returns accepted connections only once it was closed, like
+// a connection accepted just as the TCPSource is closed.
+type lateListener struct {
+	net.Listener
+	closed chan struct{}
+}
+
+func (l *lateListener) Accept() (net.Conn, error) {
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	<-l.closed
+	time.Sleep(50 * time.Millisecond)
+
+	return conn, nil
+}
+
+func (l *lateListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func TestTCPSourceCloseWhileAccepting(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	source := &TCPSource{
+		listener: &lateListener{Listener: listener, closed: make(chan struct{})},
+		opts:     ListenOptions{MaxMessageSize: maxDecoderLineSize},
+		events:   make(chan Envelope),
+		done:     make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	source.wg.Add(1)
+	go source.accept()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		source.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() blocked on a connection accepted while closing")
+	}
+}
+
+// receiveWithin returns the next event of the source or an error once the timeout expired.
+func receiveWithin(source Source, timeout time.Duration) (Envelope, error) {
+
+	type result struct {
+		env Envelope
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		env, err := source.Receive()
+		done <- result{env, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.env, r.err
+	case <-time.After(timeout):
+		return Envelope{}, errors.New("timeout")
+	}
+}
+
+func TestTCPSourceMaxConnections(t *testing.T) {
+
+	source, err := ListenTCP("127.0.0.1:0", ListenOptions{MaxConnections: 1})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+	defer source.Close()
+
+	first, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Write([]byte(eventLine + "\n"))
+	if _, err := receiveWithin(source, time.Second); err != nil {
+		t.Fatalf("Receive() = %v", err)
+	}
+
+	second, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	second.Write([]byte(eventLine + "\n"))
+
+	pending := make(chan error, 1)
+	go func() {
+		_, err := source.Receive()
+		pending <- err
+	}()
+	select {
+	case err := <-pending:
+		t.Fatalf("Receive() = %v, want the second connection to wait for a free worker", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case err := <-pending:
+		if err != nil {
+			t.Errorf("Receive() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Receive() blocked after a worker was freed")
+	}
+}
+
+func TestTCPSourceLimits(t *testing.T) {
+
+	source, err := ListenTCP("127.0.0.1:0", ListenOptions{IdleTimeout: 50 * time.Millisecond, MaxMessageSize: 256})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+	defer source.Close()
+
+	// closed is true when the source closes the connection within a second,
+	// unread data makes it reset the connection instead.
+	closed := func(conn net.Conn) bool {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, err := conn.Read(make([]byte, 1))
+		return errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+	}
+
+	idle, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	idle.Write([]byte("CEF:0|stalled"))
+	if !closed(idle) {
+		t.Error("ListenTCP() kept an idle connection open")
+	}
+
+	long, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer long.Close()
+	long.Write([]byte(eventLine + "\n" + eventLine + " msg=" + strings.Repeat("x", 256) + "\n"))
+	if env, err := receiveWithin(source, time.Second); err != nil || !reflect.DeepEqual(env.Event, event) {
+		t.Errorf("Receive() = %+v, %v, want the message within the limit", env, err)
+	}
+	if !closed(long) {
+		t.Error("ListenTCP() kept a connection sending too long messages open")
+	}
+}
+
+func TestTCPSourceRateLimit(t *testing.T) {
+
+	source, err := ListenTCP("127.0.0.1:0", ListenOptions{MessagesPerSecond: 20, Burst: 1})
+	if err != nil {
+		t.Fatalf("ListenTCP() = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", source.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(strings.Repeat(eventLine+"\n", 4)))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := receiveWithin(source, time.Second); err != nil {
+			t.Fatalf("Receive() = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Receive() returned 3 events in %v, want them limited to 20 per second", elapsed)
+	}
+
+	// the fourth message waits for the limit, which must not delay Close.
+	closing := make(chan struct{})
+	go func() {
+		source.Close()
+		close(closing)
+	}()
+	select {
+	case <-closing:
+	case <-time.After(time.Second):
+		t.Error("Close() blocked on a rate limited connection")
+	}
+}
@@ -0,0 +1,149 @@
+package cefevent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StateStore persists the state of stateful stages, the fingerprints of a
+// Suppressor and the window histogram of an AdaptiveSampler, so a restart
+// neither floods the SIEM with duplicates nor loses the counts of a window.
+//
+// It is deliberately small so that a Redis client can be adapted to it in a
+// few lines, without this package depending on one:
+//   - Load maps onto GET, a missing key (redis.Nil) is returned as nil state.
+//   - Save maps onto SET, with an expiry longer than the save interval if desired.
+type StateStore interface {
+	Load(ctx context.Context, key string) ([]byte, error)     // Load returns the state saved under key, or nil if there is none.
+	Save(ctx context.Context, key string, state []byte) error // Save replaces the state saved under key.
+}
+
+// StatefulStage is a stage whose state can be saved to and restored from a
+// StateStore, i.e. a *Suppressor or an *AdaptiveSampler.
+type StatefulStage interface {
+	SaveState(ctx context.Context, store StateStore, key string) error
+	LoadState(ctx context.Context, store StateStore, key string) error
+}
+
+// FileStateStore is a StateStore keeping every key in a file of a directory.
+// The files are replaced atomically, a crash while saving leaves the state
+// saved before intact.
+type FileStateStore struct {
+	dir  string
+	opts FileOptions
+}
+
+// NewFileStateStore returns a FileStateStore saving the state in dir.
+//
+// Parameters:
+// - dir: The directory holding the state files, it is created when needed.
+// - opts: The permissions and ownership of the state files, defaults to 0600.
+//
+// Returns:
+// - A pointer to a FileStateStore.
+// - An error if the directory could not be created.
+func NewFileStateStore(dir string, opts FileOptions) (*FileStateStore, error) {
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileStateStore{dir: dir, opts: opts}, nil
+}
+
+// path returns the file of key, keys must not leave the directory.
+func (s *FileStateStore) path(key string) (string, error) {
+
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid state key %q", key)
+	}
+
+	return filepath.Join(s.dir, key+".state"), nil
+}
+
+// Load returns the state saved under key, or nil if there is none.
+func (s *FileStateStore) Load(_ context.Context, key string) ([]byte, error) {
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return state, err
+}
+
+// Save replaces the state saved under key.
+func (s *FileStateStore) Save(_ context.Context, key string, state []byte) error {
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, state, s.opts, 0o600)
+}
+
+// RestoreState restores the state of the stage from the store. It is meant
+// to be called before the Pipeline is run, so no event is handled by the
+// stage before its state was restored.
+//
+// Parameters:
+// - ctx: Bounds the restore.
+// - store: The StateStore, e.g. a FileStateStore or an adapter around a Redis client.
+// - key: The key of the stage in the store, unique per stage and instance.
+// - stage: The *Suppressor or *AdaptiveSampler.
+//
+// Returns:
+// - An error if the state could not be restored.
+func RestoreState(ctx context.Context, store StateStore, key string, stage StatefulStage) error {
+
+	if err := stage.LoadState(ctx, store, key); err != nil {
+		return fmt.Errorf("restoring state %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// PersistState saves the state of the stage every interval until ctx is
+// done, when it is saved a last time. It is meant to run in its own
+// goroutine once the state was restored with RestoreState.
+//
+// Parameters:
+// - ctx: Stops persisting, the state is saved once more then.
+// - store: The StateStore the state was restored from.
+// - key: The key of the stage in the store, unique per stage and instance.
+// - stage: The *Suppressor or *AdaptiveSampler.
+// - interval: The time between two saves, defaults to ten seconds.
+//
+// Returns:
+// - The error of the last save.
+func PersistState(ctx context.Context, store StateStore, key string, stage StatefulStage, interval time.Duration) error {
+
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the context is done already, the last save must not be cut short by it.
+			return stage.SaveState(context.WithoutCancel(ctx), store, key)
+		case <-ticker.C:
+			// a failed save is retried with the next tick.
+			stage.SaveState(ctx, store, key)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cefevent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileStateStore(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "state")
+	store, err := NewFileStateStore(dir, FileOptions{})
+	if err != nil {
+		t.Fatalf("NewFileStateStore() = %v", err)
+	}
+	ctx := context.Background()
+
+	if state, err := store.Load(ctx, "missing"); state != nil || err != nil {
+		t.Errorf("Load() = %q, %v, want nil, nil", state, err)
+	}
+
+	if err := store.Save(ctx, "suppressor", []byte("v1")); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+	store.Save(ctx, "suppressor", []byte("v2"))
+	if state, err := store.Load(ctx, "suppressor"); string(state) != "v2" || err != nil {
+		t.Errorf("Load() = %q, %v, want v2", state, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "suppressor.state")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("state file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	for _, key := range []string{"", "..", "../escape", `a\b`} {
+		if err := store.Save(ctx, key, nil); err == nil {
+			t.Errorf("Save(%q) = nil, want an error", key)
+		}
+	}
+}
+
+func TestSuppressorState(t *testing.T) {
+
+	store, _ := NewFileStateStore(t.TempDir(), FileOptions{})
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+
+	expired := event
+	expired.Name = "expired"
+
+	before := NewSuppressor(time.Minute, 0)
+	before.now = func() time.Time { return now }
+	before.Duplicate(expired)
+	now = now.Add(30 * time.Second)
+	before.Duplicate(event)
+	before.Duplicate(event)
+
+	if err := before.SaveState(ctx, store, "suppressor"); err != nil {
+		t.Fatalf("SaveState() = %v", err)
+	}
+
+	now = now.Add(40 * time.Second)
+	after := NewSuppressor(time.Minute, 0)
+	after.now = func() time.Time { return now }
+	if err := after.LoadState(ctx, store, "suppressor"); err != nil {
+		t.Fatalf("LoadState() = %v", err)
+	}
+
+	if after.Len() != 1 || after.Passed() != 2 || after.Suppressed() != 1 {
+		t.Errorf("Len() = %d, Passed() = %d, Suppressed() = %d, want 1, 2, 1", after.Len(), after.Passed(), after.Suppressed())
+	}
+	if !after.Duplicate(event) {
+		t.Error("Duplicate() = false after restoring the state, want true")
+	}
+	if after.Duplicate(expired) {
+		t.Error("Duplicate() = true for an expired fingerprint, want false")
+	}
+
+	if err := NewSuppressor(time.Minute, 0).LoadState(ctx, store, "missing"); err != nil {
+		t.Errorf("LoadState() = %v for a missing state, want nil", err)
+	}
+}
+
+func TestAdaptiveSamplerState(t *testing.T) {
+
+	store, _ := NewFileStateStore(t.TempDir(), FileOptions{})
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+
+	before := NewAdaptiveSampler(SamplerOptions{Budget: 10})
+	before.now = func() time.Time { return now }
+	for i := 0; i < 40; i++ {
+		before.Sample(event)
+	}
+	if err := before.SaveState(ctx, store, "sampler"); err != nil {
+		t.Fatalf("SaveState() = %v", err)
+	}
+
+	after := NewAdaptiveSampler(SamplerOptions{Budget: 10})
+	after.now = func() time.Time { return now }
+	if err := after.LoadState(ctx, store, "sampler"); err != nil {
+		t.Fatalf("LoadState() = %v", err)
+	}
+
+	// the histogram of the window before the restart decides the rates of the next one.
+	now = now.Add(time.Second)
+	before.Sample(event)
+	after.Sample(event)
+	if got, want := after.Rates(), before.Rates(); !reflect.DeepEqual(got, want) || got[0] != 4 {
+		t.Errorf("Rates() = %v, want %v", got, want)
+	}
+}
+
+func TestPersistState(t *testing.T) {
+
+	store, _ := NewFileStateStore(t.TempDir(), FileOptions{})
+	suppressor := NewSuppressor(time.Hour, 0)
+
+	if err := RestoreState(context.Background(), store, "suppressor", suppressor); err != nil {
+		t.Fatalf("RestoreState() = %v, want nil without saved state", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- PersistState(ctx, store, "suppressor", suppressor, time.Hour) }()
+
+	suppressor.Duplicate(event)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("PersistState() = %v", err)
+	}
+
+	restored := NewSuppressor(time.Hour, 0)
+	if err := RestoreState(context.Background(), store, "suppressor", restored); err != nil {
+		t.Fatalf("RestoreState() = %v", err)
+	}
+	if !restored.Duplicate(event) {
+		t.Error("Duplicate() = false, want the state saved when the context was done")
+	}
+
+	store.Save(context.Background(), "broken", []byte("garbage"))
+	if err := RestoreState(context.Background(), store, "broken", NewSuppressor(time.Hour, 0)); err == nil {
+		t.Error("RestoreState() = nil, want an error for a corrupt state")
+	}
+}
@@ -0,0 +1,278 @@
+package cefevent
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fingerprint returns a hash identifying the content of the event, events
+// with the same header fields and extensions have the same fingerprint
+// regardless of the order the extensions were added in.
+func (event *CefEvent) Fingerprint() uint64 {
+
+	h := fnv.New64a()
+
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	write(strconv.Itoa(event.Version))
+	write(event.DeviceVendor)
+	write(event.DeviceProduct)
+	write(event.DeviceVersion)
+	write(event.DeviceEventClassId)
+	write(event.Name)
+	write(event.Severity)
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		write(k)
+		write(event.Extensions[k])
+	}
+
+	return h.Sum64()
+}
+
+// suppressEntry is a fingerprint remembered by the Suppressor.
+type suppressEntry struct {
+	fingerprint uint64
+	expires     time.Time
+	suppressed  uint64
+}
+
+// Suppressor drops exact duplicate events, events with the same
+// Fingerprint, which are seen again within a TTL of the first occurrence.
+//
+// The number of remembered fingerprints is bounded, when the capacity is
+// reached the least recently seen fingerprint is forgotten. A Suppressor is
+// safe for concurrent use.
+type Suppressor struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	capacity   int
+	order      *list.List
+	entries    map[uint64]*list.Element
+	passed     uint64
+	suppressed uint64
+	audit      bool
+	now        func() time.Time
+}
+
+// NewSuppressor returns a Suppressor remembering up to capacity fingerprints for ttl.
+//
+// Parameters:
+// - ttl: How long a duplicate of an event is suppressed after the event was first seen.
+// - capacity: The maximum number of remembered fingerprints, defaults to 10000 when zero or less.
+//
+// Returns:
+// - A pointer to a Suppressor.
+func NewSuppressor(ttl time.Duration, capacity int) *Suppressor {
+
+	if capacity <= 0 {
+		capacity = 10000
+	}
+
+	return &Suppressor{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[uint64]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// SetAudit enables stamping the audit extensions, see AuditPolicyKey, on
+// events passed on after duplicates of them were suppressed. The number of
+// suppressed duplicates is reported with the next occurrence of the event
+// after the TTL, duplicates of events which never occur again or whose
+// fingerprint was forgotten are not accounted for.
+func (s *Suppressor) SetAudit(enabled bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit = enabled
+}
+
+// Duplicate reports whether the event is a duplicate of an event seen within
+// the TTL and otherwise remembers it.
+func (s *Suppressor) Duplicate(event CefEvent) bool {
+
+	duplicate, _ := s.check(event)
+
+	return duplicate
+}
+
+// check reports whether the event is a duplicate like Duplicate, for events
+// which are not it returns the number of duplicates suppressed since the
+// event was passed on before.
+func (s *Suppressor) check(event CefEvent) (bool, uint64) {
+
+	fingerprint := event.Fingerprint()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if element, ok := s.entries[fingerprint]; ok {
+		entry := element.Value.(*suppressEntry)
+		if now.Before(entry.expires) {
+			s.order.MoveToFront(element)
+			s.suppressed++
+			entry.suppressed++
+			return true, 0
+		}
+		suppressed := entry.suppressed
+		entry.expires = now.Add(s.ttl)
+		entry.suppressed = 0
+		s.order.MoveToFront(element)
+		s.passed++
+		return false, suppressed
+	}
+
+	s.entries[fingerprint] = s.order.PushFront(&suppressEntry{fingerprint: fingerprint, expires: now.Add(s.ttl)})
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*suppressEntry).fingerprint)
+	}
+
+	s.passed++
+	return false, 0
+}
+
+// Middleware returns a Middleware dropping the duplicate events, with
+// auditing enabled it stamps the audit extensions on the events passed on.
+func (s *Suppressor) Middleware() Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			duplicate, suppressed := s.check(event)
+			if duplicate {
+				return nil
+			}
+
+			s.mu.Lock()
+			audit := s.audit
+			s.mu.Unlock()
+
+			if audit && suppressed > 0 {
+				event = stampAudit(event, AuditPolicySuppression, 0, suppressed, s.ttl)
+			}
+
+			return next(event)
+		}
+	}
+}
+
+// Passed returns the number of events which were not duplicates.
+func (s *Suppressor) Passed() uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.passed
+}
+
+// Suppressed returns the number of duplicate events which were dropped.
+func (s *Suppressor) Suppressed() uint64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.suppressed
+}
+
+// Len returns the number of currently remembered fingerprints.
+func (s *Suppressor) Len() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// suppressorState is the saved state of a Suppressor.
+type suppressorState struct {
+	Passed     uint64               `json:"passed"`
+	Suppressed uint64               `json:"suppressed"`
+	Entries    []suppressEntryState `json:"entries"` // Entries are ordered from the most to the least recently seen.
+}
+
+// suppressEntryState is a saved suppressEntry.
+type suppressEntryState struct {
+	Fingerprint uint64    `json:"fingerprint"`
+	Expires     time.Time `json:"expires"`
+	Suppressed  uint64    `json:"suppressed,omitempty"`
+}
+
+// SaveState saves the remembered fingerprints and the counters to the
+// store, see PersistState.
+func (s *Suppressor) SaveState(ctx context.Context, store StateStore, key string) error {
+
+	s.mu.Lock()
+	state := suppressorState{Passed: s.passed, Suppressed: s.suppressed, Entries: make([]suppressEntryState, 0, s.order.Len())}
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*suppressEntry)
+		state.Entries = append(state.Entries, suppressEntryState{Fingerprint: entry.fingerprint, Expires: entry.expires, Suppressed: entry.suppressed})
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return store.Save(ctx, key, data)
+}
+
+// LoadState replaces the remembered fingerprints and the counters with
+// those saved to the store, fingerprints which expired in the meantime or
+// exceed the capacity are dropped. Nothing is changed if no state was saved.
+func (s *Suppressor) LoadState(ctx context.Context, store StateStore, key string) error {
+
+	data, err := store.Load(ctx, key)
+	if err != nil || data == nil {
+		return err
+	}
+
+	var state suppressorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("malformed suppressor state: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.passed, s.suppressed = state.Passed, state.Suppressed
+	s.order.Init()
+	s.entries = make(map[uint64]*list.Element)
+
+	for _, entry := range state.Entries {
+		if s.order.Len() >= s.capacity {
+			break
+		}
+		if _, ok := s.entries[entry.Fingerprint]; ok || !now.Before(entry.Expires) {
+			continue
+		}
+		s.entries[entry.Fingerprint] = s.order.PushBack(&suppressEntry{fingerprint: entry.Fingerprint, expires: entry.Expires, suppressed: entry.Suppressed})
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package cefevent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCefEventFingerprint(t *testing.T) {
+
+	a := event
+	a.Extensions = map[string]string{"src": "127.0.0.1", "dst": "127.0.0.2"}
+	b := event
+	b.Extensions = map[string]string{"dst": "127.0.0.2", "src": "127.0.0.1"}
+	c := event
+	c.Extensions = map[string]string{"src": "127.0.0.12", "dst": "7.0.0.2"}
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Errorf("Fingerprint() differs for events with equal content")
+	}
+
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Errorf("Fingerprint() equal for events with different content")
+	}
+}
+
+func TestSuppressorTTL(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	suppressor := NewSuppressor(time.Minute, 0)
+	suppressor.now = func() time.Time { return now }
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+	emitter.Use(suppressor.Middleware())
+
+	emitter.Emit(event)
+	emitter.Emit(event)
+
+	now = now.Add(30 * time.Second)
+	emitter.Emit(event)
+
+	now = now.Add(31 * time.Second)
+	emitter.Emit(event)
+
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Errorf("emitted %d events, want 2", got)
+	}
+
+	if suppressor.Passed() != 2 || suppressor.Suppressed() != 2 {
+		t.Errorf("Passed() = %d, Suppressed() = %d, want 2, 2", suppressor.Passed(), suppressor.Suppressed())
+	}
+}
+
+func TestSuppressorCapacity(t *testing.T) {
+
+	suppressor := NewSuppressor(time.Hour, 2)
+
+	first := event
+	second := event
+	second.Name = "second"
+	third := event
+	third.Name = "third"
+
+	suppressor.Duplicate(first)
+	suppressor.Duplicate(second)
+	suppressor.Duplicate(third)
+
+	if suppressor.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", suppressor.Len())
+	}
+
+	// the first event was evicted as least recently seen one.
+	if suppressor.Duplicate(first) {
+		t.Errorf("Duplicate() = true for an evicted fingerprint")
+	}
+
+	if !suppressor.Duplicate(third) {
+		t.Errorf("Duplicate() = false for a remembered fingerprint")
+	}
+}
+
+func TestSuppressorAudit(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	suppressor := NewSuppressor(time.Minute, 0)
+	suppressor.now = func() time.Time { return now }
+	suppressor.SetAudit(true)
+
+	var passed []CefEvent
+	handler := suppressor.Middleware()(func(e CefEvent) error {
+		passed = append(passed, e)
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		handler(event)
+	}
+	now = now.Add(2 * time.Minute)
+	handler(event)
+	now = now.Add(2 * time.Minute)
+	handler(event)
+
+	if len(passed) != 3 {
+		t.Fatalf("passed %d events, want 3", len(passed))
+	}
+	if _, ok := passed[0].Extensions[AuditPolicyKey]; ok {
+		t.Errorf("first event = %v, want no audit extensions", passed[0].Extensions)
+	}
+	if got := passed[1].Extensions; got[AuditPolicyKey] != AuditPolicySuppression || got[AuditDroppedKey] != "2" || got[AuditWindowKey] != "60000" || got[AuditRateKey] != "" {
+		t.Errorf("event after suppression = %v, want 2 suppressed duplicates", got)
+	}
+	if _, ok := passed[2].Extensions[AuditPolicyKey]; ok {
+		t.Errorf("event without suppressed duplicates = %v, want no audit extensions", passed[2].Extensions)
+	}
+	if _, ok := event.Extensions[AuditPolicyKey]; ok {
+		t.Errorf("the original event was modified")
+	}
+}
@@ -0,0 +1,255 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogFacilityUser is the syslog facility (user-level messages) that is
+// used when wrapping CEF messages into a syslog header.
+const syslogFacilityUser = 1
+
+// syslogHostname resolves the hostname used in syslog headers once.
+var syslogHostname = sync.OnceValue(func() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "-"
+	}
+	return hostname
+})
+
+// syslogSeverity maps the CEF severity, either numeric (0-10) or one of
+// Low, Medium, High and Very-High, onto a syslog severity level.
+func syslogSeverity(severity string) int {
+
+	switch severity {
+	case "Low":
+		return 6
+	case "Medium":
+		return 4
+	case "High":
+		return 3
+	case "Very-High":
+		return 2
+	}
+
+	level, err := strconv.Atoi(severity)
+	if err != nil {
+		return 6
+	}
+
+	switch {
+	case level >= 9:
+		return 2
+	case level >= 7:
+		return 3
+	case level >= 4:
+		return 4
+	}
+	return 6
+}
+
+// ToSyslog renders the event as CEF message wrapped in a RFC 3164 syslog header,
+// e.g. "<14>Jan  2 15:04:05 host CEF:0|...". The syslog severity is derived from
+// the CEF severity of the event and the local hostname is used as syslog host.
+//
+// Returns:
+// - A string containing the syslog framed CEF message.
+// - An error if the event is not valid.
+func (event *CefEvent) ToSyslog() (string, error) {
+
+	message, err := event.String()
+	if err != nil {
+		return "", err
+	}
+
+	priority := syslogFacilityUser*8 + syslogSeverity(event.Severity)
+
+	return fmt.Sprintf(
+		"<%d>%s %s %s",
+		priority,
+		time.Now().Format(time.Stamp),
+		syslogHostname(),
+		message,
+	), nil
+}
+
+// SyslogMessage is a CEF message received with a syslog header, either in
+// the RFC 3164 (BSD) or the RFC 5424 format.
+type SyslogMessage struct {
+	Priority  int       // Priority is the PRI value of the header, -1 if the header has none.
+	Timestamp time.Time // Timestamp is the time of the header, zero if it has none.
+	Hostname  string    // Hostname is the host of the header, empty if it has none.
+	AppName   string    // AppName is the APP-NAME of RFC 5424 headers or the tag of RFC 3164 headers.
+	ProcID    string    // ProcID is the PROCID of RFC 5424 headers or the pid of RFC 3164 tags.
+	MsgID     string    // MsgID is the MSGID of RFC 5424 headers.
+	Event     CefEvent  // Event is the parsed CEF message.
+}
+
+// Facility returns the syslog facility encoded in the priority, -1 if the header has none.
+func (m SyslogMessage) Facility() int {
+	if m.Priority < 0 {
+		return -1
+	}
+	return m.Priority / 8
+}
+
+// Severity returns the syslog severity encoded in the priority, -1 if the header has none.
+func (m SyslogMessage) Severity() int {
+	if m.Priority < 0 {
+		return -1
+	}
+	return m.Priority % 8
+}
+
+// ParseSyslog parses a CEF message wrapped in a syslog header, as most
+// devices send them, e.g. "<134>Jan 12 10:00:00 host CEF:0|..." or
+// "<134>1 2024-01-12T10:00:00Z host app - - - CEF:0|...". The syslog
+// metadata is returned next to the parsed event. The header is optional,
+// plain CEF messages are parsed as well.
+//
+// Returns:
+// - A SyslogMessage holding the header fields and the parsed CEF event.
+// - An error if the header is malformed, no CEF message follows it or the CEF message is invalid.
+func ParseSyslog(line string) (SyslogMessage, error) {
+
+	m := SyslogMessage{Priority: -1}
+	rest := strings.TrimRight(line, "\r\n")
+
+	if strings.HasPrefix(rest, "<") {
+		end := strings.IndexByte(rest, '>')
+		if end < 2 || end > 4 {
+			return m, errors.New("syslog header has an invalid priority")
+		}
+		priority, err := strconv.Atoi(rest[1:end])
+		if err != nil || priority > 191 {
+			return m, errors.New("syslog header has an invalid priority")
+		}
+		m.Priority = priority
+		rest = rest[end+1:]
+	}
+
+	var err error
+	if strings.HasPrefix(rest, "1 ") {
+		rest, err = m.parseRFC5424(rest[2:])
+	} else {
+		rest = m.parseRFC3164(rest)
+	}
+	if err != nil {
+		return m, err
+	}
+
+	start := strings.Index(rest, "CEF:")
+	if start < 0 {
+		return m, errors.New("syslog message does not contain a CEF message")
+	}
+
+	m.Event, err = Parse(rest[start:])
+
+	return m, err
+}
+
+// parseRFC5424 parses the RFC 5424 header following the version and returns the message.
+func (m *SyslogMessage) parseRFC5424(header string) (string, error) {
+
+	fields := strings.SplitN(header, " ", 6)
+	if len(fields) < 6 {
+		return "", errors.New("syslog header is incomplete")
+	}
+
+	nilValue := func(s string) string {
+		if s == "-" {
+			return ""
+		}
+		return s
+	}
+
+	if fields[0] != "-" {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return "", fmt.Errorf("syslog header has an invalid timestamp: %w", err)
+		}
+		m.Timestamp = t
+	}
+	m.Hostname = nilValue(fields[1])
+	m.AppName = nilValue(fields[2])
+	m.ProcID = nilValue(fields[3])
+	m.MsgID = nilValue(fields[4])
+
+	// skip the structured data, "-" or one or more "[...]" elements whose
+	// quoted parameter values may contain escaped brackets.
+	rest := fields[5]
+	if strings.HasPrefix(rest, "-") {
+		return strings.TrimPrefix(rest[1:], " "), nil
+	}
+
+	inQuotes := false
+	depth := 0
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '\\' && inQuotes:
+			i++
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == '[' && !inQuotes:
+			depth++
+		case c == ']' && !inQuotes:
+			depth--
+			if depth == 0 && (i+1 == len(rest) || rest[i+1] != '[') {
+				return strings.TrimPrefix(strings.TrimPrefix(rest[i+1:], " "), "\ufeff"), nil
+			}
+		case depth == 0:
+			return "", errors.New("syslog header has invalid structured data")
+		}
+	}
+
+	return "", errors.New("syslog header has unterminated structured data")
+}
+
+// parseRFC3164 parses the optional timestamp, hostname and tag of a RFC 3164
+// header and returns the message. RFC 3164 headers are loosely defined, the
+// fields which are not recognized remain part of the message. The timestamp
+// lacks year and zone, it is taken as local time of the current year.
+func (m *SyslogMessage) parseRFC3164(header string) string {
+
+	if len(header) >= len(time.Stamp) {
+		if t, err := time.ParseInLocation(time.Stamp, header[:len(time.Stamp)], time.Local); err == nil {
+			now := time.Now()
+			t = t.AddDate(now.Year(), 0, 0)
+			// messages from the end of december arriving in january are from the previous year.
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.Timestamp = t
+			header = strings.TrimPrefix(header[len(time.Stamp):], " ")
+		}
+	}
+
+	if strings.HasPrefix(header, "CEF:") {
+		return header
+	}
+
+	host, rest, ok := strings.Cut(header, " ")
+	if !ok {
+		return header
+	}
+	m.Hostname = host
+
+	// an optional tag such as "app[123]:" precedes the message.
+	if tag, message, ok := strings.Cut(rest, " "); ok && strings.HasSuffix(tag, ":") && !strings.HasPrefix(tag, "CEF:") {
+		tag = strings.TrimSuffix(tag, ":")
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			m.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		m.AppName = tag
+		rest = message
+	}
+
+	return rest
+}
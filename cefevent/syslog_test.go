@@ -0,0 +1,102 @@
+package cefevent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog(t *testing.T) {
+
+	var tests = []struct {
+		line string
+		want SyslogMessage
+	}{
+		{
+			"<134>Jan 12 10:00:00 fw01 " + eventLine,
+			SyslogMessage{Priority: 134, Hostname: "fw01"},
+		},
+		{
+			"<13>Jan  2 10:00:00 fw01 cefd[4711]: " + eventLine,
+			SyslogMessage{Priority: 13, Hostname: "fw01", AppName: "cefd", ProcID: "4711"},
+		},
+		{
+			"<134>1 2024-01-12T10:00:00.123Z fw01 cefd 4711 ID47 - " + eventLine,
+			SyslogMessage{Priority: 134, Timestamp: time.Date(2024, 1, 12, 10, 0, 0, 123000000, time.UTC), Hostname: "fw01", AppName: "cefd", ProcID: "4711", MsgID: "ID47"},
+		},
+		{
+			`<134>1 - - - - - [meta x="a\]b"][origin ip="10.0.0.1"] ` + eventLine,
+			SyslogMessage{Priority: 134},
+		},
+		{
+			eventLine,
+			SyslogMessage{Priority: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSyslog(tt.line)
+		if err != nil {
+			t.Errorf("ParseSyslog(%q) = %v", tt.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got.Event, event) {
+			t.Errorf("ParseSyslog(%q).Event = %+v, want %+v", tt.line, got.Event, event)
+		}
+
+		// RFC 3164 timestamps lack the year, only the time of day is compared.
+		if strings.HasPrefix(tt.line, "<13>") && got.Timestamp.Format(time.Stamp) != tt.line[strings.IndexByte(tt.line, '>')+1:][:len(time.Stamp)] {
+			t.Errorf("ParseSyslog(%q).Timestamp = %v", tt.line, got.Timestamp)
+		}
+		if tt.want.Timestamp.IsZero() {
+			got.Timestamp = time.Time{}
+		}
+		got.Event = CefEvent{}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSyslog(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseSyslogPriority(t *testing.T) {
+
+	m, _ := ParseSyslog("<134>Jan 12 10:00:00 fw01 " + eventLine)
+	if m.Facility() != 16 || m.Severity() != 6 {
+		t.Errorf("Facility(), Severity() = %d, %d, want 16, 6", m.Facility(), m.Severity())
+	}
+
+	if m := (SyslogMessage{Priority: -1}); m.Facility() != -1 || m.Severity() != -1 {
+		t.Errorf("Facility() and Severity() should be -1 without priority")
+	}
+}
+
+func TestParseSyslogErrors(t *testing.T) {
+
+	var tests = []string{
+		"<999>Jan 12 10:00:00 fw01 " + eventLine,
+		"<abc>Jan 12 10:00:00 fw01 " + eventLine,
+		"<134>Jan 12 10:00:00 fw01 no cef here",
+		"<134>1 yesterday fw01 - - - - " + eventLine,
+		"<134>1 - fw01 - - - [unterminated " + eventLine,
+		"<134>1 - fw01",
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseSyslog(tt); err == nil {
+			t.Errorf("ParseSyslog(%q) should fail", tt)
+		}
+	}
+}
+
+func TestParseSyslogToSyslog(t *testing.T) {
+
+	line, _ := event.ToSyslog()
+
+	got, err := ParseSyslog(line)
+	if err != nil || !reflect.DeepEqual(got.Event, event) || got.Hostname != syslogHostname() {
+		t.Errorf("ParseSyslog(%q) = %+v, %v", line, got, err)
+	}
+}
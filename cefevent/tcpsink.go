@@ -0,0 +1,119 @@
+package cefevent
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// TCPSinkOptions configures the format and addressing of a TCPSink.
+type TCPSinkOptions struct {
+	Format Format      // Format is the wire format of the sent messages.
+	Dial   DialOptions // Dial selects the address family, timeout and happy eyeballs fallback delay.
+	Lazy   bool        // Lazy postpones connecting until the first Send, so the sink can be created while the collector is down.
+
+	// WriteTimeout limits the time writing a message may take, so a
+	// collector which stops reading fails Send instead of blocking it and
+	// with it the Emitter. It defaults to 10 seconds.
+	WriteTimeout time.Duration
+}
+
+// defaultWriteTimeout limits writes when TCPSinkOptions.WriteTimeout is not set.
+const defaultWriteTimeout = 10 * time.Second
+
+// TCPSink is a Sink sending newline terminated messages over a TCP
+// connection. When the destination resolves to IPv4 and IPv6 addresses the
+// connection is established with happy eyeballs, a broken connection is
+// re-established on the next Send.
+type TCPSink struct {
+	mu      sync.Mutex
+	address string
+	opts    TCPSinkOptions
+	conn    net.Conn
+	closed  bool
+}
+
+// NewTCPSink connects to the given address and returns a Sink sending messages over it.
+//
+// Parameters:
+// - address: The address of the collector, e.g. "collector:1514" or "[2001:db8::1]:1514".
+// - opts: The format and addressing options.
+//
+// Returns:
+// - A pointer to a TCPSink.
+// - An error if the connection could not be established, which is never the case for lazy sinks.
+func NewTCPSink(address string, opts TCPSinkOptions) (*TCPSink, error) {
+
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaultWriteTimeout
+	}
+
+	if opts.Lazy {
+		return &TCPSink{address: address, opts: opts}, nil
+	}
+
+	conn, err := opts.Dial.dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TCPSink{address: address, opts: opts, conn: conn}, nil
+}
+
+// Format returns the wire format of the TCPSink.
+func (s *TCPSink) Format() Format {
+	return s.opts.Format
+}
+
+// Send writes the message followed by a newline to the connection,
+// reconnecting first when the previous write failed or timed out.
+func (s *TCPSink) Send(message string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("sink is closed")
+	}
+
+	if s.conn == nil {
+		conn, err := s.opts.Dial.dial("tcp", s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.opts.WriteTimeout)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	if _, err := io.WriteString(s.conn, message+"\n"); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection.
+func (s *TCPSink) Close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
@@ -0,0 +1,163 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// templateFuncs are the functions available to event templates in addition
+// to the builtins of text/template.
+var templateFuncs = template.FuncMap{
+	// field returns a header field or extension of the event, see FieldValue.
+	"field": func(event *CefEvent, name string) string {
+		value, _ := event.FieldValue(name)
+		return value
+	},
+	// json encodes a value as JSON, e.g. to quote strings in custom JSON documents.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// csv quotes a value for a CSV record if it contains a delimiter, a quote or a line break.
+	"csv": func(value string) string {
+		if !strings.ContainsAny(value, ",\"\r\n") {
+			return value
+		}
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	},
+	// cefHeader escapes a value for a CEF header field, i.e. "|", "\" and line breaks.
+	"cefHeader": cefEscapeField,
+	// cefExtension escapes a value for a CEF extension, i.e. "=", "\" and line breaks.
+	"cefExtension": cefEscapeExtension,
+	// logfmt quotes a value for a logfmt line if it contains spaces, quotes or control characters.
+	"logfmt": func(value string) string {
+		var b strings.Builder
+		writeLogfmtPair(&b, "", value)
+		return b.String()[1:]
+	},
+	// syslogSeverity maps the CEF severity onto a syslog severity level, e.g. for custom syslog headers.
+	"syslogSeverity": syslogSeverity,
+}
+
+// firstTemplateFormat is the Format of the first template registered with
+// FormatTemplate, the following ones count up from it.
+const firstTemplateFormat Format = 1000
+
+var (
+	templateFormatsMu sync.RWMutex
+	templateFormats   []*template.Template
+)
+
+// NewEventTemplate parses a text/template for rendering events, see
+// RenderTemplate. Templates executed for many events should be parsed
+// once with NewEventTemplate and executed with the *CefEvent as data.
+//
+// Parameters:
+// - text: The template, e.g. read from a file edited by the operator.
+//
+// Returns:
+// - The parsed template.
+// - An error if the template is malformed.
+func NewEventTemplate(text string) (*template.Template, error) {
+
+	return template.New("event").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// RenderTemplate renders the event with a text/template, so operators can
+// shape the output, e.g. custom CSV, human readable alerts or custom JSON.
+// The template is executed with the event as data, the header fields are
+// available by their struct field names ({{.Name}}), the extensions by
+// their keys ({{.Extensions.src}}) and the functions field, json and csv
+// are available to look up, quote and escape values:
+//
+//	{{.Severity}},{{csv .Name}},{{csv (field . "src")}}
+//	{"alert": {{json .Name}}, "source": {{json .Extensions.src}}}
+//
+// Parameters:
+// - tmpl: The template text.
+//
+// Returns:
+// - The rendered event.
+// - An error if the template is malformed or could not be executed.
+func (event *CefEvent) RenderTemplate(tmpl string) (string, error) {
+
+	t, err := NewEventTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, event); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// FormatTemplate registers a template as Format, so events can be rendered
+// into the custom text layouts legacy SIEM ingest rules require wherever a
+// Format is used, e.g. by sinks:
+//
+//	tmpl, err := cefevent.NewEventTemplate(`{{.Severity}};{{cefHeader .Name}};{{cefExtension .Extensions.msg}}`)
+//	sink := cefevent.NewWriterSink(conn, cefevent.FormatTemplate(tmpl))
+//
+// Events are validated before they are rendered. Registering the same
+// template again returns the same Format.
+//
+// Parameters:
+// - tmpl: The template, executed with the *CefEvent as data, usually parsed by NewEventTemplate.
+//
+// Returns:
+// - The Format rendering events with the template, its name is "template" followed by its number.
+func FormatTemplate(tmpl *template.Template) Format {
+
+	templateFormatsMu.Lock()
+	defer templateFormatsMu.Unlock()
+
+	for i, registered := range templateFormats {
+		if registered == tmpl {
+			return firstTemplateFormat + Format(i)
+		}
+	}
+	templateFormats = append(templateFormats, tmpl)
+
+	return firstTemplateFormat + Format(len(templateFormats)-1)
+}
+
+// lookupTemplateFormat returns the template of a Format returned by FormatTemplate.
+func lookupTemplateFormat(format Format) (*template.Template, bool) {
+
+	templateFormatsMu.RLock()
+	defer templateFormatsMu.RUnlock()
+
+	i := int(format - firstTemplateFormat)
+	if i < 0 || i >= len(templateFormats) {
+		return nil, false
+	}
+
+	return templateFormats[i], true
+}
+
+// renderTemplateFormat renders the event with the template of a Format returned by FormatTemplate.
+func (event *CefEvent) renderTemplateFormat(format Format) (string, error) {
+
+	tmpl, ok := lookupTemplateFormat(format)
+	if !ok {
+		return "", errors.New("unknown output format")
+	}
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, event); err != nil {
+		return "", fmt.Errorf("%s failed: %w", format, err)
+	}
+
+	return b.String(), nil
+}
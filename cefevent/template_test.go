@@ -0,0 +1,94 @@
+package cefevent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+
+	event := CefEvent{
+		Version:            0,
+		DeviceVendor:       "Vendor",
+		DeviceProduct:      "Product",
+		DeviceVersion:      "1.0",
+		DeviceEventClassId: "100",
+		Name:               `Login "failed", retry`,
+		Severity:           "7",
+		Extensions:         map[string]string{"src": "10.0.0.1", "suser": "alice"},
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"header fields", "[{{.Severity}}] {{.DeviceVendor}} {{.DeviceProduct}}", "[7] Vendor Product"},
+		{"extension", "{{.Extensions.suser}} from {{.Extensions.src}}", "alice from 10.0.0.1"},
+		{"missing extension", "<{{.Extensions.dst}}>", "<>"},
+		{"field", `{{field . "Name"}} by {{field . "suser"}}`, `Login "failed", retry by alice`},
+		{"csv", `{{csv .Severity}},{{csv .Name}}`, `7,"Login ""failed"", retry"`},
+		{"json", `{"alert":{{json .Name}},"src":{{json .Extensions.src}}}`, `{"alert":"Login \"failed\", retry","src":"10.0.0.1"}`},
+		{"method", "{{.String}}", `CEF:0|Vendor|Product|1.0|100|Login "failed", retry|7|src=10.0.0.1 suser=alice`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := event.RenderTemplate(tt.tmpl)
+			if err != nil {
+				t.Fatalf("RenderTemplate() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, tmpl := range []string{"{{.Name", "{{.Unknown}}"} {
+		if _, err := event.RenderTemplate(tmpl); err == nil {
+			t.Errorf("RenderTemplate(%q) = nil, want an error", tmpl)
+		}
+	}
+}
+
+func TestFormatTemplate(t *testing.T) {
+
+	e := event
+	e.Name = "a|b"
+	e.Severity = "8"
+	e.Extensions = map[string]string{"msg": "x=y z"}
+
+	tmpl, err := NewEventTemplate(`<{{syslogSeverity .Severity}}>{{cefHeader .Name}};msg={{cefExtension .Extensions.msg}};{{logfmt .Extensions.msg}}`)
+	if err != nil {
+		t.Fatalf("NewEventTemplate() = %v", err)
+	}
+
+	format := FormatTemplate(tmpl)
+	if again := FormatTemplate(tmpl); again != format {
+		t.Errorf("FormatTemplate() = %v, want the Format of the first registration %v", again, format)
+	}
+	if other, _ := NewEventTemplate("{{.Name}}"); FormatTemplate(other) == format {
+		t.Error("FormatTemplate() returned the same Format for different templates")
+	}
+
+	want := `<3>a\|b;msg=x\=y z;"x=y z"`
+	if got, err := e.Render(format); err != nil || got != want {
+		t.Errorf("Render(%v) = %q, %v, want %q", format, got, err, want)
+	}
+	if !strings.HasPrefix(format.String(), "template") {
+		t.Errorf("String() = %q, want a template name", format.String())
+	}
+
+	if _, err := (&CefEvent{}).Render(format); err == nil {
+		t.Error("Render() of an invalid event = nil, want an error")
+	}
+	if _, err := e.Render(format + 1000); err == nil {
+		t.Error("Render() of an unregistered Format = nil, want an error")
+	}
+
+	var b strings.Builder
+	sink := NewWriterSink(&b, format)
+	if err := NewSinkEmitter(sink).Emit(e); err != nil || b.String() != want+"\n" {
+		t.Errorf("Emit() wrote %q, %v, want %q", b.String(), err, want+"\n")
+	}
+}
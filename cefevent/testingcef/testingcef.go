@@ -0,0 +1,77 @@
+// Package testingcef provides a seed corpus of valid, tricky and malformed
+// CEF messages for native Go fuzz tests, so users of the cefevent package
+// can fuzz their own pipelines starting from the inputs which exercise the
+// parser the most:
+//
+//	func FuzzPipeline(f *testing.F) {
+//		testingcef.AddSeeds(f)
+//		f.Fuzz(func(t *testing.T, line string) {
+//			event, err := cefevent.Parse(line)
+//			...
+//		})
+//	}
+//
+// The package depends on the standard library only, it can be used by the
+// tests of the cefevent package itself.
+package testingcef
+
+import "testing"
+
+// seeds is the corpus, see Seeds.
+var seeds = []string{
+	// valid messages
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232",
+	"CEF:1|Security|threatmanager|1.0|100|worm successfully stopped|Very-High|src=10.0.0.1",
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|",
+	`CEF:0|Security|threat\\manager|1.0|100|detected a \| in message|10|src=10.0.0.1`,
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 msg=User logged in from 10.0.0.2 suser=alice",
+	`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=a\=b filePath=C:\\Windows\\cmd.exe`,
+	`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=line one\nline two\r`,
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|request=https://example.com/?a=1&b=2 src=10.0.0.1",
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|rt=Jan 12 2024 10:00:00.000 UTC start=1700000000000",
+	"CEF:0|Security|threatmanager|1.0|100|Überwachung ausgelöst – 日本語|10|suser=jürgen msg=Zugriff verweigert ✓",
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|cefCompressed=msg msg=ykjNyclXQCIBAwA",
+
+	// syslog framing
+	"<134>Jan 12 10:00:00 fw01 CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+	"<134>Jan  2 10:00:00 fw01 cefd[4711]: CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+	`<134>1 2024-01-12T10:00:00.000Z fw01 cefd 4711 - [origin ip="10.0.0.1"] CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1`,
+	"97 CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2",
+
+	// malformed and truncated messages
+	"CEF:0|Security|threatmanager|1.0|100",
+	"CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src",
+	`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|msg=dangling\`,
+	"CEF:x|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+	"CEF:42|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1",
+	"CEF:0|Security||1.0|100|worm successfully stopped|10|src=10.0.0.1",
+	"CEF:0|a|b|c|d|e|f|==  =x x= \\",
+	`CEF:0|Cool\|Vendor\|x|1.0|100|name|10|src=1 msg=a\=b\ filePath=C:\\ cefCompressed=rawEvent rawEvent=%%`,
+	"CEF:0|\xff\xfe|b|c|d|e|f|msg=\xc3\x28",
+	"LEEF:2.0|Security|threatmanager|1.0|100|src=10.0.0.1",
+	"CEF:",
+	"",
+}
+
+// Seeds returns the seed corpus, a new slice on every call which the caller may modify.
+func Seeds() []string {
+	return append([]string(nil), seeds...)
+}
+
+// AddSeeds adds every message of the corpus to the fuzz target as single
+// string argument.
+func AddSeeds(f *testing.F) {
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+}
+
+// AddSeedBytes adds every message of the corpus to the fuzz target as single
+// []byte argument, for targets consuming raw records.
+func AddSeedBytes(f *testing.F) {
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+}
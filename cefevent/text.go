@@ -0,0 +1,82 @@
+package cefevent
+
+import "encoding/json"
+
+// cefEventFields has the fields of CefEvent without its methods, so the
+// JSON encoding of the struct can be used from within MarshalJSON.
+type cefEventFields CefEvent
+
+// MarshalText renders the event as CEF message like String(), it
+// implements encoding.TextMarshaler so events can be handed to flag
+// parsing, structured loggers and configuration libraries as they are.
+//
+// Like MarshalJSON it is promoted to structs embedding a CefEvent, which
+// encoders relying on encoding.TextMarshaler then render as the CEF message
+// alone without their other fields, see MarshalJSON.
+//
+// Returns:
+// - The CEF message.
+// - An error if not all mandatory fields are set.
+func (event *CefEvent) MarshalText() ([]byte, error) {
+
+	message, err := event.String()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(message), nil
+}
+
+// UnmarshalText parses a CEF message into the event like Parse, it
+// implements encoding.TextUnmarshaler.
+//
+// Returns:
+// - A *ParseError if the message could not be parsed, the event is left untouched then.
+func (event *CefEvent) UnmarshalText(text []byte) error {
+
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*event = parsed
+	return nil
+}
+
+// MarshalJSON encodes the event as JSON object of its fields as ToJSON
+// does, which encoding/json would otherwise replace with the CEF message
+// of MarshalText. Unlike ToJSON it does not validate the event.
+//
+// It is promoted to structs embedding a *CefEvent or, when they are
+// encoded through a pointer, a CefEvent, whose JSON encoding is then the
+// event alone and hides their other fields. Such structs should hold the
+// event in a named field, e.g. Event CefEvent `json:"event"`.
+func (event *CefEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*cefEventFields)(event))
+}
+
+// UnmarshalJSON decodes a JSON object of the fields of an event as written
+// by MarshalJSON and ToJSON. A JSON string is parsed as CEF message, see
+// UnmarshalText.
+//
+// Returns:
+// - An error if the data is no such object or string or the event is not valid, see Validate,
+// the event is left untouched then.
+func (event *CefEvent) UnmarshalJSON(data []byte) error {
+
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		return event.UnmarshalText([]byte(message))
+	}
+
+	var decoded CefEvent
+	if err := json.Unmarshal(data, (*cefEventFields)(&decoded)); err != nil {
+		return err
+	}
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+
+	*event = decoded
+	return nil
+}
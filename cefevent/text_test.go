@@ -0,0 +1,93 @@
+package cefevent
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ encoding.TextMarshaler   = (*CefEvent)(nil)
+	_ encoding.TextUnmarshaler = (*CefEvent)(nil)
+)
+
+func TestCefEventText(t *testing.T) {
+
+	var event CefEvent
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.TextVar(&event, "event", &CefEvent{}, "event")
+
+	if err := flags.Parse([]string{"-event", eventLine}); err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+	if got, _ := event.String(); got != eventLine {
+		t.Errorf("String() = %q, want %q", got, eventLine)
+	}
+
+	text, err := event.MarshalText()
+	if err != nil || string(text) != eventLine {
+		t.Errorf("MarshalText() = %q, %v, want %q", text, err, eventLine)
+	}
+
+	before := event
+	var parseErr *ParseError
+	if err := event.UnmarshalText([]byte("not cef")); !errors.As(err, &parseErr) {
+		t.Errorf("UnmarshalText() = %v, want a *ParseError", err)
+	}
+	if !reflect.DeepEqual(event, before) {
+		t.Errorf("UnmarshalText() changed the event to %v", event)
+	}
+
+	if _, err := (&CefEvent{}).MarshalText(); err == nil {
+		t.Error("MarshalText() = nil, want an error for an empty event")
+	}
+}
+
+func TestCefEventJSON(t *testing.T) {
+
+	want := `{"Version":0,"DeviceVendor":"Cool Vendor","DeviceProduct":"Cool Product","DeviceVersion":"1.0","DeviceEventClassId":"COOL_THING","Name":"Something cool happened.","Severity":"Unknown","Extensions":{"src":"127.0.0.1"}}`
+
+	got, err := event.ToJSON()
+	if err != nil || got != want {
+		t.Errorf("ToJSON() = %s, %v, want %s", got, err, want)
+	}
+
+	data, err := json.Marshal(struct{ Event *CefEvent }{&event})
+	if err != nil || string(data) != `{"Event":`+want+`}` {
+		t.Errorf("json.Marshal() = %s, %v, want the event as object", data, err)
+	}
+
+	for _, doc := range []string{want, `"` + eventLine + `"`} {
+		var decoded CefEvent
+		if err := json.Unmarshal([]byte(doc), &decoded); err != nil {
+			t.Fatalf("json.Unmarshal(%s) = %v", doc, err)
+		}
+		if !reflect.DeepEqual(decoded, event) {
+			t.Errorf("json.Unmarshal(%s) = %v, want %v", doc, decoded, event)
+		}
+	}
+
+	// objects missing mandatory fields are rejected and leave the event untouched.
+	for _, doc := range []string{
+		`{"Version":0,"DeviceVendor":"V","DeviceProduct":"P","DeviceVersion":"1","DeviceEventClassId":"1","Severity":"5"}`,
+		`{"Version":0,"DeviceVendor":"V","DeviceProduct":"P","DeviceVersion":"1","DeviceEventClassId":"1","Name":"N"}`,
+	} {
+		decoded := event
+		if err := json.Unmarshal([]byte(doc), &decoded); err == nil || !reflect.DeepEqual(decoded, event) {
+			t.Errorf("json.Unmarshal(%s) = %v, %v, want an error and the event untouched", doc, decoded, err)
+		}
+	}
+
+	typed, _ := event.Typed()
+	data, err = json.Marshal(&typed)
+	if err != nil {
+		t.Fatalf("json.Marshal() = %v", err)
+	}
+	var decoded TypedEvent
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded.Source.Equal(typed.Source) || decoded.Name != event.Name {
+		t.Errorf("json.Unmarshal(%s) = %v, %v, want %v", data, decoded, err, typed)
+	}
+}
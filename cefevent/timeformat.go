@@ -0,0 +1,198 @@
+package cefevent
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// TimestampFormat selects how the values of time-typed extensions such as
+// rt, start and end are rendered. CEF allows epoch milliseconds as well as
+// dates, but consumers differ in which of them they parse reliably.
+type TimestampFormat int
+
+const (
+	// TimestampKeep leaves timestamp values as they are.
+	TimestampKeep TimestampFormat = iota
+	// TimestampMillis renders timestamps as milliseconds since the epoch.
+	TimestampMillis
+	// TimestampDate renders timestamps as "MMM dd yyyy HH:mm:ss.SSS zzz" in UTC.
+	TimestampDate
+)
+
+// timestampDateLayout is the Go layout of the "MMM dd yyyy HH:mm:ss.SSS zzz" date format.
+const timestampDateLayout = "Jan 02 2006 15:04:05.000 MST"
+
+// timestampExtensions are the extensions the CEF specification defines with
+// the TimeStamp data type.
+var timestampExtensions = []string{
+	"art",
+	"deviceCustomDate1",
+	"deviceCustomDate2",
+	"end",
+	"fileCreateTime",
+	"fileModificationTime",
+	"flexDate1",
+	"oldFileCreateTime",
+	"oldFileModificationTime",
+	"rt",
+	"start",
+}
+
+// isTimestampExtension reports whether key is one of the timestampExtensions.
+func isTimestampExtension(key string) bool {
+
+	for _, k := range timestampExtensions {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the name of the TimestampFormat.
+func (tf TimestampFormat) String() string {
+	switch tf {
+	case TimestampKeep:
+		return "keep"
+	case TimestampMillis:
+		return "millis"
+	case TimestampDate:
+		return "date"
+	}
+	return fmt.Sprintf("TimestampFormat(%d)", int(tf))
+}
+
+// ParseTimestampFormat returns the TimestampFormat with the given name as
+// returned by TimestampFormat.String(), an empty name selects TimestampKeep.
+//
+// Returns:
+// - The TimestampFormat matching the name.
+// - An error if no TimestampFormat with that name exists.
+func ParseTimestampFormat(name string) (TimestampFormat, error) {
+
+	for _, tf := range []TimestampFormat{TimestampKeep, TimestampMillis, TimestampDate} {
+		if tf.String() == name {
+			return tf, nil
+		}
+	}
+	if name == "" {
+		return TimestampKeep, nil
+	}
+
+	return TimestampKeep, fmt.Errorf("unknown timestamp format %q", name)
+}
+
+// Format renders t in the TimestampFormat, TimestampKeep renders epoch milliseconds.
+func (tf TimestampFormat) Format(t time.Time) string {
+
+	if tf == TimestampDate {
+		return t.UTC().Format(timestampDateLayout)
+	}
+
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+// FormatTimestamps rewrites the values of all time-typed extensions of the
+// event in the given TimestampFormat. Values which are not valid CEF
+// timestamps are left untouched.
+//
+// The Extensions map is modified in place, copy it first when it is shared.
+//
+// Returns:
+// - Whether any value was rewritten.
+func (event *CefEvent) FormatTimestamps(tf TimestampFormat) bool {
+
+	if tf == TimestampKeep {
+		return false
+	}
+
+	changed := false
+	for _, key := range timestampExtensions {
+		value, ok := event.Extensions[key]
+		if !ok {
+			continue
+		}
+		t, err := ParseTimestamp(value)
+		if err != nil {
+			continue
+		}
+		if formatted := tf.Format(t); formatted != value {
+			event.Extensions[key] = formatted
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// FormatTimestamps returns a Middleware rendering the time-typed extensions
+// of all events in the given TimestampFormat. Use WithTimestampFormat to
+// choose the format per destination instead.
+//
+// Parameters:
+// - tf: The TimestampFormat of the timestamps.
+//
+// Returns:
+// - A Middleware formatting the events passing through it.
+func FormatTimestamps(tf TimestampFormat) Middleware {
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+			event.Extensions = cloneExtensions(event.Extensions)
+			event.FormatTimestamps(tf)
+			return next(event)
+		}
+	}
+}
+
+// TimestampSink is implemented by sinks whose destination expects the
+// time-typed extensions in a specific TimestampFormat. The Emitter formats
+// the timestamps of a copy of the event before rendering it for the sink.
+type TimestampSink interface {
+	Sink
+	TimestampFormat() TimestampFormat // TimestampFormat returns the format the destination expects.
+}
+
+// timestampSink wraps a Sink with the TimestampFormat of its destination.
+type timestampSink struct {
+	Sink
+	tf TimestampFormat
+}
+
+// WithTimestampFormat returns a Sink delivering to sink with the time-typed
+// extensions rendered in the given TimestampFormat, e.g. epoch milliseconds
+// for one collector and dates for another one.
+//
+// Parameters:
+// - sink: The Sink to wrap, size limits and closing are passed through.
+// - tf: The TimestampFormat the destination expects.
+//
+// Returns:
+// - A TimestampSink delivering to sink.
+func WithTimestampFormat(sink Sink, tf TimestampFormat) TimestampSink {
+	return &timestampSink{Sink: sink, tf: tf}
+}
+
+// TimestampFormat returns the TimestampFormat of the destination.
+func (s *timestampSink) TimestampFormat() TimestampFormat {
+	return s.tf
+}
+
+// MaxMessageSize returns the size limit of the wrapped sink, zero if it has none.
+func (s *timestampSink) MaxMessageSize() int {
+	if limited, ok := s.Sink.(SizeLimitedSink); ok {
+		return limited.MaxMessageSize()
+	}
+	return 0
+}
+
+// Close closes the wrapped sink if it implements io.Closer.
+func (s *timestampSink) Close() error {
+	if closer, ok := s.Sink.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cefevent
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatTimestamps(t *testing.T) {
+
+	timed := event
+	timed.Extensions = map[string]string{"rt": "Jan 02 2024 15:04:05.000 UTC", "end": "1704207845000", "start": "whenever", "src": "127.0.0.1"}
+
+	var tests = []struct {
+		tf   TimestampFormat
+		want map[string]string
+	}{
+		{TimestampKeep, timed.Extensions},
+		{TimestampMillis, map[string]string{"rt": "1704207845000", "end": "1704207845000", "start": "whenever", "src": "127.0.0.1"}},
+		{TimestampDate, map[string]string{"rt": "Jan 02 2024 15:04:05.000 UTC", "end": "Jan 02 2024 15:04:05.000 UTC", "start": "whenever", "src": "127.0.0.1"}},
+	}
+
+	for _, tt := range tests {
+		formatted := timed
+		formatted.Extensions = cloneExtensions(timed.Extensions)
+		formatted.FormatTimestamps(tt.tf)
+
+		if !reflect.DeepEqual(formatted.Extensions, tt.want) {
+			t.Errorf("FormatTimestamps(%v) = %v, want %v", tt.tf, formatted.Extensions, tt.want)
+		}
+	}
+}
+
+func TestParseTimestampFormat(t *testing.T) {
+
+	for _, tf := range []TimestampFormat{TimestampKeep, TimestampMillis, TimestampDate} {
+		if got, err := ParseTimestampFormat(tf.String()); err != nil || got != tf {
+			t.Errorf("ParseTimestampFormat(%q) = %v, %v, want %v", tf.String(), got, err, tf)
+		}
+	}
+
+	if _, err := ParseTimestampFormat("iso"); err == nil {
+		t.Errorf("ParseTimestampFormat() should fail for an unknown format")
+	}
+}
+
+func TestEmitterTimestampSinks(t *testing.T) {
+
+	var millis, date, kept bytes.Buffer
+	emitter := NewSinkEmitter(
+		WithTimestampFormat(NewWriterSink(&millis, FormatCEF), TimestampMillis),
+		WithTimestampFormat(NewWriterSink(&date, FormatCEF), TimestampDate),
+		NewWriterSink(&kept, FormatCEF),
+	)
+
+	timed := event
+	timed.Extensions = map[string]string{"rt": "1704207845000"}
+
+	if err := emitter.Emit(timed); err != nil {
+		t.Fatalf("Emit() = %v", err)
+	}
+
+	if !strings.HasSuffix(millis.String(), "|rt=1704207845000\n") {
+		t.Errorf("millis sink got %q", millis.String())
+	}
+	if !strings.HasSuffix(date.String(), "|rt=Jan 02 2024 15:04:05.000 UTC\n") {
+		t.Errorf("date sink got %q", date.String())
+	}
+	if kept.String() != millis.String() || timed.Extensions["rt"] != "1704207845000" {
+		t.Errorf("the event must not be modified by the emitter")
+	}
+}
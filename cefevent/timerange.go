@@ -0,0 +1,222 @@
+package cefevent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cefTimeLayouts are the date formats the CEF specification allows for
+// timestamp extensions such as rt, start and end, besides epoch milliseconds.
+var cefTimeLayouts = []string{
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05",
+	"Jan 02 15:04:05.000 MST",
+	"Jan 02 15:04:05.000",
+	"Jan 02 15:04:05 MST",
+	"Jan 02 15:04:05",
+}
+
+// ParseTimestamp parses the value of a CEF timestamp extension, either
+// milliseconds since the epoch or one of the "MMM dd [yyyy] HH:mm:ss[.SSS] [zzz]"
+// date formats of the specification. Dates without a year are placed in
+// the year which puts them nearest to now, but not more than a few days
+// into the future, dates without a zone in UTC.
+//
+// Returns:
+// - The parsed time in UTC.
+// - An error if the value matches none of the formats.
+func ParseTimestamp(value string) (time.Time, error) {
+	return ParseTimestampIn(value, time.UTC)
+}
+
+// ParseTimestampIn parses the value of a CEF timestamp extension like
+// ParseTimestamp, but dates without a zone are taken as local time of loc,
+// e.g. the location of the device which sent them. Zone abbreviations are
+// resolved against loc as well, abbreviations unknown to loc are taken as UTC.
+//
+// Parameters:
+// - value: The value of the timestamp extension.
+// - loc: The location assumed for dates without zone, nil means UTC.
+//
+// Returns:
+// - The parsed time normalized to UTC.
+// - An error if the value matches none of the formats.
+func ParseTimestampIn(value string, loc *time.Location) (time.Time, error) {
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(millis).UTC(), nil
+	}
+
+	for _, layout := range cefTimeLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			t = placeYearless(t, time.Now())
+		}
+		return t.UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a CEF timestamp", value)
+}
+
+// yearlessSkew is how far in the future a date without year may lie, as the
+// clocks of devices and collectors differ.
+const yearlessSkew = 3 * 24 * time.Hour
+
+// placeYearless places a date parsed without year in the latest year which
+// puts it no more than yearlessSkew after now, i.e. in the previous year
+// for "Dec 31" parsed in January and in the next year for "Jan 01" parsed
+// on Dec 31. Years lacking the date, i.e. Feb 29, are skipped.
+func placeYearless(t time.Time, now time.Time) time.Time {
+
+	latest := now.Add(yearlessSkew)
+	for year := latest.Year(); year > latest.Year()-8; year-- {
+		placed := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if placed.Day() == t.Day() && !placed.After(latest) {
+			return placed
+		}
+	}
+
+	return time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// normalizeTimestamps rewrites the dates of all time-typed extensions of
+// the event, which lack a zone or are given in another zone, as UTC dates
+// assuming the location loc. Epoch milliseconds are zone independent and
+// remain as they are.
+func (event *CefEvent) normalizeTimestamps(loc *time.Location) {
+
+	for _, key := range timestampExtensions {
+		value, ok := event.Extensions[key]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			continue
+		}
+		if t, err := ParseTimestampIn(value, loc); err == nil {
+			event.Extensions[key] = TimestampDate.Format(t)
+		}
+	}
+}
+
+// eventTimeKeys are the extensions holding the time of an event, in order of preference.
+var eventTimeKeys = []string{"rt", "end", "start"}
+
+// EventTime returns the time the event occurred at according to its rt
+// (receipt time), end or start extension, whichever is set first.
+//
+// Returns:
+// - The time of the event.
+// - Whether a valid timestamp extension was found.
+func (event *CefEvent) EventTime() (time.Time, bool) {
+
+	for _, key := range eventTimeKeys {
+		if value, ok := event.Extensions[key]; ok {
+			if t, err := ParseTimestamp(value); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// TimeRange is a half-open interval of time, From is included and To is
+// excluded. A zero From or To leaves the range open on that side.
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains reports whether t lies within the range.
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.From.IsZero() || !t.Before(r.From)) && (r.To.IsZero() || t.Before(r.To))
+}
+
+// overlaps reports whether the range overlaps the closed interval [min, max] of Unix nanoseconds.
+func (r TimeRange) overlaps(min, max int64) bool {
+	return (r.From.IsZero() || max >= r.From.UnixNano()) && (r.To.IsZero() || min < r.To.UnixNano())
+}
+
+// extensionValue extracts the raw value of the extension key from a CEF
+// line without parsing the complete line. The value ends where the next
+// extension key starts, so values containing spaces are returned completely.
+func extensionValue(line string, key string) (string, bool) {
+
+	var header [cefHeaderFields]string
+	_, extensions, ok := splitHeader(strings.TrimPrefix(line, "CEF:"), &header)
+	if !ok {
+		return "", false
+	}
+
+	for start := 0; start < len(extensions); {
+		if strings.HasPrefix(extensions[start:], key+"=") {
+			value := extensions[start+len(key)+1:]
+			return value[:nextExtensionStart(value)], true
+		}
+
+		next := strings.IndexByte(extensions[start:], ' ')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	return "", false
+}
+
+// ExtractLines reads newline separated CEF messages from r and calls fn for
+// every line whose rt, end or start extension lies within the range. Only
+// the timestamp is extracted from each line, the lines are not parsed, which
+// makes searching large files fast. Lines without a timestamp are skipped.
+//
+// Returns:
+// - An error if reading r failed or fn returned one, nil when the input was consumed completely.
+func ExtractLines(r io.Reader, tr TimeRange, fn func(line string, t time.Time) error) error {
+
+	decoder := NewDecoder(r)
+
+	for {
+		line, err := decoder.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, key := range eventTimeKeys {
+			value, ok := extensionValue(line, key)
+			if !ok {
+				continue
+			}
+			t, err := ParseTimestamp(value)
+			if err != nil {
+				continue
+			}
+			if tr.Contains(t) {
+				if err := fn(line, t); err != nil {
+					return err
+				}
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package cefevent
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+
+	var tests = []struct {
+		value string
+		want  time.Time
+	}{
+		{"1704207845000", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Jan 02 2024 15:04:05.123 UTC", time.Date(2024, 1, 2, 15, 4, 5, 123000000, time.UTC)},
+		{"Jan 02 2024 15:04:05", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.value)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseTimestamp("yesterday"); err == nil {
+		t.Errorf("ParseTimestamp() should fail for an invalid value")
+	}
+}
+
+func TestEventTime(t *testing.T) {
+
+	timed := CefEvent{Extensions: map[string]string{"end": "1704207845000", "rt": "invalid"}}
+
+	got, ok := timed.EventTime()
+	if !ok || got.UnixMilli() != 1704207845000 {
+		t.Errorf("EventTime() = %v, %v, want the end time", got, ok)
+	}
+
+	if _, ok := (&CefEvent{}).EventTime(); ok {
+		t.Errorf("EventTime() should not find a time without timestamp extensions")
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := TimeRange{From: from, To: from.Add(time.Hour)}
+
+	if !tr.Contains(from) || tr.Contains(from.Add(time.Hour)) || tr.Contains(from.Add(-time.Second)) {
+		t.Errorf("Contains() should include From and exclude To")
+	}
+
+	if !(TimeRange{}).Contains(from) {
+		t.Errorf("an open range should contain every time")
+	}
+}
+
+func TestExtensionValue(t *testing.T) {
+
+	line := `CEF:0|Vendor|Product|1.0|100|Name\|with pipe|5|rt=Jan 02 2024 15:04:05 msg=a\=b c src=127.0.0.1`
+
+	var tests = []struct {
+		key  string
+		want string
+	}{
+		{"rt", "Jan 02 2024 15:04:05"},
+		{"msg", `a\=b c`},
+		{"src", "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got, ok := extensionValue(line, tt.key); !ok || got != tt.want {
+			t.Errorf("extensionValue(%q) = %q, %v, want %q", tt.key, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := extensionValue(line, "end"); ok {
+		t.Errorf("extensionValue() should not find a missing key")
+	}
+}
+
+func TestExtractLines(t *testing.T) {
+
+	input := strings.Join([]string{
+		"CEF:0|Vendor|Product|1.0|100|Early|5|rt=1704067200000",
+		"CEF:0|Vendor|Product|1.0|100|Inside|5|src=127.0.0.1 end=Jan 01 2024 00:30:00",
+		"CEF:0|Vendor|Product|1.0|100|Untimed|5|src=127.0.0.1",
+		"CEF:0|Vendor|Product|1.0|100|Late|5|rt=Jan 01 2024 01:00:00",
+	}, "\n")
+
+	from := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+	tr := TimeRange{From: from, To: from.Add(time.Hour - time.Second)}
+
+	var got []string
+	err := ExtractLines(strings.NewReader(input), tr, func(line string, t time.Time) error {
+		got = append(got, line)
+		return nil
+	})
+
+	if err != nil || len(got) != 1 || !strings.Contains(got[0], "|Inside|") {
+		t.Errorf("ExtractLines() = %v, %q, want only the event inside the range", err, got)
+	}
+}
+
+func TestExtractLinesLineTooLong(t *testing.T) {
+
+	long := "CEF:0|Vendor|Product|1.0|100|Flood|9|rt=1704069000000 msg=" + strings.Repeat("x", maxDecoderLineSize)
+	input := long + "\nCEF:0|Vendor|Product|1.0|100|Inside|5|rt=1704069000000\n"
+
+	var got []string
+	err := ExtractLines(strings.NewReader(input), TimeRange{}, func(line string, t time.Time) error {
+		got = append(got, line)
+		return nil
+	})
+
+	if err != nil || len(got) != 1 || !strings.Contains(got[0], "|Inside|") {
+		t.Errorf("ExtractLines() = %v, %d lines, want only the event after the long line", err, len(got))
+	}
+}
+
+func TestArchiveExtract(t *testing.T) {
+
+	for _, closed := range []bool{true, false} {
+
+		buf, start := writeTestArchive(t, 95, closed)
+
+		ar, err := OpenArchive(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("OpenArchive() = %v", err)
+		}
+
+		tr := TimeRange{From: start.Add(42 * time.Minute), To: start.Add(57 * time.Minute)}
+
+		var got []string
+		err = ar.Extract(tr, func(record ArchiveRecord) error {
+			got = append(got, record.Event.Extensions["cnt"])
+			return nil
+		})
+
+		if err != nil || len(got) != 15 || got[0] != "42" || got[14] != "56" {
+			t.Errorf("Extract() = %v, %v, want records 42 to 56", err, got)
+		}
+	}
+}
+
+func TestPlaceYearless(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		berlin = time.UTC
+	}
+	parse := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("Jan 02 15:04:05", value, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	var tests = []struct {
+		value string
+		now   time.Time
+		want  time.Time
+	}{
+		{"Jul 02 15:04:05", time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC), time.Date(2024, 7, 2, 15, 4, 5, 0, berlin)},
+		{"Dec 31 23:59:59", time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC), time.Date(2024, 12, 31, 23, 59, 59, 0, berlin)},
+		{"Jan 01 00:00:01", time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 1, 0, berlin)},
+		{"Oct 17 10:00:00", time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC), time.Date(2024, 10, 17, 10, 0, 0, 0, berlin)},
+		{"Oct 25 10:00:00", time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC), time.Date(2023, 10, 25, 10, 0, 0, 0, berlin)},
+		{"Feb 29 12:00:00", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 12, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		if got := placeYearless(parse(tt.value), tt.now); !got.Equal(tt.want) {
+			t.Errorf("placeYearless(%q, %v) = %v, want %v", tt.value, tt.now, got, tt.want)
+		}
+	}
+
+	now := time.Now().UTC()
+	if got, err := ParseTimestamp(now.Format("Jan 02 15:04:05")); err != nil || !got.Equal(now.Truncate(time.Second)) {
+		t.Errorf("ParseTimestamp() = %v, %v, want %v", got, err, now.Truncate(time.Second))
+	}
+}
+
+func TestParseTimestampIn(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	var tests = []struct {
+		value string
+		want  time.Time
+	}{
+		{"Jan 02 2024 15:04:05", time.Date(2024, 1, 2, 14, 4, 5, 0, time.UTC)},
+		{"Jul 02 2024 15:04:05.000 CEST", time.Date(2024, 7, 2, 13, 4, 5, 0, time.UTC)},
+		{"Jan 02 2024 15:04:05 UTC", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"1704207845000", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimestampIn(tt.value, berlin)
+		if err != nil || !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("ParseTimestampIn(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTimestamps(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	timed := CefEvent{Extensions: map[string]string{"rt": "Jan 02 2024 15:04:05", "end": "1704207845000", "msg": "Jan 02 2024 15:04:05"}}
+	timed.normalizeTimestamps(berlin)
+
+	want := map[string]string{"rt": "Jan 02 2024 14:04:05.000 UTC", "end": "1704207845000", "msg": "Jan 02 2024 15:04:05"}
+	if !reflect.DeepEqual(timed.Extensions, want) {
+		t.Errorf("normalizeTimestamps() = %v, want %v", timed.Extensions, want)
+	}
+}
@@ -0,0 +1,235 @@
+package cefevent
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Tokenizer walks a CEF record held in a byte slice and yields its header
+// fields and extension key/value pairs as sub-slices of the record, without
+// allocating intermediate strings. It is meant for high-volume processing
+// where Parse allocates too much, a single Tokenizer is reused for all
+// records by calling Reset.
+//
+// The slices returned are only valid until the record is modified and still
+// contain the escape sequences of the message, AppendHeader and AppendValue
+// append their unescaped form to a caller provided buffer.
+//
+//	var tok cefevent.Tokenizer
+//	if err := tok.Reset(record); err != nil {
+//		return err
+//	}
+//	name := tok.Header(5)
+//	for tok.Next() {
+//		process(tok.Key(), tok.Value())
+//	}
+type Tokenizer struct {
+	header [cefHeaderFields][]byte
+	rest   []byte
+	key    []byte
+	value  []byte
+}
+
+// Reset starts tokenizing the record, it splits the header into its seven
+// fields. A trailing newline of the record is ignored.
+//
+// Returns:
+// - A *ParseError if the record has no "CEF:" prefix or an incomplete header,
+// the prefix is accepted like by Parse.
+func (t *Tokenizer) Reset(record []byte) error {
+
+	*t = Tokenizer{}
+	record = bytes.TrimRight(record, "\r\n")
+
+	start := prefixEnd(record, false)
+	if start < 0 {
+		return newParseError(0, "", ErrMissingPrefix, "")
+	}
+
+	n := 0
+	for i := start; i < len(record); i++ {
+		switch record[i] {
+		case '\\':
+			i++
+		case '|':
+			t.header[n] = record[start:i]
+			n++
+			start = i + 1
+			if n == cefHeaderFields {
+				t.rest = bytes.TrimRight(record[start:], " ")
+				return nil
+			}
+		}
+	}
+
+	return incompleteHeaderError(len(record), n)
+}
+
+// Header returns the i-th header field, 0 is the version and 6 the severity.
+// Escape sequences are not replaced.
+func (t *Tokenizer) Header(i int) []byte {
+	return t.header[i]
+}
+
+// AppendHeader appends the i-th header field with its escape sequences
+// replaced to dst and returns the extended buffer.
+func (t *Tokenizer) AppendHeader(dst []byte, i int) []byte {
+
+	field := t.header[i]
+	for j := 0; j < len(field); j++ {
+		c := field[j]
+		if c == '\\' && j+1 < len(field) {
+			switch field[j+1] {
+			case '|', '\\':
+				c = field[j+1]
+				j++
+			case 'n':
+				c = '\n'
+				j++
+			case 'r':
+				c = '\r'
+				j++
+			}
+		}
+		dst = append(dst, c)
+	}
+
+	return dst
+}
+
+// Next advances to the next extension, tokens which are no key=value pair
+// are skipped.
+//
+// Returns:
+// - Whether an extension is available through Key and Value.
+func (t *Tokenizer) Next() bool {
+
+	for len(t.rest) > 0 {
+		if t.rest[0] == ' ' {
+			t.rest = t.rest[1:]
+			continue
+		}
+
+		end := nextExtensionStart(t.rest)
+		pair := t.rest[:end]
+		t.rest = t.rest[end:]
+
+		// tokens without key are malformed and skipped like in Parse.
+		if eq := keyEnd(pair); eq > 0 {
+			t.key = pair[:eq]
+			t.value = pair[eq+1:]
+			return true
+		}
+	}
+
+	t.key, t.value = nil, nil
+	return false
+}
+
+// Key returns the key of the current extension.
+func (t *Tokenizer) Key() []byte {
+	return t.key
+}
+
+// Value returns the value of the current extension, escape sequences are not replaced.
+func (t *Tokenizer) Value() []byte {
+	return t.value
+}
+
+// AppendValue appends the value of the current extension with its escape
+// sequences replaced to dst and returns the extended buffer.
+func (t *Tokenizer) AppendValue(dst []byte) []byte {
+
+	value := t.value
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			switch value[i+1] {
+			case '\\', '=':
+				c = value[i+1]
+				i++
+			case 'n':
+				c = '\n'
+				i++
+			case 'r':
+				c = '\r'
+				i++
+			}
+		}
+		dst = append(dst, c)
+	}
+
+	return dst
+}
+
+// ReadBytes parses a CEF message held in a byte slice like Read and
+// populates the CefEvent struct with it. The message is tokenized in place,
+// so receivers holding network buffers do not have to convert every
+// message into a string first, only the fields of the event are allocated.
+// A trailing line ending of the message is ignored.
+//
+// Returns:
+// - A CefEvent struct populated with the parsed CEF message data.
+// - A *ParseError if the CEF message is improperly formatted or if any mandatory field is missing.
+//
+// Deprecated: ReadBytes overwrites the receiver and returns a copy of it, use ParseBytes instead.
+func (event *CefEvent) ReadBytes(line []byte) (CefEvent, error) {
+
+	var tok Tokenizer
+	if err := tok.Reset(line); err != nil {
+		return CefEvent{}, err
+	}
+	start := prefixEnd(line, false)
+
+	version, err := strconv.Atoi(string(tok.Header(0)))
+	if err != nil {
+		return CefEvent{}, &ParseError{Offset: start, Field: headerFieldNames[0], Reason: fmt.Sprintf("version %q is not a number", tok.Header(0)), Err: ErrInvalidVersion}
+	}
+	if version != Version0 && version != Version1 {
+		return CefEvent{}, &ParseError{Offset: start, Field: headerFieldNames[0], Reason: fmt.Sprintf("version %d is not 0 or 1", version), Err: ErrInvalidVersion}
+	}
+
+	var header [cefHeaderFields]string
+	buf := make([]byte, 0, 64)
+	for i := 1; i < cefHeaderFields; i++ {
+		if len(tok.Header(i)) == 0 {
+			return CefEvent{}, newParseError(start+headerFieldOffset(line[start:], i), headerFieldNames[i], ErrMissingField, "")
+		}
+		buf = tok.AppendHeader(buf[:0], i)
+		header[i] = string(buf)
+	}
+
+	extensions := make(map[string]string)
+	for tok.Next() {
+		buf = tok.AppendValue(buf[:0])
+		extensions[cefUnescapeExtension(string(tok.Key()))] = string(buf)
+	}
+
+	event.Version = version
+	event.DeviceVendor = header[1]
+	event.DeviceProduct = header[2]
+	event.DeviceVersion = header[3]
+	event.DeviceEventClassId = header[4]
+	event.Name = header[5]
+	event.Severity = header[6]
+	event.Extensions = extensions
+
+	if key, err := event.decompressExtensions(0); err != nil {
+		return CefEvent{}, newParseError(start+headerFieldOffset(line[start:], cefHeaderFields), key, err, "")
+	}
+
+	return *event, nil
+}
+
+// ParseBytes parses a CEF message held in a byte slice into a new CefEvent
+// like Parse. The message is tokenized in place, so receivers holding network
+// buffers do not have to convert every message into a string first. A
+// trailing line ending of the message is ignored.
+//
+// Returns:
+// - The parsed CefEvent.
+// - A *ParseError if the CEF message is improperly formatted or if any mandatory field is missing.
+func ParseBytes(line []byte) (CefEvent, error) {
+	return new(CefEvent).ReadBytes(line)
+}
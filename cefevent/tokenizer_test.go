@@ -0,0 +1,170 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var tokenizerLine = []byte(`CEF:0|Cool\|Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|src=127.0.0.1 msg=User logged in\nfrom a\=b cnt=3 ` + "\n")
+
+func TestTokenizer(t *testing.T) {
+
+	var tok Tokenizer
+	if err := tok.Reset(tokenizerLine); err != nil {
+		t.Fatalf("Reset() = %v", err)
+	}
+
+	if got := string(tok.Header(1)); got != `Cool\|Vendor` {
+		t.Errorf("Header(1) = %q", got)
+	}
+	if got := string(tok.AppendHeader(nil, 1)); got != "Cool|Vendor" {
+		t.Errorf("AppendHeader(1) = %q", got)
+	}
+	if got := string(tok.Header(6)); got != "Unknown" {
+		t.Errorf("Header(6) = %q", got)
+	}
+
+	got := make(map[string]string)
+	for tok.Next() {
+		got[string(tok.Key())] = string(tok.AppendValue(nil))
+	}
+
+	want := map[string]string{"src": "127.0.0.1", "msg": "User logged in\nfrom a=b", "cnt": "3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extensions = %v, want %v", got, want)
+	}
+}
+
+func TestTokenizerErrors(t *testing.T) {
+
+	var tok Tokenizer
+
+	if err := tok.Reset([]byte("LEEF:2.0|a")); !errors.Is(err, ErrMissingPrefix) {
+		t.Errorf("Reset() = %v, want ErrMissingPrefix", err)
+	}
+	if err := tok.Reset([]byte(`CEF:0|a|b\|c|d`)); !errors.Is(err, ErrIncompleteHeader) {
+		t.Errorf("Reset() = %v, want ErrIncompleteHeader", err)
+	}
+	if tok.Next() {
+		t.Errorf("Next() should not yield extensions after a failed Reset")
+	}
+}
+
+func TestTokenizerAllocations(t *testing.T) {
+
+	var tok Tokenizer
+	buf := make([]byte, 0, 256)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		tok.Reset(tokenizerLine)
+		for tok.Next() {
+			buf = tok.AppendValue(buf[:0])
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Tokenizer allocates %v times per record, want 0", allocs)
+	}
+}
+
+func TestParseAllocations(t *testing.T) {
+
+	// header fields and values without escape sequences are sub-strings of
+	// the line, only the extension map is allocated.
+	allocs := testing.AllocsPerRun(100, func() {
+		Parse(eventLine)
+	})
+
+	if allocs > 2 {
+		t.Errorf("Parse allocates %v times per event, want at most 2", allocs)
+	}
+}
+
+func TestReadBytes(t *testing.T) {
+
+	compressed := event
+	compressed.Extensions = map[string]string{"rawEvent": strings.Repeat("payload ", 20)}
+	compressed.CompressExtension("rawEvent")
+	compressedLine, _ := compressed.String()
+
+	var tests = []string{
+		eventLine,
+		string(tokenizerLine[:len(tokenizerLine)-1]),
+		`CEF:1|a\b|c
+d|1.0|100|name|10|`,
+		`CEF:0|Vendor\r\nInc|Product|1.0|100|Line\rbreak|5|msg=first\r\nsecond`,
+		"CEF:0|a|b|c|d|e|f|src=10.0.0.1 garbage dst=10.0.0.2",
+		compressedLine,
+		compressedLine[:len(compressedLine)-4],
+		"LEEF:2.0|a|b|c|d|",
+		"CEF:0|a|b|c",
+		"CEF:x|a|b|c|d|e|f|",
+		"CEF:7|a|b|c|d|e|f|",
+		"CEF:0|a|b||d|e|f|",
+		"\uFEFF \tcef:0|a|b|c|d|e|f|src=10.0.0.1",
+		" cef:0|a|b||d|e|f|",
+	}
+
+	for _, tt := range tests {
+		want, wantErr := new(CefEvent).Read(tt)
+
+		var got CefEvent
+		_, err := got.ReadBytes([]byte(tt + "\r\n"))
+		if wantErr != nil {
+			got = CefEvent{}
+		}
+		if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(err, wantErr) {
+			t.Errorf("ReadBytes(%q) = %+v, %v, want %+v, %v", tt, got, err, want, wantErr)
+		}
+	}
+
+	if got, err := ParseBytes([]byte(eventLine)); err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("ParseBytes() = %+v, %v, want %+v", got, err, event)
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+
+	line := string(tokenizerLine)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		new(CefEvent).Read(line)
+	}
+}
+
+func BenchmarkParseWithOptions(b *testing.B) {
+
+	line := string(tokenizerLine)
+	opts := ParseOptions{Strict: true, MaxExtensions: 16}
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseWithOptions(line, opts)
+	}
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+
+	var tok Tokenizer
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		tok.Reset(tokenizerLine)
+		for tok.Next() {
+			buf = tok.AppendValue(buf[:0])
+		}
+	}
+}
+
+func BenchmarkReadBytes(b *testing.B) {
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		ParseBytes(tokenizerLine)
+	}
+}
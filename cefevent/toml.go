@@ -0,0 +1,273 @@
+package cefevent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// extensionsTable is the TOML table holding the extensions.
+const extensionsTable = "Extensions"
+
+// ToTOML converts the CefEvent instance to a TOML document with the field
+// names of its toml tags, the extensions are written as [Extensions] table.
+//
+// Returns:
+// - The TOML document.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToTOML() (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("Version = " + strconv.Itoa(event.Version) + "\n")
+	for _, name := range headerFieldNames[1:] {
+		b.WriteString(name + " = " + quoteDocumentString(*documentHeader(event, name)) + "\n")
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("\n[" + extensionsTable + "]\n")
+	for _, k := range keys {
+		key := k
+		if !plainDocumentKey(k) {
+			key = quoteDocumentString(k)
+		}
+		b.WriteString(key + " = " + quoteDocumentString(event.Extensions[k]) + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// FromTOML is the inverse of ToTOML, it reads an event from a TOML
+// document and validates it like FromJSON.
+//
+// Version must be an integer and the header fields and extensions strings,
+// the extensions are read from an [Extensions] table, an inline table or
+// dotted keys such as Extensions.src. Multi-line strings and further tables
+// are not supported by this dependency-free reader.
+//
+// Parameters:
+// - data: The TOML document.
+//
+// Returns:
+// - The CefEvent.
+// - A *DocumentError if the document is malformed or violates the schema,
+// it wraps ErrMalformedDocument, ErrMissingField, ErrInvalidVersion or ErrMalformedExtension.
+func FromTOML(data []byte) (CefEvent, error) {
+
+	d := newDocumentDecoder("TOML")
+	inExtensions := false
+
+	for i, line := range strings.Split(string(data), "\n") {
+		d.line = i + 1
+		line = strings.Trim(line, " \t\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if strings.HasPrefix(line, "[[") || end < 0 || !tomlTrailer(line[end+1:]) {
+				return CefEvent{}, d.errorf("", ErrMalformedDocument, "malformed table header")
+			}
+			if table := strings.TrimSpace(line[1:end]); table != extensionsTable {
+				return CefEvent{}, d.errorf(table, ErrMalformedDocument, "unknown table")
+			}
+			if d.seen[extensionsTable] {
+				return CefEvent{}, d.errorf(extensionsTable, ErrMalformedDocument, "duplicate table")
+			}
+			d.seen[extensionsTable] = true
+			inExtensions = true
+			continue
+		}
+
+		key, rest, err := d.tomlKey(line)
+		if err != nil {
+			return CefEvent{}, err
+		}
+
+		switch {
+		case inExtensions && len(key) == 1:
+			err = d.tomlExtension(key[0], rest)
+		case !inExtensions && len(key) == 2 && key[0] == extensionsTable:
+			d.seen[extensionsTable] = true
+			err = d.tomlExtension(key[1], rest)
+		case !inExtensions && len(key) == 1 && key[0] == extensionsTable:
+			err = d.tomlInlineTable(rest)
+		case !inExtensions && len(key) == 1:
+			err = d.tomlHeader(key[0], rest)
+		default:
+			err = d.errorf(strings.Join(key, "."), ErrMalformedDocument, "unknown field")
+		}
+		if err != nil {
+			return CefEvent{}, err
+		}
+	}
+
+	return d.finish()
+}
+
+// tomlKey reads the possibly dotted key at the start of s up to the "=".
+//
+// Returns:
+// - The parts of the key.
+// - The remainder of s after the "=".
+// - A *DocumentError if s does not start with a key followed by "=".
+func (d *documentDecoder) tomlKey(s string) ([]string, string, error) {
+
+	var parts []string
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return nil, "", d.errorf("", ErrMalformedDocument, "expected a key")
+		}
+
+		var part string
+		var ok bool
+		switch s[0] {
+		case '"':
+			part, s, ok = unquoteDocumentString(s)
+		case '\'':
+			part, s, ok = unquoteLiteralString(s, false)
+		default:
+			end := 0
+			for end < len(s) && plainDocumentKey(s[end:end+1]) {
+				end++
+			}
+			part, s, ok = s[:end], s[end:], end > 0
+		}
+		if !ok {
+			return nil, "", d.errorf("", ErrMalformedDocument, "malformed key")
+		}
+		parts = append(parts, part)
+
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "=") {
+			return parts, s[1:], nil
+		}
+		if !strings.HasPrefix(s, ".") {
+			return nil, "", d.errorf(strings.Join(parts, "."), ErrMalformedDocument, "expected \"=\" after the key")
+		}
+		s = s[1:]
+	}
+}
+
+// tomlString reads the single-line string value at the start of s.
+//
+// Returns:
+// - The string.
+// - The remainder of s after the closing quote.
+// - Whether s starts with a terminated string.
+func tomlString(s string) (string, string, bool) {
+
+	s = strings.TrimLeft(s, " \t")
+
+	switch {
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		return "", "", false
+	case strings.HasPrefix(s, `"`):
+		return unquoteDocumentString(s)
+	case strings.HasPrefix(s, "'"):
+		return unquoteLiteralString(s, false)
+	}
+
+	return "", "", false
+}
+
+// tomlHeader reads the value of a header field, Version is an integer and
+// the other fields are strings.
+func (d *documentDecoder) tomlHeader(name string, rest string) error {
+
+	if name != headerFieldNames[0] {
+		value, rest, ok := tomlString(rest)
+		if !ok || !tomlTrailer(rest) {
+			return d.errorf(name, ErrMalformedDocument, "value is not a single-line string")
+		}
+		return d.header(name, value)
+	}
+
+	value := strings.TrimSpace(rest)
+	if end := strings.IndexByte(value, '#'); end >= 0 {
+		value = strings.TrimSpace(value[:end])
+	}
+	if strings.ContainsAny(value, `"'`) {
+		return d.errorf(name, ErrInvalidVersion, "version must be an integer")
+	}
+
+	return d.header(name, strings.TrimPrefix(strings.ReplaceAll(value, "_", ""), "+"))
+}
+
+// tomlExtension reads the string value of an extension.
+func (d *documentDecoder) tomlExtension(key string, rest string) error {
+
+	value, rest, ok := tomlString(rest)
+	if !ok || !tomlTrailer(rest) {
+		return d.errorf(key, ErrMalformedExtension, "value is not a single-line string")
+	}
+
+	return d.extension(key, value)
+}
+
+// tomlInlineTable reads the extensions of an inline table, e.g.
+// { src = "10.0.0.1", "ad.user" = "alice" }.
+func (d *documentDecoder) tomlInlineTable(rest string) error {
+
+	if d.seen[extensionsTable] {
+		return d.errorf(extensionsTable, ErrMalformedDocument, "duplicate table")
+	}
+	d.seen[extensionsTable] = true
+
+	rest = strings.TrimLeft(rest, " \t")
+	if !strings.HasPrefix(rest, "{") {
+		return d.errorf(extensionsTable, ErrMalformedDocument, "extensions must be a table")
+	}
+	rest = strings.TrimLeft(rest[1:], " \t")
+
+	for !strings.HasPrefix(rest, "}") {
+		key, value, err := d.tomlKey(rest)
+		if err != nil {
+			return err
+		}
+		if len(key) != 1 {
+			return d.errorf(strings.Join(key, "."), ErrMalformedExtension, "extension keys must not be dotted")
+		}
+
+		var s string
+		var ok bool
+		if s, rest, ok = tomlString(value); !ok {
+			return d.errorf(key[0], ErrMalformedExtension, "value is not a single-line string")
+		}
+		if err := d.extension(key[0], s); err != nil {
+			return err
+		}
+
+		rest = strings.TrimLeft(rest, " \t")
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimLeft(rest[1:], " \t")
+		} else if !strings.HasPrefix(rest, "}") {
+			return d.errorf(extensionsTable, ErrMalformedDocument, "expected \",\" or \"}\" in inline table")
+		}
+	}
+
+	if !tomlTrailer(rest[1:]) {
+		return d.errorf(extensionsTable, ErrMalformedDocument, "unexpected data after inline table")
+	}
+
+	return nil
+}
+
+// tomlTrailer reports whether s, following a value, is blank or a comment.
+func tomlTrailer(s string) bool {
+
+	s = strings.TrimLeft(s, " \t")
+
+	return s == "" || s[0] == '#'
+}
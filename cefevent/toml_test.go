@@ -0,0 +1,97 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCefEventTOML(t *testing.T) {
+
+	tricky := event
+	tricky.Name = "Quote \" backslash \\ tab \t bell \a ünïcode"
+	tricky.Extensions = map[string]string{"src": "127.0.0.1", "ad.user-name": "it's", "msg": "line\nbreak", "empty": ""}
+
+	for _, e := range []CefEvent{event, tricky, {Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "1", Name: "N", Severity: "5"}} {
+		doc, err := e.ToTOML()
+		if err != nil {
+			t.Fatalf("ToTOML() = %v", err)
+		}
+		got, err := FromTOML([]byte(doc))
+		if e.Extensions == nil {
+			got.Extensions = nil
+		}
+		if err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("FromTOML(%s) = %#v, %v, want %#v", doc, got, err, e)
+		}
+	}
+
+	want := "Version = 0\nDeviceVendor = \"Cool Vendor\"\nDeviceProduct = \"Cool Product\"\nDeviceVersion = \"1.0\"\nDeviceEventClassId = \"COOL_THING\"\nName = \"Something cool happened.\"\nSeverity = \"Unknown\"\n\n[Extensions]\nsrc = \"127.0.0.1\"\n"
+	if got, _ := event.ToTOML(); got != want {
+		t.Errorf("ToTOML() = %q, want %q", got, want)
+	}
+
+	templates := []string{
+		`# a hand-written template
+Version = 0
+DeviceVendor = 'Cool Vendor' # literal string
+DeviceProduct = "Cool Product"
+DeviceVersion = "1.0"
+DeviceEventClassId = "COOL_THING"
+Name = "Something cool happened."
+Severity = "Unknown"
+Extensions = { src = "127.0.0.1" }
+`,
+		`Version = +0
+DeviceVendor = "Cool Vendor"
+DeviceProduct = "Cool Product"
+DeviceVersion = "1.0"
+DeviceEventClassId = "COOL_THING"
+Name = "Something cool happened."
+Severity = "Unknown"
+Extensions.src = "127.0.0.1"
+`,
+	}
+	for _, template := range templates {
+		if got, err := FromTOML([]byte(template)); err != nil || !reflect.DeepEqual(got, event) {
+			t.Errorf("FromTOML(%s) = %v, %v, want %v", template, got, err, event)
+		}
+	}
+}
+
+func TestFromTOMLErrors(t *testing.T) {
+
+	header := "Version = 0\nDeviceVendor = \"V\"\nDeviceProduct = \"P\"\nDeviceVersion = \"1\"\nDeviceEventClassId = \"1\"\nName = \"N\"\n"
+
+	tests := []struct {
+		doc   string
+		line  int
+		field string
+		want  error
+	}{
+		{header, 0, "Severity", ErrMissingField},
+		{"Version = \"0\"\n", 1, "Version", ErrInvalidVersion},
+		{"Version = 2\n" + header[12:] + "Severity = \"5\"\n", 0, "Version", ErrInvalidVersion},
+		{header + "Severity = 5\n", 7, "Severity", ErrMalformedDocument},
+		{header + "Severity = \"\"\"5\"\"\"\n", 7, "Severity", ErrMalformedDocument},
+		{header + "Severity = \"5\"\nSeverity = \"6\"\n", 8, "Severity", ErrMalformedDocument},
+		{header + "Severity = \"5\"\n[Device]\n", 8, "Device", ErrMalformedDocument},
+		{header + "Severity = \"5\"\n[Extensions]\nspt = 514\n", 9, "spt", ErrMalformedExtension},
+		{header + "Severity = \"5\"\n[Extensions]\n[Extensions]\n", 9, "Extensions", ErrMalformedDocument},
+		{header + "Severity = \"5\"\nExtensions = { a = \"b\" c = \"d\" }\n", 8, "Extensions", ErrMalformedDocument},
+		{header + "Severity = \"5\"\n[Extensions]\n\"a b\" = \"c\"\n", 0, "a b", ErrMalformedExtension},
+		{"Version 0\n", 1, "Version", ErrMalformedDocument},
+	}
+
+	for _, tt := range tests {
+		_, err := FromTOML([]byte(tt.doc))
+		var docErr *DocumentError
+		if !errors.As(err, &docErr) {
+			t.Errorf("FromTOML(%q) = %v, want a *DocumentError", tt.doc, err)
+			continue
+		}
+		if docErr.Line != tt.line || docErr.Field != tt.field || !errors.Is(err, tt.want) {
+			t.Errorf("FromTOML(%q) = %v, want %v in %s on line %d", tt.doc, err, tt.want, tt.field, tt.line)
+		}
+	}
+}
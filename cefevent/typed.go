@@ -0,0 +1,145 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// TypedEvent is a CefEvent together with its well-known extensions decoded
+// into Go types. The Extensions of the embedded CefEvent still hold the
+// raw strings. A field is left at its zero value when the extension is
+// missing or its value could not be decoded.
+type TypedEvent struct {
+	CefEvent
+	SourcePort      int       // SourcePort is the spt extension.
+	DestinationPort int       // DestinationPort is the dpt extension.
+	Source          net.IP    // Source is the src extension.
+	Destination     net.IP    // Destination is the dst extension.
+	ReceiptTime     time.Time // ReceiptTime is the rt extension.
+	StartTime       time.Time // StartTime is the start extension.
+	EndTime         time.Time // EndTime is the end extension.
+}
+
+// ParseTyped parses a CEF message like Parse and decodes the well-known
+// extensions spt and dpt into ports, src and dst into IP addresses and rt,
+// start and end into times, accepting epoch milliseconds as well as the
+// date formats of the specification.
+//
+// Returns:
+// - The TypedEvent.
+// - A *ParseError if the message could not be parsed, or the errors of the
+// extensions which could not be decoded along with the TypedEvent, see Typed.
+func ParseTyped(line string) (TypedEvent, error) {
+
+	event, err := Parse(line)
+	if err != nil {
+		return TypedEvent{}, err
+	}
+
+	return event.Typed()
+}
+
+// Typed decodes the well-known extensions of the event, see ParseTyped.
+//
+// Returns:
+// - The TypedEvent, extensions which could not be decoded are left at their zero value.
+// - The errors of the extensions which could not be decoded, each wrapping ErrMalformedExtension.
+func (event *CefEvent) Typed() (TypedEvent, error) {
+
+	typed := TypedEvent{CefEvent: *event}
+	var errs []error
+
+	for _, port := range []struct {
+		key    string
+		target *int
+	}{{"spt", &typed.SourcePort}, {"dpt", &typed.DestinationPort}} {
+		value, ok := event.Extensions[port.key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 65535 {
+			errs = append(errs, fmt.Errorf("extension %s: %q is not a port: %w", port.key, value, ErrMalformedExtension))
+			continue
+		}
+		*port.target = n
+	}
+
+	for _, ip := range []struct {
+		key    string
+		target *net.IP
+	}{{"src", &typed.Source}, {"dst", &typed.Destination}} {
+		value, ok := event.Extensions[ip.key]
+		if !ok {
+			continue
+		}
+		if *ip.target = net.ParseIP(value); *ip.target == nil {
+			errs = append(errs, fmt.Errorf("extension %s: %q is not an IP address: %w", ip.key, value, ErrMalformedExtension))
+		}
+	}
+
+	for _, ts := range []struct {
+		key    string
+		target *time.Time
+	}{{"rt", &typed.ReceiptTime}, {"start", &typed.StartTime}, {"end", &typed.EndTime}} {
+		value, ok := event.Extensions[ts.key]
+		if !ok {
+			continue
+		}
+		t, err := ParseTimestamp(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("extension %s: %v: %w", ts.key, err, ErrMalformedExtension))
+			continue
+		}
+		*ts.target = t
+	}
+
+	return typed, errors.Join(errs...)
+}
+
+// typedEventFields is the JSON encoding of a TypedEvent, the methods of
+// the embedded CefEvent would encode the CefEvent only.
+type typedEventFields struct {
+	*cefEventFields
+	SourcePort      int
+	DestinationPort int
+	Source          net.IP
+	Destination     net.IP
+	ReceiptTime     time.Time
+	StartTime       time.Time
+	EndTime         time.Time
+}
+
+// MarshalJSON encodes the TypedEvent as JSON object of the fields of the
+// event and the decoded extensions.
+func (typed *TypedEvent) MarshalJSON() ([]byte, error) {
+
+	return json.Marshal(typedEventFields{
+		cefEventFields:  (*cefEventFields)(&typed.CefEvent),
+		SourcePort:      typed.SourcePort,
+		DestinationPort: typed.DestinationPort,
+		Source:          typed.Source,
+		Destination:     typed.Destination,
+		ReceiptTime:     typed.ReceiptTime,
+		StartTime:       typed.StartTime,
+		EndTime:         typed.EndTime,
+	})
+}
+
+// UnmarshalJSON decodes a TypedEvent written by MarshalJSON.
+func (typed *TypedEvent) UnmarshalJSON(data []byte) error {
+
+	fields := typedEventFields{cefEventFields: (*cefEventFields)(&typed.CefEvent)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	typed.SourcePort, typed.DestinationPort = fields.SourcePort, fields.DestinationPort
+	typed.Source, typed.Destination = fields.Source, fields.Destination
+	typed.ReceiptTime, typed.StartTime, typed.EndTime = fields.ReceiptTime, fields.StartTime, fields.EndTime
+	return nil
+}
@@ -0,0 +1,59 @@
+package cefevent
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTyped(t *testing.T) {
+
+	line := "CEF:0|Vendor|Product|1.0|100|name|5|src=10.0.0.1 dst=2001:db8::1 spt=51234 dpt=443 rt=1700000000000 start=Nov 14 2023 22:10:00 UTC"
+
+	typed, err := ParseTyped(line)
+	if err != nil {
+		t.Fatalf("ParseTyped() = %v", err)
+	}
+
+	if typed.SourcePort != 51234 || typed.DestinationPort != 443 {
+		t.Errorf("ParseTyped() ports = %d, %d, want 51234, 443", typed.SourcePort, typed.DestinationPort)
+	}
+	if !typed.Source.Equal(net.ParseIP("10.0.0.1")) || !typed.Destination.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("ParseTyped() addresses = %v, %v", typed.Source, typed.Destination)
+	}
+	if want := time.UnixMilli(1700000000000).UTC(); !typed.ReceiptTime.Equal(want) {
+		t.Errorf("ParseTyped() ReceiptTime = %v, want %v", typed.ReceiptTime, want)
+	}
+	if want := time.Date(2023, 11, 14, 22, 10, 0, 0, time.UTC); !typed.StartTime.Equal(want) {
+		t.Errorf("ParseTyped() StartTime = %v, want %v", typed.StartTime, want)
+	}
+	if !typed.EndTime.IsZero() {
+		t.Errorf("ParseTyped() EndTime = %v, want the zero time", typed.EndTime)
+	}
+	if typed.Extensions["spt"] != "51234" || typed.DeviceVendor != "Vendor" {
+		t.Errorf("ParseTyped() = %+v, want the raw event embedded", typed.CefEvent)
+	}
+}
+
+func TestParseTypedErrors(t *testing.T) {
+
+	typed, err := ParseTyped("CEF:0|Vendor|Product|1.0|100|name|5|src=localhost spt=99999 dpt=80 end=yesterday")
+	if !errors.Is(err, ErrMalformedExtension) {
+		t.Fatalf("ParseTyped() = %v, want ErrMalformedExtension", err)
+	}
+	for _, key := range []string{"spt", "src", "end"} {
+		if !strings.Contains(err.Error(), "extension "+key) {
+			t.Errorf("ParseTyped() = %v, want an error for %s", err, key)
+		}
+	}
+	if typed.DestinationPort != 80 || typed.SourcePort != 0 || typed.Source != nil {
+		t.Errorf("ParseTyped() = %+v, want the valid extensions decoded", typed)
+	}
+
+	var parseErr *ParseError
+	if _, err := ParseTyped("not CEF"); !errors.As(err, &parseErr) {
+		t.Errorf("ParseTyped() = %v, want a *ParseError", err)
+	}
+}
@@ -0,0 +1,260 @@
+package cefevent
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pacer is a token bucket limiting the rate packets are sent at.
+type pacer struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// newPacer returns a pacer allowing rate packets per second with bursts of up to burst packets.
+func newPacer(rate float64, burst int) *pacer {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &pacer{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// wait blocks until a packet may be sent.
+func (p *pacer) wait() {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if !p.last.IsZero() {
+		p.tokens += now.Sub(p.last).Seconds() * p.rate
+		if p.tokens > p.burst {
+			p.tokens = p.burst
+		}
+	}
+	p.last = now
+
+	if p.tokens < 1 {
+		delay := time.Duration((1 - p.tokens) / p.rate * float64(time.Second))
+		p.sleep(delay)
+		p.last = p.last.Add(delay)
+		p.tokens = 1
+	}
+
+	p.tokens--
+}
+
+// Common maximum datagram sizes for UDPSinkOptions.MaxDatagramSize.
+const (
+	// DatagramSizeDefault fits a 1500 byte Ethernet MTU even with IPv6 headers.
+	DatagramSizeDefault = 1452
+	// DatagramSizeJumbo is the message size many syslog collectors accept on jumbo frame networks.
+	DatagramSizeJumbo = 8192
+)
+
+// OversizePolicy decides what a UDPSink does with messages larger than its maximum datagram size.
+type OversizePolicy int
+
+const (
+	// OversizeTruncate trims low priority extensions until the message fits, see CefEvent.TrimToSize.
+	OversizeTruncate OversizePolicy = iota
+	// OversizeWarn sends the message unchanged and logs a warning to stderr.
+	OversizeWarn
+)
+
+// UDPSinkOptions configures the addressing, pacing and message sizing of a UDPSink.
+type UDPSinkOptions struct {
+	Format           Format         // Format is the wire format of the sent messages, typically FormatSyslog.
+	Dial             DialOptions    // Dial selects the address family of the destination.
+	PacketsPerSecond float64        // PacketsPerSecond limits the send rate, zero or less disables pacing.
+	Burst            int            // Burst is the number of packets which may be sent at once before pacing kicks in, defaults to one.
+	MaxDatagramSize  int            // MaxDatagramSize is the largest message sent, defaults to DatagramSizeDefault.
+	Oversize         OversizePolicy // Oversize decides how messages exceeding MaxDatagramSize are handled.
+}
+
+// UDPSink is a Sink sending every message as a single UDP datagram, e.g. to
+// a syslog collector. Since UDP has no flow control, messages are paced
+// according to the configured rate to reduce silent datagram loss at the
+// collector. Messages exceeding the maximum datagram size are trimmed or
+// reported according to the OversizePolicy.
+type UDPSink struct {
+	conn  net.Conn
+	opts  UDPSinkOptions
+	pacer *pacer
+}
+
+// NewUDPSink returns a Sink sending datagrams to the given address.
+//
+// Parameters:
+// - address: The address of the collector, e.g. "collector:514" or "[2001:db8::1]:514".
+// - opts: The format, addressing and pacing options.
+//
+// Returns:
+// - A pointer to a UDPSink.
+// - An error if the address could not be resolved.
+func NewUDPSink(address string, opts UDPSinkOptions) (*UDPSink, error) {
+
+	conn, err := opts.Dial.dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxDatagramSize <= 0 {
+		opts.MaxDatagramSize = DatagramSizeDefault
+	}
+
+	sink := &UDPSink{conn: conn, opts: opts}
+	if opts.PacketsPerSecond > 0 {
+		sink.pacer = newPacer(opts.PacketsPerSecond, opts.Burst)
+	}
+
+	return sink, nil
+}
+
+// Format returns the wire format of the UDPSink.
+func (s *UDPSink) Format() Format {
+	return s.opts.Format
+}
+
+// MaxMessageSize returns the maximum datagram size when oversized messages
+// are truncated, it makes the Emitter trim events before they are sent.
+func (s *UDPSink) MaxMessageSize() int {
+
+	if s.opts.Oversize != OversizeTruncate {
+		return 0
+	}
+
+	return s.opts.MaxDatagramSize
+}
+
+// Send waits until the pacing allows another datagram and sends the message.
+func (s *UDPSink) Send(message string) error {
+
+	if len(message) > s.opts.MaxDatagramSize && s.opts.Oversize == OversizeWarn {
+		stderrLogger.Printf("CEF message of %d bytes exceeds the maximum datagram size of %d bytes", len(message), s.opts.MaxDatagramSize)
+	}
+
+	if s.pacer != nil {
+		s.pacer.wait()
+	}
+
+	_, err := s.conn.Write([]byte(message))
+	return err
+}
+
+// Close closes the underlying connection.
+func (s *UDPSink) Close() error {
+	return s.conn.Close()
+}
+
+// Sequence returns a Middleware stamping every event with a monotonically
+// increasing sequence number in the given extension key, starting at one.
+// In combination with a GapDetector at the receiving side it reveals
+// datagrams which were lost on the way.
+//
+// Parameters:
+// - key: The extension key holding the sequence number.
+//
+// Returns:
+// - A Middleware numbering the events passing through it.
+func Sequence(key string) Middleware {
+
+	var mu sync.Mutex
+	var seq uint64
+
+	return func(next Handler) Handler {
+		return func(event CefEvent) error {
+
+			mu.Lock()
+			seq++
+			current := seq
+			mu.Unlock()
+
+			extensions := cloneExtensions(event.Extensions)
+			extensions[key] = strconv.FormatUint(current, 10)
+			event.Extensions = extensions
+
+			return next(event)
+		}
+	}
+}
+
+// GapDetector tracks the sequence numbers stamped by Sequence per origin
+// and reports gaps, i.e. lost or reordered messages.
+// A GapDetector is safe for concurrent use.
+type GapDetector struct {
+	mu    sync.Mutex
+	key   string
+	last  map[string]uint64
+	lost  uint64
+	onGap func(origin string, expected, got uint64)
+}
+
+// NewGapDetector returns a GapDetector reading the sequence number from the extension key.
+//
+// Parameters:
+// - key: The extension key holding the sequence number.
+// - onGap: An optional callback called with the expected and received sequence number of every gap.
+//
+// Returns:
+// - A pointer to a GapDetector.
+func NewGapDetector(key string, onGap func(origin string, expected, got uint64)) *GapDetector {
+	return &GapDetector{key: key, last: make(map[string]uint64), onGap: onGap}
+}
+
+// Observe checks the sequence number of the received envelope.
+//
+// Returns:
+// - An error if the event does not carry a valid sequence number; otherwise, returns nil.
+func (d *GapDetector) Observe(env Envelope) error {
+
+	value, ok := env.Event.Extensions[d.key]
+	if !ok {
+		return errors.New("event has no sequence number")
+	}
+
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	last, seen := d.last[env.Origin]
+	d.last[env.Origin] = seq
+	gap := seen && seq != last+1
+	if gap && seq > last+1 {
+		d.lost += seq - last - 1
+	}
+	d.mu.Unlock()
+
+	if gap && d.onGap != nil {
+		d.onGap(env.Origin, last+1, seq)
+	}
+
+	return nil
+}
+
+// Lost returns the total number of messages missing from the observed sequences.
+func (d *GapDetector) Lost() uint64 {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.lost
+}
@@ -0,0 +1,126 @@
+package cefevent
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPacer(t *testing.T) {
+
+	now := time.Unix(0, 0)
+	var slept time.Duration
+
+	p := newPacer(10, 3)
+	p.now = func() time.Time { return now }
+	p.sleep = func(d time.Duration) { slept += d; now = now.Add(d) }
+
+	// the burst is sent without delay.
+	for i := 0; i < 3; i++ {
+		p.wait()
+	}
+	if slept != 0 {
+		t.Errorf("burst was delayed by %v", slept)
+	}
+
+	// afterwards every packet waits for 1/10 second.
+	p.wait()
+	p.wait()
+	if slept != 200*time.Millisecond {
+		t.Errorf("paced packets were delayed by %v, want 200ms", slept)
+	}
+
+	// tokens refill while idle, up to the burst size.
+	now = now.Add(10 * time.Second)
+	slept = 0
+	for i := 0; i < 3; i++ {
+		p.wait()
+	}
+	if slept != 0 {
+		t.Errorf("refilled burst was delayed by %v", slept)
+	}
+}
+
+func TestUDPSink(t *testing.T) {
+
+	source, err := NewUDPSource("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	sink, err := NewUDPSink(source.Addr().String(), UDPSinkOptions{Format: FormatSyslog, PacketsPerSecond: 1000, Burst: 10})
+	if err != nil {
+		t.Fatalf("NewUDPSink() = %v", err)
+	}
+	defer sink.Close()
+
+	if sink.Format() != FormatSyslog {
+		t.Errorf("Format() = %v, want %v", sink.Format(), FormatSyslog)
+	}
+
+	if err := sink.Send(eventLine); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	env, err := source.Receive()
+	if err != nil || env.Raw != eventLine {
+		t.Errorf("Receive() = %q, %v, want %q", env.Raw, err, eventLine)
+	}
+}
+
+func TestSequenceGapDetection(t *testing.T) {
+
+	var buf bytes.Buffer
+	emitter := NewEmitter(&buf)
+	emitter.Use(Sequence("seq"))
+
+	for i := 0; i < 5; i++ {
+		emitter.Emit(event)
+	}
+
+	var gaps [][2]uint64
+	detector := NewGapDetector("seq", func(origin string, expected, got uint64) {
+		gaps = append(gaps, [2]uint64{expected, got})
+	})
+
+	decoder := NewDecoder(&buf)
+	for i := 1; i <= 5; i++ {
+		received, _ := decoder.Decode()
+		// simulate the loss of the second and third datagram.
+		if i == 2 || i == 3 {
+			continue
+		}
+		if err := detector.Observe(Envelope{Event: received, Origin: "sensor"}); err != nil {
+			t.Fatalf("Observe() = %v", err)
+		}
+	}
+
+	if want := [][2]uint64{{2, 4}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("gaps = %v, want %v", gaps, want)
+	}
+
+	if detector.Lost() != 2 {
+		t.Errorf("Lost() = %d, want 2", detector.Lost())
+	}
+
+	if err := detector.Observe(Envelope{Event: event}); err == nil {
+		t.Errorf("Observe() should fail for an event without sequence number")
+	}
+}
+
+func TestUDPSinkMaxMessageSize(t *testing.T) {
+
+	truncating, _ := NewUDPSink("127.0.0.1:9", UDPSinkOptions{})
+	defer truncating.Close()
+	if truncating.MaxMessageSize() != DatagramSizeDefault {
+		t.Errorf("MaxMessageSize() = %d, want %d", truncating.MaxMessageSize(), DatagramSizeDefault)
+	}
+
+	warning, _ := NewUDPSink("127.0.0.1:9", UDPSinkOptions{MaxDatagramSize: DatagramSizeJumbo, Oversize: OversizeWarn})
+	defer warning.Close()
+	if warning.MaxMessageSize() != 0 {
+		t.Errorf("MaxMessageSize() = %d, want 0 when oversized messages are only reported", warning.MaxMessageSize())
+	}
+}
@@ -0,0 +1,199 @@
+package cefevent
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// IssueLevel tells how severe an Issue found by ValidateBatch is.
+type IssueLevel int
+
+const (
+	IssueWarning IssueLevel = iota // IssueWarning marks events which render but are likely rejected or misread by consumers.
+	IssueError                     // IssueError marks events which do not render as valid CEF.
+)
+
+// String returns "warning" or "error".
+func (l IssueLevel) String() string {
+
+	if l == IssueError {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// MarshalText encodes the level as "warning" or "error", e.g. in JSON reports.
+func (l IssueLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Issue is a single finding of ValidateBatch.
+type Issue struct {
+	Level   IssueLevel `json:"level"`           // Level is IssueError or IssueWarning.
+	Field   string     `json:"field,omitempty"` // Field is the header field name or extension key, empty if the issue relates to the whole event.
+	Message string     `json:"message"`         // Message describes the issue for display.
+	// Err is ErrInvalidVersion, ErrMissingField, ErrMalformedExtension or
+	// ErrInvalidUTF8 for errors, nil for warnings.
+	Err error `json:"-"`
+}
+
+// Report holds the issues of an event of a batch.
+type Report struct {
+	Index  int     `json:"index"`            // Index is the position of the event in the batch.
+	Issues []Issue `json:"issues,omitempty"` // Issues are the findings, the header fields first, then the extensions by key.
+}
+
+// Valid reports whether the event has no issues of level IssueError.
+func (r Report) Valid() bool {
+
+	for _, issue := range r.Issues {
+		if issue.Level == IssueError {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateBatch checks every event of an uploaded set and reports its
+// issues, so user interfaces and APIs can give feedback per event and field
+// instead of the single error of Validate.
+//
+// Errors are violations which keep an event from being rendered as valid
+// CEF: an unknown version, empty mandatory header fields, extension keys
+// which can not be rendered and invalid UTF-8. Warnings are deviations
+// consumers commonly reject or misread: unknown severities, extension keys
+// with characters strict parsers refuse, values of the extensions of the
+// dictionary not matching their type or exceeding their length, and custom
+// extensions lacking their label.
+//
+// Parameters:
+// - events: The events of the batch.
+//
+// Returns:
+// - A Report for every event, in the order of the batch.
+func ValidateBatch(events []CefEvent) []Report {
+
+	reports := make([]Report, len(events))
+	for i := range events {
+		reports[i] = Report{Index: i, Issues: events[i].issues()}
+	}
+
+	return reports
+}
+
+// issues returns the issues of the event, see ValidateBatch.
+func (event *CefEvent) issues() []Issue {
+
+	var issues []Issue
+	add := func(level IssueLevel, field string, err error, format string, args ...interface{}) {
+		issues = append(issues, Issue{Level: level, Field: field, Message: fmt.Sprintf(format, args...), Err: err})
+	}
+
+	if event.Version != Version0 && event.Version != Version1 {
+		add(IssueError, headerFieldNames[0], ErrInvalidVersion, "version %d is not 0 or 1", event.Version)
+	}
+
+	header := [cefHeaderFields]string{"", event.DeviceVendor, event.DeviceProduct, event.DeviceVersion, event.DeviceEventClassId, event.Name, event.Severity}
+	for i := 1; i < cefHeaderFields; i++ {
+		switch {
+		case header[i] == "":
+			add(IssueError, headerFieldNames[i], ErrMissingField, "mandatory field is empty")
+		case !utf8.ValidString(header[i]):
+			add(IssueError, headerFieldNames[i], ErrInvalidUTF8, "field is not valid UTF-8")
+		}
+	}
+	if event.Severity != "" && !knownSeverity(event.Severity) {
+		add(IssueWarning, headerFieldNames[6], nil, "severity %q is none of 0 to 10, Unknown, Low, Medium, High and Very-High", event.Severity)
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := event.Extensions[key]
+
+		if key == "" || strings.ContainsAny(key, "= \t\r\n") {
+			add(IssueError, key, ErrMalformedExtension, "key can not be rendered")
+			continue
+		}
+		if !utf8.ValidString(value) {
+			add(IssueError, key, ErrInvalidUTF8, "value is not valid UTF-8")
+			continue
+		}
+		if !validExtensionKey(key) {
+			add(IssueWarning, key, nil, "key contains characters other than letters, digits, \"_\" and \".\", strict parsers reject it")
+		}
+
+		definition, ok := LookupExtension(key)
+		if !ok || definition.Key != key {
+			continue
+		}
+		for _, violation := range dictionaryViolations(definition, value) {
+			add(IssueWarning, key, nil, "%s", violation)
+		}
+		if label, ok := LookupExtension(key + "Label"); ok && label.Key == key+"Label" {
+			if _, labeled := event.Extensions[label.Key]; !labeled {
+				add(IssueWarning, key, nil, "custom extension has no %s", label.Key)
+			}
+		}
+	}
+
+	return issues
+}
+
+// dictionaryViolations describes how value violates the type and length
+// of the definition of its extension.
+func dictionaryViolations(definition ExtensionDefinition, value string) []string {
+
+	var violations []string
+	if !validExtensionValue(definition.Type, value) {
+		violations = append(violations, fmt.Sprintf("value %q is no %s", value, definition.Type))
+	}
+	if n := utf8.RuneCountInString(value); definition.Length > 0 && n > definition.Length {
+		violations = append(violations, fmt.Sprintf("value of %d characters exceeds the length of %d", n, definition.Length))
+	}
+
+	return violations
+}
+
+// validExtensionValue reports whether value is of the type of an extension
+// of the dictionary, empty values are accepted for every type.
+func validExtensionValue(t ExtensionType, value string) bool {
+
+	if value == "" {
+		return true
+	}
+
+	var err error
+	switch t {
+	case ExtensionInteger:
+		_, err = strconv.ParseInt(value, 10, 32)
+	case ExtensionLong:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case ExtensionFloatingPoint:
+		_, err = strconv.ParseFloat(value, 32)
+	case ExtensionDouble:
+		_, err = strconv.ParseFloat(value, 64)
+	case ExtensionIPv4Address, ExtensionIPv6Address, ExtensionIPAddress:
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(value); err == nil {
+			return t == ExtensionIPAddress || addr.Is4() == (t == ExtensionIPv4Address)
+		}
+	case ExtensionMACAddress:
+		_, err = net.ParseMAC(value)
+	case ExtensionTimestamp:
+		_, err = ParseTimestamp(value)
+	}
+
+	return err == nil
+}
@@ -0,0 +1,58 @@
+package cefevent
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateBatch(t *testing.T) {
+
+	invalid := CefEvent{Version: 2, DeviceVendor: "V", DeviceProduct: "P\xff", DeviceVersion: "1", DeviceEventClassId: "1", Severity: "critical",
+		Extensions: map[string]string{"a b": "x", "spt": "http", "src": "::1", "cs1": "value", "smac": "00:0a:95:9d:68:16", "user-name": "bob", "c6a1": "10.0.0.1", "act": string(make([]byte, 64))}}
+
+	reports := ValidateBatch([]CefEvent{event, invalid})
+	if len(reports) != 2 || reports[1].Index != 1 {
+		t.Fatalf("ValidateBatch() = %v, want two reports", reports)
+	}
+	if !reports[0].Valid() || len(reports[0].Issues) != 0 {
+		t.Errorf("ValidateBatch()[0] = %v, want no issues", reports[0])
+	}
+	if reports[1].Valid() {
+		t.Errorf("ValidateBatch()[1].Valid() = true, want false")
+	}
+
+	want := []struct {
+		level IssueLevel
+		field string
+		err   error
+	}{
+		{IssueError, "Version", ErrInvalidVersion},
+		{IssueError, "DeviceProduct", ErrInvalidUTF8},
+		{IssueError, "Name", ErrMissingField},
+		{IssueWarning, "Severity", nil},
+		{IssueError, "a b", ErrMalformedExtension},
+		{IssueWarning, "act", nil},
+		{IssueWarning, "c6a1", nil},
+		{IssueWarning, "c6a1", nil},
+		{IssueWarning, "cs1", nil},
+		{IssueWarning, "spt", nil},
+		{IssueWarning, "src", nil},
+		{IssueWarning, "user-name", nil},
+	}
+
+	issues := reports[1].Issues
+	if len(issues) != len(want) {
+		t.Fatalf("Issues = %v, want %d issues", issues, len(want))
+	}
+	for i, w := range want {
+		if issues[i].Level != w.level || issues[i].Field != w.field || !errors.Is(issues[i].Err, w.err) || (w.err == nil) != (issues[i].Err == nil) {
+			t.Errorf("Issues[%d] = %+v, want a %s in %s", i, issues[i], w.level, w.field)
+		}
+	}
+
+	data, err := json.Marshal(reports[1].Issues[0])
+	if want := `{"level":"error","field":"Version","message":"version 2 is not 0 or 1"}`; err != nil || string(data) != want {
+		t.Errorf("json.Marshal() = %s, %v, want %s", data, err, want)
+	}
+}
@@ -0,0 +1,109 @@
+package cefevent
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// xmlExtension is an extension in the XML encoding of an event.
+type xmlExtension struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// xmlEvent is the XML encoding of an event, encoding/xml can not encode
+// the Extensions map itself.
+type xmlEvent struct {
+	Version            int
+	DeviceVendor       string
+	DeviceProduct      string
+	DeviceVersion      string
+	DeviceEventClassId string
+	Name               string
+	Severity           string
+	Extensions         []xmlExtension `xml:"Extensions>Extension"`
+}
+
+// MarshalXML encodes the event as element of the header fields and an
+// Extensions element holding an Extension element per extension, sorted by
+// key, which carries the key as attribute:
+//
+//	<CefEvent>
+//	  <Version>0</Version>
+//	  ...
+//	  <Extensions><Extension key="src">10.0.0.1</Extension></Extensions>
+//	</CefEvent>
+//
+// It implements xml.Marshaler for values and pointers alike, so events held
+// by value in other structs are encoded the same way. Structs embedding a
+// CefEvent inherit it and are encoded as the event alone, they should hold
+// it in a named field. Characters XML can not represent, e.g. NUL, are
+// replaced with U+FFFD.
+func (event CefEvent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+
+	doc := xmlEvent{
+		Version:            event.Version,
+		DeviceVendor:       event.DeviceVendor,
+		DeviceProduct:      event.DeviceProduct,
+		DeviceVersion:      event.DeviceVersion,
+		DeviceEventClassId: event.DeviceEventClassId,
+		Name:               event.Name,
+		Severity:           event.Severity,
+		Extensions:         make([]xmlExtension, 0, len(event.Extensions)),
+	}
+	for k, v := range event.Extensions {
+		doc.Extensions = append(doc.Extensions, xmlExtension{Key: k, Value: v})
+	}
+	sort.Slice(doc.Extensions, func(i, j int) bool {
+		return doc.Extensions[i].Key < doc.Extensions[j].Key
+	})
+
+	return e.EncodeElement(doc, start)
+}
+
+// UnmarshalXML decodes an event written by MarshalXML, it implements xml.Unmarshaler.
+func (event *CefEvent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+
+	var doc xmlEvent
+	if err := d.DecodeElement(&doc, &start); err != nil {
+		return err
+	}
+
+	*event = CefEvent{
+		Version:            doc.Version,
+		DeviceVendor:       doc.DeviceVendor,
+		DeviceProduct:      doc.DeviceProduct,
+		DeviceVersion:      doc.DeviceVersion,
+		DeviceEventClassId: doc.DeviceEventClassId,
+		Name:               doc.Name,
+		Severity:           doc.Severity,
+	}
+	if len(doc.Extensions) > 0 {
+		event.Extensions = make(map[string]string, len(doc.Extensions))
+		for _, ext := range doc.Extensions {
+			event.Extensions[ext.Key] = ext.Value
+		}
+	}
+
+	return nil
+}
+
+// ToXML converts the CefEvent instance to an XML document, e.g. for
+// collectors which only accept XML such as SOAP services, see MarshalXML.
+//
+// Returns:
+// - The XML document, a CefEvent element.
+// - An error if the CefEvent is not valid or could not be marshaled.
+func (event *CefEvent) ToXML() (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	data, err := xml.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
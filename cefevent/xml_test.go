@@ -0,0 +1,60 @@
+package cefevent
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCefEventXML(t *testing.T) {
+
+	want := `<CefEvent><Version>0</Version><DeviceVendor>Cool Vendor</DeviceVendor><DeviceProduct>Cool Product</DeviceProduct><DeviceVersion>1.0</DeviceVersion><DeviceEventClassId>COOL_THING</DeviceEventClassId><Name>Something cool happened.</Name><Severity>Unknown</Severity><Extensions><Extension key="src">127.0.0.1</Extension></Extensions></CefEvent>`
+
+	got, err := event.ToXML()
+	if err != nil || got != want {
+		t.Errorf("ToXML() = %s, %v, want %s", got, err, want)
+	}
+
+	tricky := event
+	tricky.Name = `<script>&"'</script>`
+	tricky.Extensions = map[string]string{"msg": "a < b & c\nd", "ad.user": `"quoted"`, "1st": "x"}
+
+	for _, e := range []CefEvent{event, tricky, {Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "1", Name: "N", Severity: "5"}} {
+		doc, err := e.ToXML()
+		if err != nil {
+			t.Fatalf("ToXML() = %v", err)
+		}
+		var decoded CefEvent
+		if err := xml.Unmarshal([]byte(doc), &decoded); err != nil || !reflect.DeepEqual(decoded, e) {
+			t.Errorf("xml.Unmarshal(%s) = %#v, %v, want %#v", doc, decoded, err, e)
+		}
+	}
+
+	// events embedded in other documents keep the name of their field.
+	data, err := xml.Marshal(struct {
+		XMLName xml.Name  `xml:"Envelope"`
+		Event   *CefEvent `xml:"Body>Event"`
+	}{Event: &event})
+	if err != nil {
+		t.Fatalf("xml.Marshal() = %v", err)
+	}
+	if want := `<Envelope><Body><Event><Version>0</Version>`; string(data[:len(want)]) != want {
+		t.Errorf("xml.Marshal() = %s, want it to start with %s", data, want)
+	}
+
+	// events held by value are encoded the same way as pointers.
+	for _, v := range []interface{}{event, struct {
+		XMLName xml.Name `xml:"Envelope"`
+		Event   CefEvent `xml:"Event"`
+	}{Event: event}} {
+		data, err := xml.Marshal(v)
+		if err != nil || !strings.Contains(string(data), `<Extensions><Extension key="src">127.0.0.1</Extension></Extensions>`) {
+			t.Errorf("xml.Marshal(%T) = %s, %v, want the Extensions element", v, data, err)
+		}
+	}
+
+	if _, err := (&CefEvent{}).ToXML(); err == nil {
+		t.Error("ToXML() = nil, want an error for an empty event")
+	}
+}
@@ -0,0 +1,206 @@
+package cefevent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToYAML converts the CefEvent instance to a YAML document with the field
+// names of its yaml tags, e.g. to keep events as templates in
+// configuration files. The strings are double-quoted, so the document is
+// read back unchanged by any YAML parser.
+//
+// Returns:
+// - The YAML document.
+// - An error if the CefEvent is not valid.
+func (event *CefEvent) ToYAML() (string, error) {
+
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("Version: " + strconv.Itoa(event.Version) + "\n")
+	for _, name := range headerFieldNames[1:] {
+		b.WriteString(name + ": " + quoteDocumentString(*documentHeader(event, name)) + "\n")
+	}
+
+	if len(event.Extensions) == 0 {
+		b.WriteString("Extensions: {}\n")
+		return b.String(), nil
+	}
+
+	keys := make([]string, 0, len(event.Extensions))
+	for k := range event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("Extensions:\n")
+	for _, k := range keys {
+		key := k
+		if !plainDocumentKey(k) {
+			key = quoteDocumentString(k)
+		}
+		b.WriteString("  " + key + ": " + quoteDocumentString(event.Extensions[k]) + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// FromYAML is the inverse of ToYAML, it reads an event from a YAML
+// document and validates it like FromJSON.
+//
+// The document is a mapping of the header fields and an Extensions mapping
+// of strings, as hand-written templates are: block mappings with plain,
+// single- or double-quoted scalars and comments. Flow collections other
+// than an empty Extensions mapping, block scalars, anchors and tags are not
+// supported by this dependency-free reader.
+//
+// Parameters:
+// - data: The YAML document.
+//
+// Returns:
+// - The CefEvent.
+// - A *DocumentError if the document is malformed or violates the schema,
+// it wraps ErrMalformedDocument, ErrMissingField, ErrInvalidVersion or ErrMalformedExtension.
+func FromYAML(data []byte) (CefEvent, error) {
+
+	d := newDocumentDecoder("YAML")
+	inExtensions := false
+	extensionIndent := 0
+
+	for i, line := range strings.Split(string(data), "\n") {
+		d.line = i + 1
+		line = strings.TrimSuffix(line, "\r")
+
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content[0] == '#' || line == "---" {
+			continue
+		}
+		if line == "..." {
+			break
+		}
+		if content[0] == '\t' {
+			return CefEvent{}, d.errorf("", ErrMalformedDocument, "tabs are not allowed as indentation")
+		}
+
+		key, value, err := d.yamlEntry(content)
+		if err != nil {
+			return CefEvent{}, err
+		}
+
+		indent := len(line) - len(content)
+		if indent > 0 {
+			if !inExtensions {
+				return CefEvent{}, d.errorf(key, ErrMalformedDocument, "unexpected indentation")
+			}
+			if extensionIndent == 0 {
+				extensionIndent = indent
+			}
+			if indent != extensionIndent {
+				return CefEvent{}, d.errorf(key, ErrMalformedDocument, "inconsistent indentation")
+			}
+			if value == nil {
+				return CefEvent{}, d.errorf(key, ErrMalformedExtension, "extension values must be strings")
+			}
+			if err := d.extension(key, *value); err != nil {
+				return CefEvent{}, err
+			}
+			continue
+		}
+
+		inExtensions = false
+		if key != "Extensions" {
+			if value == nil {
+				return CefEvent{}, d.errorf(key, ErrMalformedDocument, "header fields must be scalars")
+			}
+			if err := d.header(key, *value); err != nil {
+				return CefEvent{}, err
+			}
+			continue
+		}
+
+		if d.seen[key] {
+			return CefEvent{}, d.errorf(key, ErrMalformedDocument, "duplicate field")
+		}
+		d.seen[key] = true
+		if value != nil && *value != "" {
+			return CefEvent{}, d.errorf(key, ErrMalformedDocument, "extensions must be a mapping")
+		}
+		// an empty mapping, a null or the start of a block mapping.
+		inExtensions = value == nil
+	}
+
+	return d.finish()
+}
+
+// yamlEntry splits a "key: value" line of a block mapping.
+//
+// Returns:
+// - The key.
+// - The scalar value, nil if the value is the empty mapping "{}" or
+// missing, i.e. a nested block mapping follows.
+// - A *DocumentError if the line is no mapping entry or uses unsupported syntax.
+func (d *documentDecoder) yamlEntry(content string) (string, *string, error) {
+
+	var key, rest string
+	var ok bool
+
+	switch content[0] {
+	case '"':
+		key, rest, ok = unquoteDocumentString(content)
+	case '\'':
+		key, rest, ok = unquoteLiteralString(content, true)
+	default:
+		end := strings.Index(content, ": ")
+		if end < 0 && strings.HasSuffix(content, ":") {
+			end = len(content) - 1
+		}
+		if end > 0 {
+			key, rest, ok = strings.TrimRight(content[:end], " "), content[end:], true
+		}
+	}
+	rest = strings.TrimLeft(rest, " ")
+	if !ok || !strings.HasPrefix(rest, ":") {
+		return "", nil, d.errorf("", ErrMalformedDocument, "expected a \"key: value\" mapping entry")
+	}
+
+	value := strings.TrimLeft(rest[1:], " ")
+	if value == "" || value[0] == '#' || strings.HasPrefix(value, "{}") && yamlTrailer(value[2:]) {
+		return key, nil, nil
+	}
+
+	var scalar string
+	switch value[0] {
+	case '"':
+		scalar, rest, ok = unquoteDocumentString(value)
+	case '\'':
+		scalar, rest, ok = unquoteLiteralString(value, true)
+	case '|', '>', '[', '{', '&', '*', '!', '%', '@', '`':
+		return "", nil, d.errorf(key, ErrMalformedDocument, "unsupported YAML syntax %q", value[:1])
+	default:
+		scalar, rest, ok = value, "", true
+		if end := strings.Index(value, " #"); end >= 0 {
+			scalar = value[:end]
+		}
+		scalar = strings.TrimRight(scalar, " \t")
+		if scalar == "~" || scalar == "null" {
+			scalar = ""
+		}
+	}
+	if !ok || !yamlTrailer(rest) {
+		return "", nil, d.errorf(key, ErrMalformedDocument, "malformed quoted string")
+	}
+
+	return key, &scalar, nil
+}
+
+// yamlTrailer reports whether s, following a value, is blank or a comment.
+func yamlTrailer(s string) bool {
+
+	s = strings.TrimLeft(s, " \t")
+
+	return s == "" || s[0] == '#'
+}
@@ -0,0 +1,85 @@
+package cefevent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCefEventYAML(t *testing.T) {
+
+	tricky := event
+	tricky.Name = "Quote \" backslash \\ tab \t bell \a ünïcode"
+	tricky.Extensions = map[string]string{"src": "127.0.0.1", "ad.user-name": "it's", "msg": "line\nbreak", "empty": ""}
+
+	for _, e := range []CefEvent{event, tricky, {Version: 1, DeviceVendor: "V", DeviceProduct: "P", DeviceVersion: "1", DeviceEventClassId: "1", Name: "N", Severity: "5"}} {
+		doc, err := e.ToYAML()
+		if err != nil {
+			t.Fatalf("ToYAML() = %v", err)
+		}
+		got, err := FromYAML([]byte(doc))
+		if err != nil || !reflect.DeepEqual(got, e) {
+			t.Errorf("FromYAML(%s) = %#v, %v, want %#v", doc, got, err, e)
+		}
+	}
+
+	want := "Version: 0\nDeviceVendor: \"Cool Vendor\"\nDeviceProduct: \"Cool Product\"\nDeviceVersion: \"1.0\"\nDeviceEventClassId: \"COOL_THING\"\nName: \"Something cool happened.\"\nSeverity: \"Unknown\"\nExtensions:\n  src: \"127.0.0.1\"\n"
+	if got, _ := event.ToYAML(); got != want {
+		t.Errorf("ToYAML() = %q, want %q", got, want)
+	}
+
+	template := `---
+# a hand-written template
+Version: 0
+DeviceVendor: Cool Vendor   # plain scalars
+DeviceProduct: 'Cool Product'
+DeviceVersion: 1.0
+DeviceEventClassId: COOL_THING
+Name: "Something cool happened."
+Severity: Unknown
+Extensions:
+    src: 127.0.0.1
+`
+	if got, err := FromYAML([]byte(template)); err != nil || !reflect.DeepEqual(got, event) {
+		t.Errorf("FromYAML(template) = %v, %v, want %v", got, err, event)
+	}
+}
+
+func TestFromYAMLErrors(t *testing.T) {
+
+	header := "Version: 0\nDeviceVendor: V\nDeviceProduct: P\nDeviceVersion: 1\nDeviceEventClassId: 1\nName: N\n"
+
+	tests := []struct {
+		doc   string
+		line  int
+		field string
+		want  error
+	}{
+		{header, 0, "Severity", ErrMissingField},
+		{header + "Severity: ~\n", 0, "Severity", ErrMissingField},
+		{"Version: one\n", 1, "Version", ErrInvalidVersion},
+		{"Version: 3\n" + header[11:] + "Severity: 5\n", 0, "Version", ErrInvalidVersion},
+		{header + "Severity: 5\nSeverity: 6\n", 8, "Severity", ErrMalformedDocument},
+		{header + "Severity: 5\nSource: x\n", 8, "Source", ErrMalformedDocument},
+		{header + "Severity: |\n  5\n", 7, "Severity", ErrMalformedDocument},
+		{header + "Severity: \"5\n", 7, "Severity", ErrMalformedDocument},
+		{header + "Severity: 5\nExtensions:\n  src: a\n   dst: b\n", 10, "dst", ErrMalformedDocument},
+		{header + "Severity: 5\nExtensions:\n  src: a\n  src: b\n", 10, "src", ErrMalformedExtension},
+		{header + "Severity: 5\nExtensions:\n  a=b: c\n", 0, "a=b", ErrMalformedExtension},
+		{header + "Severity: 5\nExtensions: src\n", 8, "Extensions", ErrMalformedDocument},
+		{header + "  Severity: 5\n", 7, "Severity", ErrMalformedDocument},
+		{"just text\n", 1, "", ErrMalformedDocument},
+	}
+
+	for _, tt := range tests {
+		_, err := FromYAML([]byte(tt.doc))
+		var docErr *DocumentError
+		if !errors.As(err, &docErr) {
+			t.Errorf("FromYAML(%q) = %v, want a *DocumentError", tt.doc, err)
+			continue
+		}
+		if docErr.Line != tt.line || docErr.Field != tt.field || !errors.Is(err, tt.want) {
+			t.Errorf("FromYAML(%q) = %v, want %v in %s on line %d", tt.doc, err, tt.want, tt.field, tt.line)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// runDict implements "cef dict", writing the extensions defined by the CEF
+// specification as JSON for editors and user interfaces offering
+// autocompletion and inline documentation.
+func runDict(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("dict", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	prefix := flags.String("prefix", "", "only write the extensions whose key or full name starts with `prefix`")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cefevent.Dictionary(*prefix))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+func TestRunDict(t *testing.T) {
+
+	var stdout bytes.Buffer
+	if err := runDict([]string{"-prefix", "dp"}, strings.NewReader(""), &stdout, io.Discard); err != nil {
+		t.Fatalf("runDict() = %v", err)
+	}
+
+	var got []cefevent.ExtensionDefinition
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("runDict() wrote %q, want JSON: %v", stdout.String(), err)
+	}
+
+	if len(got) != 4 || got[0].Key != "dpid" || got[3].Key != "dpt" || got[3].Name != "destinationPort" || got[3].Type != cefevent.ExtensionInteger {
+		t.Errorf("runDict() = %+v, want dpid, dpriv, dproc and dpt", got)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// parseTimeFlag parses a RFC 3339 time flag, an empty value results in the zero time.
+func parseTimeFlag(flagName string, value string) (time.Time, error) {
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-%s expects a RFC 3339 time, got %q", flagName, value)
+	}
+
+	return t, nil
+}
+
+// runExtract implements "cef extract", writing the events of CEF files or
+// archives which lie within a time range to stdout.
+func runExtract(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("extract", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "", "only extract events at or after this RFC 3339 `time`")
+	to := flags.String("to", "", "only extract events before this RFC 3339 `time`")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var tr cefevent.TimeRange
+	var err error
+	if tr.From, err = parseTimeFlag("from", *from); err != nil {
+		return err
+	}
+	if tr.To, err = parseTimeFlag("to", *to); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+
+	if flags.NArg() == 0 {
+		return extractLines(stdin, tr, out)
+	}
+
+	for _, path := range flags.Args() {
+		if err := extractFile(path, tr, out); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// extractFile extracts the events of a single file, which is either an
+// archive or a plain, possibly compressed, CEF file.
+func extractFile(path string, tr cefevent.TimeRange, out io.Writer) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(file, magic)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(magic[:n], []byte("CEFA")) {
+		r, err := cefevent.DecompressReader(file)
+		if err != nil {
+			return err
+		}
+		return extractLines(r, tr, out)
+	}
+
+	archive, err := cefevent.OpenArchive(file)
+	if err != nil {
+		return err
+	}
+
+	return archive.Extract(tr, func(record cefevent.ArchiveRecord) error {
+		line, err := record.Event.String()
+		if err != nil {
+			return nil
+		}
+		_, err = fmt.Fprintln(out, line)
+		return err
+	})
+}
+
+// extractLines extracts the lines of a plain CEF input.
+func extractLines(r io.Reader, tr cefevent.TimeRange, out io.Writer) error {
+
+	return cefevent.ExtractLines(r, tr, func(line string, t time.Time) error {
+		_, err := fmt.Fprintln(out, line)
+		return err
+	})
+}
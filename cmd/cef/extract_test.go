@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+const extractInput = `CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|rt=Jan 01 2024 09:00:00 suser=alice
+CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|rt=Jan 01 2024 10:30:00 suser=bob
+CEF:0|Cool Vendor|Cool Product|1.0|LOGOUT|User logged out|3|suser=alice
+`
+
+func TestRunExtract(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-from", "2024-01-01T10:00:00Z", "-to", "2024-01-01T11:00:00Z"}
+
+	if err := runExtract(args, strings.NewReader(extractInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runExtract() = %v", err)
+	}
+
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|rt=Jan 01 2024 10:30:00 suser=bob\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("runExtract() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunExtractArchive(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "events.cefa")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aw, err := cefevent.NewArchiveWriter(file, cefevent.ArchiveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, user := range []string{"alice", "bob", "carol"} {
+		event := cefevent.CefEvent{
+			Version:            0,
+			DeviceVendor:       "Cool Vendor",
+			DeviceProduct:      "Cool Product",
+			DeviceVersion:      "1.0",
+			DeviceEventClassId: "LOGIN",
+			Name:               "User logged in",
+			Severity:           "3",
+			Extensions:         map[string]string{"suser": user},
+		}
+		if err := aw.Write(start, event); err != nil {
+			t.Fatal(err)
+		}
+		start = start.Add(time.Hour)
+	}
+	aw.Close()
+	file.Close()
+
+	var stdout bytes.Buffer
+	args := []string{"-from", "2024-01-01T01:00:00Z", path}
+
+	if err := runExtract(args, strings.NewReader(""), &stdout, io.Discard); err != nil {
+		t.Fatalf("runExtract() = %v", err)
+	}
+
+	if got := stdout.String(); strings.Count(got, "\n") != 2 || strings.Contains(got, "alice") {
+		t.Errorf("runExtract() wrote %q, want the events of bob and carol", got)
+	}
+}
+
+func TestRunExtractBadFlags(t *testing.T) {
+
+	var tests = [][]string{
+		{"-from", "yesterday"},
+		{"-to", "2024-01-01"},
+		{filepath.Join(os.TempDir(), "does-not-exist.cef")},
+	}
+
+	for _, args := range tests {
+		if err := runExtract(args, strings.NewReader(""), io.Discard, io.Discard); err == nil {
+			t.Errorf("runExtract(%v) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// multiFlag is a flag.Value collecting every occurrence of a repeated flag.
+type multiFlag []string
+
+func (f *multiFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *multiFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// splitAssignment splits a "key=value" flag value.
+func splitAssignment(flagName string, value string) (string, string, error) {
+
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return "", "", fmt.Errorf("-%s expects key=value, got %q", flagName, value)
+	}
+
+	return kv[0], kv[1], nil
+}
+
+// runFilter implements "cef filter", reading CEF events from stdin and
+// writing the events passing all filters to stdout.
+func runFilter(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	var where, set, redact multiFlag
+
+	flags := flag.NewFlagSet("filter", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "cef", "output format: cef, syslog, json, ecs, leef, ocsf, rfc5424 or logfmt")
+	flags.Var(&where, "where", "only pass events where `field=value`, fields are header names or extension keys (repeatable)")
+	flags.Var(&set, "set", "set the extension `key=value` on every event (repeatable)")
+	flags.Var(&redact, "redact", "redact the value of the extension `key` (repeatable)")
+	keys := flags.String("keys", "", "normalize extension keys not defined by CEF with the `normalizer`: camel, snake or ad")
+	dryRun := flags.Bool("dry-run", false, "do not write the events, write the number of events and a preview of them instead")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	outputFormat, err := cefevent.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	var middlewares []cefevent.Middleware
+
+	for _, w := range where {
+		field, value, err := splitAssignment("where", w)
+		if err != nil {
+			return err
+		}
+		middlewares = append(middlewares, cefevent.Where(field, value))
+	}
+
+	for _, s := range set {
+		key, value, err := splitAssignment("set", s)
+		if err != nil {
+			return err
+		}
+		middlewares = append(middlewares, cefevent.SetExtension(key, value))
+	}
+
+	if len(redact) > 0 {
+		middlewares = append(middlewares, cefevent.Redact(redact...))
+	}
+
+	if *keys != "" {
+		normalizer, err := cefevent.LookupKeyNormalizer(*keys)
+		if err != nil {
+			return err
+		}
+		middlewares = append(middlewares, cefevent.NormalizeKeys(normalizer))
+	}
+
+	input, err := cefevent.DecompressReader(stdin)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		preview := cefevent.NewDryRunSink("stdout", outputFormat, cefevent.DefaultDryRunPreview)
+		if err := cefevent.RunFilter(input, preview, outputFormat, middlewares...); err != nil {
+			return err
+		}
+		return preview.WriteReport(stdout)
+	}
+
+	return cefevent.RunFilter(input, stdout, outputFormat, middlewares...)
+}
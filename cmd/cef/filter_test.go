@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const filterInput = `CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|src=127.0.0.1 suser=alice
+not a CEF line
+CEF:0|Cool Vendor|Cool Product|1.0|LOGOUT|User logged out|3|src=127.0.0.1 suser=alice
+`
+
+func TestRunFilter(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-where", "DeviceEventClassId=LOGIN", "-set", "dvchost=sensor01", "-redact", "suser"}
+
+	if err := runFilter(args, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runFilter() = %v", err)
+	}
+
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|dvchost=sensor01 src=127.0.0.1 suser=[REDACTED]\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("runFilter() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunFilterKeys(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-where", "DeviceEventClassId=LOGIN", "-set", "threat_score=7", "-keys", "ad"}
+
+	if err := runFilter(args, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runFilter() = %v", err)
+	}
+
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|ad.threat_score=7 src=127.0.0.1 suser=alice\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("runFilter() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunFilterJSON(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-format", "json", "-where", "suser=alice"}
+
+	if err := runFilter(args, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runFilter() = %v", err)
+	}
+
+	if lines := strings.Count(stdout.String(), "\n"); lines != 2 {
+		t.Errorf("runFilter() wrote %d lines, want 2", lines)
+	}
+
+	if !strings.HasPrefix(stdout.String(), `{"Version":0,`) {
+		t.Errorf("runFilter() wrote %q, want JSON", stdout.String())
+	}
+}
+
+func TestRunFilterBadFlags(t *testing.T) {
+
+	var tests = [][]string{
+		{"-format", "xml"},
+		{"-where", "no-assignment"},
+		{"-set", "=value"},
+		{"-keys", "kebab"},
+	}
+
+	for _, args := range tests {
+		if err := runFilter(args, strings.NewReader(""), io.Discard, io.Discard); err == nil {
+			t.Errorf("runFilter(%v) should fail", args)
+		}
+	}
+}
+
+func TestRunFilterDryRun(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-dry-run", "-where", "suser=alice"}
+
+	if err := runFilter(args, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runFilter() = %v", err)
+	}
+
+	if got := stdout.String(); !strings.HasPrefix(got, "stdout (cef): 2 messages,") || strings.Count(got, "\n  CEF:0|") != 2 {
+		t.Errorf("runFilter() wrote %q, want a dry run report", got)
+	}
+}
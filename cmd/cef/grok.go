@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// runGrok implements "cef grok", converting unstructured log lines of files
+// or stdin into CEF events with the rules of a grok configuration.
+func runGrok(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("grok", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "the JSON grok configuration `file` with the patterns and their field mappings")
+	format := flags.String("format", "cef", "output format: cef, syslog, json, ecs, leef, ocsf, rfc5424 or logfmt")
+	strict := flags.Bool("strict", false, "fail on the first line matching no rule instead of skipping it")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *configPath == "" {
+		return errors.New("-config is required")
+	}
+
+	outputFormat, err := cefevent.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	configFile, err := os.Open(*configPath)
+	if err != nil {
+		return err
+	}
+	config, err := cefevent.LoadGrokConfig(configFile)
+	configFile.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", *configPath, err)
+	}
+
+	converter, err := cefevent.NewGrokConverter(config)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(stdout)
+	unmatched := 0
+
+	convert := func(r io.Reader) error {
+
+		input, err := cefevent.DecompressReader(r)
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+		for number := 1; scanner.Scan(); number++ {
+			if scanner.Text() == "" {
+				continue
+			}
+
+			event, err := converter.Convert(scanner.Text())
+			if errors.Is(err, cefevent.ErrNoGrokMatch) && !*strict {
+				unmatched++
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+
+			message, err := event.Render(outputFormat)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+			fmt.Fprintln(out, message)
+		}
+
+		return scanner.Err()
+	}
+
+	if flags.NArg() == 0 {
+		if err := convert(stdin); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = convert(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if unmatched > 0 {
+		fmt.Fprintf(stderr, "lines matching no rule: %d\n", unmatched)
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const grokConfig = `{
+	"rules": [{
+		"pattern": "^%{WORD:user} logged %{WORD:action} from %{IP:src}$",
+		"fields": {
+			"DeviceVendor": "Cool Vendor",
+			"DeviceProduct": "Cool Product",
+			"DeviceVersion": "1.0",
+			"DeviceEventClassId": "LOG${action}",
+			"Name": "User logged $action",
+			"Severity": "3",
+			"src": "$src",
+			"suser": "$user"
+		}
+	}]
+}`
+
+func TestRunGrok(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "grok.json")
+	if err := os.WriteFile(path, []byte(grokConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	input := "alice logged in from 127.0.0.1\nsomething else\n\nbob logged out from 10.0.0.1\n"
+
+	if err := runGrok([]string{"-config", path}, strings.NewReader(input), &stdout, &stderr); err != nil {
+		t.Fatalf("runGrok() = %v", err)
+	}
+
+	want := "CEF:0|Cool Vendor|Cool Product|1.0|LOGin|User logged in|3|src=127.0.0.1 suser=alice\n" +
+		"CEF:0|Cool Vendor|Cool Product|1.0|LOGout|User logged out|3|src=10.0.0.1 suser=bob\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("runGrok() wrote %q, want %q", got, want)
+	}
+	if got := stderr.String(); got != "lines matching no rule: 1\n" {
+		t.Errorf("runGrok() reported %q, want the unmatched line count", got)
+	}
+
+	if err := runGrok([]string{"-config", path, "-strict"}, strings.NewReader(input), io.Discard, io.Discard); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("runGrok(-strict) = %v, want an error for line 2", err)
+	}
+
+	if err := runGrok(nil, strings.NewReader(input), io.Discard, io.Discard); err == nil {
+		t.Error("runGrok() without -config = nil, want an error")
+	}
+}
@@ -0,0 +1,70 @@
+// Command cef is a command line companion of the cefevent package to process
+// Common Event Format (CEF) events in shell pipelines.
+//
+// Usage:
+//
+//	cef <command> [flags]
+//
+// Run "cef <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command is a subcommand of the cef tool.
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// commands holds all available subcommands by name.
+var commands = map[string]command{
+	"dict":    {"write the extensions defined by CEF with their types and descriptions as JSON", runDict},
+	"filter":  {"read CEF from stdin, transform and filter it and write it to stdout", runFilter},
+	"extract": {"write the events of CEF files or archives within a time range to stdout", runExtract},
+	"grok":    {"convert unstructured log lines into CEF events with grok patterns", runGrok},
+	"profile": {"report per extension statistics of CEF events to design column schemas", runProfile},
+	"report":  {"render CEF events as Markdown or HTML incident report", runReport},
+	"repl":    {"interactively parse CEF lines and preview filters and transforms on them", runRepl},
+}
+
+func usage(w io.Writer) {
+
+	fmt.Fprintln(w, "usage: cef <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "cef: unknown command %q\n\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "cef %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// runProfile implements "cef profile", reporting per extension statistics
+// of a sample of the events of CEF files or stdin.
+func runProfile(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	sample := flags.Int("n", 10000, "profile the first `n` events only, 0 profiles all events")
+	distinct := flags.Int("distinct", cefevent.DefaultProfileDistinct, "track up to `n` distinct values per extension")
+	asJSON := flags.Bool("json", false, "write the statistics as JSON instead of a table")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	profiler := cefevent.NewProfiler(*distinct)
+	remaining := *sample
+
+	profile := func(r io.Reader) error {
+
+		input, err := cefevent.DecompressReader(r)
+		if err != nil {
+			return err
+		}
+
+		decoder := cefevent.NewDecoder(input)
+		decoder.OnError(func(line string, err error) {})
+
+		for *sample == 0 || remaining > 0 {
+			event, err := decoder.Decode()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			profiler.Add(event)
+			remaining--
+		}
+
+		return nil
+	}
+
+	if flags.NArg() == 0 {
+		if err := profile(stdin); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = profile(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(profiler.Profiles())
+	}
+
+	return profiler.WriteReport(stdout)
+}
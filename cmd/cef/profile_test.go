@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+func TestRunProfile(t *testing.T) {
+
+	var stdout bytes.Buffer
+	if err := runProfile([]string{"-n", "1", "-json"}, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runProfile() = %v", err)
+	}
+
+	var profiles []cefevent.FieldProfile
+	if err := json.Unmarshal(stdout.Bytes(), &profiles); err != nil {
+		t.Fatalf("runProfile() wrote %q: %v", stdout.String(), err)
+	}
+
+	if len(profiles) != 2 || profiles[0].Key != "src" || profiles[0].Count != 1 || profiles[1].Key != "suser" || profiles[1].Distinct != 1 {
+		t.Errorf("runProfile() = %+v, want src and suser of the first event", profiles)
+	}
+
+	stdout.Reset()
+	if err := runProfile(nil, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runProfile() = %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "2 events, 2 extensions\nKEY") {
+		t.Errorf("runProfile() wrote %q, want a table", stdout.String())
+	}
+}
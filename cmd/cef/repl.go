@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+const replHelp = `paste a CEF line, optionally with a syslog header, to parse it, or enter a command:
+  :where field=value  test whether the current event matches the filter
+  :set key=value      add a transform setting the extension on every event
+  :redact key         add a transform redacting the extension on every event
+  :reset              remove all transforms
+  :format name        render events as cef, syslog, json, ecs, leef, ocsf, rfc5424 or logfmt
+  :show               show the current event and its transformed rendering again
+  :help               show this help
+  :quit               leave the playground
+`
+
+// repl is the state of an interactive "cef repl" session.
+type repl struct {
+	out        io.Writer
+	format     cefevent.Format
+	transforms []string
+	middleware []cefevent.Middleware
+	event      *cefevent.CefEvent
+}
+
+// runRepl implements "cef repl", an interactive playground parsing pasted
+// CEF lines and previewing filters and transforms on them.
+func runRepl(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "cef", "output format of the transformed events: cef, syslog, json, ecs, leef, ocsf, rfc5424 or logfmt")
+	quiet := flags.Bool("q", false, "do not print the prompt and the greeting, e.g. when the input is piped")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	outputFormat, err := cefevent.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	r := &repl{out: stdout, format: outputFormat}
+	if !*quiet {
+		fmt.Fprint(stdout, replHelp)
+	}
+
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for {
+		if !*quiet {
+			fmt.Fprint(stdout, "cef> ")
+		}
+		if !scanner.Scan() {
+			if !*quiet {
+				fmt.Fprintln(stdout)
+			}
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == ":quit" || line == ":q" {
+			return nil
+		}
+		if err := r.eval(line); err != nil {
+			fmt.Fprintf(stdout, "error: %v\n", err)
+		}
+	}
+}
+
+// eval evaluates a single input line of the session.
+func (r *repl) eval(line string) error {
+
+	if line == "" {
+		return nil
+	}
+
+	if !strings.HasPrefix(line, ":") {
+		message, err := cefevent.ParseSyslog(line)
+		if err != nil {
+			return err
+		}
+		r.event = &message.Event
+		return r.show()
+	}
+
+	name, arg, _ := strings.Cut(line[1:], " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "help", "h":
+		fmt.Fprint(r.out, replHelp)
+		return nil
+	case "where":
+		field, value, err := splitAssignment("where", arg)
+		if err != nil {
+			return err
+		}
+		if r.event == nil {
+			return fmt.Errorf("no event, paste a CEF line first")
+		}
+		if _, passed := r.apply(*r.event, cefevent.Where(field, value)); passed {
+			fmt.Fprintln(r.out, "match")
+		} else {
+			fmt.Fprintln(r.out, "no match")
+		}
+		return nil
+	case "set":
+		key, value, err := splitAssignment("set", arg)
+		if err != nil {
+			return err
+		}
+		return r.addTransform(line, cefevent.SetExtension(key, value))
+	case "redact":
+		if arg == "" {
+			return fmt.Errorf(":redact expects an extension key")
+		}
+		return r.addTransform(line, cefevent.Redact(strings.Fields(arg)...))
+	case "reset":
+		r.transforms, r.middleware = nil, nil
+		return r.show()
+	case "format":
+		format, err := cefevent.ParseFormat(arg)
+		if err != nil {
+			return err
+		}
+		r.format = format
+		return r.show()
+	case "show":
+		return r.show()
+	}
+
+	return fmt.Errorf("unknown command :%s, see :help", name)
+}
+
+// addTransform appends a transform and shows its effect on the current event.
+func (r *repl) addTransform(line string, middleware cefevent.Middleware) error {
+
+	r.transforms = append(r.transforms, line)
+	r.middleware = append(r.middleware, middleware)
+
+	return r.show()
+}
+
+// apply passes the event through the middlewares.
+//
+// Returns:
+// - The transformed event.
+// - Whether the event was passed on by all middlewares.
+func (r *repl) apply(event cefevent.CefEvent, middlewares ...cefevent.Middleware) (cefevent.CefEvent, bool) {
+
+	var result cefevent.CefEvent
+	passed := false
+
+	handler := cefevent.Handler(func(e cefevent.CefEvent) error {
+		result, passed = e, true
+		return nil
+	})
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	handler(event)
+
+	return result, passed
+}
+
+// show prints the parsed structure of the current event and its rendering
+// after all transforms.
+func (r *repl) show() error {
+
+	if r.event == nil {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(r.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Version\t%d\n", r.event.Version)
+	fmt.Fprintf(tw, "DeviceVendor\t%s\n", r.event.DeviceVendor)
+	fmt.Fprintf(tw, "DeviceProduct\t%s\n", r.event.DeviceProduct)
+	fmt.Fprintf(tw, "DeviceVersion\t%s\n", r.event.DeviceVersion)
+	fmt.Fprintf(tw, "DeviceEventClassId\t%s\n", r.event.DeviceEventClassId)
+	fmt.Fprintf(tw, "Name\t%s\n", r.event.Name)
+	fmt.Fprintf(tw, "Severity\t%s\n", r.event.Severity)
+
+	keys := make([]string, 0, len(r.event.Extensions))
+	for k := range r.event.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(tw, "Extensions\t%d\n", len(keys))
+	for _, k := range keys {
+		fmt.Fprintf(tw, "  %s\t%q\n", k, r.event.Extensions[k])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for i, t := range r.transforms {
+		fmt.Fprintf(r.out, "transform %d: %s\n", i+1, t)
+	}
+
+	transformed, passed := r.apply(*r.event, r.middleware...)
+	if !passed {
+		fmt.Fprintln(r.out, "=> dropped")
+		return nil
+	}
+
+	message, err := transformed.Render(r.format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "=> %s\n", message)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunRepl(t *testing.T) {
+
+	input := strings.Join([]string{
+		"<134>Jan 12 10:00:00 fw01 CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|src=127.0.0.1 suser=alice",
+		":where suser=alice",
+		":where suser=bob",
+		":set dvchost=sensor01",
+		":redact suser",
+		":format leef",
+		":bogus",
+		"CEF:0|broken",
+		":quit",
+		"CEF:0|not|evaluated|after|quit|x|1|",
+	}, "\n")
+
+	var stdout bytes.Buffer
+	if err := runRepl([]string{"-q"}, strings.NewReader(input), &stdout, io.Discard); err != nil {
+		t.Fatalf("runRepl() = %v", err)
+	}
+
+	got := stdout.String()
+	for _, want := range []string{
+		"DeviceEventClassId  LOGIN\n",
+		"  suser             \"alice\"\n",
+		"=> CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|src=127.0.0.1 suser=alice\n",
+		"match\nno match\n",
+		"transform 1: :set dvchost=sensor01\n",
+		"=> CEF:0|Cool Vendor|Cool Product|1.0|LOGIN|User logged in|3|dvchost=sensor01 src=127.0.0.1 suser=[REDACTED]\n",
+		"=> LEEF:2.0|Cool Vendor|",
+		"error: unknown command :bogus",
+		"error: not a valid CEF message",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("runRepl() wrote %q, want it to contain %q", got, want)
+		}
+	}
+
+	if strings.Contains(got, "evaluated") || strings.Contains(got, "cef> ") {
+		t.Errorf("runRepl() wrote %q, want no prompt and nothing after :quit", got)
+	}
+}
+
+func TestRunReplPrompt(t *testing.T) {
+
+	var stdout bytes.Buffer
+	input := ":set a=b\n:where a=b\nCEF:0|a|b|c|d|e|f|src=1\n"
+
+	if err := runRepl(nil, strings.NewReader(input), &stdout, io.Discard); err != nil {
+		t.Fatalf("runRepl() = %v", err)
+	}
+
+	got := stdout.String()
+	if !strings.Contains(got, "error: no event") || !strings.Contains(got, "cef> ") || !strings.Contains(got, "=> CEF:0|a|b|c|d|e|f|a=b src=1\n") {
+		t.Errorf("runRepl() wrote %q", got)
+	}
+}
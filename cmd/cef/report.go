@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pcktdmp/cef/cefevent"
+)
+
+// runReport implements "cef report", rendering the events of CEF files or
+// stdin as Markdown or HTML incident report.
+func runReport(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+
+	var where multiFlag
+
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	title := flags.String("title", "", "the `title` of the report, defaults to \"Incident report\"")
+	asHTML := flags.Bool("html", false, "render the report as HTML instead of Markdown")
+	flags.Var(&where, "where", "only report events where `field=value`, fields are header names or extension keys (repeatable)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var filters []cefevent.Middleware
+	for _, w := range where {
+		field, value, err := splitAssignment("where", w)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, cefevent.Where(field, value))
+	}
+
+	var events []cefevent.CefEvent
+	collect := cefevent.Handler(func(event cefevent.CefEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	for i := len(filters) - 1; i >= 0; i-- {
+		collect = filters[i](collect)
+	}
+
+	read := func(r io.Reader) error {
+
+		input, err := cefevent.DecompressReader(r)
+		if err != nil {
+			return err
+		}
+
+		decoder := cefevent.NewDecoder(input)
+		decoder.OnError(func(line string, err error) {})
+
+		for {
+			event, err := decoder.Decode()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			collect(event)
+		}
+	}
+
+	if flags.NArg() == 0 {
+		if err := read(stdin); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range flags.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = read(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	opts := cefevent.ReportOptions{Title: *title}
+	if *asHTML {
+		opts.Format = cefevent.ReportHTML
+	}
+
+	return cefevent.WriteIncidentReport(stdout, events, opts)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunReport(t *testing.T) {
+
+	var stdout bytes.Buffer
+	args := []string{"-title", "Logins", "-where", "DeviceEventClassId=LOGIN"}
+
+	if err := runReport(args, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runReport() = %v", err)
+	}
+
+	got := stdout.String()
+	for _, want := range []string{"# Logins\n", "1 events in 1 event classes.", "## LOGIN User logged in", "| 3 | User logged in | Cool Vendor Cool Product 1.0 | 127.0.0.1 | alice |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("runReport() wrote %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "LOGOUT") {
+		t.Errorf("runReport() wrote %q, want the LOGOUT event filtered", got)
+	}
+
+	stdout.Reset()
+	if err := runReport([]string{"-html"}, strings.NewReader(filterInput), &stdout, io.Discard); err != nil {
+		t.Fatalf("runReport() = %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "<!DOCTYPE html>") {
+		t.Errorf("runReport(-html) wrote %q, want an HTML document", stdout.String())
+	}
+}
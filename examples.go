@@ -38,8 +38,10 @@ func main() {
 
 	// if you want read a CEF event from a line
 	eventLine := "CEF:0|Cool Vendor|Cool Product|1.0|COOL_THING|Something cool happened.|Unknown|src=127.0.0.1"
-	newEvent := cefevent.CefEvent{}
-	newEvent.Read(eventLine)
+	newEvent, err := cefevent.Parse(eventLine)
+	if err != nil {
+		fmt.Println("Need to handle this.")
+	}
 	eventString, err := newEvent.String()
 	if err != nil {
 		fmt.Println("Need to handle this.")